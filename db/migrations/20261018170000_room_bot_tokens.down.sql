@@ -0,0 +1,4 @@
+BEGIN;
+DROP TRIGGER IF EXISTS room_bot_tokens_update ON room_bot_tokens;
+DROP TABLE IF EXISTS room_bot_tokens;
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS room_bot_tokens (
+    room_bot_token_id SERIAL PRIMARY KEY,
+    room_id INTEGER NOT NULL UNIQUE,
+    bot_twitch_user_id TEXT NOT NULL,
+    bot_username TEXT NOT NULL,
+    access_token TEXT NOT NULL,
+    refresh_token TEXT NOT NULL,
+    expiry TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER room_bot_tokens_update
+BEFORE UPDATE ON room_bot_tokens
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TRIGGER IF EXISTS api_keys_update ON api_keys;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP TABLE IF EXISTS api_keys;
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    api_key_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    channel_name TEXT NOT NULL,
+    name TEXT NOT NULL,
+    key_prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id
+    ON api_keys (user_id ASC);
+
+CREATE TRIGGER api_keys_update
+BEFORE UPDATE ON api_keys
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP TRIGGER IF EXISTS room_permissions_update ON room_permissions;
+DROP TABLE IF EXISTS room_permissions;
+COMMIT;
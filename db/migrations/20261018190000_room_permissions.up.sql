@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS room_permissions (
+    room_permission_id SERIAL PRIMARY KEY,
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (room_id, user_id)
+);
+
+CREATE TRIGGER room_permissions_update
+BEFORE UPDATE ON room_permissions
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_id;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TRIGGER IF EXISTS webhooks_update ON webhooks;
+DROP INDEX IF EXISTS idx_webhooks_room_id;
+DROP TABLE IF EXISTS webhooks;
+COMMIT;
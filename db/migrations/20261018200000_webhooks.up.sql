@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id SERIAL PRIMARY KEY,
+    room_id INTEGER NOT NULL,
+    channel_name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_room_id
+    ON webhooks (room_id ASC);
+
+CREATE TRIGGER webhooks_update
+BEFORE UPDATE ON webhooks
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    webhook_delivery_id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL,
+    delivery_uuid TEXT NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error_msg TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL DEFAULT FALSE,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id
+    ON webhook_deliveries (webhook_id ASC, created_at DESC);
+
+COMMIT;
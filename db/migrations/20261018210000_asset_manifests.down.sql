@@ -0,0 +1,8 @@
+BEGIN;
+DROP TRIGGER IF EXISTS asset_manifest_pin_update ON asset_manifest_pin;
+DROP TABLE IF EXISTS asset_manifest_pin;
+DROP TABLE IF EXISTS asset_manifest_files;
+DROP TRIGGER IF EXISTS asset_manifests_update ON asset_manifests;
+DROP TABLE IF EXISTS asset_manifests;
+DROP TABLE IF EXISTS asset_file_versions;
+COMMIT;
@@ -0,0 +1,48 @@
+BEGIN;
+
+-- Every version of an asset file ever ingested, keyed by its path and a
+-- SHA-256 of the uncompressed contents. Data is gzip compressed.
+CREATE TABLE IF NOT EXISTS asset_file_versions (
+    file_path_hash BIGINT NOT NULL,
+    content_hash TEXT NOT NULL,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (file_path_hash, content_hash)
+);
+
+-- A manifest is the full set of asset files (including the index JSONs)
+-- produced by a single run of ingest_assets.
+CREATE TABLE IF NOT EXISTS asset_manifests (
+    manifest_id SERIAL PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    num_files INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER asset_manifests_update
+BEFORE UPDATE ON asset_manifests
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+CREATE TABLE IF NOT EXISTS asset_manifest_files (
+    manifest_id INTEGER NOT NULL REFERENCES asset_manifests (manifest_id) ON DELETE CASCADE,
+    file_path_hash BIGINT NOT NULL,
+    file_path TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    PRIMARY KEY (manifest_id, file_path_hash)
+);
+
+-- Single row table holding the manifest version the server serves.
+CREATE TABLE IF NOT EXISTS asset_manifest_pin (
+    pin_id INTEGER PRIMARY KEY DEFAULT 1 CHECK (pin_id = 1),
+    manifest_id INTEGER NOT NULL REFERENCES asset_manifests (manifest_id),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER asset_manifest_pin_update
+BEFORE UPDATE ON asset_manifest_pin
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS asset_file_versions (
+    file_path_hash BIGINT NOT NULL,
+    content_hash TEXT NOT NULL,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (file_path_hash, content_hash)
+);
+
+INSERT INTO asset_file_versions (file_path_hash, content_hash, data, created_at)
+SELECT DISTINCT f.file_path_hash, f.content_hash, b.data, b.created_at
+FROM asset_manifest_files f
+JOIN asset_blobs b ON b.content_hash = f.content_hash
+ON CONFLICT (file_path_hash, content_hash) DO NOTHING;
+
+DROP INDEX IF EXISTS idx_asset_manifest_files_content_hash;
+DROP TABLE IF EXISTS asset_blobs;
+
+COMMIT;
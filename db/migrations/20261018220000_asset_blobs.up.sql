@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Asset contents keyed by the SHA-256 of the uncompressed data, so identical
+-- files shared across skins/facings are only stored once. asset_manifest_files
+-- maps each path to its blob. Data is gzip compressed.
+CREATE TABLE IF NOT EXISTS asset_blobs (
+    content_hash TEXT PRIMARY KEY,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO asset_blobs (content_hash, data, created_at)
+SELECT DISTINCT ON (content_hash) content_hash, data, created_at
+FROM asset_file_versions
+ORDER BY content_hash, created_at ASC
+ON CONFLICT (content_hash) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_asset_manifest_files_content_hash
+    ON asset_manifest_files (content_hash);
+
+DROP TABLE IF EXISTS asset_file_versions;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+DROP TRIGGER IF EXISTS custom_chibis_update ON custom_chibis;
+DROP INDEX IF EXISTS idx_custom_chibis_room_id_operator_id;
+DROP TABLE IF EXISTS custom_chibis;
+DROP TRIGGER IF EXISTS asset_uploads_update ON asset_uploads;
+DROP INDEX IF EXISTS idx_asset_uploads_content_hash;
+DROP TABLE IF EXISTS asset_uploads;
+COMMIT;
@@ -0,0 +1,38 @@
+BEGIN;
+
+-- Files uploaded through the API (custom chibis). Served by the postgres
+-- asset store alongside the pinned manifest. Data lives in asset_blobs.
+CREATE TABLE IF NOT EXISTS asset_uploads (
+    file_path_hash BIGINT PRIMARY KEY,
+    file_path TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_asset_uploads_content_hash
+    ON asset_uploads (content_hash);
+
+CREATE TRIGGER asset_uploads_update
+BEFORE UPDATE ON asset_uploads
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+CREATE TABLE IF NOT EXISTS custom_chibis (
+    custom_chibi_id SERIAL PRIMARY KEY,
+    room_id INTEGER NOT NULL,
+    operator_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    asset_entry JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_chibis_room_id_operator_id
+    ON custom_chibis (room_id, operator_id) WHERE deleted_at IS NULL;
+
+CREATE TRIGGER custom_chibis_update
+BEFORE UPDATE ON custom_chibis
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+COMMIT;
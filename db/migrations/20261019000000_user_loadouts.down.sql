@@ -0,0 +1,7 @@
+BEGIN;
+DROP TRIGGER IF EXISTS user_room_loadouts_update ON user_room_loadouts;
+DROP TABLE IF EXISTS user_room_loadouts;
+DROP TRIGGER IF EXISTS user_loadouts_update ON user_loadouts;
+DROP INDEX IF EXISTS idx_user_loadouts_user_id_name;
+DROP TABLE IF EXISTS user_loadouts;
+COMMIT;
@@ -0,0 +1,36 @@
+BEGIN;
+
+-- Named chibis saved by a user (!chibi save <name>). Separate from the
+-- single chibi in user_preferences.
+CREATE TABLE IF NOT EXISTS user_loadouts (
+    user_loadout_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    operator_info JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_loadouts_user_id_name
+    ON user_loadouts (user_id, name);
+
+CREATE TRIGGER user_loadouts_update
+BEFORE UPDATE ON user_loadouts
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+-- The loadout a user is given when they first chat in a room
+CREATE TABLE IF NOT EXISTS user_room_loadouts (
+    user_id INTEGER NOT NULL,
+    room_id INTEGER NOT NULL,
+    user_loadout_id INTEGER NOT NULL REFERENCES user_loadouts (user_loadout_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, room_id)
+);
+
+CREATE TRIGGER user_room_loadouts_update
+BEFORE UPDATE ON user_room_loadouts
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TRIGGER IF EXISTS user_room_preferences_update ON user_room_preferences;
+DROP INDEX IF EXISTS idx_user_room_preferences_user_id_room_id;
+DROP TABLE IF EXISTS user_room_preferences;
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- A user's saved chibi for a single channel (!chibi save here). Takes
+-- precedence over their global chibi in user_preferences.
+CREATE TABLE IF NOT EXISTS user_room_preferences (
+    user_room_preference_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    room_id INTEGER NOT NULL,
+    operator_info JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_room_preferences_user_id_room_id
+    ON user_room_preferences (user_id, room_id);
+
+CREATE TRIGGER user_room_preferences_update
+BEFORE UPDATE ON user_room_preferences
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at();
+
+COMMIT;
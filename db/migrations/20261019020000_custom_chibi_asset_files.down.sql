@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE custom_chibis DROP COLUMN IF EXISTS asset_files;
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Every asset path stored for the chibi so they can be removed with it
+ALTER TABLE custom_chibis ADD COLUMN IF NOT EXISTS asset_files TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE custom_chibis c SET asset_files = ARRAY(
+    SELECT u.file_path FROM asset_uploads u
+    WHERE left(u.file_path, length('uploads/' || c.operator_id || '/')) = 'uploads/' || c.operator_id || '/'
+    ORDER BY u.file_path
+);
+
+COMMIT;
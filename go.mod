@@ -17,6 +17,7 @@ require (
 	github.com/lithammer/fuzzysearch v1.1.8
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/oauth2 v0.21.0
+	golang.org/x/sync v0.8.0
 	gorm.io/driver/postgres v1.5.9
 	gorm.io/gorm v1.25.12
 )
@@ -57,7 +58,6 @@ require (
 	golang.org/x/crypto v0.27.0 // indirect
 	golang.org/x/exp v0.0.0-20230224173230-c95f2b4c22f2 // indirect
 	golang.org/x/net v0.27.0 // indirect
-	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/text v0.18.0 // indirect
 	gopkg.in/square/go-jose.v2 v2.6.0 // indirect
@@ -0,0 +1,260 @@
+package akdb
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	ASSET_CACHE_DEFAULT_SIZE_MB = 256
+	ASSET_CACHE_PRELOAD_WORKERS = 4
+	// Fetches are shared between callers so they aren't tied to any one
+	// caller's context
+	ASSET_CACHE_FETCH_TIMEOUT = 30 * time.Second
+)
+
+type assetCacheEntry struct {
+	key   int64
+	asset *Asset
+}
+
+type AssetCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	Bytes     int64
+	MaxBytes  int64
+}
+
+// AssetCache is a size-bounded LRU cache in front of another AssetStore.
+// Concurrent misses of the same asset only fetch it once.
+type AssetCache struct {
+	store    AssetStore
+	maxBytes int64
+
+	mutex     sync.Mutex
+	lru       *list.List
+	entries   map[int64]*list.Element
+	curBytes  int64
+	hits      int64
+	misses    int64
+	evictions int64
+	// Bumped whenever cached assets are invalidated. Fetches which started
+	// before that are not added to the cache.
+	generation uint64
+
+	fetchGroup singleflight.Group
+}
+
+func NewAssetCache(store AssetStore, maxBytes int64) *AssetCache {
+	return &AssetCache{
+		store:    store,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[int64]*list.Element),
+	}
+}
+
+func (c *AssetCache) GetAsset(ctx context.Context, filePath string) (*Asset, error) {
+	key := hashFilePath(filePath)
+	asset, generation, ok := c.get(key)
+	if ok {
+		return asset, nil
+	}
+
+	// Callers after an invalidation don't share a fetch started before it
+	fetchKey := fmt.Sprintf("%d/%d", generation, key)
+	resultCh := c.fetchGroup.DoChan(fetchKey, func() (interface{}, error) {
+		// Another caller may have filled the cache while we were waiting
+		if asset, ok := c.peek(key); ok {
+			return asset, nil
+		}
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ASSET_CACHE_FETCH_TIMEOUT)
+		defer cancel()
+		asset, err := c.store.GetAsset(fetchCtx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		c.add(key, asset, generation)
+		return asset, nil
+	})
+	select {
+	case result := <-resultCh:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*Asset), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Preload fetches the assets into the cache. Assets which fail to load are
+// logged and skipped. Returns the number of assets now in the cache.
+func (c *AssetCache) Preload(ctx context.Context, filePaths []string) int {
+	pathsCh := make(chan string)
+	var wg sync.WaitGroup
+	var numLoaded int
+	var numLoadedMutex sync.Mutex
+	for i := 0; i < ASSET_CACHE_PRELOAD_WORKERS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filePath := range pathsCh {
+				if _, err := c.GetAsset(ctx, filePath); err != nil {
+					log.Println("Failed to preload asset", filePath, err)
+					continue
+				}
+				numLoadedMutex.Lock()
+				numLoaded += 1
+				numLoadedMutex.Unlock()
+			}
+		}()
+	}
+	for _, filePath := range filePaths {
+		pathsCh <- filePath
+	}
+	close(pathsCh)
+	wg.Wait()
+	return numLoaded
+}
+
+// Purge drops every cached asset. Used when the underlying assets change.
+func (c *AssetCache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lru.Init()
+	c.entries = make(map[int64]*list.Element)
+	c.curBytes = 0
+	c.generation += 1
+	misc.Monitor.AssetCacheBytes = 0
+}
+
+// PutAsset writes through to the underlying store and drops the cached copy
+// of filePath. Fails if the underlying store is not an AssetWriter.
+func (c *AssetCache) PutAsset(ctx context.Context, filePath string, data []byte) error {
+	writer, ok := c.store.(AssetWriter)
+	if !ok {
+		return errors.New("asset store does not support uploads")
+	}
+	if err := writer.PutAsset(ctx, filePath, data); err != nil {
+		return err
+	}
+	c.remove(hashFilePath(filePath))
+	return nil
+}
+
+// DeleteAsset deletes from the underlying store and drops the cached copy of
+// filePath. Fails if the underlying store is not an AssetWriter.
+func (c *AssetCache) DeleteAsset(ctx context.Context, filePath string) error {
+	writer, ok := c.store.(AssetWriter)
+	if !ok {
+		return errors.New("asset store does not support uploads")
+	}
+	if err := writer.DeleteAsset(ctx, filePath); err != nil {
+		return err
+	}
+	c.remove(hashFilePath(filePath))
+	return nil
+}
+
+// SetPinnedManifestId passes the pinned manifest to the underlying store if it
+// is a PinnedManifestStore. Callers should Purge afterwards.
+func (c *AssetCache) SetPinnedManifestId(manifestId uint) {
+	if pinned, ok := c.store.(PinnedManifestStore); ok {
+		pinned.SetPinnedManifestId(manifestId)
+	}
+}
+
+func (c *AssetCache) Stats() AssetCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return AssetCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   c.lru.Len(),
+		Bytes:     c.curBytes,
+		MaxBytes:  c.maxBytes,
+	}
+}
+
+// get also returns the current generation, which a fetch after a miss must
+// pass to add.
+func (c *AssetCache) get(key int64) (*Asset, uint64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses += 1
+		misc.Monitor.NumAssetCacheMisses += 1
+		return nil, c.generation, false
+	}
+	c.hits += 1
+	misc.Monitor.NumAssetCacheHits += 1
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*assetCacheEntry).asset, c.generation, true
+}
+
+func (c *AssetCache) peek(key int64) (*Asset, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return elem.Value.(*assetCacheEntry).asset, true
+}
+
+func (c *AssetCache) remove(key int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation += 1
+	if elem, ok := c.entries[key]; ok {
+		c.curBytes -= int64(len(elem.Value.(*assetCacheEntry).asset.Data))
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		misc.Monitor.AssetCacheBytes = c.curBytes
+	}
+}
+
+// add skips the asset if the cache was invalidated since generation, the
+// asset may be from before the change.
+func (c *AssetCache) add(key int64, asset *Asset, generation uint64) {
+	size := int64(len(asset.Data))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.generation != generation {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.curBytes -= int64(len(elem.Value.(*assetCacheEntry).asset.Data))
+		c.lru.Remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&assetCacheEntry{key: key, asset: asset})
+	c.curBytes += size
+
+	for c.curBytes > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*assetCacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.curBytes -= int64(len(entry.asset.Data))
+		c.evictions += 1
+		misc.Monitor.NumAssetCacheEvictions += 1
+	}
+	misc.Monitor.AssetCacheBytes = c.curBytes
+}
@@ -0,0 +1,169 @@
+package akdb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssetCache_HitsAndMisses(t *testing.T) {
+	assert := assert.New(t)
+	store := NewFakeAssetStore()
+	store.Put("a.png", []byte("aaaa"), ASSET_ENCODING_IDENTITY)
+	sut := NewAssetCache(store, 100)
+
+	for i := 0; i < 3; i++ {
+		asset, err := sut.GetAsset(context.TODO(), "a.png")
+		assert.Nil(err)
+		assert.Equal([]byte("aaaa"), asset.Data)
+	}
+	_, err := sut.GetAsset(context.TODO(), "missing.png")
+	assert.ErrorIs(err, ErrAssetNotFound)
+
+	stats := sut.Stats()
+	assert.Equal(int64(2), stats.Hits)
+	assert.Equal(int64(2), stats.Misses)
+	assert.Equal(1, stats.Entries)
+	assert.Equal(int64(4), stats.Bytes)
+	assert.Equal(2, store.NumGets)
+}
+
+func TestAssetCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	assert := assert.New(t)
+	store := NewFakeAssetStore()
+	store.Put("a.png", make([]byte, 4), ASSET_ENCODING_IDENTITY)
+	store.Put("b.png", make([]byte, 4), ASSET_ENCODING_IDENTITY)
+	store.Put("c.png", make([]byte, 4), ASSET_ENCODING_IDENTITY)
+	store.Put("huge.png", make([]byte, 100), ASSET_ENCODING_IDENTITY)
+	sut := NewAssetCache(store, 10)
+	ctx := context.TODO()
+
+	sut.GetAsset(ctx, "a.png")
+	sut.GetAsset(ctx, "b.png")
+	sut.GetAsset(ctx, "a.png")
+	sut.GetAsset(ctx, "c.png") // evicts b
+	stats := sut.Stats()
+	assert.Equal(int64(1), stats.Evictions)
+	assert.Equal(2, stats.Entries)
+	assert.Equal(int64(8), stats.Bytes)
+
+	numGets := store.NumGets
+	sut.GetAsset(ctx, "a.png")
+	assert.Equal(numGets, store.NumGets)
+	sut.GetAsset(ctx, "b.png")
+	assert.Equal(numGets+1, store.NumGets)
+
+	// Assets larger than the cache are served but never cached
+	asset, err := sut.GetAsset(ctx, "huge.png")
+	assert.Nil(err)
+	assert.Equal(100, len(asset.Data))
+	assert.Equal(2, sut.Stats().Entries)
+}
+
+func TestAssetCache_DeduplicatesConcurrentMisses(t *testing.T) {
+	assert := assert.New(t)
+	store := NewFakeAssetStore()
+	store.Put("a.png", []byte("aaaa"), ASSET_ENCODING_IDENTITY)
+	store.GetDelay = 50 * time.Millisecond
+	sut := NewAssetCache(store, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			asset, err := sut.GetAsset(context.TODO(), "a.png")
+			assert.Nil(err)
+			assert.Equal([]byte("aaaa"), asset.Data)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(1, store.NumGets)
+}
+
+func TestAssetCache_Preload(t *testing.T) {
+	assert := assert.New(t)
+	store := NewFakeAssetStore()
+	store.Put("a.png", []byte("a"), ASSET_ENCODING_IDENTITY)
+	store.Put("b.png", []byte("b"), ASSET_ENCODING_IDENTITY)
+	sut := NewAssetCache(store, 100)
+
+	numLoaded := sut.Preload(context.TODO(), []string{"a.png", "b.png", "missing.png"})
+	assert.Equal(2, numLoaded)
+	assert.Equal(2, sut.Stats().Entries)
+
+	sut.GetAsset(context.TODO(), "a.png")
+	assert.Equal(int64(1), sut.Stats().Hits)
+}
+
+func TestAssetCache_PutAssetInvalidates(t *testing.T) {
+	assert := assert.New(t)
+	store := NewFakeAssetStore()
+	store.Put("a.png", []byte("old"), ASSET_ENCODING_IDENTITY)
+	sut := NewAssetCache(store, 100)
+	ctx := context.TODO()
+
+	sut.GetAsset(ctx, "a.png")
+	assert.Nil(sut.PutAsset(ctx, "a.png", []byte("new")))
+	asset, err := sut.GetAsset(ctx, "a.png")
+	assert.Nil(err)
+	assert.Equal([]byte("new"), asset.Data)
+	assert.Equal(int64(3), sut.Stats().Bytes)
+
+	assert.Nil(sut.DeleteAsset(ctx, "a.png"))
+	_, err = sut.GetAsset(ctx, "a.png")
+	assert.ErrorIs(err, ErrAssetNotFound)
+	assert.Equal(int64(0), sut.Stats().Bytes)
+}
+
+func TestAssetCache_FetchOutlivesCallerContext(t *testing.T) {
+	assert := assert.New(t)
+	store := NewFakeAssetStore()
+	store.Put("a.png", []byte("aaaa"), ASSET_ENCODING_IDENTITY)
+	store.GetDelay = 50 * time.Millisecond
+	sut := NewAssetCache(store, 100)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	firstErr := make(chan error)
+	go func() {
+		_, err := sut.GetAsset(ctx, "a.png")
+		firstErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(<-firstErr, context.Canceled)
+
+	// The shared fetch wasn't cancelled along with the first caller
+	asset, err := sut.GetAsset(context.TODO(), "a.png")
+	assert.Nil(err)
+	assert.Equal([]byte("aaaa"), asset.Data)
+	assert.Equal(1, store.NumGets)
+}
+
+func TestAssetCache_PurgeDuringFetchIsNotCached(t *testing.T) {
+	assert := assert.New(t)
+	store := NewFakeAssetStore()
+	store.Put("a.png", []byte("old"), ASSET_ENCODING_IDENTITY)
+	store.GetDelay = 50 * time.Millisecond
+	sut := NewAssetCache(store, 100)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		asset, err := sut.GetAsset(context.TODO(), "a.png")
+		assert.Nil(err)
+		assert.Equal([]byte("old"), asset.Data)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	store.Put("a.png", []byte("new"), ASSET_ENCODING_IDENTITY)
+	sut.Purge()
+	<-done
+
+	assert.Equal(0, sut.Stats().Entries)
+	asset, err := sut.GetAsset(context.TODO(), "a.png")
+	assert.Nil(err)
+	assert.Equal([]byte("new"), asset.Data)
+}
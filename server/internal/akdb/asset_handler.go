@@ -0,0 +1,85 @@
+package akdb
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const ASSET_CACHE_CONTROL = "public, max-age=86400"
+
+// NewAssetHandler serves the assets in the store under prefix. It answers
+// conditional and range requests and only sends gzip encoded bodies to
+// clients which accept them.
+func NewAssetHandler(store AssetStore, prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		filePath := strings.TrimPrefix(r.URL.Path, prefix)
+		asset, err := store.GetAsset(r.Context(), filePath)
+		if errors.Is(err, ErrAssetNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			log.Println("Failed to get asset", filePath, err)
+			http.Error(w, "failed to get asset", http.StatusInternalServerError)
+			return
+		}
+
+		ct := mime.TypeByExtension(filepath.Ext(filePath))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", ct)
+		w.Header().Set("Cache-Control", ASSET_CACHE_CONTROL)
+
+		data := asset.Data
+		etag := asset.ETag
+		if asset.ContentEncoding == ASSET_ENCODING_GZIP {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if AcceptsGzip(r.Header.Get("Accept-Encoding")) {
+				w.Header().Set("Content-Encoding", "gzip")
+				// Each representation needs its own validator
+				etag += "-gzip"
+			} else {
+				data, err = asset.Decoded()
+				if err != nil {
+					log.Println("Failed to decode asset", filePath, err)
+					http.Error(w, "failed to decode asset", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+		w.Header().Set("ETag", `"`+etag+`"`)
+
+		// ServeContent handles If-None-Match, If-Modified-Since and Range
+		http.ServeContent(w, r, filePath, asset.ModTime, bytes.NewReader(data))
+	})
+}
+
+// AcceptsGzip reports whether the Accept-Encoding header allows gzip.
+func AcceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		qValue, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		q, err := strconv.ParseFloat(qValue, 64)
+		return err == nil && q > 0
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package akdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ASSET_MANIFEST_INSERT_BATCH_SIZE = 500
+)
+
+type AssetManifestRepository interface {
+	GetManifests(ctx context.Context) ([]*AssetManifestDb, error)
+	GetManifest(ctx context.Context, manifestId uint) (*AssetManifestDb, error)
+	GetManifestFiles(ctx context.Context, manifestId uint) ([]*AssetManifestFileDb, error)
+	// GetManifestFileData returns the gzip compressed contents of filePath
+	// in the given manifest.
+	GetManifestFileData(ctx context.Context, manifestId uint, filePath string) ([]byte, error)
+	CreateManifest(ctx context.Context, manifest *AssetManifestDb, files []*AssetManifestFileDb) error
+
+	// GetPinnedManifestId returns 0 if no manifest has been pinned yet
+	GetPinnedManifestId(ctx context.Context) (uint, error)
+	PinManifest(ctx context.Context, manifestId uint) error
+}
+
+type AssetManifestDb struct {
+	ManifestId  uint      `gorm:"primarykey"`
+	Description string    `gorm:"column:description"`
+	NumFiles    int       `gorm:"column:num_files"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at"`
+}
+
+func (AssetManifestDb) TableName() string {
+	return "asset_manifests"
+}
+
+type AssetManifestFileDb struct {
+	ManifestId   uint   `gorm:"primaryKey;autoIncrement:false;column:manifest_id"`
+	FilePathHash int64  `gorm:"primaryKey;autoIncrement:false;column:file_path_hash"`
+	FilePath     string `gorm:"column:file_path"`
+	ContentHash  string `gorm:"column:content_hash"`
+}
+
+func (AssetManifestFileDb) TableName() string {
+	return "asset_manifest_files"
+}
+
+type AssetManifestPinDb struct {
+	PinId      uint      `gorm:"primaryKey;autoIncrement:false;column:pin_id"`
+	ManifestId uint      `gorm:"column:manifest_id"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+}
+
+func (AssetManifestPinDb) TableName() string {
+	return "asset_manifest_pin"
+}
+
+// HashFilePath is the key the asset tables use for filePath
+func HashFilePath(filePath string) int64 {
+	return hashFilePath(filePath)
+}
+
+// HashContent is the content hash of the uncompressed asset data
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAssetIndexFile reports whether filePath is one of the top level index or
+// names JSON files that the AssetService loads from disk.
+func IsAssetIndexFile(filePath string) bool {
+	if strings.Contains(filePath, "/") {
+		return false
+	}
+	return strings.HasSuffix(filePath, "_index.json") || strings.HasSuffix(filePath, "_names.json")
+}
+
+type AssetManifestDiff struct {
+	FromManifestId uint     `json:"from_manifest_id"`
+	ToManifestId   uint     `json:"to_manifest_id"`
+	Added          []string `json:"added"`
+	Removed        []string `json:"removed"`
+	Changed        []string `json:"changed"`
+}
+
+// DiffManifestFiles returns the file paths which were added, removed or had
+// their contents changed going from -> to. The paths are sorted.
+func DiffManifestFiles(from []*AssetManifestFileDb, to []*AssetManifestFileDb) *AssetManifestDiff {
+	diff := &AssetManifestDiff{
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Changed: make([]string, 0),
+	}
+	fromFiles := make(map[string]string, len(from))
+	for _, file := range from {
+		fromFiles[file.FilePath] = file.ContentHash
+	}
+	toFiles := make(map[string]bool, len(to))
+	for _, file := range to {
+		toFiles[file.FilePath] = true
+		contentHash, ok := fromFiles[file.FilePath]
+		if !ok {
+			diff.Added = append(diff.Added, file.FilePath)
+		} else if contentHash != file.ContentHash {
+			diff.Changed = append(diff.Changed, file.FilePath)
+		}
+	}
+	for _, file := range from {
+		if !toFiles[file.FilePath] {
+			diff.Removed = append(diff.Removed, file.FilePath)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+type AssetManifestRepositoryPsql struct {
+	*DatbaseConn
+}
+
+func NewAssetManifestRepositoryPsql(akDb *DatbaseConn) *AssetManifestRepositoryPsql {
+	return &AssetManifestRepositoryPsql{
+		DatbaseConn: akDb,
+	}
+}
+
+func (r *AssetManifestRepositoryPsql) GetManifests(ctx context.Context) ([]*AssetManifestDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	manifests := make([]*AssetManifestDb, 0)
+	result := db.Order("manifest_id DESC").Find(&manifests)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return manifests, nil
+}
+
+func (r *AssetManifestRepositoryPsql) GetManifest(ctx context.Context, manifestId uint) (*AssetManifestDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var manifest AssetManifestDb
+	result := db.First(&manifest, manifestId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &manifest, nil
+}
+
+func (r *AssetManifestRepositoryPsql) GetManifestFiles(ctx context.Context, manifestId uint) ([]*AssetManifestFileDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	files := make([]*AssetManifestFileDb, 0)
+	result := db.Where("manifest_id = ?", manifestId).Find(&files)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return files, nil
+}
+
+func (r *AssetManifestRepositoryPsql) GetManifestFileData(ctx context.Context, manifestId uint, filePath string) ([]byte, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var row assetRow
+	err := db.Raw(
+		`SELECT b.data, b.created_at FROM asset_manifest_files f
+		JOIN asset_blobs b ON b.content_hash = f.content_hash
+		WHERE f.manifest_id = ? AND f.file_path_hash = ?`,
+		manifestId, hashFilePath(filePath),
+	).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(row.Data) == 0 {
+		return nil, ErrAssetNotFound
+	}
+	return row.Data, nil
+}
+
+func (r *AssetManifestRepositoryPsql) CreateManifest(ctx context.Context, manifest *AssetManifestDb, files []*AssetManifestFileDb) error {
+	return r.DefaultDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		manifest.NumFiles = len(files)
+		if err := tx.Create(manifest).Error; err != nil {
+			return err
+		}
+		for _, file := range files {
+			file.ManifestId = manifest.ManifestId
+		}
+		if len(files) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(files, ASSET_MANIFEST_INSERT_BATCH_SIZE).Error
+	})
+}
+
+func (r *AssetManifestRepositoryPsql) GetPinnedManifestId(ctx context.Context) (uint, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var pin AssetManifestPinDb
+	result := db.Where("pin_id = 1").First(&pin)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, result.Error
+	}
+	return pin.ManifestId, nil
+}
+
+func (r *AssetManifestRepositoryPsql) PinManifest(ctx context.Context, manifestId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+	pin := &AssetManifestPinDb{PinId: 1, ManifestId: manifestId}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "pin_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"manifest_id"}),
+	}).Create(pin).Error
+}
@@ -0,0 +1,115 @@
+package akdb
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type FakeAssetManifestRepository struct {
+	mutex     sync.Mutex
+	Manifests map[uint]*AssetManifestDb
+	Files     map[uint][]*AssetManifestFileDb
+	// Gzip compressed data keyed by content hash
+	Data             map[string][]byte
+	PinnedManifestId uint
+}
+
+func NewFakeAssetManifestRepository() *FakeAssetManifestRepository {
+	return &FakeAssetManifestRepository{
+		Manifests: make(map[uint]*AssetManifestDb),
+		Files:     make(map[uint][]*AssetManifestFileDb),
+		Data:      make(map[string][]byte),
+	}
+}
+
+// AddManifest creates a new manifest from the (uncompressed) file contents
+func (r *FakeAssetManifestRepository) AddManifest(files map[string][]byte) (*AssetManifestDb, error) {
+	manifestFiles := make([]*AssetManifestFileDb, 0, len(files))
+	for filePath, data := range files {
+		gzipped, err := gzipData(data)
+		if err != nil {
+			return nil, err
+		}
+		contentHash := HashContent(data)
+		r.mutex.Lock()
+		r.Data[contentHash] = gzipped
+		r.mutex.Unlock()
+		manifestFiles = append(manifestFiles, &AssetManifestFileDb{
+			FilePathHash: hashFilePath(filePath),
+			FilePath:     filePath,
+			ContentHash:  contentHash,
+		})
+	}
+	manifest := &AssetManifestDb{}
+	err := r.CreateManifest(context.Background(), manifest, manifestFiles)
+	return manifest, err
+}
+
+func (r *FakeAssetManifestRepository) GetManifests(ctx context.Context) ([]*AssetManifestDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	manifests := make([]*AssetManifestDb, 0, len(r.Manifests))
+	for _, manifest := range r.Manifests {
+		manifests = append(manifests, manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].ManifestId > manifests[j].ManifestId
+	})
+	return manifests, nil
+}
+
+func (r *FakeAssetManifestRepository) GetManifest(ctx context.Context, manifestId uint) (*AssetManifestDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	manifest, ok := r.Manifests[manifestId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return manifest, nil
+}
+
+func (r *FakeAssetManifestRepository) GetManifestFiles(ctx context.Context, manifestId uint) ([]*AssetManifestFileDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.Files[manifestId], nil
+}
+
+func (r *FakeAssetManifestRepository) GetManifestFileData(ctx context.Context, manifestId uint, filePath string) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, file := range r.Files[manifestId] {
+		if file.FilePath == filePath {
+			return r.Data[file.ContentHash], nil
+		}
+	}
+	return nil, ErrAssetNotFound
+}
+
+func (r *FakeAssetManifestRepository) CreateManifest(ctx context.Context, manifest *AssetManifestDb, files []*AssetManifestFileDb) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	manifest.ManifestId = uint(len(r.Manifests) + 1)
+	manifest.NumFiles = len(files)
+	for _, file := range files {
+		file.ManifestId = manifest.ManifestId
+	}
+	r.Manifests[manifest.ManifestId] = manifest
+	r.Files[manifest.ManifestId] = files
+	return nil
+}
+
+func (r *FakeAssetManifestRepository) GetPinnedManifestId(ctx context.Context) (uint, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.PinnedManifestId, nil
+}
+
+func (r *FakeAssetManifestRepository) PinManifest(ctx context.Context, manifestId uint) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.PinnedManifestId = manifestId
+	return nil
+}
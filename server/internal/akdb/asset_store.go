@@ -1,36 +1,120 @@
 package akdb
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/sha256"
-	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+)
+
+var ErrAssetNotFound = errors.New("asset not found")
+
+const (
+	ASSET_ENCODING_IDENTITY = ""
+	ASSET_ENCODING_GZIP     = "gzip"
 )
 
-type AssetStore struct {
-	db *DatbaseConn
+type Asset struct {
+	Data []byte
+	// The encoding of Data. Either ASSET_ENCODING_IDENTITY or ASSET_ENCODING_GZIP
+	ContentEncoding string
+	// Strong validator of Data. Does not include the surrounding quotes
+	ETag    string
+	ModTime time.Time
 }
 
-func NewAssetStore(db *DatbaseConn) *AssetStore {
-	return &AssetStore{db: db}
+func newAsset(data []byte, contentEncoding string, modTime time.Time) *Asset {
+	sum := sha256.Sum256(data)
+	return &Asset{
+		Data:            data,
+		ContentEncoding: contentEncoding,
+		ETag:            hex.EncodeToString(sum[:16]),
+		ModTime:         modTime,
+	}
 }
 
-func ProvideAssetStore(db *DatbaseConn) *AssetStore {
-	return NewAssetStore(db)
+// Decoded returns the asset contents without any content encoding applied.
+func (a *Asset) Decoded() ([]byte, error) {
+	if a.ContentEncoding != ASSET_ENCODING_GZIP {
+		return a.Data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(a.Data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
 }
 
-func hashFilePath(filePath string) int64 {
-	sum := sha256.Sum256([]byte(filePath))
-	return int64(binary.BigEndian.Uint64(sum[:8]))
+func gzipData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-type assetRow struct {
-	Data []byte
+type AssetStore interface {
+	// GetAsset returns ErrAssetNotFound if there is no asset at filePath.
+	// filePath is the forward-slash path relative to the asset root.
+	GetAsset(ctx context.Context, filePath string) (*Asset, error)
 }
 
-func (s *AssetStore) GetAsset(filePath string) ([]byte, error) {
-	var row assetRow
-	err := s.db.DefaultDB.Raw(
-		"SELECT data FROM asset_files WHERE file_path_hash = ?",
-		hashFilePath(filePath),
-	).Scan(&row).Error
-	return row.Data, err
+// AssetWriter is implemented by the stores which accept uploaded assets.
+type AssetWriter interface {
+	// PutAsset stores the uncompressed data at filePath, replacing any
+	// existing asset at that path.
+	PutAsset(ctx context.Context, filePath string, data []byte) error
+	// DeleteAsset removes the asset at filePath. Deleting a missing asset is
+	// not an error.
+	DeleteAsset(ctx context.Context, filePath string) error
+}
+
+// PinnedManifestStore is implemented by the stores which serve the pinned
+// manifest version. They cache which manifest is pinned so they must be told
+// when it changes.
+type PinnedManifestStore interface {
+	SetPinnedManifestId(manifestId uint)
+}
+
+func ProvideAssetStore(
+	db *DatbaseConn,
+	botConfig *misc.BotConfig,
+	assetDir misc.ImageAssetDirString,
+) (AssetStore, error) {
+	config := botConfig.AssetStore
+	if config == nil {
+		config = misc.DefaultAssetStoreConfig()
+	}
+	var store AssetStore
+	switch config.Backend {
+	case misc.ASSET_STORE_BACKEND_POSTGRES, "":
+		store = NewAssetStorePsql(db)
+	case misc.ASSET_STORE_BACKEND_FILESYSTEM:
+		store = NewAssetStoreFs(string(assetDir))
+	case misc.ASSET_STORE_BACKEND_S3:
+		store = NewAssetStoreS3(config)
+	default:
+		return nil, fmt.Errorf("unknown asset store backend %s", config.Backend)
+	}
+
+	if config.CacheSizeMb < 0 {
+		return store, nil
+	}
+	cacheSizeMb := config.CacheSizeMb
+	if cacheSizeMb == 0 {
+		cacheSizeMb = ASSET_CACHE_DEFAULT_SIZE_MB
+	}
+	return NewAssetCache(store, int64(cacheSizeMb)*1024*1024), nil
 }
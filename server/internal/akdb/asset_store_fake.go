@@ -0,0 +1,61 @@
+package akdb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type FakeAssetStore struct {
+	mutex    sync.Mutex
+	Assets   map[string]*Asset
+	NumGets  int
+	GetDelay time.Duration
+	// Set through SetPinnedManifestId
+	PinnedManifestId uint
+}
+
+func NewFakeAssetStore() *FakeAssetStore {
+	return &FakeAssetStore{
+		Assets: make(map[string]*Asset),
+	}
+}
+
+func (s *FakeAssetStore) Put(filePath string, data []byte, contentEncoding string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Assets[filePath] = newAsset(data, contentEncoding, time.Unix(0, 0))
+}
+
+func (s *FakeAssetStore) SetPinnedManifestId(manifestId uint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.PinnedManifestId = manifestId
+}
+
+func (s *FakeAssetStore) PutAsset(ctx context.Context, filePath string, data []byte) error {
+	s.Put(filePath, data, ASSET_ENCODING_IDENTITY)
+	return nil
+}
+
+// GetAsset returns the asset as it was when called, even with a GetDelay.
+func (s *FakeAssetStore) GetAsset(ctx context.Context, filePath string) (*Asset, error) {
+	s.mutex.Lock()
+	s.NumGets += 1
+	asset, ok := s.Assets[filePath]
+	s.mutex.Unlock()
+	if s.GetDelay > 0 {
+		time.Sleep(s.GetDelay)
+	}
+	if !ok {
+		return nil, ErrAssetNotFound
+	}
+	return asset, nil
+}
+
+func (s *FakeAssetStore) DeleteAsset(ctx context.Context, filePath string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.Assets, filePath)
+	return nil
+}
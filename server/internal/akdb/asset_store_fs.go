@@ -0,0 +1,62 @@
+package akdb
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// AssetStoreFs serves the assets directly from a directory on disk.
+type AssetStoreFs struct {
+	rootDir string
+}
+
+func NewAssetStoreFs(rootDir string) *AssetStoreFs {
+	return &AssetStoreFs{rootDir: rootDir}
+}
+
+// fullPath returns the path on disk of filePath
+func (s *AssetStoreFs) fullPath(filePath string) string {
+	// Cleaning against "/" prevents escaping the root with ".."
+	cleanPath := path.Clean("/" + filePath)
+	return filepath.Join(s.rootDir, filepath.FromSlash(cleanPath))
+}
+
+func (s *AssetStoreFs) GetAsset(ctx context.Context, filePath string) (*Asset, error) {
+	fullPath := s.fullPath(filePath)
+
+	info, err := os.Stat(fullPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrAssetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, ErrAssetNotFound
+	}
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	return newAsset(data, ASSET_ENCODING_IDENTITY, info.ModTime()), nil
+}
+
+func (s *AssetStoreFs) PutAsset(ctx context.Context, filePath string, data []byte) error {
+	fullPath := s.fullPath(filePath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(fullPath, data)
+}
+
+func (s *AssetStoreFs) DeleteAsset(ctx context.Context, filePath string) error {
+	err := os.Remove(s.fullPath(filePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package akdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AssetStorePsql serves the assets ingested by tools/ingest_assets. Asset paths
+// are resolved through the pinned manifest version to a content addressed
+// blob. Paths not in the manifest fall back to the uploaded assets. The legacy
+// asset_files table is only used when no manifest has been pinned. The data is
+// stored gzip compressed.
+//
+// The pinned manifest id is loaded once and then only changes through
+// SetPinnedManifestId, which AssetVersionService.Sync calls.
+type AssetStorePsql struct {
+	db *DatbaseConn
+
+	mutex        sync.Mutex
+	pinnedId     uint
+	pinnedLoaded bool
+}
+
+func NewAssetStorePsql(db *DatbaseConn) *AssetStorePsql {
+	return &AssetStorePsql{db: db}
+}
+
+func hashFilePath(filePath string) int64 {
+	sum := sha256.Sum256([]byte(filePath))
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+type assetRow struct {
+	Data      []byte
+	CreatedAt time.Time
+}
+
+func (s *AssetStorePsql) SetPinnedManifestId(manifestId uint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pinnedId = manifestId
+	s.pinnedLoaded = true
+}
+
+func (s *AssetStorePsql) getPinnedManifestId(ctx context.Context) (uint, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.pinnedLoaded {
+		return s.pinnedId, nil
+	}
+	manifestId, err := NewAssetManifestRepositoryPsql(s.db).GetPinnedManifestId(ctx)
+	if err != nil {
+		return 0, err
+	}
+	s.pinnedId = manifestId
+	s.pinnedLoaded = true
+	return manifestId, nil
+}
+
+// GetAsset looks up the asset with a single query. Manifest files take
+// priority over uploads, and the legacy table is only read without a pin.
+func (s *AssetStorePsql) GetAsset(ctx context.Context, filePath string) (*Asset, error) {
+	manifestId, err := s.getPinnedManifestId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db := s.db.DefaultDB.WithContext(ctx)
+	pathHash := hashFilePath(filePath)
+	var row assetRow
+	if manifestId > 0 {
+		err = db.Raw(
+			`SELECT data, created_at FROM (
+				SELECT b.data, b.created_at, 0 AS priority FROM asset_manifest_files f
+				JOIN asset_blobs b ON b.content_hash = f.content_hash
+				WHERE f.manifest_id = ? AND f.file_path_hash = ?
+				UNION ALL
+				SELECT b.data, u.updated_at AS created_at, 1 AS priority FROM asset_uploads u
+				JOIN asset_blobs b ON b.content_hash = u.content_hash
+				WHERE u.file_path_hash = ?
+			) a ORDER BY priority LIMIT 1`,
+			manifestId, pathHash, pathHash,
+		).Scan(&row).Error
+	} else {
+		err = db.Raw(
+			`SELECT data, created_at FROM (
+				SELECT b.data, u.updated_at AS created_at, 0 AS priority FROM asset_uploads u
+				JOIN asset_blobs b ON b.content_hash = u.content_hash
+				WHERE u.file_path_hash = ?
+				UNION ALL
+				SELECT data, created_at, 1 AS priority FROM asset_files
+				WHERE file_path_hash = ?
+			) a ORDER BY priority LIMIT 1`,
+			pathHash, pathHash,
+		).Scan(&row).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(row.Data) == 0 {
+		return nil, ErrAssetNotFound
+	}
+	return newAsset(row.Data, ASSET_ENCODING_GZIP, row.CreatedAt), nil
+}
+
+// PutAsset stores an uploaded asset. Uploads live outside of the manifests so
+// they survive ingests and rollbacks.
+func (s *AssetStorePsql) PutAsset(ctx context.Context, filePath string, data []byte) error {
+	gzipped, err := gzipData(data)
+	if err != nil {
+		return err
+	}
+	contentHash := HashContent(data)
+	return s.db.DefaultDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Touching created_at of an existing blob keeps the ingest tool's gc
+		// from collecting it before the upload references it. The row lock
+		// also makes a concurrent gc wait for this transaction.
+		err := tx.Exec(
+			`INSERT INTO asset_blobs (content_hash, data) VALUES (?, ?)
+			ON CONFLICT (content_hash) DO UPDATE SET created_at = CURRENT_TIMESTAMP`,
+			contentHash, gzipped,
+		).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec(
+			`INSERT INTO asset_uploads (file_path_hash, file_path, content_hash) VALUES (?, ?, ?)
+			ON CONFLICT (file_path_hash) DO UPDATE SET content_hash = EXCLUDED.content_hash`,
+			hashFilePath(filePath), filePath, contentHash,
+		).Error
+	})
+}
+
+// DeleteAsset removes the upload entry. The blob is left for the ingest
+// tool's gc to collect once nothing references it.
+func (s *AssetStorePsql) DeleteAsset(ctx context.Context, filePath string) error {
+	return s.db.DefaultDB.WithContext(ctx).Exec(
+		"DELETE FROM asset_uploads WHERE file_path_hash = ?",
+		hashFilePath(filePath),
+	).Error
+}
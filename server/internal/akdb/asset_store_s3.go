@@ -0,0 +1,208 @@
+package akdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+)
+
+const (
+	S3_REQUEST_TIMEOUT = 10 * time.Second
+	// sha256 of an empty body
+	s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// AssetStoreS3 serves assets from an S3-compatible object store (AWS S3,
+// MinIO, R2, ...) using path-style requests signed with AWS Signature V4.
+// Objects uploaded with "Content-Encoding: gzip" are served as-is.
+type AssetStoreS3 struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	prefix          string
+	accessKeyId     string
+	secretAccessKey string
+	httpClient      *http.Client
+}
+
+func NewAssetStoreS3(config *misc.AssetStoreConfig) *AssetStoreS3 {
+	endpoint, _ := url.Parse(strings.TrimSuffix(config.S3Endpoint, "/"))
+	return &AssetStoreS3{
+		endpoint:        endpoint,
+		region:          config.S3Region,
+		bucket:          config.S3Bucket,
+		prefix:          config.S3Prefix,
+		accessKeyId:     config.S3AccessKeyId,
+		secretAccessKey: config.S3SecretAccessKey,
+		httpClient:      &http.Client{Timeout: S3_REQUEST_TIMEOUT},
+	}
+}
+
+func (s *AssetStoreS3) objectKey(filePath string) string {
+	return s.prefix + strings.TrimPrefix(filePath, "/")
+}
+
+func (s *AssetStoreS3) objectUrl(key string) string {
+	objectPath := s.endpoint.Path + "/" + s.bucket + "/" + key
+	u := *s.endpoint
+	u.Path = objectPath
+	u.RawPath = s3UriEscape(objectPath)
+	return u.String()
+}
+
+func (s *AssetStoreS3) GetAsset(ctx context.Context, filePath string) (*Asset, error) {
+	key := s.objectKey(filePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectUrl(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Setting this explicitly stops the transport from transparently
+	// decompressing gzip objects, they are served to clients as-is
+	req.Header.Set("Accept-Encoding", "gzip")
+	s.signRequest(req, misc.Clock.Now().UTC(), s3EmptyPayloadHash)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrAssetNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s3 get object %s failed with status %d", key, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := ASSET_ENCODING_IDENTITY
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		encoding = ASSET_ENCODING_GZIP
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return newAsset(data, encoding, modTime), nil
+}
+
+// PutAsset uploads the data gzip compressed with "Content-Encoding: gzip" so
+// that it is served as-is by GetAsset.
+func (s *AssetStoreS3) PutAsset(ctx context.Context, filePath string, data []byte) error {
+	gzipped, err := gzipData(data)
+	if err != nil {
+		return err
+	}
+	key := s.objectKey(filePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectUrl(key), bytes.NewReader(gzipped))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "gzip")
+	payloadHash := sha256.Sum256(gzipped)
+	s.signRequest(req, misc.Clock.Now().UTC(), hex.EncodeToString(payloadHash[:]))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 put object %s failed with status %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *AssetStoreS3) DeleteAsset(ctx context.Context, filePath string) error {
+	key := s.objectKey(filePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectUrl(key), nil)
+	if err != nil {
+		return err
+	}
+	s.signRequest(req, misc.Clock.Now().UTC(), s3EmptyPayloadHash)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("s3 delete object %s failed with status %d", key, resp.StatusCode)
+	}
+}
+
+// signRequest adds the AWS Signature V4 headers. payloadHash is the hex
+// sha256 of the request body.
+func (s *AssetStoreS3) signRequest(req *http.Request, now time.Time, payloadHash string) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + s.region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+s.secretAccessKey), dateStamp)
+	signingKey = hmacSha256(signingKey, s.region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyId, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3UriEscape escapes every byte except the unreserved characters and '/'
+// as required by the canonical request of Signature V4.
+func s3UriEscape(p string) string {
+	var sb strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,178 @@
+package akdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"gorm.io/gorm"
+)
+
+var ErrAssetVersioningDisabled = errors.New("asset versioning requires the postgres asset store")
+var ErrManifestNotFound = errors.New("asset manifest not found")
+
+// AssetVersionService manages which manifest version of the asset catalog is
+// served. Only the postgres asset store is versioned.
+type AssetVersionService struct {
+	repo     AssetManifestRepository
+	store    AssetStore
+	assetDir string
+	enabled  bool
+}
+
+func NewAssetVersionService(
+	repo AssetManifestRepository,
+	store AssetStore,
+	botConfig *misc.BotConfig,
+	assetDir misc.ImageAssetDirString,
+) *AssetVersionService {
+	enabled := true
+	if botConfig.AssetStore != nil {
+		backend := botConfig.AssetStore.Backend
+		enabled = backend == misc.ASSET_STORE_BACKEND_POSTGRES || backend == ""
+	}
+	return &AssetVersionService{
+		repo:     repo,
+		store:    store,
+		assetDir: string(assetDir),
+		enabled:  enabled,
+	}
+}
+
+func (s *AssetVersionService) Enabled() bool {
+	return s.enabled
+}
+
+// GetManifests returns all the manifest versions (newest first) along with
+// the id of the pinned manifest.
+func (s *AssetVersionService) GetManifests(ctx context.Context) ([]*AssetManifestDb, uint, error) {
+	if !s.enabled {
+		return nil, 0, ErrAssetVersioningDisabled
+	}
+	manifests, err := s.repo.GetManifests(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	pinnedId, err := s.repo.GetPinnedManifestId(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return manifests, pinnedId, nil
+}
+
+func (s *AssetVersionService) Diff(ctx context.Context, fromId uint, toId uint) (*AssetManifestDiff, error) {
+	if !s.enabled {
+		return nil, ErrAssetVersioningDisabled
+	}
+	fromFiles, err := s.getManifestFiles(ctx, fromId)
+	if err != nil {
+		return nil, err
+	}
+	toFiles, err := s.getManifestFiles(ctx, toId)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffManifestFiles(fromFiles, toFiles)
+	diff.FromManifestId = fromId
+	diff.ToManifestId = toId
+	return diff, nil
+}
+
+func (s *AssetVersionService) getManifestFiles(ctx context.Context, manifestId uint) ([]*AssetManifestFileDb, error) {
+	if err := s.checkManifestExists(ctx, manifestId); err != nil {
+		return nil, err
+	}
+	return s.repo.GetManifestFiles(ctx, manifestId)
+}
+
+// checkManifestExists returns ErrManifestNotFound for an unknown manifest id
+// so that callers can tell it apart from a failing repository.
+func (s *AssetVersionService) checkManifestExists(ctx context.Context, manifestId uint) error {
+	_, err := s.repo.GetManifest(ctx, manifestId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("manifest %d: %w", manifestId, ErrManifestNotFound)
+	}
+	return err
+}
+
+// Rollback pins manifestId as the served version and syncs the index files
+// on disk to match. The caller is responsible for reloading the AssetService.
+func (s *AssetVersionService) Rollback(ctx context.Context, manifestId uint) error {
+	if !s.enabled {
+		return ErrAssetVersioningDisabled
+	}
+	if err := s.checkManifestExists(ctx, manifestId); err != nil {
+		return err
+	}
+	if err := s.repo.PinManifest(ctx, manifestId); err != nil {
+		return err
+	}
+	log.Println("Pinned asset manifest", manifestId)
+	return s.Sync(ctx)
+}
+
+// Sync writes the index files of the pinned manifest into the asset directory
+// and drops any cached assets. Does nothing if no manifest has been pinned.
+func (s *AssetVersionService) Sync(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	manifestId, err := s.repo.GetPinnedManifestId(ctx)
+	if err != nil {
+		return err
+	}
+	if manifestId == 0 {
+		return nil
+	}
+	files, err := s.repo.GetManifestFiles(ctx, manifestId)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !IsAssetIndexFile(file.FilePath) {
+			continue
+		}
+		data, err := s.repo.GetManifestFileData(ctx, manifestId, file.FilePath)
+		if err != nil {
+			return err
+		}
+		asset := &Asset{Data: data, ContentEncoding: ASSET_ENCODING_GZIP}
+		decoded, err := asset.Decoded()
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(s.assetDir, file.FilePath), decoded); err != nil {
+			return err
+		}
+	}
+
+	if pinned, ok := s.store.(PinnedManifestStore); ok {
+		pinned.SetPinnedManifestId(manifestId)
+	}
+	if cache, ok := s.store.(*AssetCache); ok {
+		cache.Purge()
+	}
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file first so that a reader never
+// sees a partially written index file.
+func writeFileAtomic(filePath string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), filePath)
+}
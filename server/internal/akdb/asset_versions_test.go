@@ -0,0 +1,93 @@
+package akdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffManifestFiles(t *testing.T) {
+	assert := assert.New(t)
+	from := []*AssetManifestFileDb{
+		{FilePath: "a.png", ContentHash: "1"},
+		{FilePath: "b.png", ContentHash: "2"},
+		{FilePath: "c.png", ContentHash: "3"},
+	}
+	to := []*AssetManifestFileDb{
+		{FilePath: "a.png", ContentHash: "1"},
+		{FilePath: "c.png", ContentHash: "4"},
+		{FilePath: "d.png", ContentHash: "5"},
+	}
+
+	diff := DiffManifestFiles(from, to)
+	assert.Equal([]string{"d.png"}, diff.Added)
+	assert.Equal([]string{"b.png"}, diff.Removed)
+	assert.Equal([]string{"c.png"}, diff.Changed)
+}
+
+func TestIsAssetIndexFile(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(IsAssetIndexFile("characters_index.json"))
+	assert.True(IsAssetIndexFile("saved_names.json"))
+	assert.False(IsAssetIndexFile("characters/ela/ela.png"))
+	assert.False(IsAssetIndexFile("characters/ela/custom_index.json"))
+}
+
+func TestAssetVersionService_Rollback(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	repo := NewFakeAssetManifestRepository()
+	v1, err := repo.AddManifest(map[string][]byte{
+		"characters_index.json": []byte(`{"version": 1}`),
+		"characters/a.png":      []byte("a1"),
+	})
+	assert.Nil(err)
+	v2, err := repo.AddManifest(map[string][]byte{
+		"characters_index.json": []byte(`{"version": 2}`),
+		"characters/a.png":      []byte("a2"),
+		"characters/b.png":      []byte("b2"),
+	})
+	assert.Nil(err)
+	repo.PinnedManifestId = v2.ManifestId
+
+	store := NewFakeAssetStore()
+	cache := NewAssetCache(store, 100)
+	cache.add(hashFilePath("characters/a.png"), newAsset([]byte("a2"), ASSET_ENCODING_IDENTITY, misc.Clock.Now()), 0)
+	sut := NewAssetVersionService(repo, cache, &misc.BotConfig{}, misc.ImageAssetDirString(assetDir))
+
+	diff, err := sut.Diff(context.TODO(), v2.ManifestId, v1.ManifestId)
+	assert.Nil(err)
+	assert.Equal([]string{"characters/b.png"}, diff.Removed)
+	assert.Equal([]string{"characters/a.png", "characters_index.json"}, diff.Changed)
+
+	err = sut.Rollback(context.TODO(), v1.ManifestId)
+	assert.Nil(err)
+	assert.Equal(v1.ManifestId, repo.PinnedManifestId)
+	data, err := os.ReadFile(filepath.Join(assetDir, "characters_index.json"))
+	assert.Nil(err)
+	assert.Equal(`{"version": 1}`, string(data))
+	assert.Equal(0, cache.Stats().Entries)
+	assert.Equal(v1.ManifestId, store.PinnedManifestId)
+
+	_, err = sut.Diff(context.TODO(), v1.ManifestId, 99)
+	assert.ErrorIs(err, ErrManifestNotFound)
+
+	err = sut.Rollback(context.TODO(), 99)
+	assert.ErrorIs(err, ErrManifestNotFound)
+	assert.Equal(v1.ManifestId, repo.PinnedManifestId)
+}
+
+func TestAssetVersionService_DisabledForOtherBackends(t *testing.T) {
+	assert := assert.New(t)
+	botConfig := &misc.BotConfig{
+		AssetStore: &misc.AssetStoreConfig{Backend: misc.ASSET_STORE_BACKEND_FILESYSTEM},
+	}
+	sut := NewAssetVersionService(NewFakeAssetManifestRepository(), NewFakeAssetStore(), botConfig, "")
+	assert.False(sut.Enabled())
+	assert.ErrorIs(sut.Rollback(context.TODO(), 1), ErrAssetVersioningDisabled)
+	assert.Nil(sut.Sync(context.TODO()))
+}
@@ -6,16 +6,21 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/customchibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,6 +37,7 @@ func Setup_TestApiServer(username string) (*ApiServer, *auth.FakeAuthService) {
 	roomManager := room.NewFakeRoomsManager()
 	authService := auth.NewFakeAuthService()
 	roomsRepo := room.NewRoomRepositoryPsql(db)
+	roomPermsRepo := room.NewRoomPermissionRepositoryPsql(db)
 	usersRepo := users.NewUserRepositoryPsql(db)
 	userPrefsRepo := users.NewUserPreferencesRepositoryPsql(db)
 	assetsService := operator.NewTestAssetService()
@@ -64,9 +70,15 @@ func Setup_TestApiServer(username string) (*ApiServer, *auth.FakeAuthService) {
 		roomManager,
 		authService,
 		roomsRepo,
+		roomPermsRepo,
 		usersRepo,
 		userPrefsRepo,
 		operatorService,
+		twitch_api.NewFakeTwitchApiClient(),
+		webhook.NewWebhookService(webhook.NewFakeWebhookRepository()),
+		events.NewHub(),
+		customchibi.NewCustomChibiService(customchibi.NewFakeCustomChibiRepository(), akdb.NewFakeAssetStore()),
+		akdb.NewAssetVersionService(akdb.NewFakeAssetManifestRepository(), akdb.NewFakeAssetStore(), botConfig, ""),
 		botConfig,
 	)
 	return apiServer, authService
@@ -366,3 +378,163 @@ func TestApiServer_HandleRoomRefresh_HappyPath(t *testing.T) {
 	fmt.Println(resp.Header.Get("Content-Type"))
 	fmt.Println(string(body))
 }
+
+func TestApiServer_HandleGetRoomChibis_HugeOffset(t *testing.T) {
+	assert := assert.New(t)
+	username := "test-api-server-chibis"
+	sut, _ := Setup_TestApiServer(username)
+	err := sut.roomsManager.CreateRoomOrNoOp(context.TODO(), username)
+	if err != nil {
+		assert.Fail(err.Error())
+	}
+
+	req := httptest.NewRequest(
+		"GET",
+		fmt.Sprintf("http://example.com/api/rooms/control/chibis/?channel_name=%s&offset=9223372036854775807", username),
+		nil,
+	)
+	req.Header.Set("Authorization", "Bearer foo")
+	w := httptest.NewRecorder()
+	sut.middleware(sut.HandleGetRoomChibis).ServeHTTP(w, req)
+
+	resp := w.Result()
+	assert.Equal(200, resp.StatusCode)
+	var gotObj GetRoomChibisResponse
+	assert.Nil(json.NewDecoder(resp.Body).Decode(&gotObj))
+	assert.Empty(gotObj.Chatters)
+}
+
+func TestApiServer_ApiKey_ScopeChecks(t *testing.T) {
+	assert := assert.New(t)
+	username := "test-api-server-apikey-1"
+	sut, authService := Setup_TestApiServer(username)
+	err := sut.roomsManager.CreateRoomOrNoOp(context.TODO(), username)
+	if err != nil {
+		assert.Fail(err.Error())
+	}
+	readKey, _, _ := authService.CreateApiKey(
+		context.TODO(), authService.UserId, username, "read",
+		[]auth.ApiKeyScope{auth.API_KEY_SCOPE_ROOM_SETTINGS_READ},
+	)
+	url := fmt.Sprintf("http://example.com/api/rooms/settings/?channel_name=%s", username)
+
+	{
+		// Key with the right scope can read the settings
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+readKey)
+		w := httptest.NewRecorder()
+		sut.middlewareScoped(sut.HandleGetRoomSettings, auth.API_KEY_SCOPE_ROOM_SETTINGS_READ).ServeHTTP(w, req)
+		assert.Equal(200, w.Result().StatusCode)
+	}
+	{
+		// Key without the write scope cannot update the settings
+		req := httptest.NewRequest("POST", "http://example.com/api/rooms/settings/", strings.NewReader("{}"))
+		req.Header.Set("Authorization", "Bearer "+readKey)
+		w := httptest.NewRecorder()
+		sut.middlewareScoped(sut.HandleUpdateRoomSettings, auth.API_KEY_SCOPE_ROOM_SETTINGS_WRITE).ServeHTTP(w, req)
+		assert.Equal(403, w.Result().StatusCode)
+	}
+	{
+		// Keys can never be used on unscoped endpoints
+		req := httptest.NewRequest("GET", "http://example.com/api/apikeys/", nil)
+		req.Header.Set("Authorization", "Bearer "+readKey)
+		w := httptest.NewRecorder()
+		sut.middleware(sut.HandleGetApiKeys).ServeHTTP(w, req)
+		assert.Equal(403, w.Result().StatusCode)
+	}
+}
+
+func TestApiServer_Catalog(t *testing.T) {
+	assert := assert.New(t)
+	sut := &ApiServer{
+		operatorsService: operator.NewDefaultOperatorService(operator.NewTestAssetService()),
+	}
+	mux := http.NewServeMux()
+	sut.RegisterHandlers(mux)
+
+	req := httptest.NewRequest("GET", "http://example.com/api/catalog/operators/?q=ami&limit=10", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(200, w.Result().StatusCode)
+	var page operator.CatalogPage
+	assert.Nil(json.NewDecoder(w.Body).Decode(&page))
+	assert.Equal(1, page.Total)
+	assert.Equal("char_002_amiya", page.Entries[0].Id)
+
+	etag := w.Result().Header.Get("ETag")
+	req = httptest.NewRequest("GET", "http://example.com/api/catalog/operators/?q=ami&limit=10", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(304, w.Result().StatusCode)
+
+	req = httptest.NewRequest("GET", "http://example.com/api/catalog/enemies/enemy_1007_slime_2/", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(200, w.Result().StatusCode)
+
+	req = httptest.NewRequest("GET", "http://example.com/api/catalog/enemies/char_002_amiya/", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(404, w.Result().StatusCode)
+
+	req = httptest.NewRequest("GET", "http://example.com/api/catalog/enemies/?offset=-1", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(400, w.Result().StatusCode)
+
+	req = httptest.NewRequest("GET", "http://example.com/api/catalog/operators/?offset=9223372036854775807", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(200, w.Result().StatusCode)
+	assert.Nil(json.NewDecoder(w.Body).Decode(&page))
+	assert.Equal(1, page.Total)
+	assert.Empty(page.Entries)
+}
+
+type failingAssetManifestRepository struct {
+	*akdb.FakeAssetManifestRepository
+}
+
+func (r *failingAssetManifestRepository) GetManifest(ctx context.Context, manifestId uint) (*akdb.AssetManifestDb, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func TestApiServer_AssetManifestsStatusCodes(t *testing.T) {
+	assert := assert.New(t)
+	repo := akdb.NewFakeAssetManifestRepository()
+	manifest, err := repo.AddManifest(map[string][]byte{
+		"characters_index.json": []byte(`{}`),
+	})
+	assert.Nil(err)
+
+	call := func(repo akdb.AssetManifestRepository, botConfig *misc.BotConfig, path string) int {
+		sut := &ApiServer{
+			assetVersions: akdb.NewAssetVersionService(repo, akdb.NewFakeAssetStore(), botConfig, ""),
+		}
+		handler := sut.HandleGetAssetManifests
+		if strings.Contains(path, "/diff/") {
+			handler = sut.HandleDiffAssetManifests
+		}
+		req := httptest.NewRequest("GET", "http://example.com"+path, nil)
+		w := httptest.NewRecorder()
+		misc.ErrorHandling(handler).ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+	diffPath := func(fromId uint, toId uint) string {
+		return fmt.Sprintf("/api/admin/assets/manifests/diff/?from=%d&to=%d", fromId, toId)
+	}
+	disabled := &misc.BotConfig{
+		AssetStore: &misc.AssetStoreConfig{Backend: misc.ASSET_STORE_BACKEND_FILESYSTEM},
+	}
+	failing := &failingAssetManifestRepository{repo}
+	id := manifest.ManifestId
+
+	assert.Equal(200, call(repo, &misc.BotConfig{}, "/api/admin/assets/manifests/"))
+	assert.Equal(400, call(repo, disabled, "/api/admin/assets/manifests/"))
+	assert.Equal(200, call(repo, &misc.BotConfig{}, diffPath(id, id)))
+	assert.Equal(400, call(repo, &misc.BotConfig{}, "/api/admin/assets/manifests/diff/?from=abc&to=1"))
+	assert.Equal(400, call(repo, disabled, diffPath(id, id)))
+	assert.Equal(404, call(repo, &misc.BotConfig{}, diffPath(id, 99)))
+	assert.Equal(500, call(failing, &misc.BotConfig{}, diffPath(id, id)))
+}
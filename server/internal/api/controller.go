@@ -2,30 +2,54 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/customchibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 	"github.com/google/uuid"
 )
 
+const (
+	// The catalog only changes when the assets are updated
+	CATALOG_CACHE_CONTROL = "public, max-age=300"
+	// Keeps idle event streams from being closed by proxies
+	EVENTS_HEARTBEAT_INTERVAL = 15 * time.Second
+	// Bulk admin operations wait on every room
+	ADMIN_BULK_TIMEOUT = 2 * time.Minute
+)
+
 type ApiServer struct {
 	roomsManager     *room.RoomsManager
 	authService      auth.AuthServiceInterface
 	roomRepo         room.RoomRepository
+	roomPermsRepo    room.RoomPermissionRepository
 	usersRepo        users.UserRepository
 	userPrefsRepo    users.UserPreferencesRepository
 	operatorsService *operator.OperatorService
+	twitchClient     twitch_api.TwitchApiClientInterface
+	webhookService   *webhook.WebhookService
+	eventsHub        *events.Hub
+	customChibis     *customchibi.CustomChibiService
+	assetVersions    *akdb.AssetVersionService
 	botConfig        *misc.BotConfig
 }
 
@@ -35,9 +59,15 @@ func NewApiServer(
 	roomManager *room.RoomsManager,
 	authService auth.AuthServiceInterface,
 	roomRepo room.RoomRepository,
+	roomPermsRepo room.RoomPermissionRepository,
 	usersRepo users.UserRepository,
 	userPrefsRepo users.UserPreferencesRepository,
 	operatorService *operator.OperatorService,
+	twitchClient twitch_api.TwitchApiClientInterface,
+	webhookService *webhook.WebhookService,
+	eventsHub *events.Hub,
+	customChibis *customchibi.CustomChibiService,
+	assetVersions *akdb.AssetVersionService,
 	botConfig *misc.BotConfig,
 ) *ApiServer {
 	log.Println("NewApiServer created")
@@ -45,17 +75,54 @@ func NewApiServer(
 		roomsManager:     roomManager,
 		authService:      authService,
 		roomRepo:         roomRepo,
+		roomPermsRepo:    roomPermsRepo,
 		usersRepo:        usersRepo,
 		userPrefsRepo:    userPrefsRepo,
 		operatorsService: operatorService,
+		twitchClient:     twitchClient,
+		webhookService:   webhookService,
+		eventsHub:        eventsHub,
+		customChibis:     customChibis,
+		assetVersions:    assetVersions,
 		botConfig:        botConfig,
 	}
 }
 
+type routeHandler interface {
+	Handle(pattern string, handler http.Handler)
+}
+
 func (s *ApiServer) RegisterHandlers(rootMux *http.ServeMux) {
 	mux := http.NewServeMux()
-	mux.Handle("GET  /api/rooms/settings/{$}", s.middleware(s.HandleGetRoomSettings))
-	mux.Handle("POST /api/rooms/settings/{$}", s.middleware(s.HandleUpdateRoomSettings))
+	s.registerRoutes(mux)
+	rootMux.Handle("/api/", mux)
+}
+
+// registerRoutes registers all of the API's routes. Every pattern must also be
+// documented in apiRoutes for the OpenAPI document.
+func (s *ApiServer) registerRoutes(mux routeHandler) {
+	mux.Handle("GET  /api/rooms/settings/{$}", s.middlewareScoped(s.HandleGetRoomSettings, auth.API_KEY_SCOPE_ROOM_SETTINGS_READ))
+	mux.Handle("POST /api/rooms/settings/{$}", s.middlewareScoped(s.HandleUpdateRoomSettings, auth.API_KEY_SCOPE_ROOM_SETTINGS_WRITE))
+	mux.Handle("POST /api/rooms/chibis/set/{$}", s.middlewareScoped(s.HandleSetChatterChibi, auth.API_KEY_SCOPE_CHIBI_SET))
+	mux.Handle("GET /api/rooms/permissions/{$}", s.middleware(s.HandleGetRoomPermissions))
+	mux.Handle("POST /api/rooms/permissions/{$}", s.middleware(s.HandleSetRoomPermission))
+	mux.Handle("DELETE /api/rooms/permissions/{$}", s.middleware(s.HandleDeleteRoomPermission))
+	mux.Handle("GET /api/rooms/webhooks/{$}", s.middleware(s.HandleGetWebhooks))
+	mux.Handle("POST /api/rooms/webhooks/{$}", s.middleware(s.HandleCreateWebhook))
+	mux.Handle("DELETE /api/rooms/webhooks/{$}", s.middleware(s.HandleDeleteWebhook))
+	mux.Handle("GET /api/rooms/webhooks/deliveries/{$}", s.middleware(s.HandleGetWebhookDeliveries))
+	mux.Handle("POST /api/rooms/webhooks/test/{$}", s.middleware(s.HandleTestWebhook))
+	mux.Handle("GET /api/rooms/events/{$}", s.middlewareStream(s.HandleRoomEvents, false))
+	mux.Handle("GET /api/rooms/custom_chibis/{$}", s.middleware(s.HandleGetCustomChibis))
+	mux.Handle("POST /api/rooms/custom_chibis/{$}", s.middleware(s.HandleUploadCustomChibi))
+	mux.Handle("DELETE /api/rooms/custom_chibis/{$}", s.middleware(s.HandleDeleteCustomChibi))
+	mux.Handle("POST /api/rooms/chibis/remove/{$}", s.middlewareScoped(s.HandleRemoveChatterChibi, auth.API_KEY_SCOPE_CHIBI_REMOVE))
+	mux.Handle("GET /api/rooms/control/chibis/{$}", s.middleware(s.HandleGetRoomChibis))
+	mux.Handle("POST /api/rooms/control/kick/{$}", s.middleware(s.HandleKickChatter))
+	mux.Handle("POST /api/rooms/control/reset/{$}", s.middleware(s.HandleResetChatter))
+	mux.Handle("POST /api/rooms/control/find/{$}", s.middleware(s.HandleFindChatter))
+	mux.Handle("POST /api/rooms/control/pause/{$}", s.middleware(s.HandlePauseRoom))
+	mux.Handle("POST /api/rooms/control/clear/{$}", s.middleware(s.HandleClearRoom))
 	mux.Handle("POST /api/rooms/remove/{$}", s.middlewareAdmin(s.HandleRemoveRoom))
 	mux.Handle("POST /api/rooms/refresh/{$}", s.middlewareAdmin(s.HandleRoomRefresh))
 	mux.Handle("POST /api/rooms/users/remove/{$}", s.middlewareAdmin(s.HandleRemoveUser))
@@ -65,16 +132,54 @@ func (s *ApiServer) RegisterHandlers(rootMux *http.ServeMux) {
 	mux.Handle("GET /api/users/preferences/{$}", s.middleware(s.HandleGetUserPreferences))
 	mux.Handle("POST /api/users/preferences/{$}", s.middleware(s.HandleUpdateUserPreferences))
 	mux.Handle("DELETE /api/users/preferences/{$}", s.middleware(s.HandleDeleteUserPreferences))
+	mux.Handle("GET /api/users/loadouts/{$}", s.middleware(s.HandleGetUserLoadouts))
+	mux.Handle("POST /api/users/loadouts/{$}", s.middleware(s.HandleSaveUserLoadout))
+	mux.Handle("DELETE /api/users/loadouts/{$}", s.middleware(s.HandleDeleteUserLoadout))
+	mux.Handle("POST /api/users/loadouts/rename/{$}", s.middleware(s.HandleRenameUserLoadout))
+	mux.Handle("POST /api/users/loadouts/select/{$}", s.middleware(s.HandleSelectUserLoadout))
 	mux.Handle("GET  /api/admin/info/{$}", s.middlewareAdmin(s.HandleAdminInfo))
+	mux.Handle("GET /api/admin/rooms/{$}", s.middlewareAdmin(s.HandleAdminListRooms))
+	mux.Handle("GET /api/admin/rooms/{channel}/chatters/{$}", s.middlewareAdmin(s.HandleAdminListChatters))
+	mux.Handle("GET /api/admin/events/{$}", s.middlewareStream(s.HandleAdminEvents, true))
+	mux.Handle("POST /api/admin/rooms/refresh/{$}", s.middlewareAdminBulk(s.HandleAdminRefreshRooms))
+	mux.Handle("POST /api/admin/rooms/evict/{$}", s.middlewareAdminBulk(s.HandleAdminEvictRooms))
+	mux.Handle("POST /api/admin/rooms/announce/{$}", s.middlewareAdminBulk(s.HandleAdminAnnounce))
+	mux.Handle("POST /api/admin/assets/reload/{$}", s.middlewareAdmin(s.HandleReloadAssets))
+	mux.Handle("GET /api/admin/assets/manifests/{$}", s.middlewareAdmin(s.HandleGetAssetManifests))
+	mux.Handle("GET /api/admin/assets/manifests/diff/{$}", s.middlewareAdmin(s.HandleDiffAssetManifests))
+	mux.Handle("POST /api/admin/assets/rollback/{$}", s.middlewareAdmin(s.HandleRollbackAssets))
+
+	mux.Handle("GET /api/catalog/operators/{$}", s.middlewarePublic(s.HandleListCatalog(operator.FACTION_ENUM_OPERATOR)))
+	mux.Handle("GET /api/catalog/operators/{id}/{$}", s.middlewarePublic(s.HandleGetCatalogDetail(operator.FACTION_ENUM_OPERATOR)))
+	mux.Handle("GET /api/catalog/enemies/{$}", s.middlewarePublic(s.HandleListCatalog(operator.FACTION_ENUM_ENEMY)))
+	mux.Handle("GET /api/catalog/enemies/{id}/{$}", s.middlewarePublic(s.HandleGetCatalogDetail(operator.FACTION_ENUM_ENEMY)))
+	mux.Handle("GET /api/catalog/factions/{$}", s.middlewarePublic(s.HandleGetFactions))
+	mux.Handle("GET /api/catalog/factions/{faction}/{$}", s.middlewarePublic(s.HandleListFactionCatalog))
+	mux.Handle("GET /api/catalog/factions/{faction}/{id}/{$}", s.middlewarePublic(s.HandleGetFactionCatalogDetail))
+
+	mux.Handle("GET /api/apikeys/{$}", s.middleware(s.HandleGetApiKeys))
+	mux.Handle("POST /api/apikeys/{$}", s.middleware(s.HandleCreateApiKey))
+	mux.Handle("DELETE /api/apikeys/{$}", s.middleware(s.HandleRevokeApiKey))
+
+	mux.Handle("GET /api/openapi.json", s.middlewarePublic(s.HandleGetOpenApi))
 
 	// mux.Handle("GET  /api/vul/get/{$}", s.middleware(s.HandleVulGet))
 	// mux.Handle("POST /api/vul/post/{$}", s.middleware(s.HandleVulPost))
 	// mux.Handle("GET  /api/vul/csrf/{$}", s.middleware(s.HandleVulCsrf))
-
-	rootMux.Handle("/api/", mux)
 }
 
 func (s *ApiServer) CheckForAuthToken(h misc.HandlerWithErr, checkAdmin bool) misc.HandlerWithErr {
+	return s.CheckForAuthTokenWithScope(h, checkAdmin, "")
+}
+
+// CheckForAuthTokenWithScope accepts either a user JWT token or an API key.
+// API keys are only accepted if the endpoint declares a scope and the key
+// was granted that scope.
+func (s *ApiServer) CheckForAuthTokenWithScope(
+	h misc.HandlerWithErr,
+	checkAdmin bool,
+	scope auth.ApiKeyScope,
+) misc.HandlerWithErr {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		bearerToken := r.Header.Get("Authorization")
 		if len(bearerToken) > 7 && strings.ToLower(bearerToken[0:6]) == "bearer" {
@@ -87,17 +192,48 @@ func (s *ApiServer) CheckForAuthToken(h misc.HandlerWithErr, checkAdmin bool) mi
 			)
 		}
 
-		claims, err := s.authService.ValidateJWTToken(bearerToken)
-		if err != nil {
-			return misc.NewHumanReadableError(
-				"invalid token",
-				http.StatusUnauthorized,
-				err,
-			)
+		var userId uint
+		var twitchUserId string
+		var twitchUserName string
+		var apiKey *auth.ApiKeyDb
+		if auth.IsApiKey(bearerToken) {
+			if checkAdmin || len(scope) == 0 {
+				return misc.NewHumanReadableError(
+					"api keys can not be used for this endpoint",
+					http.StatusForbidden,
+					fmt.Errorf("api key used on unscoped endpoint"),
+				)
+			}
+			var err error
+			apiKey, err = s.authService.ValidateApiKey(r.Context(), bearerToken)
+			if err != nil {
+				return misc.NewHumanReadableError(
+					"invalid api key",
+					http.StatusUnauthorized,
+					err,
+				)
+			}
+			if !apiKey.HasScope(scope) {
+				return misc.NewHumanReadableError(
+					fmt.Sprintf("api key is missing the %s scope", scope),
+					http.StatusForbidden,
+					fmt.Errorf("api key %d missing scope %s", apiKey.ApiKeyId, scope),
+				)
+			}
+			userId = apiKey.UserId
+		} else {
+			claims, err := s.authService.ValidateJWTToken(bearerToken)
+			if err != nil {
+				return misc.NewHumanReadableError(
+					"invalid token",
+					http.StatusUnauthorized,
+					err,
+				)
+			}
+			userId = claims.UserId
+			twitchUserId = claims.TwitchUserId
+			twitchUserName = claims.TwitchUserName
 		}
-		userId := claims.UserId
-		twitchUserId := claims.TwitchUserId
-		twitchUserName := claims.TwitchUserName
 
 		userDb, err := s.usersRepo.GetById(r.Context(), userId)
 		if err != nil {
@@ -107,6 +243,10 @@ func (s *ApiServer) CheckForAuthToken(h misc.HandlerWithErr, checkAdmin bool) mi
 				err,
 			)
 		}
+		if apiKey != nil {
+			twitchUserId = userDb.TwitchUserId
+			twitchUserName = userDb.Username
+		}
 
 		if checkAdmin {
 			if userDb.UserRole.Valid && userDb.UserRole.String != "admin" {
@@ -126,6 +266,10 @@ func (s *ApiServer) CheckForAuthToken(h misc.HandlerWithErr, checkAdmin bool) mi
 			newContext, auth.CONTEXT_USER_ID, userId)
 		newContext = context.WithValue(
 			newContext, auth.CONTEXT_USER_ROLE, userDb.UserRole)
+		if apiKey != nil {
+			newContext = context.WithValue(
+				newContext, auth.CONTEXT_API_KEY, apiKey)
+		}
 		*r = *r.WithContext(newContext)
 		return h(w, r)
 	}
@@ -138,6 +282,25 @@ func (s *ApiServer) middleware(h misc.HandlerWithErr) http.Handler {
 	)
 }
 
+func (s *ApiServer) middlewareScoped(h misc.HandlerWithErr, scope auth.ApiKeyScope) http.Handler {
+	return misc.MiddlewareWithTimeout(
+		s.CheckForAuthTokenWithScope(h, false, scope),
+		5*time.Second,
+	)
+}
+
+// middlewarePublic is for read-only endpoints which don't require any
+// authentication and can be used from any origin.
+func (s *ApiServer) middlewarePublic(h misc.HandlerWithErr) http.Handler {
+	return misc.MiddlewareWithTimeout(
+		func(w http.ResponseWriter, r *http.Request) error {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			return h(w, r)
+		},
+		5*time.Second,
+	)
+}
+
 func (s *ApiServer) middlewareAdmin(h misc.HandlerWithErr) http.Handler {
 	return misc.MiddlewareWithTimeout(
 		s.CheckForAuthToken(h, true),
@@ -145,7 +308,29 @@ func (s *ApiServer) middlewareAdmin(h misc.HandlerWithErr) http.Handler {
 	)
 }
 
+// middlewareAdminBulk is for admin operations over every room. They can run
+// well past the usual timeout and their report must not be cut off, so the
+// deadline is put on the request context instead.
+func (s *ApiServer) middlewareAdminBulk(h misc.HandlerWithErr) http.Handler {
+	return misc.MiddlewareWithDeadline(
+		s.CheckForAuthToken(h, true),
+		ADMIN_BULK_TIMEOUT,
+	)
+}
+
+// middlewareStream is for long lived streaming responses, which can't be
+// wrapped in a timeout handler.
+func (s *ApiServer) middlewareStream(h misc.HandlerWithErr, checkAdmin bool) http.Handler {
+	return misc.Middleware(s.CheckForAuthToken(h, checkAdmin))
+}
+
 func (s *ApiServer) matchRequestChannel(r *http.Request, channelName string) error {
+	return s.matchRequestChannelRole(r, channelName, room.ROOM_ROLE_OWNER)
+}
+
+// matchRequestChannelRole checks that the current user has at least minRole
+// access to the channel's room.
+func (s *ApiServer) matchRequestChannelRole(r *http.Request, channelName string, minRole room.RoomRole) error {
 	twitchUserName := r.Context().Value(auth.CONTEXT_TWITCH_USER_NAME)
 	if twitchUserName == nil {
 		return misc.NewHumanReadableError(
@@ -154,16 +339,51 @@ func (s *ApiServer) matchRequestChannel(r *http.Request, channelName string) err
 			fmt.Errorf("channel name must be provided"),
 		)
 	}
-	if channelName != twitchUserName.(string) {
+	role, err := s.getRequestRoomRole(r, channelName)
+	if err != nil {
+		return err
+	}
+	if !role.AtLeast(minRole) {
 		return misc.NewHumanReadableError(
 			"Cannot modify other user's room",
 			http.StatusBadRequest,
-			fmt.Errorf("channel name must be provided"),
+			fmt.Errorf("user %s has role '%s' in %s, needs %s", twitchUserName, role, channelName, minRole),
 		)
 	}
+	if apiKey, ok := r.Context().Value(auth.CONTEXT_API_KEY).(*auth.ApiKeyDb); ok {
+		if channelName != apiKey.ChannelName {
+			return misc.NewHumanReadableError(
+				"API key is not valid for this channel",
+				http.StatusForbidden,
+				fmt.Errorf("api key %d used for channel %s", apiKey.ApiKeyId, channelName),
+			)
+		}
+	}
 	return nil
 }
 
+func (s *ApiServer) getRequestRoomRole(r *http.Request, channelName string) (room.RoomRole, error) {
+	twitchUserName, _ := r.Context().Value(auth.CONTEXT_TWITCH_USER_NAME).(string)
+	if channelName == twitchUserName {
+		return room.ROOM_ROLE_OWNER, nil
+	}
+	if userRole, ok := r.Context().Value(auth.CONTEXT_USER_ROLE).(sql.NullString); ok {
+		if userRole.Valid && userRole.String == users.USER_ROLE_ADMIN {
+			return room.ROOM_ROLE_OWNER, nil
+		}
+	}
+	userId, ok := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	if !ok {
+		return room.ROOM_ROLE_NONE, nil
+	}
+	roomDb, err := s.roomRepo.GetRoomByChannelName(r.Context(), channelName)
+	if err != nil {
+		// The room doesn't exist so nobody but the owner has access to it
+		return room.ROOM_ROLE_NONE, nil
+	}
+	return s.roomPermsRepo.GetRoleOrNone(r.Context(), roomDb.RoomId, userId)
+}
+
 func (s *ApiServer) HandleUpdateRoomSettings(w http.ResponseWriter, r *http.Request) error {
 	if r.Method != http.MethodPost {
 		http.NotFound(w, r)
@@ -195,7 +415,7 @@ func (s *ApiServer) HandleUpdateRoomSettings(w http.ResponseWriter, r *http.Requ
 			fmt.Errorf("channel name must be alphanumeric and between 1 and 100 characters, was '%s'", channelName),
 		)
 	}
-	if err := s.matchRequestChannel(r, channelName); err != nil {
+	if err := s.matchRequestChannelRole(r, channelName, room.ROOM_ROLE_EDITOR); err != nil {
 		return err
 	}
 
@@ -223,7 +443,7 @@ func (s *ApiServer) HandleGetRoomSettings(w http.ResponseWriter, r *http.Request
 			fmt.Errorf("channel name must be alphanumeric and between 1 and 100 characters, was '%s'", channelName),
 		)
 	}
-	if err := s.matchRequestChannel(r, channelName); err != nil {
+	if err := s.matchRequestChannelRole(r, channelName, room.ROOM_ROLE_VIEWER); err != nil {
 		return err
 	}
 	resp, err := s.getRoomSettings(r.Context(), channelName)
@@ -322,6 +542,115 @@ func (s *ApiServer) HandleRoomSetOperator(w http.ResponseWriter, r *http.Request
 	return err
 }
 
+func (s *ApiServer) HandleReloadAssets(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	// Pick up a manifest which may have been pinned by tools/ingest_assets
+	if err := s.assetVersions.Sync(r.Context()); err != nil {
+		return misc.NewHumanReadableError(
+			"Failed to sync the pinned asset manifest",
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+	report, err := s.roomsManager.ReloadAssets(r.Context())
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Failed to reload assets",
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	return nil
+}
+
+// newAssetVersionsError picks the status code for an AssetVersionService error.
+func newAssetVersionsError(msg string, err error) error {
+	code := http.StatusInternalServerError
+	if errors.Is(err, akdb.ErrManifestNotFound) {
+		code = http.StatusNotFound
+	} else if errors.Is(err, akdb.ErrAssetVersioningDisabled) {
+		code = http.StatusBadRequest
+	}
+	return misc.NewHumanReadableError(msg, code, err)
+}
+
+func (s *ApiServer) HandleGetAssetManifests(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	manifests, pinnedId, err := s.assetVersions.GetManifests(r.Context())
+	if err != nil {
+		return newAssetVersionsError("Failed to get asset manifests", err)
+	}
+	resp := GetAssetManifestsResponse{
+		PinnedManifestId: pinnedId,
+		Manifests:        make([]*AssetManifestInfo, 0, len(manifests)),
+	}
+	for _, manifest := range manifests {
+		resp.Manifests = append(resp.Manifests, newAssetManifestInfo(manifest))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+	return nil
+}
+
+func (s *ApiServer) HandleDiffAssetManifests(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	fromId, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 32)
+	if err != nil {
+		return misc.NewHumanReadableError("Invalid from manifest id", http.StatusBadRequest, err)
+	}
+	toId, err := strconv.ParseUint(r.URL.Query().Get("to"), 10, 32)
+	if err != nil {
+		return misc.NewHumanReadableError("Invalid to manifest id", http.StatusBadRequest, err)
+	}
+	diff, err := s.assetVersions.Diff(r.Context(), uint(fromId), uint(toId))
+	if err != nil {
+		return newAssetVersionsError("Failed to diff asset manifests", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+	return nil
+}
+
+func (s *ApiServer) HandleRollbackAssets(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	var req RollbackAssetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return misc.NewHumanReadableError("Invalid request", http.StatusBadRequest, err)
+	}
+	if err := s.assetVersions.Rollback(r.Context(), req.ManifestId); err != nil {
+		return newAssetVersionsError("Failed to rollback assets", err)
+	}
+	report, err := s.roomsManager.ReloadAssets(r.Context())
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Rolled back the asset manifest but failed to reload assets",
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	return nil
+}
+
 func (s *ApiServer) HandleAdminInfo(w http.ResponseWriter, r *http.Request) error {
 	if r.Method != http.MethodGet {
 		http.NotFound(w, r)
@@ -334,16 +663,16 @@ func (s *ApiServer) HandleAdminInfo(w http.ResponseWriter, r *http.Request) erro
 	adminInfo.Metrics = make(map[string]interface{}, 0)
 	adminInfo.NextGCTime = s.roomsManager.GetNextGarbageCollectionTime().Format(time.DateTime)
 
-	for _, roomVal := range s.roomsManager.Rooms {
-
+	for _, roomVal := range s.roomsManager.GetRooms() {
+		stats := roomVal.GetStats()
 		newRoom := &roomInfo{
-			ChannelName:             roomVal.GetChannelName(),
-			LastTimeUsed:            roomVal.GetLastChatterTime().Format(time.DateTime),
+			ChannelName:             stats.ChannelName,
+			LastTimeUsed:            stats.LastActiveAt.Format(time.DateTime),
 			Chatters:                make([]*chatter, 0),
-			NumWebsocketConnections: roomVal.NumConnectedClients(),
-			CreatedAt:               roomVal.CreatedAt().Format(time.DateTime),
-			NextGCTime:              roomVal.GetNextGarbageCollectionTime().Format(time.DateTime),
-			ConnectionAverageFps:    make(map[string]float64),
+			NumWebsocketConnections: stats.NumWebsocketConnections,
+			CreatedAt:               stats.CreatedAt.Format(time.DateTime),
+			NextGCTime:              stats.NextGCTime.Format(time.DateTime),
+			ConnectionAverageFps:    stats.ConnectionAverageFps,
 		}
 
 		roomVal.ForEachChatter(func(chatUser *users.ChatUser) {
@@ -368,135 +697,134 @@ func (s *ApiServer) HandleAdminInfo(w http.ResponseWriter, r *http.Request) erro
 	adminInfo.Metrics["NumWebsocketConnections"] = misc.Monitor.NumWebsocketConnections
 	adminInfo.Metrics["NumUsers"] = misc.Monitor.NumUsers
 	adminInfo.Metrics["NumCommands"] = misc.Monitor.NumCommands
+	adminInfo.Metrics["NumAssetCacheHits"] = misc.Monitor.NumAssetCacheHits
+	adminInfo.Metrics["NumAssetCacheMisses"] = misc.Monitor.NumAssetCacheMisses
+	adminInfo.Metrics["NumAssetCacheEvictions"] = misc.Monitor.NumAssetCacheEvictions
+	adminInfo.Metrics["AssetCacheBytes"] = misc.Monitor.AssetCacheBytes
 	adminInfo.Metrics["Datetime"] = misc.Clock.Now().Format(time.DateTime)
 
 	json.NewEncoder(w).Encode(adminInfo)
 	return nil
 }
 
-func (s *ApiServer) HandleRemoveRoom(w http.ResponseWriter, r *http.Request) error {
-	if r.Method != http.MethodPost {
+// HandleAdminListRooms returns a page of the active rooms, or of the inactive
+// rooms with ?active=false. See room.ROOMS_SORT_* for the sort orders.
+func (s *ApiServer) HandleAdminListRooms(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
 		http.NotFound(w, r)
 		return nil
 	}
-	decoder := json.NewDecoder(r.Body)
-	var reqBody removeRoomRequest
-	if err := decoder.Decode(&reqBody); err != nil {
+	offset, limit, err := parsePagination(r)
+	if err != nil {
 		return err
 	}
-
-	channelName := reqBody.ChannelName
-	if len(channelName) == 0 {
-		return nil
-	}
-	if _, ok := s.roomsManager.Rooms[channelName]; !ok {
-		return nil
+	active := true
+	if activeStr := r.URL.Query().Get("active"); len(activeStr) > 0 {
+		active, err = strconv.ParseBool(activeStr)
+		if err != nil {
+			return misc.NewHumanReadableError(
+				"active must be true or false",
+				http.StatusBadRequest,
+				fmt.Errorf("invalid active '%s'", activeStr),
+			)
+		}
 	}
 
-	return s.roomsManager.RemoveRoom(channelName)
+	page, err := s.roomsManager.ListRoomStats(r.Context(), active, r.URL.Query().Get("sort"), offset, limit)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid room listing",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+	return nil
 }
 
-func (s *ApiServer) HandleRoomRefresh(w http.ResponseWriter, r *http.Request) error {
-	if r.Method != http.MethodPost {
+func (s *ApiServer) HandleAdminListChatters(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
 		http.NotFound(w, r)
 		return nil
 	}
-	decoder := json.NewDecoder(r.Body)
-	var reqBody RoomRefreshRequest
-	if err := decoder.Decode(&reqBody); err != nil {
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		return err
+	}
+	channelName := r.PathValue("channel")
+	if _, ok := s.roomsManager.GetRoom(channelName); !ok {
 		return misc.NewHumanReadableError(
-			"Invalid request body",
-			http.StatusBadRequest,
-			fmt.Errorf("invalid request body: %w", err),
+			"Room not found",
+			http.StatusNotFound,
+			fmt.Errorf("room %s does not exist", channelName),
 		)
 	}
 
-	channelName := reqBody.ChannelName
-	if len(channelName) == 0 {
+	page, err := s.roomsManager.ListChatterStats(channelName, r.URL.Query().Get("sort"), offset, limit)
+	if err != nil {
 		return misc.NewHumanReadableError(
-			"Channel name must be provided",
+			"Invalid chatter listing",
 			http.StatusBadRequest,
-			fmt.Errorf("channel name must be provided"),
+			err,
 		)
 	}
-	if _, ok := s.roomsManager.Rooms[channelName]; !ok {
-		return fmt.Errorf("room %s does not exist", channelName)
-	}
-	room := s.roomsManager.Rooms[channelName]
-
-	err := room.Refresh(r.Context(), s.botConfig)
-	return err
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+	return nil
 }
 
-func (s *ApiServer) HandleRemoveUser(w http.ResponseWriter, r *http.Request) error {
+// HandleAdminRefreshRooms reloads the config of every active room
+func (s *ApiServer) HandleAdminRefreshRooms(w http.ResponseWriter, r *http.Request) error {
 	if r.Method != http.MethodPost {
 		http.NotFound(w, r)
 		return nil
 	}
-	decoder := json.NewDecoder(r.Body)
-	var reqBody removeUserRequest
-	if err := decoder.Decode(&reqBody); err != nil {
-		return err
-	}
-
-	channelName := reqBody.ChannelName
-	if len(channelName) == 0 {
-		return nil
-	}
-	if _, ok := s.roomsManager.Rooms[channelName]; !ok {
-		return nil
-	}
-	room := s.roomsManager.Rooms[channelName]
-
-	userName := reqBody.Username
-	if len(userName) == 0 {
-		return nil
-	}
-	return room.RemoveUserChibi(context.Background(), userName)
+	report := s.roomsManager.RefreshAllRooms(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	return nil
 }
 
-func (s *ApiServer) HandleGetUserPreferences(w http.ResponseWriter, r *http.Request) error {
-	if r.Method != http.MethodGet {
+// HandleAdminEvictRooms closes every active room matching the room.RoomFilter
+// in the request body.
+func (s *ApiServer) HandleAdminEvictRooms(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
 		http.NotFound(w, r)
 		return nil
 	}
-
-	userIdStr := r.FormValue("user_id")
-	userId64, err := strconv.ParseUint(userIdStr, 10, 32)
-	if err != nil {
-		return fmt.Errorf("user_id must be a number: %w", err)
-	}
-	userId := uint(userId64)
-	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
-	if currentUserId != userId {
-		return fmt.Errorf("user_id must match the current user")
+	decoder := json.NewDecoder(r.Body)
+	var filter room.RoomFilter
+	if err := decoder.Decode(&filter); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
 	}
 
-	prefsDb, err := s.userPrefsRepo.GetByUserIdOrNil(r.Context(), userId)
+	report, err := s.roomsManager.EvictRooms(r.Context(), filter)
 	if err != nil {
-		http.NotFound(w, r)
-		return nil
-	}
-	if prefsDb == nil {
-		http.NotFound(w, r)
-		return nil
+		return misc.NewHumanReadableError(
+			"Invalid room filter",
+			http.StatusBadRequest,
+			err,
+		)
 	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(GetUserPreferencesResponse{
-		OperatorInfo: prefsDb.OperatorInfo,
-	})
+	json.NewEncoder(w).Encode(report)
 	return nil
 }
 
-func (s *ApiServer) HandleUpdateUserPreferences(w http.ResponseWriter, r *http.Request) error {
+// HandleAdminAnnounce shows a message from the bot in every active room,
+// such as before maintenance.
+func (s *ApiServer) HandleAdminAnnounce(w http.ResponseWriter, r *http.Request) error {
 	if r.Method != http.MethodPost {
 		http.NotFound(w, r)
 		return nil
 	}
-
 	decoder := json.NewDecoder(r.Body)
-	var reqBody UpdateUserPreferencesRequest
+	var reqBody AdminAnnounceRequest
 	if err := decoder.Decode(&reqBody); err != nil {
 		return misc.NewHumanReadableError(
 			"Invalid request body",
@@ -504,31 +832,1239 @@ func (s *ApiServer) HandleUpdateUserPreferences(w http.ResponseWriter, r *http.R
 			fmt.Errorf("invalid request body: %w", err),
 		)
 	}
-	userId := reqBody.UserId
-	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
-	if currentUserId != userId {
-		return fmt.Errorf("user_id must match the current user")
-	}
 
-	err := s.operatorsService.ValidateUpdateSetDefaultOtherwise(&(reqBody.OperatorInfo))
+	report, err := s.roomsManager.AnnounceToRooms(r.Context(), reqBody.Message)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			err.Error(),
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	return nil
+}
+
+func (s *ApiServer) HandleAdminEvents(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	var roomId uint
+	if channelName := r.URL.Query().Get("channel_name"); len(channelName) > 0 {
+		var err error
+		roomId, err = s.getRoomIdByChannelName(r.Context(), channelName)
+		if err != nil {
+			return err
+		}
+	}
+	return s.streamEvents(w, r, roomId)
+}
+
+func (s *ApiServer) HandleRoomEvents(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	channelName := r.URL.Query().Get("channel_name")
+	if len(channelName) == 0 {
+		return misc.NewHumanReadableError(
+			"Channel name must be provided",
+			http.StatusBadRequest,
+			fmt.Errorf("channel name must be provided"),
+		)
+	}
+	if err := s.matchRequestChannelRole(r, channelName, room.ROOM_ROLE_VIEWER); err != nil {
+		return err
+	}
+	roomId, err := s.getRoomIdByChannelName(r.Context(), channelName)
+	if err != nil {
+		return err
+	}
+	return s.streamEvents(w, r, roomId)
+}
+
+// streamEvents writes the hub's events for the room (0 for all rooms) as
+// Server-Sent Events until the client goes away or the server shuts down.
+func (s *ApiServer) streamEvents(w http.ResponseWriter, r *http.Request, roomId uint) error {
+	eventTypes, err := parseEventTypes(r.URL.Query().Get("events"))
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid events",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+
+	// The stream outlives the server's read and write timeouts
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	sub, err := s.eventsHub.Subscribe(roomId, eventTypes)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Server is shutting down",
+			http.StatusServiceUnavailable,
+			err,
+		)
+	}
+	defer s.eventsHub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Println("Failed to flush event stream", err)
+		return nil
+	}
+
+	heartbeat := time.NewTicker(EVENTS_HEARTBEAT_INTERVAL)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case ev, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				log.Println("Failed to encode event", ev.Event, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.EventId, ev.Event, data); err != nil {
+				return nil
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// parseEventTypes parses a comma separated list of event types. An empty
+// list means all events.
+func parseEventTypes(str string) ([]events.EventType, error) {
+	eventTypes := make([]events.EventType, 0)
+	if len(str) == 0 {
+		return eventTypes, nil
+	}
+	for _, part := range strings.Split(str, ",") {
+		event, err := events.EventType_Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		eventTypes = append(eventTypes, event)
+	}
+	return eventTypes, nil
+}
+
+func (s *ApiServer) HandleRemoveRoom(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody removeRoomRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return err
+	}
+
+	channelName := reqBody.ChannelName
+	if len(channelName) == 0 {
+		return nil
+	}
+	if _, ok := s.roomsManager.Rooms[channelName]; !ok {
+		return nil
+	}
+
+	return s.roomsManager.RemoveRoom(channelName)
+}
+
+func (s *ApiServer) HandleRoomRefresh(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody RoomRefreshRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+
+	channelName := reqBody.ChannelName
+	if len(channelName) == 0 {
+		return misc.NewHumanReadableError(
+			"Channel name must be provided",
+			http.StatusBadRequest,
+			fmt.Errorf("channel name must be provided"),
+		)
+	}
+	if _, ok := s.roomsManager.Rooms[channelName]; !ok {
+		return fmt.Errorf("room %s does not exist", channelName)
+	}
+	room := s.roomsManager.Rooms[channelName]
+
+	err := room.Refresh(r.Context(), s.botConfig)
+	return err
+}
+
+func (s *ApiServer) HandleRemoveUser(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody removeUserRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return err
+	}
+
+	channelName := reqBody.ChannelName
+	if len(channelName) == 0 {
+		return nil
+	}
+	if _, ok := s.roomsManager.Rooms[channelName]; !ok {
+		return nil
+	}
+	room := s.roomsManager.Rooms[channelName]
+
+	userName := reqBody.Username
+	if len(userName) == 0 {
+		return nil
+	}
+	return room.RemoveUserChibi(context.Background(), userName)
+}
+
+func (s *ApiServer) HandleGetUserPreferences(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	userIdStr := r.FormValue("user_id")
+	userId64, err := strconv.ParseUint(userIdStr, 10, 32)
+	if err != nil {
+		return fmt.Errorf("user_id must be a number: %w", err)
+	}
+	userId := uint(userId64)
+	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	if currentUserId != userId {
+		return fmt.Errorf("user_id must match the current user")
+	}
+
+	var opInfo *operator.OperatorInfo
+	if channelName := r.FormValue("channel_name"); len(channelName) > 0 {
+		roomId, err := s.getRoomIdByChannelName(r.Context(), channelName)
+		if err != nil {
+			return err
+		}
+		prefsDb, err := s.userPrefsRepo.GetRoomPrefsByUserIdOrNil(r.Context(), userId, roomId)
+		if err == nil && prefsDb != nil {
+			opInfo = &prefsDb.OperatorInfo
+		}
+	} else {
+		prefsDb, err := s.userPrefsRepo.GetByUserIdOrNil(r.Context(), userId)
+		if err == nil && prefsDb != nil {
+			opInfo = &prefsDb.OperatorInfo
+		}
+	}
+	if opInfo == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetUserPreferencesResponse{
+		OperatorInfo: *opInfo,
+	})
+	return nil
+}
+
+func (s *ApiServer) HandleUpdateUserPreferences(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var reqBody UpdateUserPreferencesRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	userId := reqBody.UserId
+	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	if currentUserId != userId {
+		return fmt.Errorf("user_id must match the current user")
+	}
+
+	err := s.operatorsService.ValidateUpdateSetDefaultOtherwise(&(reqBody.OperatorInfo))
+	if err != nil {
+		return err
+	}
+	if len(reqBody.ChannelName) > 0 {
+		roomId, err := s.getRoomIdByChannelName(r.Context(), reqBody.ChannelName)
+		if err != nil {
+			return err
+		}
+		return s.userPrefsRepo.SetRoomPrefsByUserId(r.Context(), userId, roomId, &reqBody.OperatorInfo)
+	}
+	err = s.userPrefsRepo.SetByUserId(r.Context(), userId, &reqBody.OperatorInfo)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *ApiServer) HandleDeleteUserPreferences(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var reqBody DeleteUserPreferencesRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	userId := reqBody.UserId
+	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	if currentUserId != userId {
+		return fmt.Errorf("user_id must match the current user")
+	}
+
+	if len(reqBody.ChannelName) > 0 {
+		roomId, err := s.getRoomIdByChannelName(r.Context(), reqBody.ChannelName)
+		if err != nil {
+			return err
+		}
+		return s.userPrefsRepo.DeleteRoomPrefsByUserId(r.Context(), userId, roomId)
+	}
+	err := s.userPrefsRepo.DeleteByUserId(r.Context(), userId)
+	return err
+}
+
+func (s *ApiServer) HandleGetUserLoadouts(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	userIdStr := r.FormValue("user_id")
+	userId64, err := strconv.ParseUint(userIdStr, 10, 32)
+	if err != nil {
+		return fmt.Errorf("user_id must be a number: %w", err)
+	}
+	userId := uint(userId64)
+	if err := checkCurrentUser(r, userId); err != nil {
+		return err
+	}
+
+	loadoutsDb, err := s.userPrefsRepo.GetLoadoutsByUserId(r.Context(), userId)
+	if err != nil {
+		return err
+	}
+	loadouts := make([]*UserLoadout, 0, len(loadoutsDb))
+	for _, loadoutDb := range loadoutsDb {
+		loadouts = append(loadouts, newUserLoadout(loadoutDb))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GetUserLoadoutsResponse{
+		Loadouts:    loadouts,
+		MaxLoadouts: users.USER_LOADOUTS_MAX,
+	})
+	return nil
+}
+
+func (s *ApiServer) HandleSaveUserLoadout(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var reqBody SaveUserLoadoutRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	if err := checkCurrentUser(r, reqBody.UserId); err != nil {
+		return err
+	}
+	name, err := users.NormalizeLoadoutName(reqBody.Name)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid loadout name",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+
+	err = s.operatorsService.ValidateUpdateSetDefaultOtherwise(&(reqBody.OperatorInfo))
+	if err != nil {
+		return err
+	}
+	loadoutDb, err := s.userPrefsRepo.SaveLoadout(r.Context(), reqBody.UserId, name, &reqBody.OperatorInfo)
+	if errors.Is(err, users.ErrTooManyLoadouts) {
+		return misc.NewHumanReadableError(
+			fmt.Sprintf("At most %d loadouts can be saved", users.USER_LOADOUTS_MAX),
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newUserLoadout(loadoutDb))
+	return nil
+}
+
+func (s *ApiServer) HandleRenameUserLoadout(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var reqBody RenameUserLoadoutRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	if err := checkCurrentUser(r, reqBody.UserId); err != nil {
+		return err
+	}
+	name, err := users.NormalizeLoadoutName(reqBody.Name)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid loadout name",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	existing, err := s.userPrefsRepo.GetLoadoutByNameOrNil(r.Context(), reqBody.UserId, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.UserLoadoutId != reqBody.LoadoutId {
+		return misc.NewHumanReadableError(
+			"A loadout with that name already exists",
+			http.StatusBadRequest,
+			fmt.Errorf("loadout %s already exists", name),
+		)
+	}
+
+	err = s.userPrefsRepo.RenameLoadout(r.Context(), reqBody.UserId, reqBody.LoadoutId, name)
+	if errors.Is(err, users.ErrLoadoutNotFound) {
+		return misc.NewHumanReadableError("Loadout not found", http.StatusNotFound, err)
+	}
+	return err
+}
+
+func (s *ApiServer) HandleDeleteUserLoadout(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var reqBody DeleteUserLoadoutRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	if err := checkCurrentUser(r, reqBody.UserId); err != nil {
+		return err
+	}
+
+	err := s.userPrefsRepo.DeleteLoadout(r.Context(), reqBody.UserId, reqBody.LoadoutId)
+	if errors.Is(err, users.ErrLoadoutNotFound) {
+		return misc.NewHumanReadableError("Loadout not found", http.StatusNotFound, err)
+	}
+	return err
+}
+
+// HandleSelectUserLoadout makes the loadout the user's saved chibi. If a
+// channel is given it is instead only used in that channel's room, a
+// loadout_id of 0 clears the channel's loadout.
+func (s *ApiServer) HandleSelectUserLoadout(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var reqBody SelectUserLoadoutRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	if err := checkCurrentUser(r, reqBody.UserId); err != nil {
+		return err
+	}
+
+	ctx := r.Context()
+	if len(reqBody.ChannelName) > 0 {
+		roomId, err := s.getRoomIdByChannelName(ctx, reqBody.ChannelName)
+		if err != nil {
+			return err
+		}
+		if reqBody.LoadoutId == 0 {
+			return s.userPrefsRepo.ClearRoomLoadout(ctx, reqBody.UserId, roomId)
+		}
+		err = s.userPrefsRepo.SetRoomLoadout(ctx, reqBody.UserId, roomId, reqBody.LoadoutId)
+		if errors.Is(err, users.ErrLoadoutNotFound) {
+			return misc.NewHumanReadableError("Loadout not found", http.StatusNotFound, err)
+		}
+		return err
+	}
+
+	loadoutsDb, err := s.userPrefsRepo.GetLoadoutsByUserId(ctx, reqBody.UserId)
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(loadoutsDb, func(l *users.UserLoadoutDb) bool {
+		return l.UserLoadoutId == reqBody.LoadoutId
+	})
+	if index < 0 {
+		return misc.NewHumanReadableError(
+			"Loadout not found",
+			http.StatusNotFound,
+			users.ErrLoadoutNotFound,
+		)
+	}
+	return s.userPrefsRepo.SetByUserId(ctx, reqBody.UserId, &loadoutsDb[index].OperatorInfo)
+}
+
+func checkCurrentUser(r *http.Request, userId uint) error {
+	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	if currentUserId != userId {
+		return fmt.Errorf("user_id must match the current user")
+	}
+	return nil
+}
+
+func newUserLoadout(loadoutDb *users.UserLoadoutDb) *UserLoadout {
+	return &UserLoadout{
+		LoadoutId:    loadoutDb.UserLoadoutId,
+		Name:         loadoutDb.Name,
+		OperatorInfo: loadoutDb.OperatorInfo,
+		UpdatedAt:    loadoutDb.UpdatedAt,
+	}
+}
+
+func (s *ApiServer) HandleSetChatterChibi(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody SetChatterChibiRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	room, err := s.getRequestRoom(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+
+	opInfo := reqBody.OperatorInfo
+	if _, err := s.operatorsService.Assets.ParseFaction(string(opInfo.Faction)); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid faction",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	if err := s.operatorsService.ValidateOperatorRequest(&opInfo); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid operator",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	if err := room.UpdateChatterChibi(r.Context(), reqBody.Username, &opInfo); err != nil {
+		return misc.NewHumanReadableError(
+			"Chatter not found",
+			http.StatusNotFound,
+			err,
+		)
+	}
+	return nil
+}
+
+func (s *ApiServer) HandleRemoveChatterChibi(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody removeUserRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	room, err := s.getRequestRoom(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	if len(reqBody.Username) == 0 {
+		return misc.NewHumanReadableError(
+			"Username must be provided",
+			http.StatusBadRequest,
+			fmt.Errorf("username must be provided"),
+		)
+	}
+	return room.RemoveUserChibi(r.Context(), reqBody.Username)
+}
+
+// HandleGetRoomChibis lists the chibis currently in the streamer's room
+func (s *ApiServer) HandleGetRoomChibis(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		return err
+	}
+	channelName := r.URL.Query().Get("channel_name")
+	roomObj, err := s.getOwnedRoom(r, channelName)
+	if err != nil {
+		return err
+	}
+
+	page, err := s.roomsManager.ListChatterStats(channelName, r.URL.Query().Get("sort"), offset, limit)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid chibi listing",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(GetRoomChibisResponse{
+		ChatterStatsPage: page,
+		Paused:           roomObj.IsPaused(),
+	})
+}
+
+func (s *ApiServer) HandleKickChatter(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	roomObj, username, err := s.getControlledChatter(r)
+	if err != nil {
+		return err
+	}
+	return roomObj.RemoveUserChibi(r.Context(), username)
+}
+
+func (s *ApiServer) HandleResetChatter(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	roomObj, username, err := s.getControlledChatter(r)
+	if err != nil {
+		return err
+	}
+	return roomObj.ResetChatterChibi(r.Context(), username)
+}
+
+func (s *ApiServer) HandleFindChatter(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	roomObj, username, err := s.getControlledChatter(r)
+	if err != nil {
+		return err
+	}
+	return roomObj.FindChatterChibi(r.Context(), username)
+}
+
+// HandlePauseRoom stops or resumes the movement of all the chibis in the room
+func (s *ApiServer) HandlePauseRoom(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody RoomPauseRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	roomObj, err := s.getOwnedRoom(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	if err := roomObj.SetPaused(r.Context(), reqBody.Paused); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(RoomPauseResponse{Paused: roomObj.IsPaused()})
+}
+
+// HandleClearRoom removes every chibi from the room's stage
+func (s *ApiServer) HandleClearRoom(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody RoomControlRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	roomObj, err := s.getOwnedRoom(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	numRemoved := roomObj.ClearChibis(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(RoomClearResponse{NumRemoved: numRemoved})
+}
+
+func (s *ApiServer) HandleGetApiKeys(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	apiKeys, err := s.authService.GetApiKeys(r.Context(), currentUserId)
+	if err != nil {
+		return err
+	}
+
+	resp := GetApiKeysResponse{
+		ApiKeys: make([]*ApiKeyInfo, 0, len(apiKeys)),
+	}
+	for _, apiKey := range apiKeys {
+		resp.ApiKeys = append(resp.ApiKeys, newApiKeyInfo(apiKey))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func (s *ApiServer) HandleCreateApiKey(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody CreateApiKeyRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	if err := s.matchRequestChannel(r, reqBody.ChannelName); err != nil {
+		return err
+	}
+	scopes := make([]auth.ApiKeyScope, 0, len(reqBody.Scopes))
+	for _, scopeStr := range reqBody.Scopes {
+		scope, err := auth.ApiKeyScope_Parse(scopeStr)
+		if err != nil {
+			return misc.NewHumanReadableError(
+				fmt.Sprintf("Invalid scope %s", scopeStr),
+				http.StatusBadRequest,
+				err,
+			)
+		}
+		scopes = append(scopes, scope)
+	}
+
+	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	key, apiKey, err := s.authService.CreateApiKey(
+		r.Context(),
+		currentUserId,
+		reqBody.ChannelName,
+		reqBody.Name,
+		scopes,
+	)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			err.Error(),
+			http.StatusBadRequest,
+			err,
+		)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(CreateApiKeyResponse{
+		ApiKey: key,
+		Info:   newApiKeyInfo(apiKey),
+	})
+}
+
+func (s *ApiServer) HandleRevokeApiKey(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody RevokeApiKeyRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
+	if err := s.authService.RevokeApiKey(r.Context(), currentUserId, reqBody.ApiKeyId); err != nil {
+		return misc.NewHumanReadableError(
+			"API key not found",
+			http.StatusNotFound,
+			err,
+		)
+	}
+	return nil
+}
+
+func (s *ApiServer) HandleGetRoomPermissions(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	channelName := r.URL.Query().Get("channel_name")
+	roomDb, err := s.getOwnedRoomDb(r, channelName)
+	if err != nil {
+		return err
+	}
+	permissions, err := s.roomPermsRepo.GetByRoomId(r.Context(), roomDb.RoomId)
+	if err != nil {
+		return err
+	}
+
+	resp := GetRoomPermissionsResponse{
+		Permissions: make([]*RoomPermissionInfo, 0, len(permissions)),
+	}
+	for _, permission := range permissions {
+		resp.Permissions = append(resp.Permissions, &RoomPermissionInfo{
+			Username:        permission.Username,
+			UserDisplayName: permission.UserDisplayName,
+			Role:            string(permission.Role),
+			Source:          permission.Source,
+			UpdatedAt:       permission.UpdatedAt,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func (s *ApiServer) HandleSetRoomPermission(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody SetRoomPermissionRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	roomDb, err := s.getOwnedRoomDb(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	role, err := room.RoomRole_Parse(reqBody.Role)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Role must be one of viewer or editor",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	userDb, err := s.getOrInsertUserByName(r.Context(), reqBody.Username)
+	if err != nil {
+		return err
+	}
+	if userDb.Username == roomDb.ChannelName {
+		return misc.NewHumanReadableError(
+			"The owner of the room already has full access",
+			http.StatusBadRequest,
+			fmt.Errorf("cannot set permission for owner"),
+		)
+	}
+	return s.roomPermsRepo.SetRole(r.Context(), roomDb.RoomId, userDb.UserId, role, room.ROOM_PERMISSION_SOURCE_MANUAL)
+}
+
+func (s *ApiServer) HandleDeleteRoomPermission(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody DeleteRoomPermissionRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	roomDb, err := s.getOwnedRoomDb(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	// Only users which were granted a permission can have one removed, so
+	// there is no need to ask Twitch about unknown users
+	username := strings.ToLower(reqBody.Username)
+	userDb, err := s.usersRepo.GetByUsername(r.Context(), username)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"User not found",
+			http.StatusNotFound,
+			fmt.Errorf("user %s not found: %w", username, err),
+		)
+	}
+	return s.roomPermsRepo.DeleteByUserId(r.Context(), roomDb.RoomId, userDb.UserId)
+}
+
+func (s *ApiServer) HandleGetWebhooks(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	channelName := r.URL.Query().Get("channel_name")
+	roomDb, err := s.getOwnedRoomDb(r, channelName)
+	if err != nil {
+		return err
+	}
+	webhooks, err := s.webhookService.GetWebhooks(r.Context(), roomDb.RoomId)
+	if err != nil {
+		return err
+	}
+
+	resp := GetWebhooksResponse{
+		Webhooks: make([]*WebhookInfo, 0, len(webhooks)),
+	}
+	for _, webhookDb := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, newWebhookInfo(webhookDb))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func (s *ApiServer) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody CreateWebhookRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	roomDb, err := s.getOwnedRoomDb(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	events := make([]webhook.EventType, 0, len(reqBody.Events))
+	for _, eventStr := range reqBody.Events {
+		event, err := webhook.EventType_Parse(eventStr)
+		if err != nil {
+			return misc.NewHumanReadableError(
+				"Invalid event: "+eventStr,
+				http.StatusBadRequest,
+				err,
+			)
+		}
+		events = append(events, event)
+	}
+
+	webhookDb, err := s.webhookService.CreateWebhook(
+		r.Context(),
+		roomDb.RoomId,
+		roomDb.ChannelName,
+		reqBody.Url,
+		events,
+	)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Failed to create webhook: "+err.Error(),
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(CreateWebhookResponse{
+		Webhook: newWebhookInfo(webhookDb),
+		Secret:  webhookDb.Secret,
+	})
+}
+
+func (s *ApiServer) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodDelete {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody WebhookRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	roomDb, err := s.getOwnedRoomDb(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	if err := s.webhookService.DeleteWebhook(r.Context(), roomDb.RoomId, reqBody.WebhookId); err != nil {
+		return misc.NewHumanReadableError(
+			"Webhook not found",
+			http.StatusNotFound,
+			err,
+		)
+	}
+	return nil
+}
+
+func (s *ApiServer) HandleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	channelName := r.URL.Query().Get("channel_name")
+	webhookId, err := strconv.Atoi(r.URL.Query().Get("webhook_id"))
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid webhook_id",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	webhookDb, err := s.getOwnedWebhook(r, channelName, uint(webhookId))
+	if err != nil {
+		return err
+	}
+	deliveries, err := s.webhookService.GetDeliveries(r.Context(), webhookDb, webhook.WEBHOOK_DELIVERY_LOG_LIMIT)
+	if err != nil {
+		return err
+	}
+
+	resp := GetWebhookDeliveriesResponse{
+		Deliveries: make([]*WebhookDeliveryInfo, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, newWebhookDeliveryInfo(delivery))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func (s *ApiServer) HandleTestWebhook(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	decoder := json.NewDecoder(r.Body)
+	var reqBody WebhookRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	webhookDb, err := s.getOwnedWebhook(r, reqBody.ChannelName, reqBody.WebhookId)
+	if err != nil {
+		return err
+	}
+	delivery, err := s.webhookService.SendTestEvent(r.Context(), webhookDb)
+	if err != nil {
+		return err
+	}
+	// The test endpoint can target any url so don't echo back what the
+	// request ran into, otherwise it can be used to probe hosts.
+	info := newWebhookDeliveryInfo(delivery)
+	info.StatusCode = 0
+	if !delivery.Success {
+		info.Error = "delivery failed"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(info)
+}
+
+func (s *ApiServer) HandleGetCustomChibis(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	channelName := r.URL.Query().Get("channel_name")
+	roomDb, err := s.getOwnedRoomDb(r, channelName)
+	if err != nil {
+		return err
+	}
+	chibis, err := s.customChibis.GetCustomChibis(r.Context(), roomDb.RoomId)
 	if err != nil {
 		return err
 	}
-	err = s.userPrefsRepo.SetByUserId(r.Context(), userId, &reqBody.OperatorInfo)
+
+	resp := GetCustomChibisResponse{
+		CustomChibis: make([]*CustomChibiInfo, 0, len(chibis)),
+	}
+	for _, chibiDb := range chibis {
+		resp.CustomChibis = append(resp.CustomChibis, newCustomChibiInfo(chibiDb))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// HandleUploadCustomChibi takes a multipart form with the fields channel_name,
+// name, stance (default base), facing (default Front), an optional comma
+// separated list of animations (required for binary .skel files) and the
+// Spine export as one or more "files".
+func (s *ApiServer) HandleUploadCustomChibi(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return nil
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, customchibi.CUSTOM_CHIBI_MAX_UPLOAD_BYTES)
+	if err := r.ParseMultipartForm(customchibi.CUSTOM_CHIBI_MAX_UPLOAD_BYTES); err != nil {
+		return misc.NewHumanReadableError(
+			fmt.Sprintf("Invalid upload. Uploads are limited to %d MB", customchibi.CUSTOM_CHIBI_MAX_UPLOAD_BYTES/1024/1024),
+			http.StatusBadRequest,
+			fmt.Errorf("invalid multipart form: %w", err),
+		)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	roomDb, err := s.getOwnedRoomDb(r, r.FormValue("channel_name"))
 	if err != nil {
 		return err
 	}
-	return nil
+	stance, err := operator.ChibiStanceEnum_Parse(formValueOr(r, "stance", string(operator.CHIBI_STANCE_ENUM_BASE)))
+	if err != nil {
+		return misc.NewHumanReadableError("Invalid stance", http.StatusBadRequest, err)
+	}
+	facing, err := operator.ChibiFacingEnum_Parse(formValueOr(r, "facing", string(operator.CHIBI_FACING_ENUM_FRONT)))
+	if err != nil {
+		return misc.NewHumanReadableError("Invalid facing", http.StatusBadRequest, err)
+	}
+	upload := &operator.CustomChibiUpload{
+		Stance: stance,
+		Facing: facing,
+		Files:  make(map[string][]byte),
+	}
+	for _, animation := range strings.Split(r.FormValue("animations"), ",") {
+		if animation = strings.TrimSpace(animation); len(animation) > 0 {
+			upload.Animations = append(upload.Animations, animation)
+		}
+	}
+	for _, fileHeader := range r.MultipartForm.File["files"] {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		upload.Files[fileHeader.Filename] = data
+	}
+
+	chibiDb, report, err := s.customChibis.Upload(r.Context(), roomDb.RoomId, r.FormValue("name"), upload)
+	if err != nil {
+		if report == nil || !report.HasFailures(false) {
+			return misc.NewHumanReadableError(
+				"Failed to upload chibi: "+err.Error(),
+				http.StatusBadRequest,
+				err,
+			)
+		}
+		// Send back the report so the streamer can fix their export
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return json.NewEncoder(w).Encode(UploadCustomChibiResponse{Report: report})
+	}
+	if err := s.roomsManager.ReloadCustomChibis(r.Context(), roomDb.ChannelName); err != nil {
+		log.Println("Failed to reload custom chibis for", roomDb.ChannelName, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(UploadCustomChibiResponse{
+		CustomChibi: newCustomChibiInfo(chibiDb),
+		Report:      report,
+	})
 }
 
-func (s *ApiServer) HandleDeleteUserPreferences(w http.ResponseWriter, r *http.Request) error {
+func (s *ApiServer) HandleDeleteCustomChibi(w http.ResponseWriter, r *http.Request) error {
 	if r.Method != http.MethodDelete {
 		http.NotFound(w, r)
 		return nil
 	}
-
 	decoder := json.NewDecoder(r.Body)
-	var reqBody DeleteUserPreferencesRquest
+	var reqBody CustomChibiRequest
 	if err := decoder.Decode(&reqBody); err != nil {
 		return misc.NewHumanReadableError(
 			"Invalid request body",
@@ -536,19 +2072,300 @@ func (s *ApiServer) HandleDeleteUserPreferences(w http.ResponseWriter, r *http.R
 			fmt.Errorf("invalid request body: %w", err),
 		)
 	}
-	userId := reqBody.UserId
-	currentUserId := r.Context().Value(auth.CONTEXT_USER_ID).(uint)
-	if currentUserId != userId {
-		return fmt.Errorf("user_id must match the current user")
+	roomDb, err := s.getOwnedRoomDb(r, reqBody.ChannelName)
+	if err != nil {
+		return err
+	}
+	if err := s.customChibis.DeleteCustomChibi(r.Context(), roomDb.RoomId, reqBody.OperatorId); err != nil {
+		return misc.NewHumanReadableError(
+			"Custom chibi not found",
+			http.StatusNotFound,
+			err,
+		)
+	}
+	if err := s.roomsManager.ReloadCustomChibis(r.Context(), roomDb.ChannelName); err != nil {
+		log.Println("Failed to reload custom chibis for", roomDb.ChannelName, err)
 	}
+	return nil
+}
 
-	err := s.userPrefsRepo.DeleteByUserId(r.Context(), userId)
-	return err
+func (s *ApiServer) HandleListCatalog(faction operator.FactionEnum) misc.HandlerWithErr {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return nil
+		}
+		offset, limit, err := parsePagination(r)
+		if err != nil {
+			return err
+		}
+		page, err := s.operatorsService.ListCatalog(faction, r.URL.Query().Get("q"), offset, limit)
+		if err != nil {
+			return misc.NewHumanReadableError(
+				"Invalid catalog request",
+				http.StatusBadRequest,
+				err,
+			)
+		}
+		return misc.WriteJsonWithETag(w, r, page, CATALOG_CACHE_CONTROL)
+	}
+}
+
+func (s *ApiServer) HandleGetCatalogDetail(faction operator.FactionEnum) misc.HandlerWithErr {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return nil
+		}
+		operatorId := r.PathValue("id")
+		detail, err := s.operatorsService.GetCatalogDetail(operatorId, faction)
+		if err != nil {
+			return misc.NewHumanReadableError(
+				"Not found",
+				http.StatusNotFound,
+				err,
+			)
+		}
+		return misc.WriteJsonWithETag(w, r, detail, CATALOG_CACHE_CONTROL)
+	}
+}
+
+func (s *ApiServer) HandleGetFactions(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	resp := GetFactionsResponse{Factions: make([]*FactionInfo, 0)}
+	for _, faction := range s.operatorsService.Assets.GetFactions() {
+		resp.Factions = append(resp.Factions, &FactionInfo{
+			Name:          faction.Name,
+			Command:       faction.Command,
+			DefaultStance: faction.DefaultStance,
+			NumChibis:     len(s.operatorsService.Assets.GetAssetMapFromFaction(faction.Name).Data),
+		})
+	}
+	return misc.WriteJsonWithETag(w, r, resp, CATALOG_CACHE_CONTROL)
+}
+
+func (s *ApiServer) HandleListFactionCatalog(w http.ResponseWriter, r *http.Request) error {
+	faction, err := s.parseCatalogFaction(r)
+	if err != nil {
+		return err
+	}
+	return s.HandleListCatalog(faction)(w, r)
+}
+
+func (s *ApiServer) HandleGetFactionCatalogDetail(w http.ResponseWriter, r *http.Request) error {
+	faction, err := s.parseCatalogFaction(r)
+	if err != nil {
+		return err
+	}
+	return s.HandleGetCatalogDetail(faction)(w, r)
 }
 
 // INTERNAL
 // ----------------------------------------------
 // TODO: Move these methods into a separate service
+func (s *ApiServer) getRequestRoom(r *http.Request, channelName string) (*room.Room, error) {
+	if len(channelName) == 0 {
+		return nil, misc.NewHumanReadableError(
+			"Channel name must be provided",
+			http.StatusBadRequest,
+			fmt.Errorf("channel name must be provided"),
+		)
+	}
+	if err := s.matchRequestChannelRole(r, channelName, room.ROOM_ROLE_EDITOR); err != nil {
+		return nil, err
+	}
+	roomObj, ok := s.roomsManager.GetRoom(channelName)
+	if !ok {
+		return nil, misc.NewHumanReadableError(
+			"Room not found",
+			http.StatusNotFound,
+			fmt.Errorf("room %s does not exist", channelName),
+		)
+	}
+	return roomObj, nil
+}
+
+// getOwnedRoom returns the active room of the channel if the current user is
+// its owner
+func (s *ApiServer) getOwnedRoom(r *http.Request, channelName string) (*room.Room, error) {
+	if len(channelName) == 0 {
+		return nil, misc.NewHumanReadableError(
+			"Channel name must be provided",
+			http.StatusBadRequest,
+			fmt.Errorf("channel name must be provided"),
+		)
+	}
+	if err := s.matchRequestChannel(r, channelName); err != nil {
+		return nil, err
+	}
+	roomObj, ok := s.roomsManager.GetRoom(channelName)
+	if !ok {
+		return nil, misc.NewHumanReadableError(
+			"Room not found",
+			http.StatusNotFound,
+			fmt.Errorf("room %s does not exist", channelName),
+		)
+	}
+	return roomObj, nil
+}
+
+// getControlledChatter decodes a RoomControlRequest for a chatter currently
+// in one of the user's own rooms.
+func (s *ApiServer) getControlledChatter(r *http.Request) (*room.Room, string, error) {
+	decoder := json.NewDecoder(r.Body)
+	var reqBody RoomControlRequest
+	if err := decoder.Decode(&reqBody); err != nil {
+		return nil, "", misc.NewHumanReadableError(
+			"Invalid request body",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid request body: %w", err),
+		)
+	}
+	roomObj, err := s.getOwnedRoom(r, reqBody.ChannelName)
+	if err != nil {
+		return nil, "", err
+	}
+	username := strings.ToLower(reqBody.Username)
+	if len(username) == 0 {
+		return nil, "", misc.NewHumanReadableError(
+			"Username must be provided",
+			http.StatusBadRequest,
+			fmt.Errorf("username must be provided"),
+		)
+	}
+	if !roomObj.HasChatter(username) {
+		return nil, "", misc.NewHumanReadableError(
+			"Chatter not found",
+			http.StatusNotFound,
+			fmt.Errorf("chatter %s is not in %s", username, reqBody.ChannelName),
+		)
+	}
+	return roomObj, username, nil
+}
+
+// getRoomIdByChannelName looks up the room in the database so that inactive
+// rooms can also be used.
+func (s *ApiServer) getRoomIdByChannelName(ctx context.Context, channelName string) (uint, error) {
+	roomDb, err := s.roomRepo.GetRoomByChannelName(ctx, channelName)
+	if err != nil {
+		return 0, misc.NewHumanReadableError(
+			"Room not found",
+			http.StatusNotFound,
+			fmt.Errorf("room not found: %w", err),
+		)
+	}
+	return roomDb.RoomId, nil
+}
+
+func (s *ApiServer) parseCatalogFaction(r *http.Request) (operator.FactionEnum, error) {
+	faction, err := s.operatorsService.Assets.ParseFaction(r.PathValue("faction"))
+	if err != nil {
+		return "", misc.NewHumanReadableError(
+			"Unknown faction",
+			http.StatusNotFound,
+			err,
+		)
+	}
+	return faction, nil
+}
+
+// getOwnedRoomDb returns the room of the channel if the current user is its owner
+func (s *ApiServer) getOwnedRoomDb(r *http.Request, channelName string) (*room.RoomDb, error) {
+	if misc.ValidateChannelName(channelName) != nil {
+		return nil, misc.NewHumanReadableError(
+			"Invalid channel name",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid channel name '%s'", channelName),
+		)
+	}
+	if err := s.matchRequestChannel(r, channelName); err != nil {
+		return nil, err
+	}
+	roomDb, err := s.roomRepo.GetRoomByChannelName(r.Context(), channelName)
+	if err != nil {
+		return nil, misc.NewHumanReadableError(
+			"Room not found",
+			http.StatusNotFound,
+			fmt.Errorf("room not found: %w", err),
+		)
+	}
+	return roomDb, nil
+}
+
+func formValueOr(r *http.Request, key string, defaultValue string) string {
+	if value := r.FormValue(key); len(value) > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+func parsePagination(r *http.Request) (offset int, limit int, err error) {
+	if offsetStr := r.URL.Query().Get("offset"); len(offsetStr) > 0 {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return 0, 0, misc.NewHumanReadableError(
+				"offset must be a non-negative integer",
+				http.StatusBadRequest,
+				fmt.Errorf("invalid offset '%s'", offsetStr),
+			)
+		}
+	}
+	if limitStr := r.URL.Query().Get("limit"); len(limitStr) > 0 {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return 0, 0, misc.NewHumanReadableError(
+				"limit must be a non-negative integer",
+				http.StatusBadRequest,
+				fmt.Errorf("invalid limit '%s'", limitStr),
+			)
+		}
+	}
+	return offset, limit, nil
+}
+
+func (s *ApiServer) getOwnedWebhook(r *http.Request, channelName string, webhookId uint) (*webhook.WebhookDb, error) {
+	roomDb, err := s.getOwnedRoomDb(r, channelName)
+	if err != nil {
+		return nil, err
+	}
+	webhookDb, err := s.webhookService.GetWebhook(r.Context(), webhookId)
+	if err != nil || webhookDb.RoomId != roomDb.RoomId {
+		return nil, misc.NewHumanReadableError(
+			"Webhook not found",
+			http.StatusNotFound,
+			fmt.Errorf("webhook %d not found in room %s: %w", webhookId, channelName, err),
+		)
+	}
+	return webhookDb, nil
+}
+
+func (s *ApiServer) getOrInsertUserByName(ctx context.Context, username string) (*users.UserDb, error) {
+	username = strings.ToLower(username)
+	if misc.ValidateChannelName(username) != nil {
+		return nil, misc.NewHumanReadableError(
+			"Invalid username",
+			http.StatusBadRequest,
+			fmt.Errorf("invalid username '%s'", username),
+		)
+	}
+	resp, err := s.twitchClient.GetUsers(username)
+	if err != nil || len(resp.Data) == 0 {
+		return nil, misc.NewHumanReadableError(
+			"Twitch user not found",
+			http.StatusNotFound,
+			fmt.Errorf("twitch user %s not found: %w", username, err),
+		)
+	}
+	return s.usersRepo.GetOrInsertUser(ctx, misc.UserInfo{
+		Username:        resp.Data[0].Login,
+		UsernameDisplay: resp.Data[0].DisplayName,
+		TwitchUserId:    resp.Data[0].Id,
+	})
+}
+
 func (s *ApiServer) updateRoomSettings(ctx context.Context, channelName string, reqBody RoomUpdateRequest) error {
 	roomDb, err := s.roomRepo.GetRoomByChannelName(ctx, channelName)
 	if err != nil {
@@ -566,17 +2383,17 @@ func (s *ApiServer) updateRoomSettings(ctx context.Context, channelName string,
 	if reqBody.MaxAnimationSpeed > 0 {
 		config.MaxAnimationSpeed = reqBody.MaxAnimationSpeed
 	}
-	if reqBody.MinVelocity > 0 {
-		config.MinMovementSpeed = reqBody.MinVelocity
+	if reqBody.MinMovementSpeed > 0 {
+		config.MinMovementSpeed = reqBody.MinMovementSpeed
 	}
-	if reqBody.MaxVelocity > 0 {
-		config.MaxMovementSpeed = reqBody.MaxVelocity
+	if reqBody.MaxMovementSpeed > 0 {
+		config.MaxMovementSpeed = reqBody.MaxMovementSpeed
 	}
-	if reqBody.MinSpriteScale > 0 {
-		config.MinScaleSize = reqBody.MinSpriteScale
+	if reqBody.MinSpriteSize > 0 {
+		config.MinScaleSize = reqBody.MinSpriteSize
 	}
-	if reqBody.MaxSpriteScale > 0 {
-		config.MaxScaleSize = reqBody.MaxSpriteScale
+	if reqBody.MaxSpriteSize > 0 {
+		config.MaxScaleSize = reqBody.MaxSpriteSize
 	}
 	if reqBody.MaxSpritePixelSize > 0 {
 		config.MaxSpritePixelSize = reqBody.MaxSpritePixelSize
@@ -589,6 +2406,43 @@ func (s *ApiServer) updateRoomSettings(ctx context.Context, channelName string,
 		)
 	}
 	config.UsernamesBlacklist = reqBody.UsernamesBlacklist
+	if reqBody.RandomPoolFilter != nil {
+		if _, err := s.operatorsService.ParseOperatorFilter(reqBody.RandomPoolFilter); err != nil {
+			return misc.NewHumanReadableError(
+				"Invalid random pool filter",
+				http.StatusBadRequest,
+				err,
+			)
+		}
+		config.RandomPoolFilter = reqBody.RandomPoolFilter
+	}
+	if reqBody.RandomPool != nil {
+		if err := misc.ValidateRandomPool(reqBody.RandomPool); err != nil {
+			return misc.NewHumanReadableError(
+				"Invalid random pool",
+				http.StatusBadRequest,
+				err,
+			)
+		}
+		if err := s.operatorsService.ValidateRandomPool(reqBody.RandomPool); err != nil {
+			return misc.NewHumanReadableError(
+				"Invalid random pool",
+				http.StatusBadRequest,
+				err,
+			)
+		}
+		config.RandomPool = *reqBody.RandomPool
+	}
+	if reqBody.SeededChibis != nil {
+		config.SeededChibis = *reqBody.SeededChibis
+	}
+	if reqBody.ChibiSeed != nil {
+		config.ChibiSeed = *reqBody.ChibiSeed
+	}
+	if config.SeededChibis && config.ChibiSeed == 0 {
+		// Kept within 32 bits so that the seed survives a JSON round trip
+		config.ChibiSeed = int64(rand.Int31()) + 1
+	}
 
 	if err := misc.ValidateSpineRuntimeConfig(&config); err != nil {
 		return misc.NewHumanReadableError(
@@ -598,10 +2452,62 @@ func (s *ApiServer) updateRoomSettings(ctx context.Context, channelName string,
 		)
 	}
 
+	var defaultOp *RoomDefaultOperator
+	if reqBody.DefaultOperator != nil {
+		if defaultOp, err = s.validateRoomDefaultOperator(reqBody.DefaultOperator); err != nil {
+			return err
+		}
+	}
+
 	err = s.roomsManager.UpdateSpineRuntimeConfig(ctx, roomDb.RoomId, &config)
+	if err != nil {
+		return err
+	}
+	if defaultOp != nil {
+		err = s.roomRepo.UpdateDefaultOperatorForId(
+			ctx,
+			roomDb.RoomId,
+			defaultOp.OperatorName,
+			&defaultOp.InitialOperatorDetails,
+		)
+	}
 	return err
 }
 
+// validateRoomDefaultOperator checks the operator and stance exist and returns
+// the details with the skin/stance/animations fixed up by the validation.
+func (s *ApiServer) validateRoomDefaultOperator(defaultOp *RoomDefaultOperator) (*RoomDefaultOperator, error) {
+	operatorName := strings.TrimSpace(defaultOp.OperatorName)
+	if len(operatorName) == 0 {
+		return &RoomDefaultOperator{}, nil
+	}
+
+	details := defaultOp.InitialOperatorDetails
+	details.SetDefaults()
+	if details.PositionX < 0 || details.PositionX > 1.0 {
+		return nil, misc.NewHumanReadableError(
+			"Invalid default operator position",
+			http.StatusBadRequest,
+			fmt.Errorf("position_x must be between 0 and 1, was %v", details.PositionX),
+		)
+	}
+	opInfo, err := s.operatorsService.OperatorFromDefault(operatorName, details)
+	if err != nil {
+		return nil, misc.NewHumanReadableError(
+			"Invalid default operator",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	details.Skin = opInfo.Skin
+	details.Stance = string(opInfo.ChibiStance)
+	details.Animations = opInfo.Action.Animations
+	return &RoomDefaultOperator{
+		OperatorName:           operatorName,
+		InitialOperatorDetails: details,
+	}, nil
+}
+
 func (s *ApiServer) getRoomSettings(ctx context.Context, channelName string) (*GetRoomSettingsResponse, error) {
 	roomDb, err := s.roomRepo.GetRoomByChannelName(ctx, channelName)
 	if err != nil {
@@ -621,6 +2527,18 @@ func (s *ApiServer) getRoomSettings(ctx context.Context, channelName string) (*G
 		MaxSpriteSize:      config.MaxScaleSize,
 		MaxSpritePixelSize: config.MaxSpritePixelSize,
 		UsernamesBlacklist: config.UsernamesBlacklist,
+		RandomPoolFilter:   config.RandomPoolFilter,
+		SeededChibis:       config.SeededChibis,
+		ChibiSeed:          config.ChibiSeed,
+	}
+	if !config.RandomPool.IsEmpty() {
+		resp.RandomPool = &config.RandomPool
+	}
+	if len(roomDb.DefaultOperatorName) > 0 {
+		resp.DefaultOperator = &RoomDefaultOperator{
+			OperatorName:           roomDb.DefaultOperatorName,
+			InitialOperatorDetails: roomDb.DefaultOperatorConfig,
+		}
 	}
 	return resp, nil
 }
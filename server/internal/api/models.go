@@ -1,6 +1,16 @@
 package api
 
-import "github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+import (
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/customchibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
+)
 
 type chatter struct {
 	Username     string `json:"username"`
@@ -38,14 +48,28 @@ type RoomUpdateRequest struct {
 	MinAnimationSpeed float64 `json:"min_animation_speed"`
 	MaxAnimationSpeed float64 `json:"max_animation_speed"`
 	// DefaultAnimationSpeed float64 `json:"default_animation_speed"`
-	MinVelocity float64 `json:"min_movement_speed"`
-	MaxVelocity float64 `json:"max_movement_speed"`
+	MinMovementSpeed float64 `json:"min_movement_speed"`
+	MaxMovementSpeed float64 `json:"max_movement_speed"`
 	// DefaultVelocity       float64 `json:"default_velocity"`
-	MinSpriteScale float64 `json:"min_sprite_size"`
-	MaxSpriteScale float64 `json:"max_sprite_size"`
+	MinSpriteSize float64 `json:"min_sprite_size"`
+	MaxSpriteSize float64 `json:"max_sprite_size"`
 	// DefaultSpriteScale    float64 `json:"default_sprite_scale"`
 	MaxSpritePixelSize int      `json:"max_sprite_pixel_size"`
 	UsernamesBlacklist []string `json:"usernames_blacklist"`
+	// Left unchanged if not set
+	RandomPoolFilter []string         `json:"random_pool_filter"`
+	RandomPool       *misc.RandomPool `json:"random_pool"`
+	SeededChibis     *bool            `json:"seeded_chibis"`
+	// A new seed is generated if seeded chibis are enabled without one
+	ChibiSeed *int64 `json:"chibi_seed"`
+	// The broadcaster's chibi when the room starts. An empty operator_name
+	// resets it to the bot's default.
+	DefaultOperator *RoomDefaultOperator `json:"default_operator"`
+}
+
+type RoomDefaultOperator struct {
+	OperatorName string `json:"operator_name"`
+	misc.InitialOperatorDetails
 }
 
 type RoomGiveOperatorRequest struct {
@@ -81,14 +105,52 @@ type GetRoomSettingsResponse struct {
 	MaxSpriteSize      float64  `json:"max_sprite_size"`
 	MaxSpritePixelSize int      `json:"max_sprite_pixel_size"`
 	UsernamesBlacklist []string `json:"usernames_blacklist"`
+	RandomPoolFilter   []string `json:"random_pool_filter"`
+	// Not set if the room doesn't curate its random chibis
+	RandomPool   *misc.RandomPool `json:"random_pool,omitempty"`
+	SeededChibis bool             `json:"seeded_chibis"`
+	ChibiSeed    int64            `json:"chibi_seed"`
+	// Not set if the room uses the bot's default chibi
+	DefaultOperator *RoomDefaultOperator `json:"default_operator,omitempty"`
 }
 
 type RoomRefreshRequest struct {
 	ChannelName string `json:"channel_name"`
 }
 
+// RoomControlRequest is used by the streamer's room control endpoints. The
+// username is only needed when acting on a single chatter.
+type RoomControlRequest struct {
+	ChannelName string `json:"channel_name"`
+	Username    string `json:"username"`
+}
+
+type RoomPauseRequest struct {
+	ChannelName string `json:"channel_name"`
+	Paused      bool   `json:"paused"`
+}
+
+type RoomPauseResponse struct {
+	Paused bool `json:"paused"`
+}
+
+type RoomClearResponse struct {
+	NumRemoved int `json:"num_removed"`
+}
+
+type GetRoomChibisResponse struct {
+	*room.ChatterStatsPage
+	Paused bool `json:"paused"`
+}
+
+type AdminAnnounceRequest struct {
+	Message string `json:"message"`
+}
+
 type GetUserPreferencesRequest struct {
 	UserId uint `json:"user_id"`
+	// Optional. Get the preferences for only this channel
+	ChannelName string `json:"channel_name"`
 }
 type GetUserPreferencesResponse struct {
 	OperatorInfo operator.OperatorInfo `json:"operator_info"`
@@ -96,7 +158,237 @@ type GetUserPreferencesResponse struct {
 type UpdateUserPreferencesRequest struct {
 	UserId       uint                  `json:"user_id"`
 	OperatorInfo operator.OperatorInfo `json:"operator_info"`
+	// Optional. Save the preferences for only this channel
+	ChannelName string `json:"channel_name"`
 }
-type DeleteUserPreferencesRquest struct {
-	UserId uint `json:"user_id"`
+type DeleteUserPreferencesRequest struct {
+	UserId      uint   `json:"user_id"`
+	ChannelName string `json:"channel_name"`
+}
+
+type UserLoadout struct {
+	LoadoutId    uint                  `json:"loadout_id"`
+	Name         string                `json:"name"`
+	OperatorInfo operator.OperatorInfo `json:"operator_info"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+type GetUserLoadoutsResponse struct {
+	Loadouts    []*UserLoadout `json:"loadouts"`
+	MaxLoadouts int            `json:"max_loadouts"`
+}
+type SaveUserLoadoutRequest struct {
+	UserId       uint                  `json:"user_id"`
+	Name         string                `json:"name"`
+	OperatorInfo operator.OperatorInfo `json:"operator_info"`
+}
+type RenameUserLoadoutRequest struct {
+	UserId    uint   `json:"user_id"`
+	LoadoutId uint   `json:"loadout_id"`
+	Name      string `json:"name"`
+}
+type DeleteUserLoadoutRequest struct {
+	UserId    uint `json:"user_id"`
+	LoadoutId uint `json:"loadout_id"`
+}
+type SelectUserLoadoutRequest struct {
+	UserId    uint `json:"user_id"`
+	LoadoutId uint `json:"loadout_id"`
+	// Optional. Only use the loadout in this channel
+	ChannelName string `json:"channel_name"`
+}
+
+type SetChatterChibiRequest struct {
+	ChannelName  string                `json:"channel_name"`
+	Username     string                `json:"username"`
+	OperatorInfo operator.OperatorInfo `json:"operator_info"`
+}
+
+type ApiKeyInfo struct {
+	ApiKeyId    uint       `json:"api_key_id"`
+	Name        string     `json:"name"`
+	ChannelName string     `json:"channel_name"`
+	KeyPrefix   string     `json:"key_prefix"`
+	Scopes      []string   `json:"scopes"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newApiKeyInfo(apiKey *auth.ApiKeyDb) *ApiKeyInfo {
+	info := &ApiKeyInfo{
+		ApiKeyId:    apiKey.ApiKeyId,
+		Name:        apiKey.Name,
+		ChannelName: apiKey.ChannelName,
+		KeyPrefix:   apiKey.KeyPrefix,
+		Scopes:      apiKey.Scopes,
+		CreatedAt:   apiKey.CreatedAt,
+	}
+	if apiKey.LastUsedAt.Valid {
+		info.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+	return info
+}
+
+type GetApiKeysResponse struct {
+	ApiKeys []*ApiKeyInfo `json:"api_keys"`
+}
+type CreateApiKeyRequest struct {
+	ChannelName string   `json:"channel_name"`
+	Name        string   `json:"name"`
+	Scopes      []string `json:"scopes"`
+}
+type CreateApiKeyResponse struct {
+	// The plaintext key. This is the only time it is returned.
+	ApiKey string      `json:"api_key"`
+	Info   *ApiKeyInfo `json:"info"`
+}
+type RevokeApiKeyRequest struct {
+	ApiKeyId uint `json:"api_key_id"`
+}
+
+type RoomPermissionInfo struct {
+	Username        string    `json:"username"`
+	UserDisplayName string    `json:"user_display_name"`
+	Role            string    `json:"role"`
+	Source          string    `json:"source"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+type GetRoomPermissionsResponse struct {
+	Permissions []*RoomPermissionInfo `json:"permissions"`
+}
+type SetRoomPermissionRequest struct {
+	ChannelName string `json:"channel_name"`
+	Username    string `json:"username"`
+	Role        string `json:"role"`
+}
+type DeleteRoomPermissionRequest struct {
+	ChannelName string `json:"channel_name"`
+	Username    string `json:"username"`
+}
+
+type WebhookInfo struct {
+	WebhookId uint      `json:"webhook_id"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newWebhookInfo(webhookDb *webhook.WebhookDb) *WebhookInfo {
+	return &WebhookInfo{
+		WebhookId: webhookDb.WebhookId,
+		Url:       webhookDb.Url,
+		Events:    webhookDb.Events,
+		CreatedAt: webhookDb.CreatedAt,
+	}
+}
+
+type GetWebhooksResponse struct {
+	Webhooks []*WebhookInfo `json:"webhooks"`
+}
+type CreateWebhookRequest struct {
+	ChannelName string   `json:"channel_name"`
+	Url         string   `json:"url"`
+	Events      []string `json:"events"`
+}
+type CreateWebhookResponse struct {
+	Webhook *WebhookInfo `json:"webhook"`
+	// Secret is only ever returned on creation. It is used to verify the
+	// X-Ak-Chibi-Bot-Signature header of each delivery.
+	Secret string `json:"secret"`
+}
+type WebhookRequest struct {
+	ChannelName string `json:"channel_name"`
+	WebhookId   uint   `json:"webhook_id"`
+}
+
+type WebhookDeliveryInfo struct {
+	DeliveryId string    `json:"delivery_id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	Success    bool      `json:"success"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newWebhookDeliveryInfo(deliveryDb *webhook.WebhookDeliveryDb) *WebhookDeliveryInfo {
+	return &WebhookDeliveryInfo{
+		DeliveryId: deliveryDb.DeliveryUuid,
+		Event:      string(deliveryDb.Event),
+		Attempt:    deliveryDb.Attempt,
+		StatusCode: deliveryDb.StatusCode,
+		Error:      deliveryDb.ErrorMsg,
+		Success:    deliveryDb.Success,
+		DurationMs: deliveryDb.DurationMs,
+		CreatedAt:  deliveryDb.CreatedAt,
+	}
+}
+
+type GetWebhookDeliveriesResponse struct {
+	Deliveries []*WebhookDeliveryInfo `json:"deliveries"`
+}
+
+type CustomChibiInfo struct {
+	OperatorId string    `json:"operator_id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func newCustomChibiInfo(chibiDb *customchibi.CustomChibiDb) *CustomChibiInfo {
+	return &CustomChibiInfo{
+		OperatorId: chibiDb.OperatorId,
+		Name:       chibiDb.Name,
+		CreatedAt:  chibiDb.CreatedAt,
+		UpdatedAt:  chibiDb.UpdatedAt,
+	}
+}
+
+type GetCustomChibisResponse struct {
+	CustomChibis []*CustomChibiInfo `json:"custom_chibis"`
+}
+type UploadCustomChibiResponse struct {
+	// Not set if the upload failed validation
+	CustomChibi *CustomChibiInfo                `json:"custom_chibi,omitempty"`
+	Report      *operator.AssetValidationReport `json:"report"`
+}
+type CustomChibiRequest struct {
+	ChannelName string `json:"channel_name"`
+	OperatorId  string `json:"operator_id"`
+}
+
+type AssetManifestInfo struct {
+	ManifestId  uint      `json:"manifest_id"`
+	Description string    `json:"description"`
+	NumFiles    int       `json:"num_files"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newAssetManifestInfo(manifestDb *akdb.AssetManifestDb) *AssetManifestInfo {
+	return &AssetManifestInfo{
+		ManifestId:  manifestDb.ManifestId,
+		Description: manifestDb.Description,
+		NumFiles:    manifestDb.NumFiles,
+		CreatedAt:   manifestDb.CreatedAt,
+	}
+}
+
+type GetAssetManifestsResponse struct {
+	PinnedManifestId uint                 `json:"pinned_manifest_id"`
+	Manifests        []*AssetManifestInfo `json:"manifests"`
+}
+
+type RollbackAssetsRequest struct {
+	ManifestId uint `json:"manifest_id"`
+}
+
+type FactionInfo struct {
+	Name          operator.FactionEnum     `json:"name"`
+	Command       string                   `json:"command"`
+	DefaultStance operator.ChibiStanceEnum `json:"default_stance"`
+	NumChibis     int                      `json:"num_chibis"`
+}
+
+type GetFactionsResponse struct {
+	Factions []*FactionInfo `json:"factions"`
 }
@@ -0,0 +1,715 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/openapi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
+)
+
+const (
+	OPENAPI_TITLE         = "AK Chibi Bot API"
+	OPENAPI_VERSION       = "1.0.0"
+	OPENAPI_CACHE_CONTROL = "public, max-age=300"
+)
+
+// The document only changes between builds
+var openApiDocument = sync.OnceValue(NewOpenApiDocument)
+
+type routeAuth int
+
+const (
+	// A user's JWT token, or an API key if the route has a scope
+	ROUTE_AUTH_USER routeAuth = iota
+	ROUTE_AUTH_ADMIN
+	ROUTE_AUTH_PUBLIC
+)
+
+var routePathParamRegex = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// apiRoute documents one of the patterns registered in RegisterHandlers. The
+// OpenAPI document is built from these and the tests check that every
+// registered pattern is documented.
+type apiRoute struct {
+	pattern     string
+	operationId string
+	summary     string
+	tag         string
+	auth        routeAuth
+	// API keys with this scope can be used instead of a user token
+	scope  auth.ApiKeyScope
+	params []*openapi.Parameter
+	// Zero values of the JSON request and response bodies. Left nil if the
+	// route doesn't have one.
+	request  interface{}
+	response interface{}
+	// Set instead of request for multipart form uploads
+	multipartRequest *openapi.Schema
+	// The response is a stream of these events instead of a JSON body
+	streamResponse interface{}
+}
+
+func queryParam(name string, schemaType string, required bool, description string) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        name,
+		In:          "query",
+		Description: description,
+		Required:    required,
+		Schema:      &openapi.Schema{Type: schemaType},
+	}
+}
+
+func channelNameParam() *openapi.Parameter {
+	return queryParam("channel_name", "string", true, "The channel name of the room")
+}
+
+func paginationParams(sortDescription string) []*openapi.Parameter {
+	params := []*openapi.Parameter{
+		queryParam("offset", "integer", false, ""),
+		queryParam("limit", "integer", false, ""),
+	}
+	if len(sortDescription) > 0 {
+		params = append(params, queryParam("sort", "string", false, sortDescription))
+	}
+	return params
+}
+
+func catalogParams() []*openapi.Parameter {
+	return append(
+		[]*openapi.Parameter{queryParam("q", "string", false, "Only chibis whose id or names contain this")},
+		paginationParams("")...,
+	)
+}
+
+func eventsParam() *openapi.Parameter {
+	names := make([]string, 0, len(events.AllEventTypes))
+	for _, event := range events.AllEventTypes {
+		names = append(names, string(event))
+	}
+	return queryParam("events", "string", false,
+		"Comma separated events to receive. All events if not set.\n"+oneOf(names...))
+}
+
+func oneOf(values ...string) string {
+	return "One of: " + strings.Join(values, ", ")
+}
+
+func apiRoutes() []*apiRoute {
+	return []*apiRoute{
+		{
+			pattern:     "GET  /api/rooms/settings/{$}",
+			operationId: "GetRoomSettings",
+			summary:     "Get the settings of a room",
+			tag:         "rooms",
+			scope:       auth.API_KEY_SCOPE_ROOM_SETTINGS_READ,
+			params:      []*openapi.Parameter{channelNameParam()},
+			response:    GetRoomSettingsResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/settings/{$}",
+			operationId: "UpdateRoomSettings",
+			summary:     "Update the settings of a room. Unset fields are left unchanged",
+			tag:         "rooms",
+			scope:       auth.API_KEY_SCOPE_ROOM_SETTINGS_WRITE,
+			request:     RoomUpdateRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/chibis/set/{$}",
+			operationId: "SetChatterChibi",
+			summary:     "Set the chibi of a chatter in a room",
+			tag:         "rooms",
+			scope:       auth.API_KEY_SCOPE_CHIBI_SET,
+			request:     SetChatterChibiRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/chibis/remove/{$}",
+			operationId: "RemoveChatterChibi",
+			summary:     "Remove the chibi of a chatter from a room",
+			tag:         "rooms",
+			scope:       auth.API_KEY_SCOPE_CHIBI_REMOVE,
+			request:     removeUserRequest{},
+		},
+		{
+			pattern:     "GET /api/rooms/permissions/{$}",
+			operationId: "GetRoomPermissions",
+			summary:     "List the users with a role in a room",
+			tag:         "rooms",
+			params:      []*openapi.Parameter{channelNameParam()},
+			response:    GetRoomPermissionsResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/permissions/{$}",
+			operationId: "SetRoomPermission",
+			summary:     "Give a user a role in a room",
+			tag:         "rooms",
+			request:     SetRoomPermissionRequest{},
+		},
+		{
+			pattern:     "DELETE /api/rooms/permissions/{$}",
+			operationId: "DeleteRoomPermission",
+			summary:     "Remove a user's role in a room",
+			tag:         "rooms",
+			request:     DeleteRoomPermissionRequest{},
+		},
+		{
+			pattern:     "GET /api/rooms/webhooks/{$}",
+			operationId: "GetWebhooks",
+			summary:     "List the webhooks of a room",
+			tag:         "webhooks",
+			params:      []*openapi.Parameter{channelNameParam()},
+			response:    GetWebhooksResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/webhooks/{$}",
+			operationId: "CreateWebhook",
+			summary:     "Create a webhook for a room",
+			tag:         "webhooks",
+			request:     CreateWebhookRequest{},
+			response:    CreateWebhookResponse{},
+		},
+		{
+			pattern:     "DELETE /api/rooms/webhooks/{$}",
+			operationId: "DeleteWebhook",
+			summary:     "Delete a webhook",
+			tag:         "webhooks",
+			request:     WebhookRequest{},
+		},
+		{
+			pattern:     "GET /api/rooms/webhooks/deliveries/{$}",
+			operationId: "GetWebhookDeliveries",
+			summary:     "List the recent deliveries of a webhook",
+			tag:         "webhooks",
+			params: []*openapi.Parameter{
+				channelNameParam(),
+				queryParam("webhook_id", "integer", true, ""),
+			},
+			response: GetWebhookDeliveriesResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/webhooks/test/{$}",
+			operationId: "TestWebhook",
+			summary:     "Send a test event to a webhook",
+			tag:         "webhooks",
+			request:     WebhookRequest{},
+			response:    WebhookDeliveryInfo{},
+		},
+		{
+			pattern:        "GET /api/rooms/events/{$}",
+			operationId:    "RoomEvents",
+			summary:        "Stream the live events of a room as Server-Sent Events",
+			tag:            "events",
+			params:         []*openapi.Parameter{channelNameParam(), eventsParam()},
+			streamResponse: events.Event{},
+		},
+		{
+			pattern:     "GET /api/rooms/custom_chibis/{$}",
+			operationId: "GetCustomChibis",
+			summary:     "List the custom chibis uploaded to a room",
+			tag:         "custom_chibis",
+			params:      []*openapi.Parameter{channelNameParam()},
+			response:    GetCustomChibisResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/custom_chibis/{$}",
+			operationId: "UploadCustomChibi",
+			summary:     "Upload the spine files of a custom chibi",
+			tag:         "custom_chibis",
+			multipartRequest: &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"channel_name": {Type: "string"},
+					"name":         {Type: "string"},
+					"animations":   {Type: "string", Description: "Comma separated animations to check for"},
+					"files": {
+						Type:  "array",
+						Items: &openapi.Schema{Type: "string", Format: "binary"},
+					},
+				},
+			},
+			response: UploadCustomChibiResponse{},
+		},
+		{
+			pattern:     "DELETE /api/rooms/custom_chibis/{$}",
+			operationId: "DeleteCustomChibi",
+			summary:     "Delete a custom chibi",
+			tag:         "custom_chibis",
+			request:     CustomChibiRequest{},
+		},
+		{
+			pattern:     "GET /api/rooms/control/chibis/{$}",
+			operationId: "GetRoomChibis",
+			summary:     "List the chatters with a chibi in the streamer's room",
+			tag:         "room_control",
+			params:      append([]*openapi.Parameter{channelNameParam()}, paginationParams(oneOf(room.CHATTERS_SORT_USERNAME, room.CHATTERS_SORT_LAST_CHAT))...),
+			response:    GetRoomChibisResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/control/kick/{$}",
+			operationId: "KickChatter",
+			summary:     "Remove a chatter's chibi from the streamer's room",
+			tag:         "room_control",
+			request:     RoomControlRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/control/reset/{$}",
+			operationId: "ResetChatter",
+			summary:     "Give a chatter's chibi a new random operator",
+			tag:         "room_control",
+			request:     RoomControlRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/control/find/{$}",
+			operationId: "FindChatter",
+			summary:     "Highlight a chatter's chibi on the overlay",
+			tag:         "room_control",
+			request:     RoomControlRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/control/pause/{$}",
+			operationId: "PauseRoom",
+			summary:     "Pause or resume the movement of all chibis",
+			tag:         "room_control",
+			request:     RoomPauseRequest{},
+			response:    RoomPauseResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/control/clear/{$}",
+			operationId: "ClearRoom",
+			summary:     "Remove every chatter's chibi from the streamer's room",
+			tag:         "room_control",
+			request:     RoomControlRequest{},
+			response:    RoomClearResponse{},
+		},
+		{
+			pattern:     "POST /api/rooms/remove/{$}",
+			operationId: "RemoveRoom",
+			summary:     "Close a room",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     removeRoomRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/refresh/{$}",
+			operationId: "RefreshRoom",
+			summary:     "Reload the runtime config of a room's overlays",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     RoomRefreshRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/users/remove/{$}",
+			operationId: "RemoveUser",
+			summary:     "Remove a chatter's chibi from any room",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     removeUserRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/users/give/{$}",
+			operationId: "GiveOperator",
+			summary:     "Give a chatter a random chibi in any room",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     RoomGiveOperatorRequest{},
+		},
+		{
+			pattern:     "POST /api/rooms/users/set/{$}",
+			operationId: "SetOperator",
+			summary:     "Set a chatter's chibi in any room",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     RoomSetOperatorRequest{},
+		},
+
+		{
+			pattern:     "GET /api/users/preferences/{$}",
+			operationId: "GetUserPreferences",
+			summary:     "Get the user's saved chibi",
+			tag:         "users",
+			params: []*openapi.Parameter{
+				queryParam("user_id", "integer", true, ""),
+				queryParam("channel_name", "string", false, "Get the chibi saved for only this channel"),
+			},
+			response: GetUserPreferencesResponse{},
+		},
+		{
+			pattern:     "POST /api/users/preferences/{$}",
+			operationId: "UpdateUserPreferences",
+			summary:     "Save the user's chibi",
+			tag:         "users",
+			request:     UpdateUserPreferencesRequest{},
+		},
+		{
+			pattern:     "DELETE /api/users/preferences/{$}",
+			operationId: "DeleteUserPreferences",
+			summary:     "Delete the user's saved chibi",
+			tag:         "users",
+			request:     DeleteUserPreferencesRequest{},
+		},
+		{
+			pattern:     "GET /api/users/loadouts/{$}",
+			operationId: "GetUserLoadouts",
+			summary:     "List the user's chibi loadouts",
+			tag:         "users",
+			params:      []*openapi.Parameter{queryParam("user_id", "integer", true, "")},
+			response:    GetUserLoadoutsResponse{},
+		},
+		{
+			pattern:     "POST /api/users/loadouts/{$}",
+			operationId: "SaveUserLoadout",
+			summary:     "Save a chibi as a new loadout",
+			tag:         "users",
+			request:     SaveUserLoadoutRequest{},
+			response:    UserLoadout{},
+		},
+		{
+			pattern:     "DELETE /api/users/loadouts/{$}",
+			operationId: "DeleteUserLoadout",
+			summary:     "Delete a loadout",
+			tag:         "users",
+			request:     DeleteUserLoadoutRequest{},
+		},
+		{
+			pattern:     "POST /api/users/loadouts/rename/{$}",
+			operationId: "RenameUserLoadout",
+			summary:     "Rename a loadout",
+			tag:         "users",
+			request:     RenameUserLoadoutRequest{},
+		},
+		{
+			pattern:     "POST /api/users/loadouts/select/{$}",
+			operationId: "SelectUserLoadout",
+			summary:     "Use a loadout as the user's chibi",
+			tag:         "users",
+			request:     SelectUserLoadoutRequest{},
+		},
+
+		{
+			pattern:     "GET  /api/admin/info/{$}",
+			operationId: "AdminInfo",
+			summary:     "Get the active rooms and server metrics",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			response:    AdminInfo{},
+		},
+		{
+			pattern:     "GET /api/admin/rooms/{$}",
+			operationId: "AdminListRooms",
+			summary:     "List the rooms with their stats",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			params: append(
+				[]*openapi.Parameter{queryParam("active", "boolean", false, "List the active or inactive rooms. Defaults to true")},
+				paginationParams(oneOf(
+					room.ROOMS_SORT_CHANNEL,
+					room.ROOMS_SORT_CREATED,
+					room.ROOMS_SORT_LAST_ACTIVE,
+					room.ROOMS_SORT_CHATTERS,
+					room.ROOMS_SORT_CONNECTIONS,
+					room.ROOMS_SORT_COMMANDS,
+				))...,
+			),
+			response: room.RoomStatsPage{},
+		},
+		{
+			pattern:     "GET /api/admin/rooms/{channel}/chatters/{$}",
+			operationId: "AdminListChatters",
+			summary:     "List the chatters of an active room",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			params:      paginationParams(oneOf(room.CHATTERS_SORT_USERNAME, room.CHATTERS_SORT_LAST_CHAT)),
+			response:    room.ChatterStatsPage{},
+		},
+		{
+			pattern:     "GET /api/admin/events/{$}",
+			operationId: "AdminEvents",
+			summary:     "Stream the live events of every room as Server-Sent Events",
+			tag:         "events",
+			auth:        ROUTE_AUTH_ADMIN,
+			params: []*openapi.Parameter{
+				queryParam("channel_name", "string", false, "Only stream the events of this room"),
+				eventsParam(),
+			},
+			streamResponse: events.Event{},
+		},
+		{
+			pattern:     "POST /api/admin/rooms/refresh/{$}",
+			operationId: "AdminRefreshRooms",
+			summary:     "Reload the runtime config of every active room",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			response:    room.BulkRoomsReport{},
+		},
+		{
+			pattern:     "POST /api/admin/rooms/evict/{$}",
+			operationId: "AdminEvictRooms",
+			summary:     "Close every active room matching the filter",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     room.RoomFilter{},
+			response:    room.BulkRoomsReport{},
+		},
+		{
+			pattern:     "POST /api/admin/rooms/announce/{$}",
+			operationId: "AdminAnnounce",
+			summary:     "Show a message in every active room",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     AdminAnnounceRequest{},
+			response:    room.BulkRoomsReport{},
+		},
+		{
+			pattern:     "POST /api/admin/assets/reload/{$}",
+			operationId: "ReloadAssets",
+			summary:     "Reload the chibi assets",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			response:    operator.AssetReloadReport{},
+		},
+		{
+			pattern:     "GET /api/admin/assets/manifests/{$}",
+			operationId: "GetAssetManifests",
+			summary:     "List the ingested asset manifests",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			response:    GetAssetManifestsResponse{},
+		},
+		{
+			pattern:     "GET /api/admin/assets/manifests/diff/{$}",
+			operationId: "DiffAssetManifests",
+			summary:     "Compare the files of two asset manifests",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			params: []*openapi.Parameter{
+				queryParam("from", "integer", true, "Manifest id"),
+				queryParam("to", "integer", true, "Manifest id"),
+			},
+			response: akdb.AssetManifestDiff{},
+		},
+		{
+			pattern:     "POST /api/admin/assets/rollback/{$}",
+			operationId: "RollbackAssets",
+			summary:     "Pin an older asset manifest and reload the assets",
+			tag:         "admin",
+			auth:        ROUTE_AUTH_ADMIN,
+			request:     RollbackAssetsRequest{},
+			response:    operator.AssetReloadReport{},
+		},
+
+		{
+			pattern:     "GET /api/catalog/operators/{$}",
+			operationId: "ListOperatorsCatalog",
+			summary:     "Search the operators",
+			tag:         "catalog",
+			auth:        ROUTE_AUTH_PUBLIC,
+			params:      catalogParams(),
+			response:    operator.CatalogPage{},
+		},
+		{
+			pattern:     "GET /api/catalog/operators/{id}/{$}",
+			operationId: "GetOperatorCatalogDetail",
+			summary:     "Get the skins and animations of an operator",
+			tag:         "catalog",
+			auth:        ROUTE_AUTH_PUBLIC,
+			response:    operator.CatalogDetail{},
+		},
+		{
+			pattern:     "GET /api/catalog/enemies/{$}",
+			operationId: "ListEnemiesCatalog",
+			summary:     "Search the enemies",
+			tag:         "catalog",
+			auth:        ROUTE_AUTH_PUBLIC,
+			params:      catalogParams(),
+			response:    operator.CatalogPage{},
+		},
+		{
+			pattern:     "GET /api/catalog/enemies/{id}/{$}",
+			operationId: "GetEnemyCatalogDetail",
+			summary:     "Get the skins and animations of an enemy",
+			tag:         "catalog",
+			auth:        ROUTE_AUTH_PUBLIC,
+			response:    operator.CatalogDetail{},
+		},
+		{
+			pattern:     "GET /api/catalog/factions/{$}",
+			operationId: "GetFactions",
+			summary:     "List the factions of chibis",
+			tag:         "catalog",
+			auth:        ROUTE_AUTH_PUBLIC,
+			response:    GetFactionsResponse{},
+		},
+		{
+			pattern:     "GET /api/catalog/factions/{faction}/{$}",
+			operationId: "ListFactionCatalog",
+			summary:     "Search the chibis of a faction",
+			tag:         "catalog",
+			auth:        ROUTE_AUTH_PUBLIC,
+			params:      catalogParams(),
+			response:    operator.CatalogPage{},
+		},
+		{
+			pattern:     "GET /api/catalog/factions/{faction}/{id}/{$}",
+			operationId: "GetFactionCatalogDetail",
+			summary:     "Get the skins and animations of a chibi in a faction",
+			tag:         "catalog",
+			auth:        ROUTE_AUTH_PUBLIC,
+			response:    operator.CatalogDetail{},
+		},
+
+		{
+			pattern:     "GET /api/apikeys/{$}",
+			operationId: "GetApiKeys",
+			summary:     "List the user's API keys",
+			tag:         "apikeys",
+			response:    GetApiKeysResponse{},
+		},
+		{
+			pattern:     "POST /api/apikeys/{$}",
+			operationId: "CreateApiKey",
+			summary:     "Create an API key. The key is only returned once",
+			tag:         "apikeys",
+			request:     CreateApiKeyRequest{},
+			response:    CreateApiKeyResponse{},
+		},
+		{
+			pattern:     "DELETE /api/apikeys/{$}",
+			operationId: "RevokeApiKey",
+			summary:     "Revoke an API key",
+			tag:         "apikeys",
+			request:     RevokeApiKeyRequest{},
+		},
+
+		{
+			pattern:     "GET /api/openapi.json",
+			operationId: "GetOpenApi",
+			summary:     "Get this OpenAPI document",
+			tag:         "meta",
+			auth:        ROUTE_AUTH_PUBLIC,
+			response:    map[string]interface{}{},
+		},
+	}
+}
+
+// NewOpenApiDocument describes every route of the API
+func NewOpenApiDocument() *openapi.Document {
+	schemas := openapi.NewSchemas()
+	doc := &openapi.Document{
+		OpenApi: openapi.OPENAPI_VERSION,
+		Info: openapi.Info{
+			Title: OPENAPI_TITLE,
+			Description: "Errors are returned as a plain text message with a 4xx or 5xx status code.\n" +
+				"Authenticated routes take a user's token, or an API key if the route lists an x-api-key-scope, " +
+				"in the Authorization: Bearer header.",
+			Version: OPENAPI_VERSION,
+		},
+		Paths: make(map[string]openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				openapi.SECURITY_SCHEME_BEARER: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT or API key",
+				},
+			},
+		},
+	}
+
+	tags := make(map[string]bool)
+	for _, route := range apiRoutes() {
+		method, path := splitRoutePattern(route.pattern)
+		op := &openapi.Operation{
+			OperationId: route.operationId,
+			Summary:     route.summary,
+			Tags:        []string{route.tag},
+			Parameters:  make([]*openapi.Parameter, 0),
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "OK"},
+				"default": {
+					Description: "Error",
+					Content: map[string]*openapi.MediaType{
+						openapi.CONTENT_TYPE_TEXT: {Schema: &openapi.Schema{Type: "string"}},
+					},
+				},
+			},
+		}
+		if !tags[route.tag] {
+			tags[route.tag] = true
+			doc.Tags = append(doc.Tags, &openapi.Tag{Name: route.tag})
+		}
+
+		switch route.auth {
+		case ROUTE_AUTH_USER:
+			op.Security = []map[string][]string{{openapi.SECURITY_SCHEME_BEARER: {}}}
+			op.ApiKeyScope = string(route.scope)
+		case ROUTE_AUTH_ADMIN:
+			op.Security = []map[string][]string{{openapi.SECURITY_SCHEME_BEARER: {}}}
+			op.Description = "Requires an admin account."
+		}
+
+		for _, match := range routePathParamRegex.FindAllStringSubmatch(path, -1) {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+		op.Parameters = append(op.Parameters, route.params...)
+
+		if route.request != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content: map[string]*openapi.MediaType{
+					openapi.CONTENT_TYPE_JSON: {Schema: schemas.For(route.request)},
+				},
+			}
+		} else if route.multipartRequest != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content: map[string]*openapi.MediaType{
+					openapi.CONTENT_TYPE_MULTIPART: {Schema: route.multipartRequest},
+				},
+			}
+		}
+
+		if route.response != nil {
+			op.Responses["200"].Content = map[string]*openapi.MediaType{
+				openapi.CONTENT_TYPE_JSON: {Schema: schemas.For(route.response)},
+			}
+		} else if route.streamResponse != nil {
+			op.Responses["200"].Description = "Each event's data is one of these as JSON"
+			op.Responses["200"].Content = map[string]*openapi.MediaType{
+				openapi.CONTENT_TYPE_STREAM: {Schema: schemas.For(route.streamResponse)},
+			}
+		}
+
+		if _, ok := doc.Paths[path]; !ok {
+			doc.Paths[path] = make(openapi.PathItem)
+		}
+		doc.Paths[path][strings.ToLower(method)] = op
+	}
+	doc.Components.Schemas = schemas.Components()
+	return doc
+}
+
+// splitRoutePattern turns a ServeMux pattern into its method and OpenAPI path
+func splitRoutePattern(pattern string) (string, string) {
+	fields := strings.Fields(pattern)
+	return fields[0], strings.TrimSuffix(fields[1], "{$}")
+}
+
+func (s *ApiServer) HandleGetOpenApi(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return nil
+	}
+	return misc.WriteJsonWithETag(w, r, openApiDocument(), OPENAPI_CACHE_CONTROL)
+}
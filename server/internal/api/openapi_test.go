@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/apiclient"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/openapi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingRouteHandler struct {
+	patterns []string
+}
+
+func (h *recordingRouteHandler) Handle(pattern string, handler http.Handler) {
+	h.patterns = append(h.patterns, pattern)
+}
+
+func normalizePattern(pattern string) string {
+	return strings.Join(strings.Fields(pattern), " ")
+}
+
+func newTestOpenApiServer() *ApiServer {
+	return &ApiServer{
+		authService:      auth.NewFakeAuthService(),
+		operatorsService: operator.NewDefaultOperatorService(operator.NewTestAssetService()),
+	}
+}
+
+func TestOpenApi_EveryRouteIsDocumented(t *testing.T) {
+	assert := assert.New(t)
+	recorder := &recordingRouteHandler{}
+	newTestOpenApiServer().registerRoutes(recorder)
+
+	registered := make(map[string]bool)
+	for _, pattern := range recorder.patterns {
+		registered[normalizePattern(pattern)] = true
+	}
+	documented := make(map[string]bool)
+	operationIds := make(map[string]bool)
+	for _, route := range apiRoutes() {
+		pattern := normalizePattern(route.pattern)
+		assert.True(registered[pattern], "%s is documented but not registered", pattern)
+		assert.False(documented[pattern], "%s is documented twice", pattern)
+		assert.False(operationIds[route.operationId], "%s is used twice", route.operationId)
+		documented[pattern] = true
+		operationIds[route.operationId] = true
+	}
+	for pattern := range registered {
+		assert.True(documented[pattern], "%s is registered but not documented", pattern)
+	}
+}
+
+// Calls every route with a missing and an API key token to check that the
+// documented auth matches the middleware the route was registered with. Admin
+// routes look the same as user routes until a user is looked up.
+func TestOpenApi_DocumentedAuthMatchesMiddleware(t *testing.T) {
+	sut := newTestOpenApiServer()
+	mux := http.NewServeMux()
+	sut.RegisterHandlers(mux)
+
+	call := func(route *apiRoute, token string) string {
+		method, path := splitRoutePattern(route.pattern)
+		path = routePathParamRegex.ReplaceAllString(path, "amiya")
+		req := httptest.NewRequest(method, "http://example.com"+path, strings.NewReader("{}"))
+		if len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		body, _ := io.ReadAll(w.Result().Body)
+		return string(body)
+	}
+
+	for _, route := range apiRoutes() {
+		t.Run(route.operationId, func(t *testing.T) {
+			assert := assert.New(t)
+			noToken := call(route, "")
+			apiKey := call(route, auth.API_KEY_PREFIX+"unknown")
+			switch {
+			case route.auth == ROUTE_AUTH_PUBLIC:
+				assert.NotEqual("invalid authorization header", noToken)
+			case len(route.scope) > 0:
+				assert.Equal("invalid authorization header", noToken)
+				assert.Equal("invalid api key", apiKey)
+			default:
+				assert.Equal("invalid authorization header", noToken)
+				assert.Equal("api keys can not be used for this endpoint", apiKey)
+			}
+		})
+	}
+}
+
+func TestOpenApi_GeneratedClientIsUpToDate(t *testing.T) {
+	assert := assert.New(t)
+	generated, err := openapi.GenerateClient(NewOpenApiDocument(), "apiclient")
+	assert.Nil(err)
+	existing, err := os.ReadFile("../apiclient/client_gen.go")
+	assert.Nil(err)
+	assert.Equal(
+		string(existing),
+		string(generated),
+		"the client is out of date, run: go generate ./server/internal/apiclient",
+	)
+}
+
+func TestApiServer_OpenApiClient(t *testing.T) {
+	assert := assert.New(t)
+	mux := http.NewServeMux()
+	newTestOpenApiServer().RegisterHandlers(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := apiclient.NewClient(server.URL, "", server.Client())
+
+	doc, err := client.GetOpenApi(context.Background())
+	assert.Nil(err)
+	assert.Equal(openapi.OPENAPI_VERSION, doc["openapi"])
+	assert.Contains(doc["paths"], "/api/rooms/settings/")
+
+	page, err := client.ListOperatorsCatalog(context.Background(), apiclient.ListOperatorsCatalogParams{
+		Q:     "ami",
+		Limit: 10,
+	})
+	assert.Nil(err)
+	assert.Equal(1, page.Total)
+	assert.Equal("char_002_amiya", page.Entries[0].Id)
+
+	detail, err := client.GetEnemyCatalogDetail(context.Background(), "enemy_1007_slime_2")
+	assert.Nil(err)
+	assert.Equal("enemy", detail.Faction)
+
+	_, err = client.GetEnemyCatalogDetail(context.Background(), "char_002_amiya")
+	var apiErr *apiclient.ApiError
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(http.StatusNotFound, apiErr.StatusCode)
+
+	// Routes which need a token fail with the server's message
+	_, err = client.GetApiKeys(context.Background())
+	assert.True(errors.As(err, &apiErr))
+	assert.Equal(http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal("invalid authorization header", apiErr.Message)
+}
@@ -0,0 +1,102 @@
+package apiclient
+
+//go:generate go run ../../tools/openapi_client -output client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Error messages are short human readable strings
+	API_ERROR_MAX_BYTES = 4096
+)
+
+// Client calls the bot's HTTP API. Its methods and models are generated from
+// the OpenAPI document served at /api/openapi.json.
+type Client struct {
+	baseUrl    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the server at baseUrl (ie.
+// http://localhost:8080). The token is either a user's JWT token or an API
+// key and can be empty for the public routes.
+func NewClient(baseUrl string, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// ApiError is returned when the server responds with a non 2xx status
+type ApiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) do(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body interface{},
+	out interface{},
+) error {
+	reqUrl := c.baseUrl + path
+	if len(query) > 0 {
+		reqUrl += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqUrl, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, API_ERROR_MAX_BYTES))
+		return &ApiError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(msg)),
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
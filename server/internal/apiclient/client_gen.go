@@ -0,0 +1,1302 @@
+// Code generated by tools/openapi_client. DO NOT EDIT.
+
+package apiclient
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ActionUnion struct {
+	ActionFollowIdleAnimation string   `json:"action_follow_idle_animation"`
+	ActionFollowTarget        string   `json:"action_follow_target"`
+	ActionFollowWalkAnimation string   `json:"action_follow_walk_animation"`
+	Animations                []string `json:"animations"`
+	CurrentAction             string   `json:"current_action"`
+	IsSet                     bool     `json:"is_set"`
+	PaceAroundAnimation       string   `json:"pace_around_animation"`
+	PaceEndPos                *Vector2 `json:"pace_end_pos"`
+	PaceStartPos              *Vector2 `json:"pace_start_pos"`
+	TargetPos                 *Vector2 `json:"target_pos"`
+	WalkAnimation             string   `json:"walk_animation"`
+	WalkToAnimation           string   `json:"walk_to_animation"`
+	WalkToFinalAnimation      string   `json:"walk_to_final_animation"`
+	WanderAnimation           string   `json:"wander_animation"`
+	WanderAnimationIdle       string   `json:"wander_animation_idle"`
+}
+
+type AdminAnnounceRequest struct {
+	Message string `json:"message"`
+}
+
+type AdminInfo struct {
+	Metrics    map[string]interface{} `json:"metrics"`
+	NextGcTime string                 `json:"next_gc_time"`
+	Rooms      []*RoomInfo            `json:"rooms"`
+}
+
+type ApiKeyInfo struct {
+	ApiKeyId    int        `json:"api_key_id"`
+	ChannelName string     `json:"channel_name"`
+	CreatedAt   time.Time  `json:"created_at"`
+	KeyPrefix   string     `json:"key_prefix"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	Name        string     `json:"name"`
+	Scopes      []string   `json:"scopes"`
+}
+
+type AssetLoadError struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+type AssetManifestDiff struct {
+	Added          []string `json:"added"`
+	Changed        []string `json:"changed"`
+	FromManifestId int      `json:"from_manifest_id"`
+	Removed        []string `json:"removed"`
+	ToManifestId   int      `json:"to_manifest_id"`
+}
+
+type AssetManifestInfo struct {
+	CreatedAt   time.Time `json:"created_at"`
+	Description string    `json:"description"`
+	ManifestId  int       `json:"manifest_id"`
+	NumFiles    int       `json:"num_files"`
+}
+
+type AssetReloadReport struct {
+	AddedIds          []string          `json:"added_ids"`
+	Duration          int64             `json:"duration"`
+	LoadErrors        []*AssetLoadError `json:"load_errors"`
+	NumChibis         map[string]int    `json:"num_chibis"`
+	NumChibisMigrated int               `json:"num_chibis_migrated"`
+	NumEnemies        int               `json:"num_enemies"`
+	NumOperators      int               `json:"num_operators"`
+	RemovedIds        []string          `json:"removed_ids"`
+}
+
+type AssetValidationIssue struct {
+	Code       string `json:"code"`
+	Facing     string `json:"facing"`
+	Faction    string `json:"faction"`
+	File       string `json:"file"`
+	Message    string `json:"message"`
+	OperatorId string `json:"operator_id"`
+	Severity   string `json:"severity"`
+	Skin       string `json:"skin"`
+	Stance     string `json:"stance"`
+}
+
+type AssetValidationReport struct {
+	Issues      []*AssetValidationIssue `json:"issues"`
+	NumChecked  int                     `json:"num_checked"`
+	NumErrors   int                     `json:"num_errors"`
+	NumWarnings int                     `json:"num_warnings"`
+}
+
+type BulkRoomResult struct {
+	ChannelName string `json:"channel_name"`
+	Error       string `json:"error"`
+	Success     bool   `json:"success"`
+}
+
+type BulkRoomsReport struct {
+	NumFailed    int               `json:"num_failed"`
+	NumSucceeded int               `json:"num_succeeded"`
+	Results      []*BulkRoomResult `json:"results"`
+}
+
+type CatalogDetail struct {
+	Faction      string               `json:"faction"`
+	Metadata     *OperatorMetadata    `json:"metadata"`
+	Names        []string             `json:"names"`
+	OperatorId   string               `json:"operator_id"`
+	OperatorName string               `json:"operator_name"`
+	Skins        map[string]*SkinData `json:"skins"`
+}
+
+type CatalogEntry struct {
+	Id    string   `json:"id"`
+	Name  string   `json:"name"`
+	Names []string `json:"names"`
+	Skins []string `json:"skins"`
+}
+
+type CatalogPage struct {
+	Entries []*CatalogEntry `json:"entries"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+	Total   int             `json:"total"`
+}
+
+type Chatter struct {
+	LastChatTime string `json:"last_chat_time"`
+	Operator     string `json:"operator"`
+	Username     string `json:"username"`
+}
+
+type ChatterStats struct {
+	Faction         string    `json:"faction"`
+	LastChatTime    time.Time `json:"last_chat_time"`
+	OperatorId      string    `json:"operator_id"`
+	OperatorName    string    `json:"operator_name"`
+	TwitchUserId    string    `json:"twitch_user_id"`
+	Username        string    `json:"username"`
+	UsernameDisplay string    `json:"username_display"`
+}
+
+type ChatterStatsPage struct {
+	ChannelName string          `json:"channel_name"`
+	Chatters    []*ChatterStats `json:"chatters"`
+	Limit       int             `json:"limit"`
+	Offset      int             `json:"offset"`
+	Total       int             `json:"total"`
+}
+
+type CreateApiKeyRequest struct {
+	ChannelName string   `json:"channel_name"`
+	Name        string   `json:"name"`
+	Scopes      []string `json:"scopes"`
+}
+
+type CreateApiKeyResponse struct {
+	ApiKey string      `json:"api_key"`
+	Info   *ApiKeyInfo `json:"info"`
+}
+
+type CreateWebhookRequest struct {
+	ChannelName string   `json:"channel_name"`
+	Events      []string `json:"events"`
+	Url         string   `json:"url"`
+}
+
+type CreateWebhookResponse struct {
+	Secret  string       `json:"secret"`
+	Webhook *WebhookInfo `json:"webhook"`
+}
+
+type CustomChibiInfo struct {
+	CreatedAt  time.Time `json:"created_at"`
+	Name       string    `json:"name"`
+	OperatorId string    `json:"operator_id"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type CustomChibiRequest struct {
+	ChannelName string `json:"channel_name"`
+	OperatorId  string `json:"operator_id"`
+}
+
+type DeleteRoomPermissionRequest struct {
+	ChannelName string `json:"channel_name"`
+	Username    string `json:"username"`
+}
+
+type DeleteUserLoadoutRequest struct {
+	LoadoutId int `json:"loadout_id"`
+	UserId    int `json:"user_id"`
+}
+
+type DeleteUserPreferencesRequest struct {
+	ChannelName string `json:"channel_name"`
+	UserId      int    `json:"user_id"`
+}
+
+type Event struct {
+	Data      interface{} `json:"data"`
+	Event     string      `json:"event"`
+	EventId   int64       `json:"event_id"`
+	RoomId    int         `json:"room_id"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+type FacingData struct {
+	Facing map[string][]string `json:"facing"`
+}
+
+type FactionInfo struct {
+	Command       string `json:"command"`
+	DefaultStance string `json:"default_stance"`
+	Name          string `json:"name"`
+	NumChibis     int    `json:"num_chibis"`
+}
+
+type GetApiKeysResponse struct {
+	ApiKeys []*ApiKeyInfo `json:"api_keys"`
+}
+
+type GetAssetManifestsResponse struct {
+	Manifests        []*AssetManifestInfo `json:"manifests"`
+	PinnedManifestId int                  `json:"pinned_manifest_id"`
+}
+
+type GetCustomChibisResponse struct {
+	CustomChibis []*CustomChibiInfo `json:"custom_chibis"`
+}
+
+type GetFactionsResponse struct {
+	Factions []*FactionInfo `json:"factions"`
+}
+
+type GetRoomChibisResponse struct {
+	ChannelName string          `json:"channel_name"`
+	Chatters    []*ChatterStats `json:"chatters"`
+	Limit       int             `json:"limit"`
+	Offset      int             `json:"offset"`
+	Paused      bool            `json:"paused"`
+	Total       int             `json:"total"`
+}
+
+type GetRoomPermissionsResponse struct {
+	Permissions []*RoomPermissionInfo `json:"permissions"`
+}
+
+type GetRoomSettingsResponse struct {
+	ChibiSeed          int64                `json:"chibi_seed"`
+	DefaultOperator    *RoomDefaultOperator `json:"default_operator"`
+	MaxAnimationSpeed  float64              `json:"max_animation_speed"`
+	MaxMovementSpeed   float64              `json:"max_movement_speed"`
+	MaxSpritePixelSize int                  `json:"max_sprite_pixel_size"`
+	MaxSpriteSize      float64              `json:"max_sprite_size"`
+	MinAnimationSpeed  float64              `json:"min_animation_speed"`
+	MinMovementSpeed   float64              `json:"min_movement_speed"`
+	MinSpriteSize      float64              `json:"min_sprite_size"`
+	RandomPool         *RandomPool          `json:"random_pool"`
+	RandomPoolFilter   []string             `json:"random_pool_filter"`
+	SeededChibis       bool                 `json:"seeded_chibis"`
+	UsernamesBlacklist []string             `json:"usernames_blacklist"`
+}
+
+type GetUserLoadoutsResponse struct {
+	Loadouts    []*UserLoadout `json:"loadouts"`
+	MaxLoadouts int            `json:"max_loadouts"`
+}
+
+type GetUserPreferencesResponse struct {
+	OperatorInfo *OperatorInfo `json:"operator_info"`
+}
+
+type GetWebhookDeliveriesResponse struct {
+	Deliveries []*WebhookDeliveryInfo `json:"deliveries"`
+}
+
+type GetWebhooksResponse struct {
+	Webhooks []*WebhookInfo `json:"webhooks"`
+}
+
+type OperatorInfo struct {
+	Action              *ActionUnion `json:"action"`
+	AnimationSpeed      float64      `json:"animation_speed"`
+	AvailableAnimations []string     `json:"available_animations"`
+	ChibiStance         string       `json:"chibi_stance"`
+	CurrentAction       string       `json:"current_action"`
+	Facing              string       `json:"facing"`
+	Faction             string       `json:"faction"`
+	MovementSpeed       *Vector2     `json:"movement_speed"`
+	OperatorDisplayName string       `json:"operator_display_name"`
+	OperatorId          string       `json:"operator_id"`
+	Skin                string       `json:"skin"`
+	Skins               []string     `json:"skins"`
+	SpriteScale         *Vector2     `json:"sprite_scale"`
+	StartPos            *Vector2     `json:"start_pos"`
+}
+
+type OperatorMetadata struct {
+	Class       string `json:"class"`
+	Nation      string `json:"nation"`
+	Rarity      int    `json:"rarity"`
+	ReleaseDate string `json:"release_date"`
+	SubClass    string `json:"sub_class"`
+}
+
+type RandomPool struct {
+	ExcludeOperators []string           `json:"exclude_operators"`
+	IncludeEnemies   []string           `json:"include_enemies"`
+	IncludeOperators []string           `json:"include_operators"`
+	SkinWeights      map[string]float64 `json:"skin_weights"`
+	Weights          map[string]float64 `json:"weights"`
+}
+
+type RemoveRoomRequest struct {
+	ChannelName string `json:"channel_name"`
+}
+
+type RemoveUserRequest struct {
+	ChannelName string `json:"channel_name"`
+	Username    string `json:"username"`
+}
+
+type RenameUserLoadoutRequest struct {
+	LoadoutId int    `json:"loadout_id"`
+	Name      string `json:"name"`
+	UserId    int    `json:"user_id"`
+}
+
+type RevokeApiKeyRequest struct {
+	ApiKeyId int `json:"api_key_id"`
+}
+
+type RollbackAssetsRequest struct {
+	ManifestId int `json:"manifest_id"`
+}
+
+type RoomClearResponse struct {
+	NumRemoved int `json:"num_removed"`
+}
+
+type RoomControlRequest struct {
+	ChannelName string `json:"channel_name"`
+	Username    string `json:"username"`
+}
+
+type RoomDefaultOperator struct {
+	Animations   []string `json:"animations"`
+	OperatorName string   `json:"operator_name"`
+	PositionX    float64  `json:"position_x"`
+	Skin         string   `json:"skin"`
+	Stance       string   `json:"stance"`
+}
+
+type RoomFilter struct {
+	ChannelNames  []string `json:"channel_names"`
+	IdleMins      int      `json:"idle_mins"`
+	NoConnections bool     `json:"no_connections"`
+}
+
+type RoomGiveOperatorRequest struct {
+	ChannelName     string `json:"channel_name"`
+	UserDisplayName string `json:"user_display_name"`
+	Username        string `json:"username"`
+}
+
+type RoomInfo struct {
+	ChannelName             string             `json:"channel_name"`
+	Chatters                []*Chatter         `json:"chatters"`
+	ConnectionAverageFps    map[string]float64 `json:"connection_average_fps"`
+	CreatedAt               string             `json:"created_at"`
+	LastTimeUsed            string             `json:"last_time_used"`
+	NextGcTime              string             `json:"next_gc_time"`
+	NumWebsocketConnections int                `json:"num_websocket_connections"`
+}
+
+type RoomPauseRequest struct {
+	ChannelName string `json:"channel_name"`
+	Paused      bool   `json:"paused"`
+}
+
+type RoomPauseResponse struct {
+	Paused bool `json:"paused"`
+}
+
+type RoomPermissionInfo struct {
+	Role            string    `json:"role"`
+	Source          string    `json:"source"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	UserDisplayName string    `json:"user_display_name"`
+	Username        string    `json:"username"`
+}
+
+type RoomRefreshRequest struct {
+	ChannelName string `json:"channel_name"`
+}
+
+type RoomSetOperatorRequest struct {
+	ChannelName     string  `json:"channel_name"`
+	Faction         string  `json:"faction"`
+	OperatorId      string  `json:"operator_id"`
+	PositionX       float64 `json:"position_x"`
+	PositionY       float64 `json:"position_y"`
+	Skin            string  `json:"skin"`
+	Stance          string  `json:"stance"`
+	UserDisplayName string  `json:"user_display_name"`
+	Username        string  `json:"username"`
+}
+
+type RoomStats struct {
+	ChannelName             string             `json:"channel_name"`
+	CommandsPerMinute       float64            `json:"commands_per_minute"`
+	ConnectionAverageFps    map[string]float64 `json:"connection_average_fps"`
+	CreatedAt               time.Time          `json:"created_at"`
+	IsActive                bool               `json:"is_active"`
+	LastActiveAt            time.Time          `json:"last_active_at"`
+	NextGcTime              *time.Time         `json:"next_gc_time"`
+	NumChatters             int                `json:"num_chatters"`
+	NumCommands             int                `json:"num_commands"`
+	NumWebsocketConnections int                `json:"num_websocket_connections"`
+	RoomId                  int                `json:"room_id"`
+}
+
+type RoomStatsPage struct {
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+	Rooms  []*RoomStats `json:"rooms"`
+	Total  int          `json:"total"`
+}
+
+type RoomUpdateRequest struct {
+	ChannelName        string               `json:"channel_name"`
+	ChibiSeed          *int64               `json:"chibi_seed"`
+	DefaultOperator    *RoomDefaultOperator `json:"default_operator"`
+	MaxAnimationSpeed  float64              `json:"max_animation_speed"`
+	MaxMovementSpeed   float64              `json:"max_movement_speed"`
+	MaxSpritePixelSize int                  `json:"max_sprite_pixel_size"`
+	MaxSpriteSize      float64              `json:"max_sprite_size"`
+	MinAnimationSpeed  float64              `json:"min_animation_speed"`
+	MinMovementSpeed   float64              `json:"min_movement_speed"`
+	MinSpriteSize      float64              `json:"min_sprite_size"`
+	RandomPool         *RandomPool          `json:"random_pool"`
+	RandomPoolFilter   []string             `json:"random_pool_filter"`
+	SeededChibis       *bool                `json:"seeded_chibis"`
+	UsernamesBlacklist []string             `json:"usernames_blacklist"`
+}
+
+type SaveUserLoadoutRequest struct {
+	Name         string        `json:"name"`
+	OperatorInfo *OperatorInfo `json:"operator_info"`
+	UserId       int           `json:"user_id"`
+}
+
+type SelectUserLoadoutRequest struct {
+	ChannelName string `json:"channel_name"`
+	LoadoutId   int    `json:"loadout_id"`
+	UserId      int    `json:"user_id"`
+}
+
+type SetChatterChibiRequest struct {
+	ChannelName  string        `json:"channel_name"`
+	OperatorInfo *OperatorInfo `json:"operator_info"`
+	Username     string        `json:"username"`
+}
+
+type SetRoomPermissionRequest struct {
+	ChannelName string `json:"channel_name"`
+	Role        string `json:"role"`
+	Username    string `json:"username"`
+}
+
+type SkinData struct {
+	Stance map[string]*FacingData `json:"stance"`
+}
+
+type UpdateUserPreferencesRequest struct {
+	ChannelName  string        `json:"channel_name"`
+	OperatorInfo *OperatorInfo `json:"operator_info"`
+	UserId       int           `json:"user_id"`
+}
+
+type UploadCustomChibiResponse struct {
+	CustomChibi *CustomChibiInfo       `json:"custom_chibi"`
+	Report      *AssetValidationReport `json:"report"`
+}
+
+type UserLoadout struct {
+	LoadoutId    int           `json:"loadout_id"`
+	Name         string        `json:"name"`
+	OperatorInfo *OperatorInfo `json:"operator_info"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+type Vector2 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type WebhookDeliveryInfo struct {
+	Attempt    int       `json:"attempt"`
+	CreatedAt  time.Time `json:"created_at"`
+	DeliveryId string    `json:"delivery_id"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error"`
+	Event      string    `json:"event"`
+	StatusCode int       `json:"status_code"`
+	Success    bool      `json:"success"`
+}
+
+type WebhookInfo struct {
+	CreatedAt time.Time `json:"created_at"`
+	Events    []string  `json:"events"`
+	Url       string    `json:"url"`
+	WebhookId int       `json:"webhook_id"`
+}
+
+type WebhookRequest struct {
+	ChannelName string `json:"channel_name"`
+	WebhookId   int    `json:"webhook_id"`
+}
+
+// AdminAnnounce calls POST /api/admin/rooms/announce/
+//
+// Show a message in every active room
+func (c *Client) AdminAnnounce(ctx context.Context, body *AdminAnnounceRequest) (*BulkRoomsReport, error) {
+	path := "/api/admin/rooms/announce/"
+	var resp *BulkRoomsReport
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AdminEvictRooms calls POST /api/admin/rooms/evict/
+//
+// Close every active room matching the filter
+func (c *Client) AdminEvictRooms(ctx context.Context, body *RoomFilter) (*BulkRoomsReport, error) {
+	path := "/api/admin/rooms/evict/"
+	var resp *BulkRoomsReport
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AdminInfo calls GET /api/admin/info/
+//
+// Get the active rooms and server metrics
+func (c *Client) AdminInfo(ctx context.Context) (*AdminInfo, error) {
+	path := "/api/admin/info/"
+	var resp *AdminInfo
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AdminListChattersParams are the query parameters of AdminListChatters
+type AdminListChattersParams struct {
+	Offset int
+	Limit  int
+	// One of: username, last_chat
+	Sort string
+}
+
+// AdminListChatters calls GET /api/admin/rooms/{channel}/chatters/
+//
+// List the chatters of an active room
+func (c *Client) AdminListChatters(ctx context.Context, channel string, params AdminListChattersParams) (*ChatterStatsPage, error) {
+	path := "/api/admin/rooms/" + url.PathEscape(channel) + "/chatters/"
+	query := url.Values{}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if len(params.Sort) > 0 {
+		query.Set("sort", params.Sort)
+	}
+	var resp *ChatterStatsPage
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AdminListRoomsParams are the query parameters of AdminListRooms
+type AdminListRoomsParams struct {
+	// List the active or inactive rooms. Defaults to true
+	Active *bool
+	Offset int
+	Limit  int
+	// One of: channel, created, last_active, chatters, connections, commands
+	Sort string
+}
+
+// AdminListRooms calls GET /api/admin/rooms/
+//
+// List the rooms with their stats
+func (c *Client) AdminListRooms(ctx context.Context, params AdminListRoomsParams) (*RoomStatsPage, error) {
+	path := "/api/admin/rooms/"
+	query := url.Values{}
+	if params.Active != nil {
+		query.Set("active", strconv.FormatBool(*params.Active))
+	}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if len(params.Sort) > 0 {
+		query.Set("sort", params.Sort)
+	}
+	var resp *RoomStatsPage
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AdminRefreshRooms calls POST /api/admin/rooms/refresh/
+//
+// Reload the runtime config of every active room
+func (c *Client) AdminRefreshRooms(ctx context.Context) (*BulkRoomsReport, error) {
+	path := "/api/admin/rooms/refresh/"
+	var resp *BulkRoomsReport
+	if err := c.do(ctx, "POST", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ClearRoom calls POST /api/rooms/control/clear/
+//
+// Remove every chatter's chibi from the streamer's room
+func (c *Client) ClearRoom(ctx context.Context, body *RoomControlRequest) (*RoomClearResponse, error) {
+	path := "/api/rooms/control/clear/"
+	var resp *RoomClearResponse
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateApiKey calls POST /api/apikeys/
+//
+// Create an API key. The key is only returned once
+func (c *Client) CreateApiKey(ctx context.Context, body *CreateApiKeyRequest) (*CreateApiKeyResponse, error) {
+	path := "/api/apikeys/"
+	var resp *CreateApiKeyResponse
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateWebhook calls POST /api/rooms/webhooks/
+//
+// Create a webhook for a room
+func (c *Client) CreateWebhook(ctx context.Context, body *CreateWebhookRequest) (*CreateWebhookResponse, error) {
+	path := "/api/rooms/webhooks/"
+	var resp *CreateWebhookResponse
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteCustomChibi calls DELETE /api/rooms/custom_chibis/
+//
+// Delete a custom chibi
+func (c *Client) DeleteCustomChibi(ctx context.Context, body *CustomChibiRequest) error {
+	path := "/api/rooms/custom_chibis/"
+	return c.do(ctx, "DELETE", path, nil, body, nil)
+}
+
+// DeleteRoomPermission calls DELETE /api/rooms/permissions/
+//
+// Remove a user's role in a room
+func (c *Client) DeleteRoomPermission(ctx context.Context, body *DeleteRoomPermissionRequest) error {
+	path := "/api/rooms/permissions/"
+	return c.do(ctx, "DELETE", path, nil, body, nil)
+}
+
+// DeleteUserLoadout calls DELETE /api/users/loadouts/
+//
+// Delete a loadout
+func (c *Client) DeleteUserLoadout(ctx context.Context, body *DeleteUserLoadoutRequest) error {
+	path := "/api/users/loadouts/"
+	return c.do(ctx, "DELETE", path, nil, body, nil)
+}
+
+// DeleteUserPreferences calls DELETE /api/users/preferences/
+//
+// Delete the user's saved chibi
+func (c *Client) DeleteUserPreferences(ctx context.Context, body *DeleteUserPreferencesRequest) error {
+	path := "/api/users/preferences/"
+	return c.do(ctx, "DELETE", path, nil, body, nil)
+}
+
+// DeleteWebhook calls DELETE /api/rooms/webhooks/
+//
+// Delete a webhook
+func (c *Client) DeleteWebhook(ctx context.Context, body *WebhookRequest) error {
+	path := "/api/rooms/webhooks/"
+	return c.do(ctx, "DELETE", path, nil, body, nil)
+}
+
+// DiffAssetManifestsParams are the query parameters of DiffAssetManifests
+type DiffAssetManifestsParams struct {
+	// Manifest id
+	From int
+	// Manifest id
+	To int
+}
+
+// DiffAssetManifests calls GET /api/admin/assets/manifests/diff/
+//
+// Compare the files of two asset manifests
+func (c *Client) DiffAssetManifests(ctx context.Context, params DiffAssetManifestsParams) (*AssetManifestDiff, error) {
+	path := "/api/admin/assets/manifests/diff/"
+	query := url.Values{}
+	if params.From != 0 {
+		query.Set("from", strconv.Itoa(params.From))
+	}
+	if params.To != 0 {
+		query.Set("to", strconv.Itoa(params.To))
+	}
+	var resp *AssetManifestDiff
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FindChatter calls POST /api/rooms/control/find/
+//
+// Highlight a chatter's chibi on the overlay
+func (c *Client) FindChatter(ctx context.Context, body *RoomControlRequest) error {
+	path := "/api/rooms/control/find/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// GetApiKeys calls GET /api/apikeys/
+//
+// List the user's API keys
+func (c *Client) GetApiKeys(ctx context.Context) (*GetApiKeysResponse, error) {
+	path := "/api/apikeys/"
+	var resp *GetApiKeysResponse
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetAssetManifests calls GET /api/admin/assets/manifests/
+//
+// List the ingested asset manifests
+func (c *Client) GetAssetManifests(ctx context.Context) (*GetAssetManifestsResponse, error) {
+	path := "/api/admin/assets/manifests/"
+	var resp *GetAssetManifestsResponse
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetCustomChibisParams are the query parameters of GetCustomChibis
+type GetCustomChibisParams struct {
+	// The channel name of the room
+	ChannelName string
+}
+
+// GetCustomChibis calls GET /api/rooms/custom_chibis/
+//
+// List the custom chibis uploaded to a room
+func (c *Client) GetCustomChibis(ctx context.Context, params GetCustomChibisParams) (*GetCustomChibisResponse, error) {
+	path := "/api/rooms/custom_chibis/"
+	query := url.Values{}
+	if len(params.ChannelName) > 0 {
+		query.Set("channel_name", params.ChannelName)
+	}
+	var resp *GetCustomChibisResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetEnemyCatalogDetail calls GET /api/catalog/enemies/{id}/
+//
+// Get the skins and animations of an enemy
+func (c *Client) GetEnemyCatalogDetail(ctx context.Context, id string) (*CatalogDetail, error) {
+	path := "/api/catalog/enemies/" + url.PathEscape(id) + "/"
+	var resp *CatalogDetail
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetFactionCatalogDetail calls GET /api/catalog/factions/{faction}/{id}/
+//
+// Get the skins and animations of a chibi in a faction
+func (c *Client) GetFactionCatalogDetail(ctx context.Context, faction string, id string) (*CatalogDetail, error) {
+	path := "/api/catalog/factions/" + url.PathEscape(faction) + "/" + url.PathEscape(id) + "/"
+	var resp *CatalogDetail
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetFactions calls GET /api/catalog/factions/
+//
+// List the factions of chibis
+func (c *Client) GetFactions(ctx context.Context) (*GetFactionsResponse, error) {
+	path := "/api/catalog/factions/"
+	var resp *GetFactionsResponse
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetOpenApi calls GET /api/openapi.json
+//
+// Get this OpenAPI document
+func (c *Client) GetOpenApi(ctx context.Context) (map[string]interface{}, error) {
+	path := "/api/openapi.json"
+	var resp map[string]interface{}
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetOperatorCatalogDetail calls GET /api/catalog/operators/{id}/
+//
+// Get the skins and animations of an operator
+func (c *Client) GetOperatorCatalogDetail(ctx context.Context, id string) (*CatalogDetail, error) {
+	path := "/api/catalog/operators/" + url.PathEscape(id) + "/"
+	var resp *CatalogDetail
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetRoomChibisParams are the query parameters of GetRoomChibis
+type GetRoomChibisParams struct {
+	// The channel name of the room
+	ChannelName string
+	Offset      int
+	Limit       int
+	// One of: username, last_chat
+	Sort string
+}
+
+// GetRoomChibis calls GET /api/rooms/control/chibis/
+//
+// List the chatters with a chibi in the streamer's room
+func (c *Client) GetRoomChibis(ctx context.Context, params GetRoomChibisParams) (*GetRoomChibisResponse, error) {
+	path := "/api/rooms/control/chibis/"
+	query := url.Values{}
+	if len(params.ChannelName) > 0 {
+		query.Set("channel_name", params.ChannelName)
+	}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if len(params.Sort) > 0 {
+		query.Set("sort", params.Sort)
+	}
+	var resp *GetRoomChibisResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetRoomPermissionsParams are the query parameters of GetRoomPermissions
+type GetRoomPermissionsParams struct {
+	// The channel name of the room
+	ChannelName string
+}
+
+// GetRoomPermissions calls GET /api/rooms/permissions/
+//
+// List the users with a role in a room
+func (c *Client) GetRoomPermissions(ctx context.Context, params GetRoomPermissionsParams) (*GetRoomPermissionsResponse, error) {
+	path := "/api/rooms/permissions/"
+	query := url.Values{}
+	if len(params.ChannelName) > 0 {
+		query.Set("channel_name", params.ChannelName)
+	}
+	var resp *GetRoomPermissionsResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetRoomSettingsParams are the query parameters of GetRoomSettings
+type GetRoomSettingsParams struct {
+	// The channel name of the room
+	ChannelName string
+}
+
+// GetRoomSettings calls GET /api/rooms/settings/
+//
+// Get the settings of a room
+func (c *Client) GetRoomSettings(ctx context.Context, params GetRoomSettingsParams) (*GetRoomSettingsResponse, error) {
+	path := "/api/rooms/settings/"
+	query := url.Values{}
+	if len(params.ChannelName) > 0 {
+		query.Set("channel_name", params.ChannelName)
+	}
+	var resp *GetRoomSettingsResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUserLoadoutsParams are the query parameters of GetUserLoadouts
+type GetUserLoadoutsParams struct {
+	UserId int
+}
+
+// GetUserLoadouts calls GET /api/users/loadouts/
+//
+// List the user's chibi loadouts
+func (c *Client) GetUserLoadouts(ctx context.Context, params GetUserLoadoutsParams) (*GetUserLoadoutsResponse, error) {
+	path := "/api/users/loadouts/"
+	query := url.Values{}
+	if params.UserId != 0 {
+		query.Set("user_id", strconv.Itoa(params.UserId))
+	}
+	var resp *GetUserLoadoutsResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUserPreferencesParams are the query parameters of GetUserPreferences
+type GetUserPreferencesParams struct {
+	UserId int
+	// Get the chibi saved for only this channel
+	ChannelName string
+}
+
+// GetUserPreferences calls GET /api/users/preferences/
+//
+// Get the user's saved chibi
+func (c *Client) GetUserPreferences(ctx context.Context, params GetUserPreferencesParams) (*GetUserPreferencesResponse, error) {
+	path := "/api/users/preferences/"
+	query := url.Values{}
+	if params.UserId != 0 {
+		query.Set("user_id", strconv.Itoa(params.UserId))
+	}
+	if len(params.ChannelName) > 0 {
+		query.Set("channel_name", params.ChannelName)
+	}
+	var resp *GetUserPreferencesResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetWebhookDeliveriesParams are the query parameters of GetWebhookDeliveries
+type GetWebhookDeliveriesParams struct {
+	// The channel name of the room
+	ChannelName string
+	WebhookId   int
+}
+
+// GetWebhookDeliveries calls GET /api/rooms/webhooks/deliveries/
+//
+// List the recent deliveries of a webhook
+func (c *Client) GetWebhookDeliveries(ctx context.Context, params GetWebhookDeliveriesParams) (*GetWebhookDeliveriesResponse, error) {
+	path := "/api/rooms/webhooks/deliveries/"
+	query := url.Values{}
+	if len(params.ChannelName) > 0 {
+		query.Set("channel_name", params.ChannelName)
+	}
+	if params.WebhookId != 0 {
+		query.Set("webhook_id", strconv.Itoa(params.WebhookId))
+	}
+	var resp *GetWebhookDeliveriesResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetWebhooksParams are the query parameters of GetWebhooks
+type GetWebhooksParams struct {
+	// The channel name of the room
+	ChannelName string
+}
+
+// GetWebhooks calls GET /api/rooms/webhooks/
+//
+// List the webhooks of a room
+func (c *Client) GetWebhooks(ctx context.Context, params GetWebhooksParams) (*GetWebhooksResponse, error) {
+	path := "/api/rooms/webhooks/"
+	query := url.Values{}
+	if len(params.ChannelName) > 0 {
+		query.Set("channel_name", params.ChannelName)
+	}
+	var resp *GetWebhooksResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GiveOperator calls POST /api/rooms/users/give/
+//
+// Give a chatter a random chibi in any room
+func (c *Client) GiveOperator(ctx context.Context, body *RoomGiveOperatorRequest) error {
+	path := "/api/rooms/users/give/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// KickChatter calls POST /api/rooms/control/kick/
+//
+// Remove a chatter's chibi from the streamer's room
+func (c *Client) KickChatter(ctx context.Context, body *RoomControlRequest) error {
+	path := "/api/rooms/control/kick/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// ListEnemiesCatalogParams are the query parameters of ListEnemiesCatalog
+type ListEnemiesCatalogParams struct {
+	// Only chibis whose id or names contain this
+	Q      string
+	Offset int
+	Limit  int
+}
+
+// ListEnemiesCatalog calls GET /api/catalog/enemies/
+//
+// Search the enemies
+func (c *Client) ListEnemiesCatalog(ctx context.Context, params ListEnemiesCatalogParams) (*CatalogPage, error) {
+	path := "/api/catalog/enemies/"
+	query := url.Values{}
+	if len(params.Q) > 0 {
+		query.Set("q", params.Q)
+	}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	var resp *CatalogPage
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListFactionCatalogParams are the query parameters of ListFactionCatalog
+type ListFactionCatalogParams struct {
+	// Only chibis whose id or names contain this
+	Q      string
+	Offset int
+	Limit  int
+}
+
+// ListFactionCatalog calls GET /api/catalog/factions/{faction}/
+//
+// Search the chibis of a faction
+func (c *Client) ListFactionCatalog(ctx context.Context, faction string, params ListFactionCatalogParams) (*CatalogPage, error) {
+	path := "/api/catalog/factions/" + url.PathEscape(faction) + "/"
+	query := url.Values{}
+	if len(params.Q) > 0 {
+		query.Set("q", params.Q)
+	}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	var resp *CatalogPage
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListOperatorsCatalogParams are the query parameters of ListOperatorsCatalog
+type ListOperatorsCatalogParams struct {
+	// Only chibis whose id or names contain this
+	Q      string
+	Offset int
+	Limit  int
+}
+
+// ListOperatorsCatalog calls GET /api/catalog/operators/
+//
+// Search the operators
+func (c *Client) ListOperatorsCatalog(ctx context.Context, params ListOperatorsCatalogParams) (*CatalogPage, error) {
+	path := "/api/catalog/operators/"
+	query := url.Values{}
+	if len(params.Q) > 0 {
+		query.Set("q", params.Q)
+	}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	var resp *CatalogPage
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// PauseRoom calls POST /api/rooms/control/pause/
+//
+// Pause or resume the movement of all chibis
+func (c *Client) PauseRoom(ctx context.Context, body *RoomPauseRequest) (*RoomPauseResponse, error) {
+	path := "/api/rooms/control/pause/"
+	var resp *RoomPauseResponse
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RefreshRoom calls POST /api/rooms/refresh/
+//
+// Reload the runtime config of a room's overlays
+func (c *Client) RefreshRoom(ctx context.Context, body *RoomRefreshRequest) error {
+	path := "/api/rooms/refresh/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// ReloadAssets calls POST /api/admin/assets/reload/
+//
+// Reload the chibi assets
+func (c *Client) ReloadAssets(ctx context.Context) (*AssetReloadReport, error) {
+	path := "/api/admin/assets/reload/"
+	var resp *AssetReloadReport
+	if err := c.do(ctx, "POST", path, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RemoveChatterChibi calls POST /api/rooms/chibis/remove/
+//
+// Remove the chibi of a chatter from a room
+func (c *Client) RemoveChatterChibi(ctx context.Context, body *RemoveUserRequest) error {
+	path := "/api/rooms/chibis/remove/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// RemoveRoom calls POST /api/rooms/remove/
+//
+// Close a room
+func (c *Client) RemoveRoom(ctx context.Context, body *RemoveRoomRequest) error {
+	path := "/api/rooms/remove/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// RemoveUser calls POST /api/rooms/users/remove/
+//
+// Remove a chatter's chibi from any room
+func (c *Client) RemoveUser(ctx context.Context, body *RemoveUserRequest) error {
+	path := "/api/rooms/users/remove/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// RenameUserLoadout calls POST /api/users/loadouts/rename/
+//
+// Rename a loadout
+func (c *Client) RenameUserLoadout(ctx context.Context, body *RenameUserLoadoutRequest) error {
+	path := "/api/users/loadouts/rename/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// ResetChatter calls POST /api/rooms/control/reset/
+//
+// Give a chatter's chibi a new random operator
+func (c *Client) ResetChatter(ctx context.Context, body *RoomControlRequest) error {
+	path := "/api/rooms/control/reset/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// RevokeApiKey calls DELETE /api/apikeys/
+//
+// Revoke an API key
+func (c *Client) RevokeApiKey(ctx context.Context, body *RevokeApiKeyRequest) error {
+	path := "/api/apikeys/"
+	return c.do(ctx, "DELETE", path, nil, body, nil)
+}
+
+// RollbackAssets calls POST /api/admin/assets/rollback/
+//
+// Pin an older asset manifest and reload the assets
+func (c *Client) RollbackAssets(ctx context.Context, body *RollbackAssetsRequest) (*AssetReloadReport, error) {
+	path := "/api/admin/assets/rollback/"
+	var resp *AssetReloadReport
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SaveUserLoadout calls POST /api/users/loadouts/
+//
+// Save a chibi as a new loadout
+func (c *Client) SaveUserLoadout(ctx context.Context, body *SaveUserLoadoutRequest) (*UserLoadout, error) {
+	path := "/api/users/loadouts/"
+	var resp *UserLoadout
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SelectUserLoadout calls POST /api/users/loadouts/select/
+//
+// Use a loadout as the user's chibi
+func (c *Client) SelectUserLoadout(ctx context.Context, body *SelectUserLoadoutRequest) error {
+	path := "/api/users/loadouts/select/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// SetChatterChibi calls POST /api/rooms/chibis/set/
+//
+// Set the chibi of a chatter in a room
+func (c *Client) SetChatterChibi(ctx context.Context, body *SetChatterChibiRequest) error {
+	path := "/api/rooms/chibis/set/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// SetOperator calls POST /api/rooms/users/set/
+//
+// Set a chatter's chibi in any room
+func (c *Client) SetOperator(ctx context.Context, body *RoomSetOperatorRequest) error {
+	path := "/api/rooms/users/set/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// SetRoomPermission calls POST /api/rooms/permissions/
+//
+// Give a user a role in a room
+func (c *Client) SetRoomPermission(ctx context.Context, body *SetRoomPermissionRequest) error {
+	path := "/api/rooms/permissions/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// TestWebhook calls POST /api/rooms/webhooks/test/
+//
+// Send a test event to a webhook
+func (c *Client) TestWebhook(ctx context.Context, body *WebhookRequest) (*WebhookDeliveryInfo, error) {
+	path := "/api/rooms/webhooks/test/"
+	var resp *WebhookDeliveryInfo
+	if err := c.do(ctx, "POST", path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateRoomSettings calls POST /api/rooms/settings/
+//
+// Update the settings of a room. Unset fields are left unchanged
+func (c *Client) UpdateRoomSettings(ctx context.Context, body *RoomUpdateRequest) error {
+	path := "/api/rooms/settings/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
+
+// UpdateUserPreferences calls POST /api/users/preferences/
+//
+// Save the user's chibi
+func (c *Client) UpdateUserPreferences(ctx context.Context, body *UpdateUserPreferencesRequest) error {
+	path := "/api/users/preferences/"
+	return c.do(ctx, "POST", path, nil, body, nil)
+}
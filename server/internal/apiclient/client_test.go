@@ -0,0 +1,47 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SendsTokenQueryAndBody(t *testing.T) {
+	assert := assert.New(t)
+	var gotAuth, gotQuery string
+	var gotBody RoomFilter
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotQuery = r.URL.RawQuery
+		switch r.URL.Path {
+		case "/api/admin/rooms/":
+			json.NewEncoder(w).Encode(RoomStatsPage{Total: 3})
+		case "/api/admin/rooms/evict/":
+			json.NewDecoder(r.Body).Decode(&gotBody)
+			json.NewEncoder(w).Encode(BulkRoomsReport{NumSucceeded: 1})
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	sut := NewClient(server.URL+"/", "token", server.Client())
+
+	active := false
+	page, err := sut.AdminListRooms(context.Background(), AdminListRoomsParams{Active: &active, Limit: 5})
+	assert.Nil(err)
+	assert.Equal(3, page.Total)
+	assert.Equal("Bearer token", gotAuth)
+	assert.Equal("active=false&limit=5", gotQuery)
+
+	report, err := sut.AdminEvictRooms(context.Background(), &RoomFilter{ChannelNames: []string{"alpha"}})
+	assert.Nil(err)
+	assert.Equal(1, report.NumSucceeded)
+	assert.Equal([]string{"alpha"}, gotBody.ChannelNames)
+
+	err = sut.KickChatter(context.Background(), &RoomControlRequest{})
+	assert.Equal(&ApiError{StatusCode: http.StatusNotFound, Message: "not found"}, err)
+}
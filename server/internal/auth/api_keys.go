@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+)
+
+type ApiKeyScope string
+
+const (
+	API_KEY_PREFIX           = "akcb_"
+	API_KEY_MAX_PER_USER     = 10
+	API_KEY_NAME_MAX_LENGTH  = 64
+	API_KEY_DISPLAY_PREFIX_N = 12
+
+	API_KEY_SCOPE_ROOM_SETTINGS_READ  = ApiKeyScope("room:settings:read")
+	API_KEY_SCOPE_ROOM_SETTINGS_WRITE = ApiKeyScope("room:settings:write")
+	API_KEY_SCOPE_CHIBI_SET           = ApiKeyScope("chibi:set")
+	API_KEY_SCOPE_CHIBI_REMOVE        = ApiKeyScope("chibi:remove")
+)
+
+var AllApiKeyScopes = []ApiKeyScope{
+	API_KEY_SCOPE_ROOM_SETTINGS_READ,
+	API_KEY_SCOPE_ROOM_SETTINGS_WRITE,
+	API_KEY_SCOPE_CHIBI_SET,
+	API_KEY_SCOPE_CHIBI_REMOVE,
+}
+
+func ApiKeyScope_Parse(s string) (ApiKeyScope, error) {
+	for _, scope := range AllApiKeyScopes {
+		if string(scope) == s {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("invalid api key scope: %s", s)
+}
+
+// IsApiKey returns true if the bearer token looks like one of our API keys
+// rather than a JWT token.
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, API_KEY_PREFIX)
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateApiKey() (string, error) {
+	var keyBytes [32]byte
+	if _, err := rand.Read(keyBytes[:]); err != nil {
+		return "", err
+	}
+	return API_KEY_PREFIX + hex.EncodeToString(keyBytes[:]), nil
+}
+
+// CreateApiKey creates a new API key for the user. The plaintext key is only
+// ever returned here, we only keep the hash around.
+func (s *AuthService) CreateApiKey(
+	ctx context.Context,
+	userId uint,
+	channelName string,
+	name string,
+	scopes []ApiKeyScope,
+) (string, *ApiKeyDb, error) {
+	if len(name) == 0 || len(name) > API_KEY_NAME_MAX_LENGTH {
+		return "", nil, fmt.Errorf("name must be between 1 and %d characters", API_KEY_NAME_MAX_LENGTH)
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope must be provided")
+	}
+	existing, err := s.authRepo.GetApiKeysByUserId(ctx, userId)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(existing) >= API_KEY_MAX_PER_USER {
+		return "", nil, fmt.Errorf("too many api keys, maximum is %d", API_KEY_MAX_PER_USER)
+	}
+
+	key, err := generateApiKey()
+	if err != nil {
+		return "", nil, err
+	}
+	scopeStrs := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scopeStrs = append(scopeStrs, string(scope))
+	}
+	apiKey := &ApiKeyDb{
+		UserId:      userId,
+		ChannelName: channelName,
+		Name:        name,
+		KeyPrefix:   key[:API_KEY_DISPLAY_PREFIX_N],
+		KeyHash:     hashApiKey(key),
+		Scopes:      scopeStrs,
+	}
+	if err := s.authRepo.CreateApiKey(ctx, apiKey); err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// ValidateApiKey looks up the API key and records its usage.
+func (s *AuthService) ValidateApiKey(ctx context.Context, key string) (*ApiKeyDb, error) {
+	apiKey, err := s.authRepo.GetApiKeyByHashOrNil(ctx, hashApiKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	if err := s.authRepo.SetApiKeyLastUsedAt(ctx, apiKey.ApiKeyId, misc.Clock.Now()); err != nil {
+		log.Println("Failed to update api key last used time", apiKey.ApiKeyId, err)
+	}
+	return apiKey, nil
+}
+
+func (s *AuthService) GetApiKeys(ctx context.Context, userId uint) ([]*ApiKeyDb, error) {
+	return s.authRepo.GetApiKeysByUserId(ctx, userId)
+}
+
+func (s *AuthService) RevokeApiKey(ctx context.Context, userId uint, apiKeyId uint) error {
+	return s.authRepo.DeleteApiKeyById(ctx, userId, apiKeyId)
+}
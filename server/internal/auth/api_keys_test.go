@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateApiKey(t *testing.T) {
+	assert := assert.New(t)
+
+	key1, err := generateApiKey()
+	assert.Nil(err)
+	key2, err := generateApiKey()
+	assert.Nil(err)
+
+	assert.True(IsApiKey(key1))
+	assert.NotEqual(key1, key2)
+	assert.NotEqual(hashApiKey(key1), hashApiKey(key2))
+	assert.Equal(hashApiKey(key1), hashApiKey(key1))
+	assert.False(IsApiKey("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"))
+}
+
+func TestApiKeyScope_Parse(t *testing.T) {
+	assert := assert.New(t)
+
+	scope, err := ApiKeyScope_Parse("chibi:set")
+	assert.Nil(err)
+	assert.Equal(API_KEY_SCOPE_CHIBI_SET, scope)
+
+	_, err = ApiKeyScope_Parse("admin")
+	assert.NotNil(err)
+
+	apiKey := &ApiKeyDb{Scopes: []string{"room:settings:read"}}
+	assert.True(apiKey.HasScope(API_KEY_SCOPE_ROOM_SETTINGS_READ))
+	assert.False(apiKey.HasScope(API_KEY_SCOPE_ROOM_SETTINGS_WRITE))
+}
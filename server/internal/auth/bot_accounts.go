@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// EnableBotAccounts allows channel owners to authorize a custom bot account
+// for their room. The bot's tokens are encrypted with tokenKey before being
+// stored in the DB.
+func (s *AuthService) EnableBotAccounts(redirectUrl string, tokenKey string) {
+	s.BotOauth2Config = &oauth2.Config{
+		ClientID:     s.Oauth2Config.ClientID,
+		ClientSecret: s.Oauth2Config.ClientSecret,
+		Scopes:       []string{"chat:read", "chat:edit"},
+		Endpoint:     s.Oauth2Config.Endpoint,
+		RedirectURL:  redirectUrl,
+	}
+	s.botTokenKey = tokenKey
+}
+
+func (s *AuthService) BotAccountsEnabled() bool {
+	return s.BotOauth2Config != nil
+}
+
+// SaveRoomBotToken validates the bot token against Twitch and stores it
+// (encrypted) as the custom bot account of the room.
+func (s *AuthService) SaveRoomBotToken(ctx context.Context, roomId uint, token *oauth2.Token) (*BotCredentials, error) {
+	if !s.BotAccountsEnabled() {
+		return nil, fmt.Errorf("custom bot accounts are not enabled")
+	}
+	if token.RefreshToken == "" {
+		return nil, fmt.Errorf("bot token is missing a refresh token")
+	}
+	resp, err := s.twitchClient.ValidateToken(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenDb := &RoomBotTokenDb{
+		RoomId:          roomId,
+		BotTwitchUserId: resp.UserId,
+		BotUsername:     resp.Login,
+		Expiry:          token.Expiry,
+	}
+	if err := s.encryptRoomBotToken(tokenDb, token.AccessToken, token.RefreshToken); err != nil {
+		return nil, err
+	}
+	if err := s.authRepo.SetRoomBotToken(ctx, tokenDb); err != nil {
+		return nil, err
+	}
+	return &BotCredentials{
+		BotUsername:     resp.Login,
+		BotTwitchUserId: resp.UserId,
+		AccessToken:     token.AccessToken,
+	}, nil
+}
+
+// GetRoomBotCredentials returns the custom bot account for the room or nil
+// if the room should use the default bot.
+func (s *AuthService) GetRoomBotCredentials(ctx context.Context, roomId uint) (*BotCredentials, error) {
+	if !s.BotAccountsEnabled() {
+		return nil, nil
+	}
+	tokenDb, err := s.authRepo.GetRoomBotTokenByRoomIdOrNil(ctx, roomId)
+	if err != nil || tokenDb == nil {
+		return nil, err
+	}
+	if time.Until(tokenDb.Expiry) < time.Minute {
+		if err := s.refreshRoomBotToken(ctx, tokenDb); err != nil {
+			return nil, err
+		}
+	}
+	accessToken, err := decryptToken(s.botTokenKey, tokenDb.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &BotCredentials{
+		BotUsername:     tokenDb.BotUsername,
+		BotTwitchUserId: tokenDb.BotTwitchUserId,
+		AccessToken:     accessToken,
+	}, nil
+}
+
+// RemoveRoomBotToken revokes and deletes the custom bot account of the room.
+func (s *AuthService) RemoveRoomBotToken(ctx context.Context, roomId uint) error {
+	tokenDb, err := s.authRepo.GetRoomBotTokenByRoomIdOrNil(ctx, roomId)
+	if err != nil || tokenDb == nil {
+		return err
+	}
+	if accessToken, err := decryptToken(s.botTokenKey, tokenDb.AccessToken); err == nil {
+		// Best effort revoke
+		if err := s.twitchClient.RevokeToken(accessToken); err != nil {
+			log.Println("Failed to revoke bot token for room", roomId, err)
+		}
+	}
+	return s.authRepo.DeleteRoomBotTokenByRoomId(ctx, roomId)
+}
+
+func (s *AuthService) refreshRoomBotTokens() error {
+	if !s.BotAccountsEnabled() {
+		return nil
+	}
+	log.Println("Refreshing room bot tokens")
+	ctx := context.Background()
+	tokenDbs, err := s.authRepo.GetAllRoomBotTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	numTokensRefreshed := 0
+	numTokensFailedRefresh := 0
+	numTokensRemoved := 0
+	for _, tokenDb := range tokenDbs {
+		if time.Until(tokenDb.Expiry) > ROOM_BOT_TOKEN_REFRESH_WINDOW {
+			continue
+		}
+		if err := s.refreshRoomBotToken(ctx, tokenDb); err != nil {
+			log.Println("Failed to refresh bot token for room", tokenDb.RoomId, err)
+			numTokensFailedRefresh += 1
+			if tokenDb.Expiry.Before(time.Now()) {
+				// The token can no longer be used. Fallback to the default bot
+				s.authRepo.DeleteRoomBotTokenByRoomId(ctx, tokenDb.RoomId)
+				numTokensRemoved += 1
+			}
+			continue
+		}
+		numTokensRefreshed += 1
+	}
+
+	log.Println("Finished refreshing room bot tokens:")
+	log.Println("  NumTokensRefreshed:", numTokensRefreshed)
+	log.Println("  NumTokensFailedRefresh:", numTokensFailedRefresh)
+	log.Println("  NumTokensRemoved:", numTokensRemoved)
+	return nil
+}
+
+func (s *AuthService) refreshRoomBotToken(ctx context.Context, tokenDb *RoomBotTokenDb) error {
+	refreshToken, err := decryptToken(s.botTokenKey, tokenDb.RefreshToken)
+	if err != nil {
+		return err
+	}
+	resp, err := s.twitchClient.RefreshToken(s.twitchSecret, refreshToken)
+	if err != nil {
+		return err
+	}
+	tokenDb.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	if err := s.encryptRoomBotToken(tokenDb, resp.AccessToken, resp.RefreshToken); err != nil {
+		return err
+	}
+	return s.authRepo.SetRoomBotToken(ctx, tokenDb)
+}
+
+func (s *AuthService) encryptRoomBotToken(tokenDb *RoomBotTokenDb, accessToken string, refreshToken string) error {
+	encAccessToken, err := encryptToken(s.botTokenKey, accessToken)
+	if err != nil {
+		return err
+	}
+	encRefreshToken, err := encryptToken(s.botTokenKey, refreshToken)
+	if err != nil {
+		return err
+	}
+	tokenDb.AccessToken = encAccessToken
+	tokenDb.RefreshToken = encRefreshToken
+	return nil
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecryptToken(t *testing.T) {
+	assert := assert.New(t)
+
+	ciphertext, err := encryptToken("secret", "my-refresh-token")
+	assert.Nil(err)
+	assert.NotEqual("my-refresh-token", ciphertext)
+
+	plaintext, err := decryptToken("secret", ciphertext)
+	assert.Nil(err)
+	assert.Equal("my-refresh-token", plaintext)
+
+	_, err = decryptToken("wrong-secret", ciphertext)
+	assert.NotNil(err)
+
+	_, err = encryptToken("", "my-refresh-token")
+	assert.NotNil(err)
+}
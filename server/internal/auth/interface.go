@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
@@ -12,4 +13,10 @@ type AuthServiceInterface interface {
 	GetUserFromTwitchId(twitchUserIdStr string) (*misc.UserInfo, error)
 	RevokeSessionToken(token *oauth2.Token) error
 	ValidateJWTToken(tokenString string) (*AkChibiBotClaims, error)
+	GetRoomBotCredentials(ctx context.Context, roomId uint) (*BotCredentials, error)
+
+	CreateApiKey(ctx context.Context, userId uint, channelName string, name string, scopes []ApiKeyScope) (string, *ApiKeyDb, error)
+	ValidateApiKey(ctx context.Context, key string) (*ApiKeyDb, error)
+	GetApiKeys(ctx context.Context, userId uint) ([]*ApiKeyDb, error)
+	RevokeApiKey(ctx context.Context, userId uint, apiKeyId uint) error
 }
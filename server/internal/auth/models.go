@@ -6,6 +6,7 @@ type ContextTwitchUserId string
 type ContextTwitchUserName string
 type ContextUserId string
 type ContextUserRole string
+type ContextApiKey string
 
 type AkChibiBotClaims struct {
 	jwt.RegisteredClaims
@@ -34,3 +35,11 @@ type AuthorizedInfo struct {
 	Authenticated bool
 	User          AuthUserInfo
 }
+
+// BotCredentials are the decrypted credentials of a channel's custom bot
+// account, ready to be used for logging into Twitch chat.
+type BotCredentials struct {
+	BotUsername     string
+	BotTwitchUserId string
+	AccessToken     string
+}
@@ -1,12 +1,25 @@
 package auth
 
 import (
+	"context"
+	"database/sql"
 	"time"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
 type AuthRepository interface {
+	GetRoomBotTokenByRoomIdOrNil(ctx context.Context, roomId uint) (*RoomBotTokenDb, error)
+	GetAllRoomBotTokens(ctx context.Context) ([]*RoomBotTokenDb, error)
+	SetRoomBotToken(ctx context.Context, token *RoomBotTokenDb) error
+	DeleteRoomBotTokenByRoomId(ctx context.Context, roomId uint) error
+
+	CreateApiKey(ctx context.Context, apiKey *ApiKeyDb) error
+	GetApiKeyByHashOrNil(ctx context.Context, keyHash string) (*ApiKeyDb, error)
+	GetApiKeysByUserId(ctx context.Context, userId uint) ([]*ApiKeyDb, error)
+	DeleteApiKeyById(ctx context.Context, userId uint, apiKeyId uint) error
+	SetApiKeyLastUsedAt(ctx context.Context, apiKeyId uint, lastUsedAt time.Time) error
 }
 
 type HttpSessionDb struct {
@@ -25,3 +38,52 @@ func (h *HttpSessionDb) TableName() string {
 func (h *HttpSessionDb) Save(db *gorm.DB) error {
 	return db.Save(h).Error
 }
+
+// RoomBotTokenDb holds the OAUTH credentials of a custom bot account which a
+// channel owner has authorized for their room. AccessToken and RefreshToken
+// are stored encrypted (see encryptToken).
+type RoomBotTokenDb struct {
+	RoomBotTokenId  uint `gorm:"primarykey"`
+	RoomId          uint
+	BotTwitchUserId string
+	BotUsername     string
+	AccessToken     string
+	RefreshToken    string
+	Expiry          time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (RoomBotTokenDb) TableName() string {
+	return "room_bot_tokens"
+}
+
+// ApiKeyDb is a long-lived key which a user can hand to third party tools
+// (i.e Stream Deck). Only the sha256 hash of the key is stored. A key is
+// scoped to a single channel and to a set of ApiKeyScopes.
+type ApiKeyDb struct {
+	ApiKeyId    uint           `gorm:"primarykey"`
+	UserId      uint           `gorm:"column:user_id"`
+	ChannelName string         `gorm:"column:channel_name"`
+	Name        string         `gorm:"column:name"`
+	KeyPrefix   string         `gorm:"column:key_prefix"`
+	KeyHash     string         `gorm:"column:key_hash"`
+	Scopes      pq.StringArray `gorm:"column:scopes;type:text[]"`
+	LastUsedAt  sql.NullTime   `gorm:"column:last_used_at"`
+	CreatedAt   time.Time      `gorm:"column:created_at"`
+	UpdatedAt   time.Time      `gorm:"column:updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (ApiKeyDb) TableName() string {
+	return "api_keys"
+}
+
+func (a *ApiKeyDb) HasScope(scope ApiKeyScope) bool {
+	for _, s := range a.Scopes {
+		if s == string(scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,14 @@
 package auth
 
-import "github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type AuthRepositoryPsql struct {
 	*akdb.DatbaseConn
@@ -11,3 +19,96 @@ func NewAuthRepositoryPsql(db *akdb.DatbaseConn) *AuthRepositoryPsql {
 		DatbaseConn: db,
 	}
 }
+
+func (r *AuthRepositoryPsql) GetRoomBotTokenByRoomIdOrNil(ctx context.Context, roomId uint) (*RoomBotTokenDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var tokenDb RoomBotTokenDb
+	result := db.Where("room_id = ?", roomId).First(&tokenDb)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &tokenDb, nil
+}
+
+func (r *AuthRepositoryPsql) GetAllRoomBotTokens(ctx context.Context) ([]*RoomBotTokenDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	tokenDbs := make([]*RoomBotTokenDb, 0)
+	result := db.Find(&tokenDbs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tokenDbs, nil
+}
+
+func (r *AuthRepositoryPsql) SetRoomBotToken(ctx context.Context, token *RoomBotTokenDb) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "room_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"bot_twitch_user_id",
+			"bot_username",
+			"access_token",
+			"refresh_token",
+			"expiry",
+		}),
+	}).Create(token)
+	return result.Error
+}
+
+func (r *AuthRepositoryPsql) DeleteRoomBotTokenByRoomId(ctx context.Context, roomId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.Where("room_id = ?", roomId).Delete(&RoomBotTokenDb{})
+	return result.Error
+}
+
+func (r *AuthRepositoryPsql) CreateApiKey(ctx context.Context, apiKey *ApiKeyDb) error {
+	db := r.DefaultDB.WithContext(ctx)
+	return db.Create(apiKey).Error
+}
+
+func (r *AuthRepositoryPsql) GetApiKeyByHashOrNil(ctx context.Context, keyHash string) (*ApiKeyDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var apiKey ApiKeyDb
+	result := db.Where("key_hash = ?", keyHash).First(&apiKey)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &apiKey, nil
+}
+
+func (r *AuthRepositoryPsql) GetApiKeysByUserId(ctx context.Context, userId uint) ([]*ApiKeyDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	apiKeys := make([]*ApiKeyDb, 0)
+	result := db.Where("user_id = ?", userId).Order("api_key_id ASC").Find(&apiKeys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return apiKeys, nil
+}
+
+func (r *AuthRepositoryPsql) DeleteApiKeyById(ctx context.Context, userId uint, apiKeyId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.Where("user_id = ? AND api_key_id = ?", userId, apiKeyId).Delete(&ApiKeyDb{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *AuthRepositoryPsql) SetApiKeyLastUsedAt(ctx context.Context, apiKeyId uint, lastUsedAt time.Time) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.
+		Model(&ApiKeyDb{}).
+		Where("api_key_id = ?", apiKeyId).
+		UpdateColumn("last_used_at", lastUsedAt)
+	return result.Error
+}
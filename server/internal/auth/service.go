@@ -22,22 +22,27 @@ import (
 const (
 	STATE_CALLBACK_KEY       = "oauth-state-callback"
 	STATE_JWT_NONCE_KEY      = "oauth-state-jwt-nonce"
+	STATE_BOT_CALLBACK_KEY   = "oauth-state-bot-callback"
 	OAUTH_SESSION_NAME       = "oauth-oidc-session"
 	OAUTH_TOKEN_KEY          = "oauth-token"
 	CONTEXT_TWITCH_USER_ID   = ContextTwitchUserId("twitch-user-id")
 	CONTEXT_TWITCH_USER_NAME = ContextTwitchUserName("twitch-user-name")
 	CONTEXT_USER_ID          = ContextUserId("user-id")
 	CONTEXT_USER_ROLE        = ContextUserRole("user-role")
+	CONTEXT_API_KEY          = ContextApiKey("api-key")
 	JWT_ISSUER               = "ak-chibi-bot"
 	JWT_AUDIENCE             = "ak-chibi-bot"
 	// TODO: Move JWT token valid duration into config
 	JWT_TOKEN_VALID_DURATION     = 5 * time.Minute
 	VALIDATE_OAUTH_TOKENS_PERIOD = 1 * time.Hour
+	// Room bot tokens expiring within this window are refreshed ahead of time
+	ROOM_BOT_TOKEN_REFRESH_WINDOW = 2 * VALIDATE_OAUTH_TOKENS_PERIOD
 	COOKIE_MAX_AGE               = 6 * time.Hour
 )
 
 type AuthService struct {
 	userRepo       users.UserRepository
+	authRepo       AuthRepository
 	akDb           *akdb.DatbaseConn
 	twitchClientId string
 	twitchSecret   string
@@ -49,16 +54,21 @@ type AuthService struct {
 	Verifier       *oidc.IDTokenVerifier
 	shutdownChan   chan struct{}
 	jwtSecretKey   string
+
+	// Only set if per-channel bot accounts are enabled
+	BotOauth2Config *oauth2.Config
+	botTokenKey     string
 }
 
 func ProvideAuthService(
 	botConfig *misc.BotConfig,
 	twitchClient twitch_api.TwitchApiClientInterface,
 	usersRepo users.UserRepository,
+	authRepo AuthRepository,
 	akDb *akdb.DatbaseConn,
 ) (*AuthService, error) {
 	log.Println("ProvideAuthService")
-	return NewAuthService(
+	s, err := NewAuthService(
 		botConfig.TwitchClientId,
 		botConfig.TwitchClientSecret,
 		botConfig.CookieSecret,
@@ -66,8 +76,16 @@ func ProvideAuthService(
 		botConfig.TwitchOauthRedirectUrl,
 		twitchClient,
 		usersRepo,
+		authRepo,
 		akDb,
 	)
+	if err != nil {
+		return nil, err
+	}
+	if len(botConfig.TwitchBotOauthRedirectUrl) > 0 {
+		s.EnableBotAccounts(botConfig.TwitchBotOauthRedirectUrl, botConfig.BotTokenEncryptionKey)
+	}
+	return s, nil
 }
 
 func NewAuthService(
@@ -78,6 +96,7 @@ func NewAuthService(
 	redirectUrl string,
 	twitchClient twitch_api.TwitchApiClientInterface,
 	userRepo users.UserRepository,
+	authRepo AuthRepository,
 	akDb *akdb.DatbaseConn,
 ) (*AuthService, error) {
 	gob.Register(&oauth2.Token{})
@@ -128,6 +147,7 @@ func NewAuthService(
 
 	return &AuthService{
 		userRepo:       userRepo,
+		authRepo:       authRepo,
 		akDb:           akDb,
 		twitchClientId: twitchClientId,
 		twitchSecret:   twitchSecret,
@@ -157,6 +177,7 @@ func (s *AuthService) RunLoop() {
 		func() {
 			s.validateAndRefreshOauthTokens()
 			s.cleanupExpiredSessions()
+			s.refreshRoomBotTokens()
 		},
 	)
 	defer stopTimer()
@@ -351,6 +372,19 @@ func (s *AuthService) HasAuthorizedSession(w http.ResponseWriter, r *http.Reques
 	}, nil
 }
 
+// GetSessionToken returns the twitch OAUTH token of the logged in user
+func (s *AuthService) GetSessionToken(r *http.Request) (*oauth2.Token, error) {
+	session, err := s.CookieStore.Get(r, OAUTH_SESSION_NAME)
+	if err != nil {
+		return nil, err
+	}
+	token, ok := session.Values[OAUTH_TOKEN_KEY].(*oauth2.Token)
+	if !ok {
+		return nil, fmt.Errorf("token not found")
+	}
+	return token, nil
+}
+
 func (s *AuthService) getTokenSecretBytes(token *jwt.Token) (interface{}, error) {
 	// Don't forget to validate the alg is what you expect:
 	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -16,10 +17,15 @@ type FakeAuthService struct {
 	Username        string
 	TwitchUserId    string
 	UserId          uint
+	BotCredentials  map[uint]*BotCredentials
+	ApiKeys         map[string]*ApiKeyDb
 }
 
 func NewFakeAuthService() *FakeAuthService {
-	return &FakeAuthService{}
+	return &FakeAuthService{
+		BotCredentials: make(map[uint]*BotCredentials),
+		ApiKeys:        make(map[string]*ApiKeyDb),
+	}
 }
 
 func (s *FakeAuthService) HasAuthorizedSession(w http.ResponseWriter, r *http.Request) (*AuthorizedInfo, error) {
@@ -63,3 +69,55 @@ func (s *FakeAuthService) ValidateJWTToken(tokenString string) (*AkChibiBotClaim
 		}, nil
 	}
 }
+
+func (s *FakeAuthService) GetRoomBotCredentials(ctx context.Context, roomId uint) (*BotCredentials, error) {
+	return s.BotCredentials[roomId], nil
+}
+
+func (s *FakeAuthService) CreateApiKey(ctx context.Context, userId uint, channelName string, name string, scopes []ApiKeyScope) (string, *ApiKeyDb, error) {
+	key, err := generateApiKey()
+	if err != nil {
+		return "", nil, err
+	}
+	apiKey := &ApiKeyDb{
+		ApiKeyId:    uint(len(s.ApiKeys) + 1),
+		UserId:      userId,
+		ChannelName: channelName,
+		Name:        name,
+		KeyPrefix:   key[:API_KEY_DISPLAY_PREFIX_N],
+		KeyHash:     hashApiKey(key),
+	}
+	for _, scope := range scopes {
+		apiKey.Scopes = append(apiKey.Scopes, string(scope))
+	}
+	s.ApiKeys[key] = apiKey
+	return key, apiKey, nil
+}
+
+func (s *FakeAuthService) ValidateApiKey(ctx context.Context, key string) (*ApiKeyDb, error) {
+	apiKey, ok := s.ApiKeys[key]
+	if !ok {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	return apiKey, nil
+}
+
+func (s *FakeAuthService) GetApiKeys(ctx context.Context, userId uint) ([]*ApiKeyDb, error) {
+	apiKeys := make([]*ApiKeyDb, 0)
+	for _, apiKey := range s.ApiKeys {
+		if apiKey.UserId == userId {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	return apiKeys, nil
+}
+
+func (s *FakeAuthService) RevokeApiKey(ctx context.Context, userId uint, apiKeyId uint) error {
+	for key, apiKey := range s.ApiKeys {
+		if apiKey.UserId == userId && apiKey.ApiKeyId == apiKeyId {
+			delete(s.ApiKeys, key)
+			return nil
+		}
+	}
+	return fmt.Errorf("api key not found")
+}
@@ -2,11 +2,13 @@ package chat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
 )
 
 type ChatCommandNoOp struct{}
@@ -79,6 +81,9 @@ type ChatCommandSavePrefsAction int
 const (
 	ChatCommandSaveChibi_Save   = ChatCommandSavePrefsAction(0)
 	ChatCommandSaveChibi_Remove = ChatCommandSavePrefsAction(1)
+	// Only for the current room (ie. !chibi save here)
+	ChatCommandSaveChibi_SaveRoom   = ChatCommandSavePrefsAction(2)
+	ChatCommandSaveChibi_RemoveRoom = ChatCommandSavePrefsAction(3)
 )
 
 type ChatCommandSavePrefs struct {
@@ -99,13 +104,75 @@ func (c *ChatCommandSavePrefs) UpdateActor(a ActorUpdater) error {
 		TwitchUserId:    c.twitchUserId,
 	}
 
-	if c.action == ChatCommandSaveChibi_Save {
+	switch c.action {
+	case ChatCommandSaveChibi_Save:
 		return a.SaveUserPreferences(ctx, ui, c.update)
-	} else {
+	case ChatCommandSaveChibi_SaveRoom:
+		return a.SaveUserRoomPreferences(ctx, ui, c.update)
+	case ChatCommandSaveChibi_RemoveRoom:
+		return a.ClearUserRoomPreferences(ctx, ui)
+	default:
 		return a.ClearUserPreferences(ctx, ui)
 	}
 }
 
+type ChatCommandLoadoutAction int
+
+const (
+	ChatCommandLoadout_Save = ChatCommandLoadoutAction(0)
+	ChatCommandLoadout_Load = ChatCommandLoadoutAction(1)
+	ChatCommandLoadout_List = ChatCommandLoadoutAction(2)
+)
+
+type ChatCommandLoadout struct {
+	replyMessage    string
+	username        string
+	usernameDisplay string
+	twitchUserId    string
+	name            string
+	update          *operator.OperatorInfo
+	action          ChatCommandLoadoutAction
+}
+
+// Reply is called after UpdateActor so a failed save/load is replied back
+func (c *ChatCommandLoadout) Reply(a ActorUpdater) string {
+	if c.action != ChatCommandLoadout_List {
+		return c.replyMessage
+	}
+	ctx := context.Background()
+	names, err := a.GetUserLoadoutNames(ctx, c.userInfo())
+	if err != nil {
+		return ""
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("%s has no loadouts, try !chibi save <name>", c.usernameDisplay)
+	}
+	return fmt.Sprintf("%s loadouts: %s", c.usernameDisplay, strings.Join(names, ", "))
+}
+func (c *ChatCommandLoadout) UpdateActor(a ActorUpdater) error {
+	ctx := context.Background()
+	var err error
+	switch c.action {
+	case ChatCommandLoadout_Save:
+		err = a.SaveUserLoadout(ctx, c.userInfo(), c.name, c.update)
+	case ChatCommandLoadout_Load:
+		err = a.LoadUserLoadout(ctx, c.userInfo(), c.name)
+	}
+	if errors.Is(err, users.ErrTooManyLoadouts) {
+		c.replyMessage = fmt.Sprintf("%s: %s", c.usernameDisplay, err.Error())
+	} else if errors.Is(err, users.ErrLoadoutNotFound) {
+		c.replyMessage = fmt.Sprintf("%s has no loadout named %s", c.usernameDisplay, c.name)
+	}
+	return err
+}
+func (c *ChatCommandLoadout) userInfo() misc.UserInfo {
+	return misc.UserInfo{
+		Username:        c.username,
+		UsernameDisplay: c.usernameDisplay,
+		TwitchUserId:    c.twitchUserId,
+	}
+}
+
 type ChatCommandShowMessage struct {
 	replyMessage    string
 	username        string
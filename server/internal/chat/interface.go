@@ -24,6 +24,11 @@ type ActorUpdater interface {
 	FollowChibi(ctx context.Context, userInfo misc.UserInfo, update *operator.OperatorInfo) error
 	SaveUserPreferences(ctx context.Context, userInfo misc.UserInfo, update *operator.OperatorInfo) error
 	ClearUserPreferences(ctx context.Context, userInfo misc.UserInfo) error
+	SaveUserRoomPreferences(ctx context.Context, userInfo misc.UserInfo, update *operator.OperatorInfo) error
+	ClearUserRoomPreferences(ctx context.Context, userInfo misc.UserInfo) error
+	SaveUserLoadout(ctx context.Context, userInfo misc.UserInfo, name string, update *operator.OperatorInfo) error
+	LoadUserLoadout(ctx context.Context, userInfo misc.UserInfo, name string) error
+	GetUserLoadoutNames(ctx context.Context, userInfo misc.UserInfo) ([]string, error)
 	ShowMessage(ctx context.Context, userInfo misc.UserInfo, msg string) error
 	FindOperator(ctx context.Context, userInfo misc.UserInfo) error
 }
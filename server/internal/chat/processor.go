@@ -10,6 +10,7 @@ import (
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
 )
 
 type ChatCommandProcessor struct {
@@ -70,6 +71,7 @@ func (c *ChatCommandProcessor) HandleMessage(current *operator.OperatorInfo, cha
 	// !chibi face front|back
 	// !chibi walk <number>
 	// !chibi enemy The last steam knight
+	// !chibi <faction command> <name> (ie. !chibi npc Closure)
 	// !chibi admin <username> "!chibi command"
 	// !chibi speed 0.1
 	// !chibi size 0.5 [0.1 1.5]
@@ -78,9 +80,15 @@ func (c *ChatCommandProcessor) HandleMessage(current *operator.OperatorInfo, cha
 	// !chibi pace 0.1 0.5
 	// !chibi move_speed default
 	// !chibi save
+	// !chibi save here
+	// !chibi save <loadout name>
+	// !chibi load <loadout name>
+	// !chibi loadouts
 	// !chibi unsave
+	// !chibi unsave here
 	// !chibi follow stymtwitchbot
 	// !chibi findme
+	// !chibi random [6star] [caster]
 
 	// var msg string
 	subCommand := strings.TrimSpace(args[1])
@@ -126,8 +134,6 @@ func (c *ChatCommandProcessor) HandleMessage(current *operator.OperatorInfo, cha
 		return c.setStance(chatArgs, current)
 	case "face":
 		return c.setFacing(chatArgs, current)
-	case "enemy":
-		return c.setEnemy(chatArgs, current)
 	case "walk":
 		return c.setWalk(chatArgs, current)
 	case "wander":
@@ -150,10 +156,18 @@ func (c *ChatCommandProcessor) HandleMessage(current *operator.OperatorInfo, cha
 		return c.setSaveUserPrefs(chatArgs, current)
 	case "unsave":
 		return c.setClearUserPrefs(chatArgs, current)
+	case "load":
+		return c.setLoadLoadout(chatArgs, current)
+	case "loadouts":
+		return c.listLoadouts(chatArgs, current)
 	case "findme":
 		return c.setFindMe(chatArgs, current)
+	case "random":
+		return c.setRandomChibi(chatArgs, current)
 	default:
-		if _, ok := misc.MatchesKeywords(subCommand, current.AvailableAnimations); ok {
+		if faction, ok := c.spineService.Assets.GetFactionByCommand(subCommand); ok {
+			return c.setFactionChibi(chatArgs, current, faction)
+		} else if _, ok := misc.MatchesKeywords(subCommand, current.AvailableAnimations); ok {
 			chatArgs.args = []string{"!chibi", "play", subCommand}
 			return c.setAnimation(chatArgs, current)
 		} else if _, ok := misc.MatchesKeywords(subCommand, current.Skins); ok {
@@ -281,20 +295,29 @@ func (c *ChatCommandProcessor) setFacing(args *ChatArgs, current *operator.Opera
 	}, nil
 }
 
-func (c *ChatCommandProcessor) setEnemy(args *ChatArgs, current *operator.OperatorInfo) (ChatCommand, error) {
-	errMsg := errors.New("try something like !chibi enemy <enemyname or ID> (ie. !chibi enemy Avenger, !chibi enemy SM8")
+// setFactionChibi changes into a chibi of the faction chosen with the
+// faction's command keyword (ie. !chibi enemy <name>)
+func (c *ChatCommandProcessor) setFactionChibi(
+	args *ChatArgs,
+	current *operator.OperatorInfo,
+	faction *operator.Faction,
+) (ChatCommand, error) {
+	errMsg := fmt.Errorf("try something like !chibi %s <name or ID>", faction.Command)
+	if faction.Name == operator.FACTION_ENUM_ENEMY {
+		errMsg = errors.New("try something like !chibi enemy <enemyname or ID> (ie. !chibi enemy Avenger, !chibi enemy SM8")
+	}
 	if len(args.args) < 3 {
 		return &ChatCommandNoOp{}, errMsg
 	}
 	trimmed := strings.Join(args.args[2:], " ")
 
 	mobName := strings.TrimSpace(trimmed)
-	operatorId, matches := c.spineService.GetOperatorIdFromName(mobName, operator.FACTION_ENUM_ENEMY)
+	operatorId, matches := c.spineService.GetOperatorIdFromName(mobName, faction.Name)
 	if matches != nil {
 		return &ChatCommandNoOp{}, nil
 	}
+	c.setFaction(current, faction.Name)
 	current.OperatorId = operatorId
-	current.Faction = operator.FACTION_ENUM_ENEMY
 	current.AnimationSpeed = c.spineService.GetDefaultAnimationSpeed()
 	current.SpriteScale = misc.EmptyOption[misc.Vector2]()
 	// current.MovementSpeed = misc.EmptyOption[misc.Vector2]()
@@ -308,6 +331,27 @@ func (c *ChatCommandProcessor) setEnemy(args *ChatArgs, current *operator.Operat
 	}, nil
 }
 
+// setFaction changes the faction of the chibi. If changing factions while
+// "walking/wander" we need to set the stance to the new faction's walking
+// stance in order to make the chibi continue to walk.
+func (c *ChatCommandProcessor) setFaction(current *operator.OperatorInfo, faction operator.FactionEnum) {
+	prevFaction := current.Faction
+	current.Faction = faction
+	if prevFaction != faction && operator.IsWalkingAction(current.CurrentAction) {
+		c.setWalkingStance(current)
+	}
+}
+
+// setWalkingStance puts chibis of factions which default to the base stance
+// (ie. operators) into the base stance. Their battle chibis don't have "Move"
+// animations.
+func (c *ChatCommandProcessor) setWalkingStance(current *operator.OperatorInfo) {
+	faction, ok := c.spineService.Assets.GetFaction(current.Faction)
+	if ok && faction.DefaultStance == operator.CHIBI_STANCE_ENUM_BASE {
+		current.ChibiStance = operator.CHIBI_STANCE_ENUM_BASE
+	}
+}
+
 func (c *ChatCommandProcessor) getMoveAnimFromCurrent(current *operator.OperatorInfo) string {
 	currentAnimations := current.Action.GetAnimations(current.CurrentAction)
 	moveAnimation := operator.DEFAULT_MOVE_ANIM_NAME
@@ -329,9 +373,7 @@ func (c *ChatCommandProcessor) getMoveAnimFromCurrent(current *operator.Operator
 }
 
 func (c *ChatCommandProcessor) setWalk(args *ChatArgs, current *operator.OperatorInfo) (ChatCommand, error) {
-	if current.Faction == operator.FACTION_ENUM_OPERATOR {
-		current.ChibiStance = operator.CHIBI_STANCE_ENUM_BASE
-	}
+	c.setWalkingStance(current)
 
 	// Set the animation to "Move". If "Move" doesn't exist in the list of
 	// animations then try to find an animation with "Move" in its name
@@ -399,9 +441,7 @@ func (c *ChatCommandProcessor) setWalk(args *ChatArgs, current *operator.Operato
 }
 
 func (c *ChatCommandProcessor) setWander(args *ChatArgs, current *operator.OperatorInfo) (ChatCommand, error) {
-	if current.Faction == operator.FACTION_ENUM_OPERATOR {
-		current.ChibiStance = operator.CHIBI_STANCE_ENUM_BASE
-	}
+	c.setWalkingStance(current)
 
 	// Set the animation to "Move". If "Move" doesn't exist in the list of
 	// animations then try to find an animation with "Move" in its name
@@ -458,40 +498,29 @@ func (c *ChatCommandProcessor) getWhoInfo(args *ChatArgs) (ChatCommand, error) {
 	chibiName := strings.Join(args.args[2:], " ")
 	log.Printf("Searching for %s\n", chibiName)
 
-	operatorId, operatorMatches := c.spineService.GetOperatorIdFromName(chibiName, operator.FACTION_ENUM_OPERATOR)
-	enemyId, enemyMatches := c.spineService.GetOperatorIdFromName(chibiName, operator.FACTION_ENUM_ENEMY)
-
-	opMat := make([]string, 0)
-	if operatorMatches != nil {
-		opMat = append(opMat, operatorMatches...)
-	} else {
-		resp, err := c.spineService.GetOperator(operatorId, operator.FACTION_ENUM_OPERATOR)
-		if err != nil {
-			return &ChatCommandNoOp{}, nil
+	found := make([]string, 0)
+	for _, faction := range c.spineService.Assets.GetFactions() {
+		operatorId, matches := c.spineService.GetOperatorIdFromName(chibiName, faction.Name)
+		names := make([]string, 0)
+		if matches != nil {
+			names = append(names, matches...)
+		} else {
+			resp, err := c.spineService.GetOperator(operatorId, faction.Name)
+			if err != nil {
+				return &ChatCommandNoOp{}, nil
+			}
+			names = append(names, resp.OperatorName)
 		}
-		opMat = append(opMat, resp.OperatorName)
-	}
-
-	enemyMat := make([]string, 0)
-	if enemyMatches != nil {
-		enemyMat = append(enemyMat, enemyMatches...)
-	} else {
-		resp, err := c.spineService.GetOperator(enemyId, operator.FACTION_ENUM_ENEMY)
-		if err != nil {
-			return &ChatCommandNoOp{}, nil
+		if len(names) > 0 {
+			found = append(found, fmt.Sprintf("%s: %s", faction.Name, strings.Join(names, ", ")))
 		}
-		enemyMat = append(enemyMat, resp.OperatorName)
 	}
 
 	var msg string
-	if len(opMat) == 0 && len(enemyMat) == 0 {
+	if len(found) == 0 {
 		msg = "Could not find any operators/enemies with that name"
-	} else if len(opMat) == 0 {
-		msg = fmt.Sprintf("Did you mean enemies: %s", strings.Join(enemyMat, ", "))
-	} else if len(enemyMat) == 0 {
-		msg = fmt.Sprintf("Did you mean opeators: %s", strings.Join(opMat, ", "))
 	} else {
-		msg = fmt.Sprintf("Did you mean: %s or enemies %s", strings.Join(opMat, ", "), strings.Join(enemyMat, ", "))
+		msg = fmt.Sprintf("Did you mean %s", strings.Join(found, " or "))
 	}
 	return &ChatCommandSimpleMessage{replyMessage: msg}, nil
 }
@@ -515,18 +544,8 @@ func (c *ChatCommandProcessor) setChibiModel(chatArgs *ChatArgs, current *operat
 		return &ChatCommandNoOp{}, nil
 	}
 
-	prevFaction := current.Faction
-	if prevFaction == operator.FACTION_ENUM_ENEMY {
-		// If changing from an enemy to an operator and the current action is
-		// "walking/wander" we need to set the stance to base in order to
-		// make the chibi continue to walk
-		if operator.IsWalkingAction(current.CurrentAction) {
-			current.ChibiStance = operator.CHIBI_STANCE_ENUM_BASE
-		}
-	}
-
+	c.setFaction(current, operator.FACTION_ENUM_OPERATOR)
 	current.OperatorId = operatorId
-	current.Faction = operator.FACTION_ENUM_OPERATOR
 	current.AnimationSpeed = c.spineService.GetDefaultAnimationSpeed()
 	current.SpriteScale = misc.EmptyOption[misc.Vector2]()
 	// current.MovementSpeed = misc.EmptyOption[misc.Vector2]()
@@ -539,6 +558,39 @@ func (c *ChatCommandProcessor) setChibiModel(chatArgs *ChatArgs, current *operat
 	}, nil
 }
 
+func (c *ChatCommandProcessor) setRandomChibi(args *ChatArgs, current *operator.OperatorInfo) (ChatCommand, error) {
+	// !chibi random uses the room's random pool, otherwise the filter terms
+	var randomOp *operator.OperatorInfo
+	var err error
+	if len(args.args) < 3 {
+		randomOp, err = c.spineService.GetRandomOperator()
+	} else {
+		filter, filterErr := c.spineService.ParseOperatorFilter(args.args[2:])
+		if filterErr != nil {
+			return &ChatCommandNoOp{}, fmt.Errorf("%w, try something like !chibi random 6star caster", filterErr)
+		}
+		randomOp, err = c.spineService.GetRandomOperatorWithFilter(filter)
+	}
+	if errors.Is(err, operator.ErrNoMatchingOperators) {
+		return &ChatCommandNoOp{}, fmt.Errorf("no operators are %s", strings.Join(args.args[2:], " "))
+	}
+	if err != nil {
+		return &ChatCommandNoOp{}, err
+	}
+
+	c.setFaction(current, operator.FACTION_ENUM_OPERATOR)
+	current.OperatorId = randomOp.OperatorId
+	current.AnimationSpeed = c.spineService.GetDefaultAnimationSpeed()
+	current.SpriteScale = misc.EmptyOption[misc.Vector2]()
+	return &ChatCommandUpdateActor{
+		replyMessage:    "",
+		username:        args.chatMsg.Username,
+		usernameDisplay: args.chatMsg.UserDisplayName,
+		twitchUserId:    args.chatMsg.TwitchUserId,
+		update:          current,
+	}, nil
+}
+
 func (c *ChatCommandProcessor) getChibiInfo(args *ChatArgs, subInfoName string) (ChatCommand, error) {
 	return &ChatCommandInfo{
 		info:     subInfoName,
@@ -606,9 +658,7 @@ func (c *ChatCommandProcessor) setPace(args *ChatArgs, current *operator.Operato
 	if len(args.args) < 4 {
 		return &ChatCommandNoOp{}, errors.New("try something like !chibi pace 0.1 0.5")
 	}
-	if current.Faction == operator.FACTION_ENUM_OPERATOR {
-		current.ChibiStance = operator.CHIBI_STANCE_ENUM_BASE
-	}
+	c.setWalkingStance(current)
 
 	startPos, err := strconv.ParseFloat(args.args[2], 64)
 	if err != nil {
@@ -644,8 +694,33 @@ func (c *ChatCommandProcessor) setSaveUserPrefs(
 	args *ChatArgs,
 	current *operator.OperatorInfo,
 ) (ChatCommand, error) {
+	if len(args.args) == 3 && strings.ToLower(args.args[2]) == users.LOADOUT_NAME_HERE {
+		return &ChatCommandSavePrefs{
+			replyMessage:    "",
+			username:        args.chatMsg.Username,
+			usernameDisplay: args.chatMsg.UserDisplayName,
+			twitchUserId:    args.chatMsg.TwitchUserId,
+			action:          ChatCommandSaveChibi_SaveRoom,
+			update:          current,
+		}, nil
+	}
+	if len(args.args) == 3 {
+		name, err := users.NormalizeLoadoutName(args.args[2])
+		if err != nil {
+			return &ChatCommandNoOp{}, err
+		}
+		return &ChatCommandLoadout{
+			replyMessage:    "",
+			username:        args.chatMsg.Username,
+			usernameDisplay: args.chatMsg.UserDisplayName,
+			twitchUserId:    args.chatMsg.TwitchUserId,
+			name:            name,
+			update:          current,
+			action:          ChatCommandLoadout_Save,
+		}, nil
+	}
 	if len(args.args) != 2 {
-		return &ChatCommandNoOp{}, errors.New("try something like !chibi save")
+		return &ChatCommandNoOp{}, errors.New("try something like !chibi save or !chibi save casual")
 	}
 	return &ChatCommandSavePrefs{
 		replyMessage:    "",
@@ -661,19 +736,56 @@ func (c *ChatCommandProcessor) setClearUserPrefs(
 	args *ChatArgs,
 	_ *operator.OperatorInfo,
 ) (ChatCommand, error) {
-	if len(args.args) != 2 {
-		return &ChatCommandNoOp{}, errors.New("try something like !chibi unsave")
+	action := ChatCommandSaveChibi_Remove
+	if len(args.args) == 3 && strings.ToLower(args.args[2]) == users.LOADOUT_NAME_HERE {
+		action = ChatCommandSaveChibi_RemoveRoom
+	} else if len(args.args) != 2 {
+		return &ChatCommandNoOp{}, errors.New("try something like !chibi unsave or !chibi unsave here")
 	}
 	return &ChatCommandSavePrefs{
 		replyMessage:    "",
 		username:        args.chatMsg.Username,
 		usernameDisplay: args.chatMsg.UserDisplayName,
 		twitchUserId:    args.chatMsg.TwitchUserId,
-		action:          ChatCommandSaveChibi_Remove,
+		action:          action,
 		update:          nil,
 	}, nil
 }
 
+func (c *ChatCommandProcessor) setLoadLoadout(
+	args *ChatArgs,
+	_ *operator.OperatorInfo,
+) (ChatCommand, error) {
+	if len(args.args) != 3 {
+		return &ChatCommandNoOp{}, errors.New("try something like !chibi load casual")
+	}
+	name, err := users.NormalizeLoadoutName(args.args[2])
+	if err != nil {
+		return &ChatCommandNoOp{}, err
+	}
+	return &ChatCommandLoadout{
+		replyMessage:    "",
+		username:        args.chatMsg.Username,
+		usernameDisplay: args.chatMsg.UserDisplayName,
+		twitchUserId:    args.chatMsg.TwitchUserId,
+		name:            name,
+		action:          ChatCommandLoadout_Load,
+	}, nil
+}
+
+func (c *ChatCommandProcessor) listLoadouts(
+	args *ChatArgs,
+	_ *operator.OperatorInfo,
+) (ChatCommand, error) {
+	return &ChatCommandLoadout{
+		replyMessage:    "",
+		username:        args.chatMsg.Username,
+		usernameDisplay: args.chatMsg.UserDisplayName,
+		twitchUserId:    args.chatMsg.TwitchUserId,
+		action:          ChatCommandLoadout_List,
+	}, nil
+}
+
 func (c *ChatCommandProcessor) setFindMe(
 	args *ChatArgs,
 	_ *operator.OperatorInfo,
@@ -702,9 +814,7 @@ func (c *ChatCommandProcessor) setFollow(
 		return &ChatCommandNoOp{}, errors.New("try something like !chibi follow <username>")
 	}
 
-	if current.Faction == operator.FACTION_ENUM_OPERATOR {
-		current.ChibiStance = operator.CHIBI_STANCE_ENUM_BASE
-	}
+	c.setWalkingStance(current)
 	animationAfterStance := ""
 	if current.ChibiStance == operator.CHIBI_STANCE_ENUM_BASE {
 		animationAfterStance = operator.DEFAULT_ANIM_BASE_RELAX
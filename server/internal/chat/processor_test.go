@@ -104,6 +104,21 @@ func (f *FakeActorUpdater) SaveUserPreferences(ctx context.Context, userInfo mis
 func (f *FakeActorUpdater) ClearUserPreferences(ctx context.Context, userInfo misc.UserInfo) error {
 	return nil
 }
+func (f *FakeActorUpdater) SaveUserRoomPreferences(ctx context.Context, userInfo misc.UserInfo, update *operator.OperatorInfo) error {
+	return nil
+}
+func (f *FakeActorUpdater) ClearUserRoomPreferences(ctx context.Context, userInfo misc.UserInfo) error {
+	return nil
+}
+func (f *FakeActorUpdater) SaveUserLoadout(ctx context.Context, userInfo misc.UserInfo, name string, update *operator.OperatorInfo) error {
+	return nil
+}
+func (f *FakeActorUpdater) LoadUserLoadout(ctx context.Context, userInfo misc.UserInfo, name string) error {
+	return nil
+}
+func (f *FakeActorUpdater) GetUserLoadoutNames(ctx context.Context, userInfo misc.UserInfo) ([]string, error) {
+	return []string{"battle", "casual"}, nil
+}
 func (f *FakeActorUpdater) ShowMessage(ctx context.Context, userInfo misc.UserInfo, msg string) error {
 	return nil
 }
@@ -799,3 +814,138 @@ func TestCmdProcessorHandleMessage_ChibiFindMe(t *testing.T) {
 		assert.Fail("Command is not of type: ChatCommandFindMe")
 	}
 }
+
+func TestCmdProcessorHandleMessage_ChibiRandom(t *testing.T) {
+	current, actor, sut := setupCommandTest()
+	current.Faction = operator.FACTION_ENUM_ENEMY
+	current.OperatorId = "enemy_1007_slime_2"
+
+	assert := assert.New(t)
+	cmd, err := sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi random 5star casters",
+	})
+
+	assert.Nil(err)
+	assert.Empty(cmd.Reply(actor))
+	if _, ok := cmd.(*ChatCommandUpdateActor); !ok {
+		assert.Fail("Command is not of type: ChatCommandUpdateActor")
+	}
+	assert.Equal("char_002_amiya", current.OperatorId)
+	assert.Equal(operator.FACTION_ENUM_OPERATOR, current.Faction)
+}
+
+func TestCmdProcessorHandleMessage_ChibiRandomNoMatches(t *testing.T) {
+	current, _, sut := setupCommandTest()
+
+	assert := assert.New(t)
+	cmd, err := sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi random 6star",
+	})
+	assert.ErrorContains(err, "no operators are 6star")
+	if _, ok := cmd.(*ChatCommandNoOp); !ok {
+		assert.Fail("Command is not of type: ChatCommandNoOp")
+	}
+
+	_, err = sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi random wizard",
+	})
+	assert.ErrorContains(err, "unknown filter (wizard)")
+}
+
+func TestCmdProcessorHandleMessage_ChibiLoadouts(t *testing.T) {
+	current, actor, sut := setupCommandTest()
+	assert := assert.New(t)
+	cmd, err := sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi save Casual",
+	})
+	assert.Nil(err)
+	if loadoutCmd, ok := cmd.(*ChatCommandLoadout); ok {
+		assert.Equal("casual", loadoutCmd.name)
+		assert.Equal(ChatCommandLoadout_Save, loadoutCmd.action)
+		assert.Equal(current, loadoutCmd.update)
+	} else {
+		assert.Fail("Command is not of type: ChatCommandLoadout")
+	}
+
+	cmd, err = sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi load battle",
+	})
+	assert.Nil(err)
+	if loadoutCmd, ok := cmd.(*ChatCommandLoadout); ok {
+		assert.Equal("battle", loadoutCmd.name)
+		assert.Equal(ChatCommandLoadout_Load, loadoutCmd.action)
+	} else {
+		assert.Fail("Command is not of type: ChatCommandLoadout")
+	}
+
+	cmd, err = sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi loadouts",
+	})
+	assert.Nil(err)
+	assert.Equal("user1DisplayName loadouts: battle, casual", cmd.Reply(actor))
+
+	_, err = sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi load bad!name",
+	})
+	assert.ErrorContains(err, "loadout names must be")
+}
+
+func TestCmdProcessorHandleMessage_ChibiSaveHere(t *testing.T) {
+	current, _, sut := setupCommandTest()
+	assert := assert.New(t)
+	cmd, err := sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi save here",
+	})
+	assert.Nil(err)
+	if saveCmd, ok := cmd.(*ChatCommandSavePrefs); ok {
+		assert.Equal(ChatCommandSaveChibi_SaveRoom, saveCmd.action)
+		assert.Equal(current, saveCmd.update)
+	} else {
+		assert.Fail("Command is not of type: ChatCommandSavePrefs")
+	}
+
+	cmd, err = sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi unsave here",
+	})
+	assert.Nil(err)
+	if saveCmd, ok := cmd.(*ChatCommandSavePrefs); ok {
+		assert.Equal(ChatCommandSaveChibi_RemoveRoom, saveCmd.action)
+	} else {
+		assert.Fail("Command is not of type: ChatCommandSavePrefs")
+	}
+
+	_, err = sut.HandleMessage(current, ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "user1DisplayName",
+		TwitchUserId:    "100",
+		Message:         "!chibi load here",
+	})
+	assert.ErrorContains(err, "cannot be used as a loadout name")
+}
@@ -14,6 +14,7 @@ import (
 type TwitchBot struct {
 	chatMessageHandler chat.ChatMessageHandler
 	channelName        string
+	botName            string
 	tc                 *twitch.Client
 }
 
@@ -37,11 +38,22 @@ func NewTwitchBot(
 	self := &TwitchBot{
 		chatMessageHandler: chatMessageHandler,
 		channelName:        twitchChannelName,
+		botName:            twitchBotName,
 		tc:                 tc,
 	}
 	return self, nil
 }
 
+func (t *TwitchBot) BotName() string {
+	return t.botName
+}
+
+// SetAccessToken replaces the token the bot logs in with the next time it
+// (re)connects to twitch. The current connection is left alone.
+func (t *TwitchBot) SetAccessToken(accessToken string) {
+	t.tc.SetIRCToken("oauth:" + accessToken)
+}
+
 func (t *TwitchBot) Close() error {
 	log.Println("TwitchBot::Close() called")
 	err := t.tc.Disconnect()
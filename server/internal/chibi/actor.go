@@ -2,19 +2,30 @@ package chibi
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/chat"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	spine "github.com/Stymphalian/ak_chibi_bot/server/internal/spine_runtime"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 )
 
+// The window over which the room's chat commands per minute is averaged
+const COMMAND_RATE_WINDOW_MINS = 5
+
 type ChibiActor struct {
+	// Guards the chatters and the pause state. HandleMessage holds it while
+	// handling a chat message, everything else must go through WithLock.
+	mutex sync.Mutex
+
 	spineService  *operator.OperatorService
 	usersRepo     users.UserRepository
 	chattersRepo  users.ChatterRepository
@@ -25,11 +36,25 @@ type ChibiActor struct {
 	client               spine.SpineClient
 	chatCommandProcessor *chat.ChatCommandProcessor
 	excludeNames         []string
+	notifier             webhook.Notifier
+	publisher            events.Publisher
+	commandRate          *misc.RateCounter
+
+	// While paused any movement is replaced with standing still. The actions
+	// interrupted by the pause are restored when resuming.
+	paused        bool
+	pausedActions map[string]pausedAction
 
 	// TODO: Find a better way to get the roomId into the ChibiActors/ChatUsers
 	roomId uint
 }
 
+type pausedAction struct {
+	operatorId    string
+	currentAction operator.ActionEnum
+	action        operator.ActionUnion
+}
+
 func NewChibiActor(
 	roomId uint,
 	spineService *operator.OperatorService,
@@ -37,6 +62,8 @@ func NewChibiActor(
 	userPrefsRepo users.UserPreferencesRepository,
 	chattersRepo users.ChatterRepository,
 	client spine.SpineClient,
+	notifier webhook.Notifier,
+	publisher events.Publisher,
 	excludeNames []string,
 ) *ChibiActor {
 	a := &ChibiActor{
@@ -50,11 +77,24 @@ func NewChibiActor(
 		client:               client,
 		chatCommandProcessor: chat.NewChatCommandProcessor(spineService),
 		excludeNames:         excludeNames,
+		notifier:             notifier,
+		publisher:            publisher,
+		commandRate:          misc.NewRateCounter(COMMAND_RATE_WINDOW_MINS),
+		pausedActions:        make(map[string]pausedAction),
 		roomId:               roomId,
 	}
 	return a
 }
 
+// WithLock runs fn while holding the actor's lock. Reads or changes of the
+// chatters from outside of the chat goroutine must happen inside fn. fn must
+// not call WithLock, HandleMessage or the other methods which take the lock.
+func (c *ChibiActor) WithLock(fn func() error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return fn()
+}
+
 func (c *ChibiActor) Close() error {
 	// No need to send the remove Operators to the clients.
 	// This room is going down on the server anyways and the WS connections
@@ -66,23 +106,44 @@ func (c *ChibiActor) Close() error {
 	return nil
 }
 
+// getSavedChibi returns the user's chibi saved for this room, then their
+// loadout for this room, then their global saved chibi. nil if none are saved.
+func (c *ChibiActor) getSavedChibi(ctx context.Context, twitchUserId string) *operator.OperatorInfo {
+	saved, _ := c.userPrefsRepo.GetSavedChibiByTwitchIdOrNil(ctx, twitchUserId, c.roomId)
+	return saved
+}
+
+func (c *ChibiActor) HasSavedChibi(ctx context.Context, twitchUserId string) bool {
+	return c.getSavedChibi(ctx, twitchUserId) != nil
+}
+
+// GetCommandRate counts the !chibi commands sent in the room
+func (c *ChibiActor) GetCommandRate() *misc.RateCounter {
+	return c.commandRate
+}
+
 func (c *ChibiActor) GetLastChatterTime() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	return c.lastChatterTime
 }
 
+func (c *ChibiActor) NumChatters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.ChatUsers)
+}
+
 func (c *ChibiActor) GiveChibiToUser(ctx context.Context, userInfo misc.UserInfo) error {
 	// Skip giving chibis to these Users
 	if slices.Contains(c.excludeNames, userInfo.Username) {
 		return nil
 	}
 
-	userPrefs, _ := c.userPrefsRepo.GetByTwitchIdOrNil(ctx, userInfo.TwitchUserId)
-	var operatorInfo *operator.OperatorInfo
-	if userPrefs != nil {
-		operatorInfo = &userPrefs.OperatorInfo
-	} else {
+	operatorInfo := c.getSavedChibi(ctx, userInfo.TwitchUserId)
+	if operatorInfo == nil {
 		var err error
-		operatorInfo, err = c.spineService.GetRandomOperator()
+		operatorInfo, err = c.spineService.GetRandomOperatorForUser(userInfo.TwitchUserId)
 		if err != nil {
 			return err
 		}
@@ -114,6 +175,11 @@ func (c *ChibiActor) RemoveUserChibi(ctx context.Context, userName string) error
 	}
 	c.ChatUsers[userName].SetActive(false)
 	delete(c.ChatUsers, userName)
+	eventData := webhook.ChibiEventData{
+		Username: userName,
+	}
+	c.notifier.Notify(c.roomId, webhook.EVENT_CHIBI_REMOVED, eventData)
+	c.publisher.Publish(c.roomId, events.EVENT_CHIBI_REMOVED, eventData)
 	return nil
 }
 
@@ -133,16 +199,20 @@ func (c *ChibiActor) SetToDefault(
 	userInfo misc.UserInfo,
 	opName string,
 	details misc.InitialOperatorDetails,
-) {
+) error {
 	if c.ShouldExcludeUser(userInfo.Username) {
-		return
+		return nil
 	}
 
-	opInfo := c.spineService.OperatorFromDefault(opName, details)
-	err := c.UpdateChatter(ctx, userInfo, opInfo)
+	opInfo, err := c.spineService.OperatorFromDefault(opName, details)
+	if err != nil {
+		return err
+	}
+	err = c.UpdateChatter(ctx, userInfo, opInfo)
 	if err != nil {
 		log.Printf("Failed to SetToDefault for %s: %s\n", userInfo.Username, err)
 	}
+	return err
 }
 
 func (r *ChibiActor) UpdateExcludeNames(usernames []string) {
@@ -153,6 +223,8 @@ func (c *ChibiActor) ShouldExcludeUser(username string) bool {
 }
 
 func (c *ChibiActor) HandleMessage(msg chat.ChatMessage) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	ctx := context.Background()
 	if !c.HasChibi(ctx, msg.Username) {
 		c.GiveChibiToUser(ctx, misc.UserInfo{
@@ -168,6 +240,9 @@ func (c *ChibiActor) HandleMessage(msg chat.ChatMessage) (string, error) {
 	if len(msg.Message) == 0 {
 		return "", nil
 	}
+	if strings.HasPrefix(msg.Message, "!chibi") {
+		c.commandRate.Add(misc.Clock.Now())
+	}
 	// if msg.Message[0] != '!' {
 	// 	return "", nil
 	// }
@@ -182,12 +257,22 @@ func (c *ChibiActor) HandleMessage(msg chat.ChatMessage) (string, error) {
 	}
 
 	chatCommand, err := c.chatCommandProcessor.HandleMessage(&current, msg)
+	if err != nil {
+		c.publisher.Publish(c.roomId, events.EVENT_COMMAND_ERROR, events.CommandErrorEventData{
+			Username: msg.Username,
+			Message:  msg.Message,
+			Error:    err.Error(),
+		})
+	}
 	chatCommand.UpdateActor(c)
 	return chatCommand.Reply(c), err
 }
 
 // TODO: Leaky interface
 func (c *ChibiActor) UpdateChibi(ctx context.Context, userinfo misc.UserInfo, opInfo *operator.OperatorInfo) error {
+	if c.paused && operator.IsWalkingAction(opInfo.CurrentAction) {
+		c.spineService.SetIdleAction(opInfo)
+	}
 	c.spineService.ValidateUpdateSetDefaultOtherwise(opInfo)
 
 	_, err := c.client.SetOperator(
@@ -201,7 +286,29 @@ func (c *ChibiActor) UpdateChibi(ctx context.Context, userinfo misc.UserInfo, op
 		return nil
 	}
 
-	return c.UpdateChatter(ctx, userinfo, opInfo)
+	// Only notify when the chibi itself changes, not for every action/animation
+	notify := true
+	if chatUser, ok := c.ChatUsers[userinfo.Username]; ok {
+		prev := chatUser.GetOperatorInfo()
+		notify = prev.OperatorId != opInfo.OperatorId ||
+			prev.Skin != opInfo.Skin ||
+			prev.Faction != opInfo.Faction
+	}
+	if err := c.UpdateChatter(ctx, userinfo, opInfo); err != nil {
+		return err
+	}
+	if notify {
+		eventData := webhook.ChibiEventData{
+			Username:     userinfo.Username,
+			OperatorId:   opInfo.OperatorId,
+			OperatorName: opInfo.OperatorDisplayName,
+			Faction:      string(opInfo.Faction),
+			Skin:         opInfo.Skin,
+		}
+		c.notifier.Notify(c.roomId, webhook.EVENT_CHIBI_SET, eventData)
+		c.publisher.Publish(c.roomId, events.EVENT_CHIBI_SET, eventData)
+	}
+	return nil
 }
 
 func (c *ChibiActor) FollowChibi(ctx context.Context, userinfo misc.UserInfo, opInfo *operator.OperatorInfo) error {
@@ -232,7 +339,17 @@ func (c *ChibiActor) SaveUserPreferences(ctx context.Context, userInfo misc.User
 	if err != nil {
 		return err
 	}
-	return c.userPrefsRepo.SetByUserId(ctx, userDb.UserId, update)
+	if err := c.userPrefsRepo.SetByUserId(ctx, userDb.UserId, update); err != nil {
+		return err
+	}
+	c.notifier.Notify(c.roomId, webhook.EVENT_PREFERENCES_SAVED, webhook.ChibiEventData{
+		Username:     userInfo.Username,
+		OperatorId:   update.OperatorId,
+		OperatorName: update.OperatorDisplayName,
+		Faction:      string(update.Faction),
+		Skin:         update.Skin,
+	})
+	return nil
 }
 
 func (c *ChibiActor) ClearUserPreferences(ctx context.Context, userInfo misc.UserInfo) error {
@@ -258,6 +375,73 @@ func (c *ChibiActor) GetUserPreferences(ctx context.Context, userInfo misc.UserI
 	return &val.OperatorInfo, nil
 }
 
+func (c *ChibiActor) SaveUserRoomPreferences(ctx context.Context, userInfo misc.UserInfo, update *operator.OperatorInfo) error {
+	userDb, err := c.usersRepo.GetByTwitchId(ctx, userInfo.TwitchUserId)
+	if err != nil {
+		return err
+	}
+	if err := c.userPrefsRepo.SetRoomPrefsByUserId(ctx, userDb.UserId, c.roomId, update); err != nil {
+		return err
+	}
+	c.notifier.Notify(c.roomId, webhook.EVENT_PREFERENCES_SAVED, webhook.ChibiEventData{
+		Username:     userInfo.Username,
+		OperatorId:   update.OperatorId,
+		OperatorName: update.OperatorDisplayName,
+		Faction:      string(update.Faction),
+		Skin:         update.Skin,
+	})
+	return nil
+}
+
+func (c *ChibiActor) ClearUserRoomPreferences(ctx context.Context, userInfo misc.UserInfo) error {
+	userDb, err := c.usersRepo.GetByTwitchId(ctx, userInfo.TwitchUserId)
+	if err != nil {
+		return err
+	}
+	return c.userPrefsRepo.DeleteRoomPrefsByUserId(ctx, userDb.UserId, c.roomId)
+}
+
+func (c *ChibiActor) SaveUserLoadout(ctx context.Context, userInfo misc.UserInfo, name string, update *operator.OperatorInfo) error {
+	userDb, err := c.usersRepo.GetByTwitchId(ctx, userInfo.TwitchUserId)
+	if err != nil {
+		return err
+	}
+	_, err = c.userPrefsRepo.SaveLoadout(ctx, userDb.UserId, name, update)
+	return err
+}
+
+// LoadUserLoadout changes the user's chibi to their saved loadout
+func (c *ChibiActor) LoadUserLoadout(ctx context.Context, userInfo misc.UserInfo, name string) error {
+	userDb, err := c.usersRepo.GetByTwitchId(ctx, userInfo.TwitchUserId)
+	if err != nil {
+		return err
+	}
+	loadout, err := c.userPrefsRepo.GetLoadoutByNameOrNil(ctx, userDb.UserId, name)
+	if err != nil {
+		return err
+	}
+	if loadout == nil {
+		return users.ErrLoadoutNotFound
+	}
+	return c.UpdateChibi(ctx, userInfo, &loadout.OperatorInfo)
+}
+
+func (c *ChibiActor) GetUserLoadoutNames(ctx context.Context, userInfo misc.UserInfo) ([]string, error) {
+	userDb, err := c.usersRepo.GetByTwitchId(ctx, userInfo.TwitchUserId)
+	if err != nil {
+		return nil, err
+	}
+	loadouts, err := c.userPrefsRepo.GetLoadoutsByUserId(ctx, userDb.UserId)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(loadouts))
+	for _, loadout := range loadouts {
+		names = append(names, loadout.Name)
+	}
+	return names, nil
+}
+
 func (c *ChibiActor) ShowMessage(ctx context.Context, userInfo misc.UserInfo, msg string) error {
 	_, ok := c.ChatUsers[userInfo.Username]
 	if !ok {
@@ -270,6 +454,90 @@ func (c *ChibiActor) ShowMessage(ctx context.Context, userInfo misc.UserInfo, ms
 	return err
 }
 
+func (c *ChibiActor) IsPaused() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.paused
+}
+
+// SetPaused stops all the chibis which are moving. Resuming puts them back to
+// what they were doing, unless the chatter has changed chibis in the meantime.
+func (c *ChibiActor) SetPaused(ctx context.Context, paused bool) error {
+	if c.paused == paused {
+		return nil
+	}
+	c.paused = paused
+
+	if paused {
+		for username, chatUser := range c.ChatUsers {
+			opInfo := *chatUser.GetOperatorInfo()
+			if !operator.IsWalkingAction(opInfo.CurrentAction) {
+				continue
+			}
+			c.pausedActions[username] = pausedAction{
+				operatorId:    opInfo.OperatorId,
+				currentAction: opInfo.CurrentAction,
+				action:        opInfo.Action,
+			}
+			// UpdateChibi stops the movement now that the room is paused
+			if err := c.UpdateChibi(ctx, chatUserInfo(chatUser), &opInfo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for username, saved := range c.pausedActions {
+		chatUser, ok := c.ChatUsers[username]
+		if !ok {
+			continue
+		}
+		opInfo := *chatUser.GetOperatorInfo()
+		if opInfo.OperatorId != saved.operatorId {
+			continue
+		}
+		opInfo.CurrentAction = saved.currentAction
+		opInfo.Action = saved.action
+		if err := c.UpdateChibi(ctx, chatUserInfo(chatUser), &opInfo); err != nil {
+			return err
+		}
+	}
+	clear(c.pausedActions)
+	return nil
+}
+
+func chatUserInfo(chatUser *users.ChatUser) misc.UserInfo {
+	return misc.UserInfo{
+		Username:        chatUser.GetUsername(),
+		UsernameDisplay: chatUser.GetUsernameDisplay(),
+		TwitchUserId:    chatUser.GetTwitchUserId(),
+	}
+}
+
+// Announce shows the message over the announcer's chibi, first giving them
+// the default chibi if they aren't in the room yet.
+func (c *ChibiActor) Announce(
+	ctx context.Context,
+	announcer misc.UserInfo,
+	opName string,
+	details misc.InitialOperatorDetails,
+	msg string,
+) error {
+	if c.ShouldExcludeUser(announcer.Username) {
+		return fmt.Errorf("%s is excluded from this room", announcer.Username)
+	}
+	if _, ok := c.ChatUsers[announcer.Username]; !ok {
+		opInfo, err := c.spineService.OperatorFromDefault(opName, details)
+		if err != nil {
+			return err
+		}
+		if err := c.UpdateChibi(ctx, announcer, opInfo); err != nil {
+			return err
+		}
+	}
+	return c.ShowMessage(ctx, announcer, msg)
+}
+
 func (c *ChibiActor) FindOperator(ctx context.Context, userInfo misc.UserInfo) error {
 	_, ok := c.ChatUsers[userInfo.Username]
 	if !ok {
@@ -39,11 +39,12 @@ func (f *FakeChibiActor) HasChibi(ctx context.Context, userName string) bool {
 	return ok
 }
 
-func (f *FakeChibiActor) SetToDefault(ctx context.Context, broadcasterName string, opName string, details misc.InitialOperatorDetails) {
+func (f *FakeChibiActor) SetToDefault(ctx context.Context, broadcasterName string, opName string, details misc.InitialOperatorDetails) error {
 	opInfo := f.Users[broadcasterName]
 	opInfo.OperatorId = "DefaultChibi"
 	opInfo.OperatorDisplayName = "DefaultChibi"
 	f.Users[broadcasterName] = opInfo
+	return nil
 }
 
 func (f *FakeChibiActor) HandleMessage(msg chat.ChatMessage) (string, error) {
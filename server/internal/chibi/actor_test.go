@@ -2,16 +2,20 @@ package chibi
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/chat"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	spine "github.com/Stymphalian/ak_chibi_bot/server/internal/spine_runtime"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,6 +49,8 @@ func setupActorTest() *ChibiActor {
 		userPrefsRepo,
 		chattersRepo,
 		fakeSpineClient,
+		webhook.NewFakeNotifier(),
+		events.NewHub(),
 		[]string{"exlude_user"},
 	)
 	return sut
@@ -138,12 +144,13 @@ func TestSetToDefault(t *testing.T) {
 		TwitchUserId:    "100",
 	}
 
-	sut.SetToDefault(ctx, userinfo, "Amiya", misc.InitialOperatorDetails{
+	err := sut.SetToDefault(ctx, userinfo, "Amiya", misc.InitialOperatorDetails{
 		Skin:       operator.DEFAULT_SKIN_NAME,
 		Stance:     string(operator.CHIBI_STANCE_ENUM_BASE),
 		Animations: []string{operator.DEFAULT_ANIM_BASE},
 		PositionX:  0.5,
 	})
+	assert.Nil(err)
 	assert.Contains(sut.ChatUsers, "user1")
 	assert.Contains(sut.ChatUsers["user1"].GetOperatorInfo().Skin, "default")
 }
@@ -163,6 +170,29 @@ func TestChibiActorHandleMessage(t *testing.T) {
 	assert.True(misc.Clock.Since(sut.ChatUsers["user1"].GetLastChatTime()) < time.Duration(1)*time.Second)
 }
 
+func TestChibiActorHandleMessage_PublishesCommandError(t *testing.T) {
+	assert := assert.New(t)
+	sut := setupActorTest()
+	sub, err := sut.publisher.(*events.Hub).Subscribe(0, []events.EventType{events.EVENT_COMMAND_ERROR})
+	assert.Nil(err)
+
+	_, err = sut.HandleMessage(chat.ChatMessage{
+		Username:        "user1",
+		UserDisplayName: "userDisplay",
+		TwitchUserId:    "100",
+		Message:         "!chibi speed fast",
+	})
+	assert.NotNil(err)
+
+	assert.Len(sub.C, 1)
+	ev := <-sub.C
+	assert.Equal(uint(5000), ev.RoomId)
+	data := ev.Data.(events.CommandErrorEventData)
+	assert.Equal("user1", data.Username)
+	assert.Equal("!chibi speed fast", data.Message)
+	assert.Equal(err.Error(), data.Error)
+}
+
 func TestChibiActorUpdateChibi(t *testing.T) {
 	assert := assert.New(t)
 	sut := setupActorTest()
@@ -193,6 +223,69 @@ func TestChibiActorUpdateChibi(t *testing.T) {
 	assert.Equal(sut.ChatUsers["user1"].GetOperatorInfo().AvailableAnimations, []string{"Move", "base_front1", "base_front2"})
 }
 
+func TestChibiActorSetPaused(t *testing.T) {
+	assert := assert.New(t)
+	sut := setupActorTest()
+	ctx := context.TODO()
+	userinfo := misc.UserInfo{
+		Username:        "user1",
+		UsernameDisplay: "userDisplay1",
+		TwitchUserId:    "100",
+	}
+
+	opInfo := amiyaOpInfo
+	opInfo.CurrentAction = operator.ACTION_WANDER
+	opInfo.Action = operator.NewActionWander(operator.DEFAULT_ANIM_BASE, "base_front1")
+	assert.Nil(sut.UpdateChibi(ctx, userinfo, &opInfo))
+
+	assert.Nil(sut.SetPaused(ctx, true))
+	assert.True(sut.IsPaused())
+	assert.Equal(operator.ACTION_PLAY_ANIMATION, sut.ChatUsers["user1"].GetOperatorInfo().CurrentAction)
+
+	// Chatters can't start moving again while paused
+	walkInfo := *sut.ChatUsers["user1"].GetOperatorInfo()
+	walkInfo.CurrentAction = operator.ACTION_WALK
+	walkInfo.Action = operator.NewActionWalk(operator.DEFAULT_ANIM_BASE)
+	assert.Nil(sut.UpdateChibi(ctx, userinfo, &walkInfo))
+	assert.Equal(operator.ACTION_PLAY_ANIMATION, sut.ChatUsers["user1"].GetOperatorInfo().CurrentAction)
+
+	assert.Nil(sut.SetPaused(ctx, false))
+	assert.False(sut.IsPaused())
+	assert.Equal(operator.ACTION_WANDER, sut.ChatUsers["user1"].GetOperatorInfo().CurrentAction)
+}
+
+// Run with -race. The room's control endpoints change the chatters from the
+// API goroutines while chat messages are being handled.
+func TestChibiActor_WithLockSerializesWithChat(t *testing.T) {
+	assert := assert.New(t)
+	sut := setupActorTest()
+	ctx := context.TODO()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 20 {
+			sut.HandleMessage(chat.ChatMessage{
+				Username:        fmt.Sprintf("user%d", i),
+				UserDisplayName: "userDisplay",
+				TwitchUserId:    fmt.Sprintf("%d", 100+i),
+				Message:         "!chibi walk",
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range 20 {
+			sut.WithLock(func() error {
+				return sut.SetPaused(ctx, i%2 == 0)
+			})
+		}
+	}()
+	wg.Wait()
+	assert.Equal(20, sut.NumChatters())
+}
+
 func TestActorCurrentInfoEmpty(t *testing.T) {
 	assert := assert.New(t)
 	sut := setupActorTest()
@@ -378,3 +471,37 @@ func TestChibiActorFollowChibi(t *testing.T) {
 	assert.Equal(sut.ChatUsers["user1"].GetOperatorInfo().CurrentAction, operator.ACTION_FOLLOW)
 	assert.Equal(sut.ChatUsers["user1"].GetOperatorInfo().Action.ActionFollowTarget, twitchUserinfo.Username)
 }
+
+func TestChibiActor_GetSavedChibiPriority(t *testing.T) {
+	assert := assert.New(t)
+	sut := setupActorTest()
+	ctx := context.TODO()
+	username := "test-chibi-actor-saved"
+	userinfo := misc.UserInfo{
+		Username:        username,
+		UsernameDisplay: "display-" + username,
+		TwitchUserId:    "twitch-" + username,
+	}
+	userDb, _ := sut.usersRepo.GetOrInsertUser(ctx, userinfo)
+	sut.userPrefsRepo.DeleteByUserId(ctx, userDb.UserId)
+	sut.userPrefsRepo.DeleteRoomPrefsByUserId(ctx, userDb.UserId, sut.roomId)
+	sut.userPrefsRepo.ClearRoomLoadout(ctx, userDb.UserId, sut.roomId)
+	assert.Nil(sut.getSavedChibi(ctx, userinfo.TwitchUserId))
+
+	withSkin := func(skin string) *operator.OperatorInfo {
+		opInfo := amiyaOpInfo
+		opInfo.Skin = skin
+		return &opInfo
+	}
+	sut.userPrefsRepo.SetByUserId(ctx, userDb.UserId, withSkin("global"))
+	assert.Equal("global", sut.getSavedChibi(ctx, userinfo.TwitchUserId).Skin)
+
+	loadout, err := sut.userPrefsRepo.SaveLoadout(ctx, userDb.UserId, "saved", withSkin("loadout"))
+	assert.Nil(err)
+	assert.Nil(sut.userPrefsRepo.SetRoomLoadout(ctx, userDb.UserId, sut.roomId, loadout.UserLoadoutId))
+	assert.Equal("loadout", sut.getSavedChibi(ctx, userinfo.TwitchUserId).Skin)
+
+	sut.userPrefsRepo.SetRoomPrefsByUserId(ctx, userDb.UserId, sut.roomId, withSkin("room"))
+	assert.Equal("room", sut.getSavedChibi(ctx, userinfo.TwitchUserId).Skin)
+	assert.True(sut.HasSavedChibi(ctx, userinfo.TwitchUserId))
+}
@@ -0,0 +1,61 @@
+package customchibi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type CustomChibiRepository interface {
+	GetByRoomId(ctx context.Context, roomId uint) ([]*CustomChibiDb, error)
+	// GetByOperatorId returns nil if the room has no such custom chibi
+	GetByOperatorId(ctx context.Context, roomId uint, operatorId string) (*CustomChibiDb, error)
+	// Create inserts a new chibi. Returns ErrTooManyCustomChibis if the room
+	// already has maxPerRoom chibis. The check and insert are atomic.
+	Create(ctx context.Context, chibi *CustomChibiDb, maxPerRoom int) error
+	// Save creates the chibi if CustomChibiId is 0, otherwise updates it
+	Save(ctx context.Context, chibi *CustomChibiDb) error
+	DeleteByOperatorId(ctx context.Context, roomId uint, operatorId string) error
+}
+
+type CustomChibiDb struct {
+	CustomChibiId uint   `gorm:"primarykey"`
+	RoomId        uint   `gorm:"column:room_id"`
+	OperatorId    string `gorm:"column:operator_id"`
+	Name          string `gorm:"column:name"`
+	// JSON encoded operator.ChibiAssetPathEntry
+	AssetEntry []byte `gorm:"column:asset_entry;type:jsonb"`
+	// Every asset path stored in the asset store for this chibi
+	AssetFiles pq.StringArray `gorm:"column:asset_files;type:text[]"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (CustomChibiDb) TableName() string {
+	return "custom_chibis"
+}
+
+func (c *CustomChibiDb) GetAssetEntry() (*operator.ChibiAssetPathEntry, error) {
+	entry := &operator.ChibiAssetPathEntry{}
+	if err := json.Unmarshal(c.AssetEntry, entry); err != nil {
+		return nil, err
+	}
+	if err := entry.LocalizeFilepaths(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (c *CustomChibiDb) SetAssetEntry(entry *operator.ChibiAssetPathEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	c.AssetEntry = data
+	return nil
+}
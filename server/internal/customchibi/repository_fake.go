@@ -0,0 +1,89 @@
+package customchibi
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type FakeCustomChibiRepository struct {
+	mutex  sync.Mutex
+	Chibis map[uint]*CustomChibiDb
+	nextId uint
+}
+
+func NewFakeCustomChibiRepository() *FakeCustomChibiRepository {
+	return &FakeCustomChibiRepository{
+		Chibis: make(map[uint]*CustomChibiDb),
+		nextId: 1,
+	}
+}
+
+func (r *FakeCustomChibiRepository) GetByRoomId(ctx context.Context, roomId uint) ([]*CustomChibiDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	chibis := make([]*CustomChibiDb, 0)
+	for _, chibi := range r.Chibis {
+		if chibi.RoomId == roomId {
+			chibis = append(chibis, chibi)
+		}
+	}
+	sort.Slice(chibis, func(i, j int) bool {
+		return chibis[i].CustomChibiId < chibis[j].CustomChibiId
+	})
+	return chibis, nil
+}
+
+func (r *FakeCustomChibiRepository) GetByOperatorId(ctx context.Context, roomId uint, operatorId string) (*CustomChibiDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, chibi := range r.Chibis {
+		if chibi.RoomId == roomId && chibi.OperatorId == operatorId {
+			return chibi, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *FakeCustomChibiRepository) Create(ctx context.Context, chibi *CustomChibiDb, maxPerRoom int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	count := 0
+	for _, existing := range r.Chibis {
+		if existing.RoomId == chibi.RoomId {
+			count += 1
+		}
+	}
+	if count >= maxPerRoom {
+		return ErrTooManyCustomChibis
+	}
+	chibi.CustomChibiId = r.nextId
+	r.nextId += 1
+	r.Chibis[chibi.CustomChibiId] = chibi
+	return nil
+}
+
+func (r *FakeCustomChibiRepository) Save(ctx context.Context, chibi *CustomChibiDb) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if chibi.CustomChibiId == 0 {
+		chibi.CustomChibiId = r.nextId
+		r.nextId += 1
+	}
+	r.Chibis[chibi.CustomChibiId] = chibi
+	return nil
+}
+
+func (r *FakeCustomChibiRepository) DeleteByOperatorId(ctx context.Context, roomId uint, operatorId string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for id, chibi := range r.Chibis {
+		if chibi.RoomId == roomId && chibi.OperatorId == operatorId {
+			delete(r.Chibis, id)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
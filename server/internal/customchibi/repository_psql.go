@@ -0,0 +1,76 @@
+package customchibi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"gorm.io/gorm"
+)
+
+type CustomChibiRepositoryPsql struct {
+	*akdb.DatbaseConn
+}
+
+func NewCustomChibiRepositoryPsql(akDb *akdb.DatbaseConn) *CustomChibiRepositoryPsql {
+	return &CustomChibiRepositoryPsql{
+		DatbaseConn: akDb,
+	}
+}
+
+func (r *CustomChibiRepositoryPsql) GetByRoomId(ctx context.Context, roomId uint) ([]*CustomChibiDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	chibis := make([]*CustomChibiDb, 0)
+	result := db.Where("room_id = ?", roomId).Order("custom_chibi_id ASC").Find(&chibis)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return chibis, nil
+}
+
+func (r *CustomChibiRepositoryPsql) GetByOperatorId(ctx context.Context, roomId uint, operatorId string) (*CustomChibiDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var chibi CustomChibiDb
+	result := db.Where("room_id = ? AND operator_id = ?", roomId, operatorId).First(&chibi)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &chibi, nil
+}
+
+func (r *CustomChibiRepositoryPsql) Create(ctx context.Context, chibi *CustomChibiDb, maxPerRoom int) error {
+	return r.DefaultDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serializes concurrent creates in the same room
+		if err := tx.Exec("SELECT 1 FROM rooms WHERE room_id = ? FOR UPDATE", chibi.RoomId).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&CustomChibiDb{}).Where("room_id = ?", chibi.RoomId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(maxPerRoom) {
+			return ErrTooManyCustomChibis
+		}
+		return tx.Create(chibi).Error
+	})
+}
+
+func (r *CustomChibiRepositoryPsql) Save(ctx context.Context, chibi *CustomChibiDb) error {
+	db := r.DefaultDB.WithContext(ctx)
+	return db.Save(chibi).Error
+}
+
+func (r *CustomChibiRepositoryPsql) DeleteByOperatorId(ctx context.Context, roomId uint, operatorId string) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.Where("room_id = ? AND operator_id = ?", roomId, operatorId).Delete(&CustomChibiDb{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package customchibi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+)
+
+const (
+	CUSTOM_CHIBI_MAX_PER_ROOM = 10
+	// Limit on the combined size of the files of a single upload
+	CUSTOM_CHIBI_MAX_UPLOAD_BYTES = 8 * 1024 * 1024
+)
+
+var (
+	ErrUploadsNotSupported = errors.New("the asset store does not support uploads")
+	ErrTooManyCustomChibis = fmt.Errorf("a room can have at most %d custom chibis", CUSTOM_CHIBI_MAX_PER_ROOM)
+)
+
+// CustomChibiService manages the chibis which channel owners upload for their
+// own room. The files are stored in the asset store under
+// operator.CUSTOM_CHIBI_ASSET_SUBDIR and the asset entries in the database.
+type CustomChibiService struct {
+	repo  CustomChibiRepository
+	store akdb.AssetStore
+}
+
+func NewCustomChibiService(repo CustomChibiRepository, store akdb.AssetStore) *CustomChibiService {
+	return &CustomChibiService{
+		repo:  repo,
+		store: store,
+	}
+}
+
+func (s *CustomChibiService) GetCustomChibis(ctx context.Context, roomId uint) ([]*CustomChibiDb, error) {
+	return s.repo.GetByRoomId(ctx, roomId)
+}
+
+// Upload validates and stores a single stance/facing of a custom chibi.
+// Uploading to an existing name adds or replaces that stance/facing. The
+// validation report is returned whenever validation ran, even on failure.
+func (s *CustomChibiService) Upload(
+	ctx context.Context,
+	roomId uint,
+	name string,
+	upload *operator.CustomChibiUpload,
+) (*CustomChibiDb, *operator.AssetValidationReport, error) {
+	writer, ok := s.store.(akdb.AssetWriter)
+	if !ok {
+		return nil, nil, ErrUploadsNotSupported
+	}
+	operatorId, err := operator.CustomChibiOperatorId(roomId, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	upload.OperatorId = operatorId
+
+	chibi, err := s.repo.GetByOperatorId(ctx, roomId, operatorId)
+	if err != nil {
+		return nil, nil, err
+	}
+	isNew := chibi == nil
+	entry := &operator.ChibiAssetPathEntry{Skins: make(map[string]*operator.SpineSkinData)}
+	if isNew {
+		existing, err := s.repo.GetByRoomId(ctx, roomId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(existing) >= CUSTOM_CHIBI_MAX_PER_ROOM {
+			return nil, nil, ErrTooManyCustomChibis
+		}
+		chibi = &CustomChibiDb{
+			RoomId:     roomId,
+			OperatorId: operatorId,
+		}
+	} else {
+		entry, err = chibi.GetAssetEntry()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	previous := *chibi
+
+	spineData, files, report, err := operator.ValidateCustomChibiUpload(upload)
+	if err != nil {
+		return nil, report, err
+	}
+
+	skin, ok := entry.Skins[operator.DEFAULT_SKIN_NAME]
+	if !ok {
+		skin = &operator.SpineSkinData{
+			Base:   make(map[operator.ChibiFacingEnum]*operator.SpineData),
+			Battle: make(map[operator.ChibiFacingEnum]*operator.SpineData),
+		}
+		entry.Skins[operator.DEFAULT_SKIN_NAME] = skin
+	}
+	if upload.Stance == operator.CHIBI_STANCE_ENUM_BASE {
+		skin.Base[upload.Facing] = spineData
+	} else {
+		skin.Battle[upload.Facing] = spineData
+	}
+
+	// The files of the replaced stance/facing which the upload didn't
+	// overwrite are no longer referenced
+	dir := operator.CustomChibiAssetDir(operatorId, upload.Stance, upload.Facing) + "/"
+	assetFiles := make([]string, 0, len(chibi.AssetFiles)+len(files))
+	staleFiles := make([]string, 0)
+	for _, assetPath := range chibi.AssetFiles {
+		if !strings.HasPrefix(assetPath, dir) {
+			assetFiles = append(assetFiles, assetPath)
+		} else if _, ok := files[assetPath]; !ok {
+			staleFiles = append(staleFiles, assetPath)
+		}
+	}
+	for assetPath := range files {
+		assetFiles = append(assetFiles, assetPath)
+	}
+	sort.Strings(assetFiles)
+
+	chibi.Name = name
+	chibi.AssetFiles = assetFiles
+	if err := chibi.SetAssetEntry(entry); err != nil {
+		return nil, report, err
+	}
+	// Saving first means the cap and any other database failure are hit
+	// before anything is written to the asset store
+	if isNew {
+		err = s.repo.Create(ctx, chibi, CUSTOM_CHIBI_MAX_PER_ROOM)
+	} else {
+		err = s.repo.Save(ctx, chibi)
+	}
+	if err != nil {
+		return nil, report, err
+	}
+
+	written := make([]string, 0, len(files))
+	for assetPath, data := range files {
+		if err := writer.PutAsset(ctx, assetPath, data); err != nil {
+			s.revertUpload(ctx, writer, chibi, &previous, isNew, written)
+			return nil, report, fmt.Errorf("failed to store %s: %w", assetPath, err)
+		}
+		written = append(written, assetPath)
+	}
+	s.deleteAssets(ctx, writer, staleFiles)
+	log.Printf("Uploaded custom chibi %s (%s %s) for room %d\n", operatorId, upload.Stance, upload.Facing, roomId)
+	return chibi, report, nil
+}
+
+// revertUpload undoes the saved chibi after its files failed to be stored
+// and deletes the written files which the previous version didn't use.
+// Files which the previous version used were overwritten in place and are
+// kept.
+func (s *CustomChibiService) revertUpload(
+	ctx context.Context,
+	writer akdb.AssetWriter,
+	chibi *CustomChibiDb,
+	previous *CustomChibiDb,
+	isNew bool,
+	written []string,
+) {
+	// The request context may be what failed the upload
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	if isNew {
+		err = s.repo.DeleteByOperatorId(ctx, chibi.RoomId, chibi.OperatorId)
+	} else {
+		chibi.Name = previous.Name
+		chibi.AssetEntry = previous.AssetEntry
+		chibi.AssetFiles = previous.AssetFiles
+		err = s.repo.Save(ctx, chibi)
+	}
+	if err != nil {
+		log.Printf("Failed to revert custom chibi %s: %v\n", chibi.OperatorId, err)
+	}
+
+	previousFiles := make(map[string]bool, len(previous.AssetFiles))
+	for _, assetPath := range previous.AssetFiles {
+		previousFiles[assetPath] = true
+	}
+	orphaned := make([]string, 0, len(written))
+	for _, assetPath := range written {
+		if !previousFiles[assetPath] {
+			orphaned = append(orphaned, assetPath)
+		}
+	}
+	s.deleteAssets(ctx, writer, orphaned)
+}
+
+// DeleteCustomChibi removes the chibi from the room along with its files in
+// the asset store.
+func (s *CustomChibiService) DeleteCustomChibi(ctx context.Context, roomId uint, operatorId string) error {
+	chibi, err := s.repo.GetByOperatorId(ctx, roomId, operatorId)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteByOperatorId(ctx, roomId, operatorId); err != nil {
+		return err
+	}
+	if writer, ok := s.store.(akdb.AssetWriter); ok && chibi != nil {
+		s.deleteAssets(ctx, writer, chibi.AssetFiles)
+	}
+	return nil
+}
+
+// deleteAssets removes files which are no longer referenced by any chibi.
+// Failures are only logged since the chibi itself is already updated.
+func (s *CustomChibiService) deleteAssets(ctx context.Context, writer akdb.AssetWriter, assetPaths []string) {
+	for _, assetPath := range assetPaths {
+		if err := writer.DeleteAsset(ctx, assetPath); err != nil {
+			log.Printf("Failed to delete custom chibi asset %s: %v\n", assetPath, err)
+		}
+	}
+}
+
+// LoadCustomAssets replaces the contents of custom with the room's chibis.
+// Chibis with an unreadable asset entry are skipped.
+func (s *CustomChibiService) LoadCustomAssets(ctx context.Context, roomId uint, custom *operator.CustomAssets) error {
+	chibis, err := s.repo.GetByRoomId(ctx, roomId)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string, len(chibis))
+	entries := make(map[string]*operator.ChibiAssetPathEntry, len(chibis))
+	for _, chibi := range chibis {
+		entry, err := chibi.GetAssetEntry()
+		if err != nil {
+			log.Printf("Skipping custom chibi %s: %v\n", chibi.OperatorId, err)
+			continue
+		}
+		names[chibi.OperatorId] = chibi.Name
+		entries[chibi.OperatorId] = entry
+	}
+	custom.Reset(names, entries)
+	return nil
+}
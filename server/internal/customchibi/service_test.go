@@ -0,0 +1,191 @@
+package customchibi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+	"github.com/stretchr/testify/assert"
+)
+
+type readOnlyStore struct {
+	akdb.AssetStore
+}
+
+// failingStore fails every PutAsset after the first numPuts
+type failingStore struct {
+	*akdb.FakeAssetStore
+	numPuts int
+}
+
+func (s *failingStore) PutAsset(ctx context.Context, filePath string, data []byte) error {
+	if s.numPuts <= 0 {
+		return errors.New("put failed")
+	}
+	s.numPuts -= 1
+	return s.FakeAssetStore.PutAsset(ctx, filePath, data)
+}
+
+func newTestPng() []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	return buf.Bytes()
+}
+
+func newTestUpload(stance operator.ChibiStanceEnum) *operator.CustomChibiUpload {
+	return &operator.CustomChibiUpload{
+		Stance: stance,
+		Facing: operator.CHIBI_FACING_ENUM_FRONT,
+		Files: map[string][]byte{
+			"kitty.atlas":     []byte("\nkitty.png\nsize: 10,10\nregion\n  bounds: 0,0,1,1\n"),
+			"kitty.png":       newTestPng(),
+			"kitty.skel.json": []byte(`{"animations": {"Move": {}, "Relax": {}, "Idle": {}}}`),
+		},
+	}
+}
+
+func TestCustomChibiService_Upload(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	store := akdb.NewFakeAssetStore()
+	sut := NewCustomChibiService(NewFakeCustomChibiRepository(), store)
+
+	chibi, report, err := sut.Upload(ctx, 3, "Kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.Nil(err)
+	assert.NotNil(report)
+	assert.Equal("custom_3_kitty", chibi.OperatorId)
+	asset, err := store.GetAsset(ctx, "uploads/custom_3_kitty/default/base/Front/kitty.atlas")
+	assert.Nil(err)
+	assert.NotEmpty(asset.Data)
+
+	// Uploading the same name adds the stance to the existing chibi
+	_, _, err = sut.Upload(ctx, 3, "kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BATTLE))
+	assert.Nil(err)
+	chibis, err := sut.GetCustomChibis(ctx, 3)
+	assert.Nil(err)
+	assert.Len(chibis, 1)
+	entry, err := chibis[0].GetAssetEntry()
+	assert.Nil(err)
+	skin := entry.Skins[operator.DEFAULT_SKIN_NAME]
+	assert.Len(skin.Base, 1)
+	assert.Len(skin.Battle, 1)
+	assert.Equal("uploads/custom_3_kitty/default/base/Front/kitty.atlas", skin.Base[operator.CHIBI_FACING_ENUM_FRONT].AtlasFilepath)
+
+	custom := operator.NewCustomAssets()
+	assert.Nil(sut.LoadCustomAssets(ctx, 3, custom))
+	opService := operator.NewDefaultOperatorService(operator.NewTestAssetService())
+	opService.SetCustomAssets(custom)
+	operatorId, matches := opService.GetOperatorIdFromName("kitty", operator.FACTION_ENUM_OPERATOR)
+	assert.Nil(matches)
+	assert.Equal("custom_3_kitty", operatorId)
+
+	assert.Nil(sut.DeleteCustomChibi(ctx, 3, "custom_3_kitty"))
+	assert.NotNil(sut.DeleteCustomChibi(ctx, 3, "custom_3_kitty"))
+	// The files are deleted along with the chibi
+	assert.Empty(store.Assets)
+}
+
+func TestCustomChibiService_UploadReplacesStaleFiles(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	store := akdb.NewFakeAssetStore()
+	sut := NewCustomChibiService(NewFakeCustomChibiRepository(), store)
+
+	_, _, err := sut.Upload(ctx, 3, "kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.Nil(err)
+	_, _, err = sut.Upload(ctx, 3, "kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BATTLE))
+	assert.Nil(err)
+
+	upload := newTestUpload(operator.CHIBI_STANCE_ENUM_BASE)
+	upload.Files = map[string][]byte{
+		"cat.atlas":     []byte("\ncat.png\nsize: 10,10\nregion\n  bounds: 0,0,1,1\n"),
+		"cat.png":       upload.Files["kitty.png"],
+		"cat.skel.json": upload.Files["kitty.skel.json"],
+	}
+	chibi, _, err := sut.Upload(ctx, 3, "kitty", upload)
+	assert.Nil(err)
+	assert.Equal([]string{
+		"uploads/custom_3_kitty/default/base/Front/cat.animations.json",
+		"uploads/custom_3_kitty/default/base/Front/cat.atlas",
+		"uploads/custom_3_kitty/default/base/Front/cat.png",
+		"uploads/custom_3_kitty/default/base/Front/cat.skel.json",
+		"uploads/custom_3_kitty/default/battle/Front/kitty.animations.json",
+		"uploads/custom_3_kitty/default/battle/Front/kitty.atlas",
+		"uploads/custom_3_kitty/default/battle/Front/kitty.png",
+		"uploads/custom_3_kitty/default/battle/Front/kitty.skel.json",
+	}, []string(chibi.AssetFiles))
+	_, err = store.GetAsset(ctx, "uploads/custom_3_kitty/default/base/Front/kitty.atlas")
+	assert.ErrorIs(err, akdb.ErrAssetNotFound)
+	assert.Len(store.Assets, len(chibi.AssetFiles))
+}
+
+func TestCustomChibiService_UploadInvalid(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	store := akdb.NewFakeAssetStore()
+	sut := NewCustomChibiService(NewFakeCustomChibiRepository(), store)
+
+	upload := newTestUpload(operator.CHIBI_STANCE_ENUM_BASE)
+	delete(upload.Files, "kitty.png")
+	_, report, err := sut.Upload(ctx, 3, "Kitty", upload)
+	assert.NotNil(err)
+	assert.True(report.HasFailures(false))
+	assert.Empty(store.Assets)
+
+	_, _, err = sut.Upload(ctx, 3, "Kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.Nil(err)
+
+	_, _, err = NewCustomChibiService(NewFakeCustomChibiRepository(), readOnlyStore{store}).
+		Upload(ctx, 3, "Kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.ErrorIs(err, ErrUploadsNotSupported)
+}
+
+func TestCustomChibiService_MaxPerRoom(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	sut := NewCustomChibiService(NewFakeCustomChibiRepository(), akdb.NewFakeAssetStore())
+	for i := 0; i < CUSTOM_CHIBI_MAX_PER_ROOM; i++ {
+		_, _, err := sut.Upload(ctx, 3, string(rune('a'+i)), newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+		assert.Nil(err)
+	}
+	_, _, err := sut.Upload(ctx, 3, "one_more", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.NotNil(err)
+	// Other rooms have their own limit
+	_, _, err = sut.Upload(ctx, 4, "one_more", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.Nil(err)
+}
+
+func TestCustomChibiService_UploadCleansUpOnFailure(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	store := &failingStore{FakeAssetStore: akdb.NewFakeAssetStore(), numPuts: 2}
+	repo := NewFakeCustomChibiRepository()
+	sut := NewCustomChibiService(repo, store)
+
+	// A new chibi is removed again along with the files already written
+	_, _, err := sut.Upload(ctx, 3, "kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.NotNil(err)
+	assert.Empty(repo.Chibis)
+	assert.Empty(store.Assets)
+
+	// An existing chibi goes back to its previous files
+	store.numPuts = 100
+	before, _, err := sut.Upload(ctx, 3, "kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BASE))
+	assert.Nil(err)
+	beforeFiles := append([]string{}, before.AssetFiles...)
+	beforeEntry := append([]byte{}, before.AssetEntry...)
+	store.numPuts = 2
+	_, _, err = sut.Upload(ctx, 3, "kitty", newTestUpload(operator.CHIBI_STANCE_ENUM_BATTLE))
+	assert.NotNil(err)
+	chibis, err := sut.GetCustomChibis(ctx, 3)
+	assert.Nil(err)
+	assert.Len(chibis, 1)
+	assert.Equal(beforeFiles, []string(chibis[0].AssetFiles))
+	assert.Equal(beforeEntry, chibis[0].AssetEntry)
+	assert.Len(store.Assets, len(beforeFiles))
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+)
+
+const (
+	EVENTS_SUBSCRIPTION_BUFFER = 64
+)
+
+var ErrHubShutdown = errors.New("event hub is shutdown")
+
+// Publisher is implemented by anything which wants to hear about live
+// room, websocket and chibi events. Publish must never block the caller.
+type Publisher interface {
+	Publish(roomId uint, event EventType, data interface{})
+}
+
+type Subscription struct {
+	// Only events for this room are delivered. 0 means all rooms
+	roomId uint
+	// Only these events are delivered. Empty means all events
+	eventTypes []EventType
+
+	// Closed when the subscription is removed or the hub is shutdown
+	C chan *Event
+}
+
+func (s *Subscription) matches(roomId uint, event EventType) bool {
+	if s.roomId != 0 && s.roomId != roomId {
+		return false
+	}
+	return len(s.eventTypes) == 0 || slices.Contains(s.eventTypes, event)
+}
+
+// Hub fans out published events to all of the matching subscriptions.
+type Hub struct {
+	mutex         sync.Mutex
+	nextEventId   uint64
+	subscriptions map[*Subscription]struct{}
+	isShutdown    bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(roomId uint, eventTypes []EventType) (*Subscription, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.isShutdown {
+		return nil, ErrHubShutdown
+	}
+
+	sub := &Subscription{
+		roomId:     roomId,
+		eventTypes: eventTypes,
+		C:          make(chan *Event, EVENTS_SUBSCRIPTION_BUFFER),
+	}
+	h.subscriptions[sub] = struct{}{}
+	return sub, nil
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if _, ok := h.subscriptions[sub]; !ok {
+		return
+	}
+	delete(h.subscriptions, sub)
+	close(sub.C)
+}
+
+// Publish sends the event to every matching subscription. Subscribers which
+// have fallen behind and have a full buffer miss the event.
+func (h *Hub) Publish(roomId uint, event EventType, data interface{}) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.isShutdown {
+		return
+	}
+
+	h.nextEventId += 1
+	ev := &Event{
+		EventId:   h.nextEventId,
+		Event:     event,
+		RoomId:    roomId,
+		Timestamp: misc.Clock.Now(),
+		Data:      data,
+	}
+	for sub := range h.subscriptions {
+		if !sub.matches(roomId, event) {
+			continue
+		}
+		select {
+		case sub.C <- ev:
+		default:
+		}
+	}
+}
+
+func (h *Hub) NumSubscriptions() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return len(h.subscriptions)
+}
+
+// Shutdown closes all the subscriptions so that any open streams can finish
+func (h *Hub) Shutdown() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.isShutdown = true
+	for sub := range h.subscriptions {
+		close(sub.C)
+	}
+	clear(h.subscriptions)
+}
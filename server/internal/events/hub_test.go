@@ -0,0 +1,76 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub_PublishFiltersByRoomAndEvent(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewHub()
+
+	all, err := sut.Subscribe(0, nil)
+	assert.Nil(err)
+	room1, err := sut.Subscribe(1, nil)
+	assert.Nil(err)
+	chibis, err := sut.Subscribe(0, []EventType{EVENT_CHIBI_SET})
+	assert.Nil(err)
+
+	sut.Publish(1, EVENT_ROOM_CREATED, nil)
+	sut.Publish(2, EVENT_CHIBI_SET, CommandErrorEventData{Username: "user1"})
+
+	assert.Len(all.C, 2)
+	assert.Len(room1.C, 1)
+	assert.Len(chibis.C, 1)
+
+	ev := <-all.C
+	assert.Equal(uint64(1), ev.EventId)
+	assert.Equal(EVENT_ROOM_CREATED, ev.Event)
+	ev = <-all.C
+	assert.Equal(uint64(2), ev.EventId)
+	assert.Equal(uint(2), ev.RoomId)
+
+	ev = <-room1.C
+	assert.Equal(EVENT_ROOM_CREATED, ev.Event)
+	ev = <-chibis.C
+	assert.Equal(EVENT_CHIBI_SET, ev.Event)
+	assert.Equal("user1", ev.Data.(CommandErrorEventData).Username)
+}
+
+func TestHub_PublishDoesNotBlockOnFullSubscription(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewHub()
+
+	sub, err := sut.Subscribe(0, nil)
+	assert.Nil(err)
+	for range EVENTS_SUBSCRIPTION_BUFFER + 10 {
+		sut.Publish(1, EVENT_COMMAND_ERROR, nil)
+	}
+	assert.Len(sub.C, EVENTS_SUBSCRIPTION_BUFFER)
+}
+
+func TestHub_UnsubscribeAndShutdown(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewHub()
+
+	sub1, _ := sut.Subscribe(0, nil)
+	sub2, _ := sut.Subscribe(0, nil)
+	assert.Equal(2, sut.NumSubscriptions())
+
+	sut.Unsubscribe(sub1)
+	sut.Unsubscribe(sub1)
+	_, ok := <-sub1.C
+	assert.False(ok)
+	assert.Equal(1, sut.NumSubscriptions())
+
+	sut.Shutdown()
+	_, ok = <-sub2.C
+	assert.False(ok)
+	assert.Equal(0, sut.NumSubscriptions())
+	sut.Unsubscribe(sub2)
+
+	_, err := sut.Subscribe(0, nil)
+	assert.ErrorIs(err, ErrHubShutdown)
+	sut.Publish(1, EVENT_ROOM_CLOSED, nil)
+}
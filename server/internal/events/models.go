@@ -0,0 +1,57 @@
+package events
+
+import (
+	"fmt"
+	"time"
+)
+
+type EventType string
+
+const (
+	EVENT_ROOM_CREATED           = EventType("room_created")
+	EVENT_ROOM_CLOSED            = EventType("room_closed")
+	EVENT_WEBSOCKET_CONNECTED    = EventType("websocket_connected")
+	EVENT_WEBSOCKET_DISCONNECTED = EventType("websocket_disconnected")
+	EVENT_CHIBI_SET              = EventType("chibi_set")
+	EVENT_CHIBI_REMOVED          = EventType("chibi_removed")
+	EVENT_COMMAND_ERROR          = EventType("command_error")
+)
+
+var AllEventTypes = []EventType{
+	EVENT_ROOM_CREATED,
+	EVENT_ROOM_CLOSED,
+	EVENT_WEBSOCKET_CONNECTED,
+	EVENT_WEBSOCKET_DISCONNECTED,
+	EVENT_CHIBI_SET,
+	EVENT_CHIBI_REMOVED,
+	EVENT_COMMAND_ERROR,
+}
+
+func EventType_Parse(str string) (EventType, error) {
+	for _, event := range AllEventTypes {
+		if string(event) == str {
+			return event, nil
+		}
+	}
+	return "", fmt.Errorf("invalid event (%s)", str)
+}
+
+// Event is what gets written to the event stream subscribers
+type Event struct {
+	EventId   uint64      `json:"event_id"`
+	Event     EventType   `json:"event"`
+	RoomId    uint        `json:"room_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+type ConnectionEventData struct {
+	ConnectionId   string `json:"connection_id"`
+	NumConnections int    `json:"num_connections"`
+}
+
+type CommandErrorEventData struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+	Error    string `json:"error"`
+}
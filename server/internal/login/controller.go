@@ -13,6 +13,8 @@ import (
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
 	"golang.org/x/oauth2"
 )
@@ -20,19 +22,28 @@ import (
 type LoginServer struct {
 	assetDir    string
 	authService *auth.AuthService
-	usersRepo   users.UserRepository
+	usersRepo     users.UserRepository
+	roomRepo      room.RoomRepository
+	roomPermsRepo room.RoomPermissionRepository
+	twitchClient  twitch_api.TwitchApiClientInterface
 }
 
 func NewLoginServer(
 	assetDir misc.StaticAssetDirString,
 	authService *auth.AuthService,
 	usersRepo users.UserRepository,
+	roomRepo room.RoomRepository,
+	roomPermsRepo room.RoomPermissionRepository,
+	twitchClient twitch_api.TwitchApiClientInterface,
 ) (*LoginServer, error) {
 	log.Println("NewLoginServer created")
 	return &LoginServer{
-		assetDir:    string(assetDir),
-		authService: authService,
-		usersRepo:   usersRepo,
+		assetDir:      string(assetDir),
+		authService:   authService,
+		usersRepo:     usersRepo,
+		roomRepo:      roomRepo,
+		roomPermsRepo: roomPermsRepo,
+		twitchClient:  twitchClient,
 	}, nil
 }
 
@@ -50,6 +61,13 @@ func (s *LoginServer) RegisterHandlers(rootMux *http.ServeMux) error {
 	mux.Handle("GET /auth/token/{$}", s.middlewareNoAuthCheck(s.HandleGetToken))
 	mux.Handle("GET /auth/token/validate/{$}", s.middlewareNoAuthCheck(s.HandleValidateToken))
 
+	mux.Handle("GET /auth/login/twitch/bot/{$}", s.middlewareNoAuthCheck(s.HandleLoginTwitchBot))
+	mux.Handle("GET /auth/twitch/bot/callback/{$}", s.middlewareNoAuthCheck(s.HandleBotOAuthCallback))
+	mux.Handle("GET /auth/bot/{$}", s.middlewareNoAuthCheck(s.HandleGetBot))
+	mux.Handle("POST /auth/bot/remove/{$}", s.middlewareNoAuthCheck(s.HandleRemoveBot))
+
+	mux.Handle("POST /auth/moderators/sync/{$}", s.middlewareNoAuthCheck(s.HandleSyncModerators))
+
 	rootMux.Handle("/auth/", mux)
 	return nil
 }
@@ -83,7 +101,7 @@ func (s *LoginServer) HandleLoginTwitch(w http.ResponseWriter, r *http.Request)
 	claims := oauth2.SetAuthURLParam("claims", `{"id_token":{}}`)
 	nonce := oauth2.SetAuthURLParam("nonce", jwtNonce)
 	forceVerify := oauth2.SetAuthURLParam("force_verify", "true")
-	scopes := oauth2.SetAuthURLParam("scope", "channel:bot openid")
+	scopes := oauth2.SetAuthURLParam("scope", "channel:bot openid moderation:read")
 	http.Redirect(w, r, s.authService.Oauth2Config.AuthCodeURL(state, claims, nonce, scopes, forceVerify), http.StatusTemporaryRedirect)
 	return nil
 }
@@ -273,3 +291,185 @@ func (s *LoginServer) HandleLogout(w http.ResponseWriter, r *http.Request) error
 	delete(session.Values, auth.OAUTH_TOKEN_KEY)
 	return nil
 }
+
+// getOwnedRoom returns the room belonging to the currently logged in user.
+func (s *LoginServer) getOwnedRoom(w http.ResponseWriter, r *http.Request) (*room.RoomDb, error) {
+	authInfo, err := s.authService.HasAuthorizedSession(w, r)
+	if err != nil {
+		return nil, misc.NewHumanReadableError("not logged in", http.StatusUnauthorized, err)
+	}
+	roomDb, err := s.roomRepo.GetRoomByChannelName(r.Context(), authInfo.User.Username)
+	if err != nil {
+		return nil, misc.NewHumanReadableError(
+			"room not found, open your room at least once before adding a bot",
+			http.StatusNotFound,
+			err,
+		)
+	}
+	return roomDb, nil
+}
+
+// HandleLoginTwitchBot starts the OAUTH flow in which a channel owner
+// authorizes a custom bot account to talk in their channel. The owner must
+// already be logged in with their own account.
+func (s *LoginServer) HandleLoginTwitchBot(w http.ResponseWriter, r *http.Request) error {
+	if !s.authService.BotAccountsEnabled() {
+		return misc.NewHumanReadableError("custom bot accounts are not enabled", http.StatusNotFound, errors.New("bot accounts disabled"))
+	}
+	if _, err := s.getOwnedRoom(w, r); err != nil {
+		return err
+	}
+	session, err := s.authService.CookieStore.Get(r, auth.OAUTH_SESSION_NAME)
+	if err != nil {
+		return err
+	}
+
+	var tokenBytes [128]byte
+	if _, err := rand.Read(tokenBytes[:]); err != nil {
+		return fmt.Errorf("couldn't generate a session! %s", err)
+	}
+	state := hex.EncodeToString(tokenBytes[:])
+	session.AddFlash(state, auth.STATE_BOT_CALLBACK_KEY)
+	if err = session.Save(r, w); err != nil {
+		return err
+	}
+
+	// Force verify so that the owner gets a chance to switch to the bot account
+	forceVerify := oauth2.SetAuthURLParam("force_verify", "true")
+	http.Redirect(w, r, s.authService.BotOauth2Config.AuthCodeURL(state, forceVerify), http.StatusTemporaryRedirect)
+	return nil
+}
+
+func (s *LoginServer) HandleBotOAuthCallback(w http.ResponseWriter, r *http.Request) error {
+	if !s.authService.BotAccountsEnabled() {
+		return misc.NewHumanReadableError("custom bot accounts are not enabled", http.StatusNotFound, errors.New("bot accounts disabled"))
+	}
+	session, err := s.authService.CookieStore.Get(r, auth.OAUTH_SESSION_NAME)
+	if err != nil {
+		log.Printf("corrupted session %s -- generated new", err)
+		err = nil
+	}
+
+	// ensure we flush the csrf challenge even if the request is ultimately unsuccessful
+	defer func() {
+		session.Flashes(auth.STATE_BOT_CALLBACK_KEY)
+		if err := session.Save(r, w); err != nil {
+			log.Printf("error saving session: %s", err)
+		}
+	}()
+
+	stateFlash := session.Flashes(auth.STATE_BOT_CALLBACK_KEY)
+	stateFromForm := r.FormValue("state")
+	switch stateChallenge, state := stateFlash, stateFromForm; {
+	case state == "", len(stateChallenge) < 1:
+		err = errors.New("missing state challenge")
+	case state != stateChallenge[0]:
+		err = fmt.Errorf("invalid oauth state, expected '%s', got '%s'", state, stateChallenge[0])
+	}
+	if err != nil {
+		http.Redirect(w, r, "/login/callback?status=failed", http.StatusTemporaryRedirect)
+		return fmt.Errorf("couldn't verify your confirmation, please try again. %s", err)
+	}
+	if r.FormValue("error") != "" {
+		http.Redirect(w, r, "/login/callback?status=failed", http.StatusTemporaryRedirect)
+		return fmt.Errorf("couldn't verify your confirmation, please try again. %s", r.FormValue("error"))
+	}
+
+	// The owner's session is still for their own account. The bot identity
+	// comes from the newly exchanged token.
+	roomDb, err := s.getOwnedRoom(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login/callback?status=failed", http.StatusTemporaryRedirect)
+		return err
+	}
+	token, err := s.authService.BotOauth2Config.Exchange(context.Background(), r.FormValue("code"))
+	if err != nil {
+		log.Println("Failed to exchange bot token")
+		http.Redirect(w, r, "/login/callback?status=failed", http.StatusTemporaryRedirect)
+		return err
+	}
+	creds, err := s.authService.SaveRoomBotToken(r.Context(), roomDb.RoomId, token)
+	if err != nil {
+		http.Redirect(w, r, "/login/callback?status=failed", http.StatusTemporaryRedirect)
+		return err
+	}
+	log.Println("Room", roomDb.ChannelName, "authorized bot account", creds.BotUsername)
+
+	http.Redirect(w, r, "/login/callback?status=success", http.StatusTemporaryRedirect)
+	return nil
+}
+
+func (s *LoginServer) HandleGetBot(w http.ResponseWriter, r *http.Request) error {
+	roomDb, err := s.getOwnedRoom(w, r)
+	if err != nil {
+		return err
+	}
+	creds, err := s.authService.GetRoomBotCredentials(r.Context(), roomDb.RoomId)
+	if err != nil {
+		return err
+	}
+
+	resp := &RoomBotResponse{
+		Enabled: s.authService.BotAccountsEnabled(),
+	}
+	if creds != nil {
+		resp.BotUsername = creds.BotUsername
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func (s *LoginServer) HandleRemoveBot(w http.ResponseWriter, r *http.Request) error {
+	roomDb, err := s.getOwnedRoom(w, r)
+	if err != nil {
+		return err
+	}
+	return s.authService.RemoveRoomBotToken(r.Context(), roomDb.RoomId)
+}
+
+// HandleSyncModerators grants the editor role for the owner's room to all the
+// moderators of their twitch channel. This needs the owner's own OAUTH token
+// which is why it lives here instead of in the API server.
+func (s *LoginServer) HandleSyncModerators(w http.ResponseWriter, r *http.Request) error {
+	authInfo, err := s.authService.HasAuthorizedSession(w, r)
+	if err != nil {
+		return misc.NewHumanReadableError("not logged in", http.StatusUnauthorized, err)
+	}
+	roomDb, err := s.getOwnedRoom(w, r)
+	if err != nil {
+		return err
+	}
+	token, err := s.authService.GetSessionToken(r)
+	if err != nil {
+		return misc.NewHumanReadableError("not logged in", http.StatusUnauthorized, err)
+	}
+
+	moderators, err := s.twitchClient.GetModerators(token.AccessToken, authInfo.User.TwitchUserId)
+	if err != nil {
+		return misc.NewHumanReadableError(
+			"Failed to fetch moderators from Twitch. Try logging in again.",
+			http.StatusBadGateway,
+			err,
+		)
+	}
+	userIds := make([]uint, 0, len(moderators))
+	for _, moderator := range moderators {
+		userDb, err := s.usersRepo.GetOrInsertUser(r.Context(), misc.UserInfo{
+			Username:        moderator.UserLogin,
+			UsernameDisplay: moderator.UserName,
+			TwitchUserId:    moderator.UserId,
+		})
+		if err != nil {
+			return err
+		}
+		userIds = append(userIds, userDb.UserId)
+	}
+	if err := s.roomPermsRepo.SyncModerators(r.Context(), roomDb.RoomId, userIds); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(&SyncModeratorsResponse{
+		NumModerators: len(userIds),
+	})
+}
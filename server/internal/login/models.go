@@ -10,3 +10,12 @@ type LoggedInResponse struct {
 type TokenResponse struct {
 	Token string `json:"token"`
 }
+
+type RoomBotResponse struct {
+	Enabled     bool   `json:"enabled"`
+	BotUsername string `json:"bot_user_name"`
+}
+
+type SyncModeratorsResponse struct {
+	NumModerators int `json:"num_moderators"`
+}
@@ -73,12 +73,104 @@ type BotConfig struct {
 	// Secret key used for encrypting JWT tokens. Must be kept safe.
 	JwtSecretKey string `json:"jwt_secret_key"`
 
+	// Optional
+	// Twitch Oauth Redirect URL used when a channel owner authorizes a custom
+	// bot account for their room. Leave empty to disable per-channel bots.
+	TwitchBotOauthRedirectUrl string `json:"twitch_bot_oauth_redirect_url"`
+
+	// Optional
+	// Secret key used to encrypt the stored OAUTH tokens of per-channel bot
+	// accounts. Required if twitch_bot_oauth_redirect_url is set.
+	BotTokenEncryptionKey string `json:"bot_token_encryption_key"`
+
+	// Optional
+	// Where the image assets are served from. Defaults to the postgres
+	// asset_files table.
+	AssetStore *AssetStoreConfig `json:"asset_store"`
+
+	// Optional. Default 0 (disabled)
+	// The interval in seconds to poll the asset index files for changes. When
+	// they change the asset catalog is reloaded without restarting the server.
+	WatchAssetIndexSeconds int `json:"watch_asset_index_seconds"`
+
+	// Optional. Default false
+	// Whether to refuse to start (or reload) when an entry in the asset index
+	// files fails to load. Otherwise the bad entries are skipped and logged.
+	StrictAssetLoading bool `json:"strict_asset_loading"`
+
 	// Optional. Defalt false
 	// Whether to enable the websocket/terminal based text chat.
 	// Only avaiable in development
 	EnableTextTerminalChatBot bool `json:"enable_text_terminal_chat_bot"`
 }
 
+const (
+	ASSET_STORE_BACKEND_POSTGRES   = "postgres"
+	ASSET_STORE_BACKEND_FILESYSTEM = "filesystem"
+	ASSET_STORE_BACKEND_S3         = "s3"
+)
+
+type AssetStoreConfig struct {
+	// One of postgres, filesystem or s3. Default: postgres
+	// The filesystem backend serves from the -image_assetdir directory.
+	Backend string `json:"backend"`
+
+	// Required for the s3 backend. Any S3-compatible endpoint can be used
+	// (ie. https://s3.us-east-1.amazonaws.com or http://localhost:9000)
+	S3Endpoint        string `json:"s3_endpoint"`
+	S3Region          string `json:"s3_region"`
+	S3Bucket          string `json:"s3_bucket"`
+	S3AccessKeyId     string `json:"s3_access_key_id"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+
+	// Optional. Prefix prepended to every object key.
+	S3Prefix string `json:"s3_prefix"`
+
+	// Optional
+	// Size of the in-memory LRU cache in front of the backend. Set to -1 to
+	// disable the cache.
+	// Default: 256 MB
+	CacheSizeMb int `json:"cache_size_mb"`
+
+	// Optional. Default false
+	// Whether to preload the assets of the chibis in all active rooms on startup
+	PreloadActiveAssets bool `json:"preload_active_assets"`
+}
+
+func DefaultAssetStoreConfig() *AssetStoreConfig {
+	return &AssetStoreConfig{
+		Backend:     ASSET_STORE_BACKEND_POSTGRES,
+		CacheSizeMb: 256,
+	}
+}
+
+func ValidateAssetStoreConfig(config *AssetStoreConfig) error {
+	switch config.Backend {
+	case "":
+		config.Backend = ASSET_STORE_BACKEND_POSTGRES
+	case ASSET_STORE_BACKEND_POSTGRES, ASSET_STORE_BACKEND_FILESYSTEM:
+	case ASSET_STORE_BACKEND_S3:
+		if _, err := url.ParseRequestURI(config.S3Endpoint); err != nil {
+			return fmt.Errorf("asset_store.s3_endpoint is not a valid URL: %w", err)
+		}
+		if len(config.S3Bucket) == 0 {
+			return fmt.Errorf("asset_store.s3_bucket must be set")
+		}
+		if len(config.S3AccessKeyId) == 0 || len(config.S3SecretAccessKey) == 0 {
+			return fmt.Errorf("asset_store.s3_access_key_id and s3_secret_access_key must be set")
+		}
+		if len(config.S3Region) == 0 {
+			config.S3Region = "us-east-1"
+		}
+	default:
+		return fmt.Errorf("unknown asset_store.backend %s", config.Backend)
+	}
+	if config.CacheSizeMb == 0 {
+		config.CacheSizeMb = 256
+	}
+	return nil
+}
+
 func LoadBotConfig(path string) (*BotConfig, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -127,22 +219,7 @@ func LoadBotConfig(path string) (*BotConfig, error) {
 	if config.RemoveUnusedRoomsLastChatMinutes == 0 {
 		config.RemoveUnusedRoomsLastChatMinutes = 360
 	}
-	if config.OperatorDetails.Skin == "" {
-		config.OperatorDetails.Skin = "default"
-	}
-	if config.OperatorDetails.Stance == "" {
-		config.OperatorDetails.Stance = "base"
-	}
-	if len(config.OperatorDetails.Animations) == 0 {
-		if config.OperatorDetails.Stance == "base" {
-			config.OperatorDetails.Animations = []string{"Move"}
-		} else {
-			config.OperatorDetails.Animations = []string{"Idle"}
-		}
-	}
-	if config.OperatorDetails.PositionX == 0 {
-		config.OperatorDetails.PositionX = 0.5
-	}
+	config.OperatorDetails.SetDefaults()
 	if config.SpineRuntimeConfig == nil {
 		config.SpineRuntimeConfig = DefaultSpineRuntimeConfig()
 	} else {
@@ -150,12 +227,27 @@ func LoadBotConfig(path string) (*BotConfig, error) {
 			return nil, err
 		}
 	}
+	if config.AssetStore == nil {
+		config.AssetStore = DefaultAssetStoreConfig()
+	} else {
+		if err := ValidateAssetStoreConfig(config.AssetStore); err != nil {
+			return nil, err
+		}
+	}
 	if len(config.CookieSecret) == 0 {
 		return nil, fmt.Errorf("cookie_secret not set in bot config (%s)", path)
 	}
 	if len(config.JwtSecretKey) == 0 {
 		return nil, fmt.Errorf("jwt_secret_key not set in bot config (%s)", path)
 	}
+	if len(config.TwitchBotOauthRedirectUrl) > 0 {
+		if _, err := url.Parse(config.TwitchBotOauthRedirectUrl); err != nil {
+			return nil, fmt.Errorf("twitch_bot_oauth_redirect_url is not a valid URL in bot config (%s): %w", path, err)
+		}
+		if len(config.BotTokenEncryptionKey) == 0 {
+			return nil, fmt.Errorf("bot_token_encryption_key not set in bot config (%s)", path)
+		}
+	}
 
 	return &config, nil
 }
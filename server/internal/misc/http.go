@@ -1,8 +1,13 @@
 package misc
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -78,3 +83,53 @@ func MiddlewareWithTimeout(h HandlerWithErr, timeout time.Duration) http.Handler
 		"",
 	)
 }
+
+// MiddlewareWithDeadline is for long running handlers which must always
+// write their response, such as a report of the work already done. Instead of
+// cutting the response off like MiddlewareWithTimeout, the request context is
+// cancelled after the timeout and the handler is expected to wrap up.
+func MiddlewareWithDeadline(h HandlerWithErr, timeout time.Duration) http.Handler {
+	return Middleware(func(w http.ResponseWriter, r *http.Request) error {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		return h(w, r.WithContext(ctx))
+	})
+}
+
+// WriteJsonWithETag encodes v as the JSON response with an ETag derived from
+// the body. If the request's If-None-Match header already matches then only a
+// 304 Not Modified is written.
+func WriteJsonWithETag(w http.ResponseWriter, r *http.Request, v interface{}, cacheControl string) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if len(cacheControl) > 0 {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(body)
+	return err
+}
+
+func ETagMatches(ifNoneMatch string, etag string) bool {
+	if len(ifNoneMatch) == 0 {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		candidate = strings.TrimPrefix(candidate, "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package misc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJsonWithETag(t *testing.T) {
+	assert := assert.New(t)
+	body := map[string]string{"hello": "world"}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	assert.Nil(WriteJsonWithETag(w, req, body, "public, max-age=60"))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(`{"hello":"world"}`, w.Body.String())
+	assert.Equal("public, max-age=60", w.Header().Get("Cache-Control"))
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(etag)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	w = httptest.NewRecorder()
+	assert.Nil(WriteJsonWithETag(w, req, body, ""))
+	assert.Equal(http.StatusNotModified, w.Code)
+	assert.Empty(w.Body.String())
+	assert.Equal(etag, w.Header().Get("ETag"))
+}
+
+func TestETagMatches(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(ETagMatches("", `"a"`))
+	assert.True(ETagMatches(`"a"`, `"a"`))
+	assert.True(ETagMatches(`*`, `"a"`))
+	assert.False(ETagMatches(`"b", "c"`, `"a"`))
+}
+
+func TestMiddlewareWithDeadline(t *testing.T) {
+	assert := assert.New(t)
+	sut := MiddlewareWithDeadline(func(w http.ResponseWriter, r *http.Request) error {
+		<-r.Context().Done()
+		assert.ErrorIs(r.Context().Err(), context.DeadlineExceeded)
+		// The response written after the deadline still makes it out
+		w.Write([]byte("report"))
+		return nil
+	}, 10*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	sut.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("report", w.Body.String())
+}
@@ -44,6 +44,26 @@ type InitialOperatorDetails struct {
 	PositionX  float64  `json:"position_x"`
 }
 
+// SetDefaults fills in the skin, stance, animations and position if not set
+func (oi *InitialOperatorDetails) SetDefaults() {
+	if oi.Skin == "" {
+		oi.Skin = "default"
+	}
+	if oi.Stance == "" {
+		oi.Stance = "base"
+	}
+	if len(oi.Animations) == 0 {
+		if oi.Stance == "base" {
+			oi.Animations = []string{"Move"}
+		} else {
+			oi.Animations = []string{"Idle"}
+		}
+	}
+	if oi.PositionX == 0 {
+		oi.PositionX = 0.5
+	}
+}
+
 func (oi *InitialOperatorDetails) Scan(value interface{}) error {
 	bytes, ok := value.([]byte)
 	if !ok {
@@ -11,6 +11,11 @@ type MonitorStruct struct {
 	NumWebsocketConnections int
 	NumUsers                int
 	NumCommands             int
+
+	NumAssetCacheHits      int64
+	NumAssetCacheMisses    int64
+	NumAssetCacheEvictions int64
+	AssetCacheBytes        int64
 }
 
 // var GoRunCounter atomic.Int64
@@ -0,0 +1,102 @@
+package misc
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	RANDOM_POOL_MAX_IDS    = 500
+	RANDOM_POOL_MAX_WEIGHT = 1000.0
+)
+
+// RandomPool curates which chibis are randomly given to chatters in a room.
+// The operators are narrowed by the room's random_pool_filter first.
+type RandomPool struct {
+	// Only these operator ids can be picked. Empty for every operator
+	IncludeOperators []string `json:"include_operators"`
+	ExcludeOperators []string `json:"exclude_operators"`
+	// Enemy ids which can be picked along with the operators
+	IncludeEnemies []string `json:"include_enemies"`
+	// Relative weight of an operator/enemy id. Unlisted ids have a weight of 1
+	// and a weight of 0 removes the id from the pool.
+	Weights map[string]float64 `json:"weights"`
+	// Relative weight of the skins whose name contains the key (ie. "summer").
+	// If empty the default skin is always used, otherwise unlisted skins have
+	// a weight of 0 and the default skin a weight of 1.
+	SkinWeights map[string]float64 `json:"skin_weights"`
+}
+
+func DefaultRandomPool() *RandomPool {
+	return &RandomPool{
+		IncludeOperators: []string{},
+		ExcludeOperators: []string{},
+		IncludeEnemies:   []string{},
+		Weights:          map[string]float64{},
+		SkinWeights:      map[string]float64{},
+	}
+}
+
+func (p *RandomPool) IsEmpty() bool {
+	return len(p.IncludeOperators) == 0 &&
+		len(p.ExcludeOperators) == 0 &&
+		len(p.IncludeEnemies) == 0 &&
+		len(p.Weights) == 0 &&
+		len(p.SkinWeights) == 0
+}
+
+// ValidateRandomPool checks the sizes and weights and normalizes the ids to
+// lowercase. It does not check that the ids exist.
+func ValidateRandomPool(pool *RandomPool) error {
+	var err error
+	if pool.IncludeOperators, err = normalizePoolIds("include_operators", pool.IncludeOperators); err != nil {
+		return err
+	}
+	if pool.ExcludeOperators, err = normalizePoolIds("exclude_operators", pool.ExcludeOperators); err != nil {
+		return err
+	}
+	if pool.IncludeEnemies, err = normalizePoolIds("include_enemies", pool.IncludeEnemies); err != nil {
+		return err
+	}
+	if pool.Weights, err = normalizePoolWeights("weights", pool.Weights); err != nil {
+		return err
+	}
+	if pool.SkinWeights, err = normalizePoolWeights("skin_weights", pool.SkinWeights); err != nil {
+		return err
+	}
+	return nil
+}
+
+func normalizePoolIds(field string, ids []string) ([]string, error) {
+	if len(ids) > RANDOM_POOL_MAX_IDS {
+		return nil, fmt.Errorf("%s can have at most %d ids", field, RANDOM_POOL_MAX_IDS)
+	}
+	newIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if len(id) == 0 {
+			return nil, fmt.Errorf("invalid id in %s", field)
+		}
+		newIds = append(newIds, id)
+	}
+	return removeDuplicates(newIds), nil
+}
+
+func normalizePoolWeights(field string, weights map[string]float64) (map[string]float64, error) {
+	if len(weights) > RANDOM_POOL_MAX_IDS {
+		return nil, fmt.Errorf("%s can have at most %d entries", field, RANDOM_POOL_MAX_IDS)
+	}
+	newWeights := make(map[string]float64, len(weights))
+	for key, weight := range weights {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if len(key) == 0 {
+			return nil, fmt.Errorf("invalid key in %s", field)
+		}
+		if math.IsNaN(weight) || weight < 0 || weight > RANDOM_POOL_MAX_WEIGHT {
+			return nil, fmt.Errorf("%s must be between 0 and %v", field, RANDOM_POOL_MAX_WEIGHT)
+		}
+		newWeights[key] = weight
+	}
+	return newWeights, nil
+}
@@ -0,0 +1,58 @@
+package misc
+
+import (
+	"sync"
+	"time"
+)
+
+// RateCounter counts events in one minute buckets over a rolling window so
+// that the recent rate can be reported (ie. chat commands per minute).
+type RateCounter struct {
+	mutex   sync.Mutex
+	buckets []int
+	// The unix minute which each bucket is counting
+	bucketMinutes []int64
+	total         int
+}
+
+func NewRateCounter(windowMins int) *RateCounter {
+	return &RateCounter{
+		buckets:       make([]int, windowMins),
+		bucketMinutes: make([]int64, windowMins),
+	}
+}
+
+func (c *RateCounter) Add(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	minute := now.Unix() / 60
+	index := int(minute % int64(len(c.buckets)))
+	if c.bucketMinutes[index] != minute {
+		c.bucketMinutes[index] = minute
+		c.buckets[index] = 0
+	}
+	c.buckets[index] += 1
+	c.total += 1
+}
+
+// PerMinute returns the average number of events per minute over the window
+func (c *RateCounter) PerMinute(now time.Time) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	minute := now.Unix() / 60
+	windowMins := int64(len(c.buckets))
+	count := 0
+	for index, bucketMinute := range c.bucketMinutes {
+		if minute-bucketMinute >= 0 && minute-bucketMinute < windowMins {
+			count += c.buckets[index]
+		}
+	}
+	return float64(count) / float64(windowMins)
+}
+
+// Total returns the number of events since the counter was created
+func (c *RateCounter) Total() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.total
+}
@@ -0,0 +1,30 @@
+package misc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateCounter(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewRateCounter(5)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := range 10 {
+		sut.Add(start.Add(time.Duration(i) * time.Second))
+	}
+	sut.Add(start.Add(2 * time.Minute))
+	assert.Equal(11, sut.Total())
+	assert.InDelta(11.0/5.0, sut.PerMinute(start.Add(2*time.Minute)), 0.001)
+
+	// The first minute falls out of the window
+	assert.InDelta(1.0/5.0, sut.PerMinute(start.Add(5*time.Minute)), 0.001)
+	assert.InDelta(0.0, sut.PerMinute(start.Add(10*time.Minute)), 0.001)
+
+	// Buckets are reused once the window wraps around
+	sut.Add(start.Add(5 * time.Minute))
+	assert.InDelta(2.0/5.0, sut.PerMinute(start.Add(5*time.Minute)), 0.001)
+	assert.Equal(12, sut.Total())
+}
@@ -24,6 +24,17 @@ type SpineRuntimeConfig struct {
 	MaxMovementSpeed         float64 `json:"max_movement_speed"`
 
 	UsernamesBlacklist []string `json:"usernames_blacklist"`
+
+	// Operator filter terms (ie. 6star, caster) which limit the operators
+	// randomly given to chatters. Empty to use all operators.
+	RandomPoolFilter []string `json:"random_pool_filter"`
+	// Include/exclude lists and weights of the random chibis
+	RandomPool RandomPool `json:"random_pool"`
+	// Chatters without saved preferences always get the same random chibi,
+	// derived from their twitch user id and the ChibiSeed.
+	SeededChibis bool `json:"seeded_chibis"`
+	// Changing the seed reshuffles every chatter's seeded chibi
+	ChibiSeed int64 `json:"chibi_seed"`
 }
 
 func DefaultSpineRuntimeConfig() *SpineRuntimeConfig {
@@ -42,6 +53,8 @@ func DefaultSpineRuntimeConfig() *SpineRuntimeConfig {
 		MinMovementSpeed:         0.1,
 		MaxMovementSpeed:         2.0,
 		UsernamesBlacklist:       []string{},
+		RandomPoolFilter:         []string{},
+		RandomPool:               *DefaultRandomPool(),
 	}
 }
 
@@ -87,6 +100,19 @@ func ValidateSpineRuntimeConfig(config *SpineRuntimeConfig) error {
 	}
 	config.UsernamesBlacklist = removeDuplicates(newUsernames)
 
+	newFilter := make([]string, 0)
+	for _, term := range config.RandomPoolFilter {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if len(term) == 0 {
+			return fmt.Errorf("invalid term in random_pool_filter")
+		}
+		newFilter = append(newFilter, term)
+	}
+	config.RandomPoolFilter = removeDuplicates(newFilter)
+	if err := ValidateRandomPool(&config.RandomPool); err != nil {
+		return err
+	}
+
 	return nil
 }
 
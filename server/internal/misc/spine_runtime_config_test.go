@@ -75,3 +75,20 @@ func TestValidateSpineRuntimeConfig(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "min_movement_speed must be less than max_movement_speed")
 }
+
+func TestValidateRandomPool(t *testing.T) {
+	assert := assert.New(t)
+	config := DefaultSpineRuntimeConfig()
+	config.RandomPool.IncludeOperators = []string{" Char_002_Amiya ", "char_002_amiya"}
+	config.RandomPool.SkinWeights = map[string]float64{"Summer": 2}
+	assert.NoError(ValidateSpineRuntimeConfig(config))
+	assert.Equal([]string{"char_002_amiya"}, config.RandomPool.IncludeOperators)
+	assert.Equal(map[string]float64{"summer": 2}, config.RandomPool.SkinWeights)
+
+	config.RandomPool.Weights = map[string]float64{"char_002_amiya": -1}
+	assert.ErrorContains(ValidateSpineRuntimeConfig(config), "weights must be between")
+
+	config = DefaultSpineRuntimeConfig()
+	config.RandomPool.ExcludeOperators = []string{""}
+	assert.ErrorContains(ValidateSpineRuntimeConfig(config), "invalid id in exclude_operators")
+}
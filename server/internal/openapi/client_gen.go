@@ -0,0 +1,291 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+// GenerateClient writes the Go source of a typed client for the document.
+// Every component schema becomes a struct and every operation a method on
+// the package's Client, which is expected to provide:
+//
+//	func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body interface{}, out interface{}) error
+//
+// Operations which stream their response or take a multipart upload are not
+// generated.
+func GenerateClient(doc *Document, pkgName string) ([]byte, error) {
+	var body bytes.Buffer
+	for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+		writeStruct(&body, name, doc.Components.Schemas[name])
+	}
+	for _, op := range doc.Operations() {
+		if !isClientOperation(op) {
+			continue
+		}
+		if err := writeOperation(&body, op); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by tools/openapi_client. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkgName)
+	out.WriteString("import (\n")
+	for _, pkg := range []string{"context", "net/url", "strconv", "time"} {
+		selector := pkg[strings.LastIndex(pkg, "/")+1:] + "."
+		if bytes.Contains(body.Bytes(), []byte(selector)) {
+			fmt.Fprintf(&out, "\t%q\n", pkg)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+func isClientOperation(op *PathOperation) bool {
+	if op.RequestBody != nil && op.RequestBody.Content[CONTENT_TYPE_JSON] == nil {
+		return false
+	}
+	for _, resp := range op.Responses {
+		if resp.Content[CONTENT_TYPE_STREAM] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func writeStruct(w *bytes.Buffer, name string, schema *Schema) {
+	if len(schema.Description) > 0 {
+		writeComment(w, "", schema.Description)
+	}
+	fmt.Fprintf(w, "type %s %s\n\n", name, goType(schema))
+}
+
+func writeOperation(w *bytes.Buffer, op *PathOperation) error {
+	name := exportedName(op.OperationId)
+	args := []string{"ctx context.Context"}
+
+	// Build the path out of the literal segments and the escaped parameters
+	pathExpr := make([]string, 0)
+	last := 0
+	for _, match := range pathParamRegex.FindAllStringSubmatchIndex(op.Path, -1) {
+		param := op.Path[match[2]:match[3]]
+		if !isIdentifier(param) {
+			return fmt.Errorf("path parameter %s of %s is not a valid identifier", param, op.OperationId)
+		}
+		args = append(args, param+" string")
+		pathExpr = append(pathExpr, fmt.Sprintf("%q", op.Path[last:match[0]]), "url.PathEscape("+param+")")
+		last = match[1]
+	}
+	if last < len(op.Path) {
+		pathExpr = append(pathExpr, fmt.Sprintf("%q", op.Path[last:]))
+	}
+
+	query := make([]*Parameter, 0)
+	for _, param := range op.Parameters {
+		if param.In == "query" {
+			query = append(query, param)
+		}
+	}
+	paramsType := name + "Params"
+	if len(query) > 0 {
+		args = append(args, "params "+paramsType)
+	}
+	if op.RequestBody != nil {
+		args = append(args, "body "+goType(op.RequestBody.Content[CONTENT_TYPE_JSON].Schema))
+	}
+
+	var respSchema *Schema
+	for code, resp := range op.Responses {
+		if strings.HasPrefix(code, "2") && resp.Content[CONTENT_TYPE_JSON] != nil {
+			respSchema = resp.Content[CONTENT_TYPE_JSON].Schema
+		}
+	}
+
+	if len(query) > 0 {
+		fmt.Fprintf(w, "// %s are the query parameters of %s\n", paramsType, name)
+		fmt.Fprintf(w, "type %s struct {\n", paramsType)
+		for _, param := range query {
+			if len(param.Description) > 0 {
+				writeComment(w, "\t", param.Description)
+			}
+			fmt.Fprintf(w, "\t%s %s\n", fieldName(param.Name), queryGoType(param.Schema))
+		}
+		w.WriteString("}\n\n")
+	}
+
+	comment := fmt.Sprintf("%s calls %s %s", name, strings.ToUpper(op.Method), op.Path)
+	if len(op.Summary) > 0 {
+		comment += "\n\n" + op.Summary
+	}
+	writeComment(w, "", comment)
+	if respSchema != nil {
+		fmt.Fprintf(w, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), goType(respSchema))
+	} else {
+		fmt.Fprintf(w, "func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+	}
+	fmt.Fprintf(w, "\tpath := %s\n", strings.Join(pathExpr, " + "))
+
+	queryArg := "nil"
+	if len(query) > 0 {
+		queryArg = "query"
+		w.WriteString("\tquery := url.Values{}\n")
+		for _, param := range query {
+			writeQueryParam(w, param)
+		}
+	}
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		bodyArg = "body"
+	}
+
+	method := strings.ToUpper(op.Method)
+	if respSchema != nil {
+		fmt.Fprintf(w, "\tvar resp %s\n", goType(respSchema))
+		fmt.Fprintf(w, "\tif err := c.do(ctx, %q, path, %s, %s, &resp); err != nil {\n", method, queryArg, bodyArg)
+		fmt.Fprintf(w, "\t\treturn %s, err\n\t}\n", zeroValue(goType(respSchema)))
+		w.WriteString("\treturn resp, nil\n}\n\n")
+	} else {
+		fmt.Fprintf(w, "\treturn c.do(ctx, %q, path, %s, %s, nil)\n}\n\n", method, queryArg, bodyArg)
+	}
+	return nil
+}
+
+func writeQueryParam(w *bytes.Buffer, param *Parameter) {
+	field := "params." + fieldName(param.Name)
+	switch queryGoType(param.Schema) {
+	case "string":
+		fmt.Fprintf(w, "\tif len(%s) > 0 {\n\t\tquery.Set(%q, %s)\n\t}\n", field, param.Name, field)
+	case "int":
+		fmt.Fprintf(w, "\tif %s != 0 {\n\t\tquery.Set(%q, strconv.Itoa(%s))\n\t}\n", field, param.Name, field)
+	case "*bool":
+		fmt.Fprintf(w, "\tif %s != nil {\n\t\tquery.Set(%q, strconv.FormatBool(*%s))\n\t}\n", field, param.Name, field)
+	}
+}
+
+// queryGoType only supports the simple types used by the query parameters.
+// Booleans are pointers so that false can be told apart from not set.
+func queryGoType(schema *Schema) string {
+	switch schema.Type {
+	case "integer":
+		return "int"
+	case "boolean":
+		return "*bool"
+	default:
+		return "string"
+	}
+}
+
+func goType(schema *Schema) string {
+	if len(schema.Ref) > 0 {
+		return "*" + schema.Ref[strings.LastIndex(schema.Ref, "/")+1:]
+	}
+
+	var t string
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			t = "time.Time"
+		case "byte":
+			return "[]byte"
+		default:
+			t = "string"
+		}
+	case "integer":
+		if schema.Format == "int64" {
+			t = "int64"
+		} else {
+			t = "int"
+		}
+	case "number":
+		if schema.Format == "float" {
+			t = "float32"
+		} else {
+			t = "float64"
+		}
+	case "boolean":
+		t = "bool"
+	case "array":
+		return "[]" + goType(schema.Items)
+	case "object":
+		if len(schema.Properties) == 0 {
+			if schema.AdditionalProperties == nil {
+				return "map[string]interface{}"
+			}
+			return "map[string]" + goType(schema.AdditionalProperties)
+		}
+		var fields strings.Builder
+		fields.WriteString("struct {\n")
+		for _, prop := range slices.Sorted(maps.Keys(schema.Properties)) {
+			fmt.Fprintf(&fields, "\t%s %s `json:\"%s\"`\n", fieldName(prop), goType(schema.Properties[prop]), prop)
+		}
+		fields.WriteString("}")
+		return fields.String()
+	default:
+		return "interface{}"
+	}
+
+	if schema.Nullable {
+		return "*" + t
+	}
+	return t
+}
+
+func zeroValue(goType string) string {
+	switch {
+	case strings.HasPrefix(goType, "*"), strings.HasPrefix(goType, "[]"),
+		strings.HasPrefix(goType, "map["), goType == "interface{}":
+		return "nil"
+	case goType == "string":
+		return `""`
+	case goType == "bool":
+		return "false"
+	case strings.HasPrefix(goType, "int"), strings.HasPrefix(goType, "float"):
+		return "0"
+	default:
+		return goType + "{}"
+	}
+}
+
+// fieldName converts a snake_case JSON name into a Go field name
+func fieldName(jsonName string) string {
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(jsonName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		name.WriteString(exportedName(part))
+	}
+	if name.Len() == 0 || unicode.IsDigit([]rune(name.String())[0]) {
+		return "X" + name.String()
+	}
+	return name.String()
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return len(name) > 0
+}
+
+func writeComment(w *bytes.Buffer, indent string, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) == 0 {
+			fmt.Fprintf(w, "%s//\n", indent)
+		} else {
+			fmt.Fprintf(w, "%s// %s\n", indent, line)
+		}
+	}
+}
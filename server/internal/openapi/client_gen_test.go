@@ -0,0 +1,66 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLoadout struct {
+	LoadoutId uint   `json:"loadout_id"`
+	Name      string `json:"name"`
+}
+
+func TestGenerateClient(t *testing.T) {
+	assert := assert.New(t)
+	schemas := NewSchemas()
+	doc := &Document{
+		OpenApi: OPENAPI_VERSION,
+		Paths: map[string]PathItem{
+			"/api/loadouts/{id}/": {
+				"get": {
+					OperationId: "GetLoadout",
+					Summary:     "Get a loadout",
+					Parameters: []*Parameter{
+						{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+						{Name: "active", In: "query", Schema: &Schema{Type: "boolean"}},
+					},
+					Responses: map[string]*Response{
+						"200": {Content: map[string]*MediaType{
+							CONTENT_TYPE_JSON: {Schema: schemas.For(testLoadout{})},
+						}},
+					},
+				},
+			},
+			"/api/events/": {
+				"get": {
+					OperationId: "Events",
+					Responses: map[string]*Response{
+						"200": {Content: map[string]*MediaType{
+							CONTENT_TYPE_STREAM: {Schema: &Schema{Type: "string"}},
+						}},
+					},
+				},
+			},
+		},
+	}
+	doc.Components.Schemas = schemas.Components()
+
+	src, err := GenerateClient(doc, "client")
+	assert.Nil(err)
+	code := string(src)
+	assert.Contains(code, "// Code generated by tools/openapi_client. DO NOT EDIT.")
+	assert.Contains(code, "type TestLoadout struct {")
+	assert.Contains(code, "LoadoutId int    `json:\"loadout_id\"`")
+	assert.Contains(code, "type GetLoadoutParams struct {\n\tActive *bool\n}")
+	assert.Contains(code, "func (c *Client) GetLoadout(ctx context.Context, id string, params GetLoadoutParams) (*TestLoadout, error) {")
+	assert.Contains(code, `path := "/api/loadouts/" + url.PathEscape(id) + "/"`)
+	assert.NotContains(code, "Events")
+}
+
+func TestFieldName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("ChannelName", fieldName("channel_name"))
+	assert.Equal("Q", fieldName("q"))
+	assert.Equal("X2fa", fieldName("2fa"))
+}
@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+)
+
+var (
+	timeType   = reflect.TypeFor[time.Time]()
+	optionPath = reflect.TypeFor[misc.Option[int]]().PkgPath()
+)
+
+// Schemas builds JSON schemas from Go types using the same rules as
+// encoding/json. Named structs are added to the components once and
+// referenced everywhere else.
+type Schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func NewSchemas() *Schemas {
+	return &Schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+// For returns the schema of the value's type, or nil if v is nil
+func (s *Schemas) For(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return s.forType(reflect.TypeOf(v))
+}
+
+func (s *Schemas) forType(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if isOption(t) {
+		value, _ := t.FieldByName("value")
+		schema := s.forType(value.Type)
+		if len(schema.Ref) == 0 {
+			schema.Nullable = true
+		}
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.forType(t.Elem())
+		if len(schema.Ref) == 0 {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Struct:
+		return s.forStruct(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.forType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.forType(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	default:
+		panic(fmt.Sprintf("openapi: unsupported type %s", t))
+	}
+}
+
+func (s *Schemas) forStruct(t reflect.Type) *Schema {
+	if len(t.Name()) == 0 {
+		return s.structSchema(t)
+	}
+	if name, ok := s.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := exportedName(t.Name())
+	if _, taken := s.components[name]; taken {
+		pkgName := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = exportedName(pkgName) + name
+	}
+	s.names[t] = name
+	// Reserve the name before recursing in case the struct refers to itself
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.structSchema(t)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (s *Schemas) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the struct's fields to the schema. Fields of embedded
+// structs are promoted like encoding/json does, but are shadowed by fields
+// of the same name on the outer struct.
+func (s *Schemas) addFields(schema *Schema, t reflect.Type) {
+	embedded := make([]reflect.Type, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && len(name) == 0 {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				embedded = append(embedded, fieldType)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		schema.Properties[name] = s.forType(field.Type)
+	}
+
+	for _, embeddedType := range embedded {
+		promoted := &Schema{Properties: make(map[string]*Schema)}
+		s.addFields(promoted, embeddedType)
+		for name, fieldSchema := range promoted.Properties {
+			if _, ok := schema.Properties[name]; !ok {
+				schema.Properties[name] = fieldSchema
+			}
+		}
+	}
+}
+
+func isOption(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t.PkgPath() == optionPath &&
+		strings.HasPrefix(t.Name(), "Option[")
+}
+
+func exportedName(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,69 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+type testInner struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type testOuter struct {
+	*testInner
+	// Shadows the embedded field
+	Name      string                    `json:"name,omitempty"`
+	CreatedAt time.Time                 `json:"created_at"`
+	LastSeen  *time.Time                `json:"last_seen"`
+	Seed      *int64                    `json:"seed"`
+	Pos       misc.Option[misc.Vector2] `json:"pos"`
+	Scale     misc.Option[float64]      `json:"scale"`
+	Tags      []string                  `json:"tags"`
+	Weights   map[string]float64        `json:"weights"`
+	Data      interface{}               `json:"data"`
+	Ignored   string                    `json:"-"`
+	private   string
+}
+
+func TestSchemas_For(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewSchemas()
+
+	assert.Nil(sut.For(nil))
+	assert.Equal(&Schema{Ref: "#/components/schemas/TestOuter"}, sut.For(testOuter{}))
+	assert.Equal(&Schema{Ref: "#/components/schemas/TestOuter"}, sut.For(&testOuter{}))
+
+	outer := sut.Components()["TestOuter"]
+	assert.Equal(map[string]*Schema{
+		"name":       {Type: "string"},
+		"count":      {Type: "integer"},
+		"created_at": {Type: "string", Format: "date-time"},
+		"last_seen":  {Type: "string", Format: "date-time", Nullable: true},
+		"seed":       {Type: "integer", Format: "int64", Nullable: true},
+		"pos":        {Ref: "#/components/schemas/Vector2"},
+		"scale":      {Type: "number", Format: "double", Nullable: true},
+		"tags":       {Type: "array", Items: &Schema{Type: "string"}},
+		"weights":    {Type: "object", AdditionalProperties: &Schema{Type: "number", Format: "double"}},
+		"data":       {},
+	}, outer.Properties)
+	assert.Contains(sut.Components(), "Vector2")
+	assert.NotContains(sut.Components(), "TestInner")
+}
+
+func TestSchemas_ForPrefixesClashingNames(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewSchemas()
+
+	sut.For(struct {
+		Vector2 misc.Vector2 `json:"vector2"`
+	}{})
+	type Vector2 struct {
+		Z float64 `json:"z"`
+	}
+	assert.Equal(&Schema{Ref: "#/components/schemas/OpenapiVector2"}, sut.For(Vector2{}))
+	assert.Contains(sut.Components(), "Vector2")
+}
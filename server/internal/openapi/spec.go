@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	OPENAPI_VERSION = "3.0.3"
+
+	CONTENT_TYPE_JSON      = "application/json"
+	CONTENT_TYPE_MULTIPART = "multipart/form-data"
+	CONTENT_TYPE_STREAM    = "text/event-stream"
+	CONTENT_TYPE_TEXT      = "text/plain"
+
+	SECURITY_SCHEME_BEARER = "bearerAuth"
+)
+
+// Document is the subset of an OpenAPI 3 document which the bot's API uses.
+type Document struct {
+	OpenApi    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Tags       []*Tag              `json:"tags,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps a lowercase HTTP method to its operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	// The API key scope which lets an API key be used in place of a user's
+	// token. Empty if API keys are not accepted.
+	ApiKeyScope string `json:"x-api-key-scope,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Operations returns every operation in the document ordered by their ids
+func (d *Document) Operations() []*PathOperation {
+	ops := make([]*PathOperation, 0)
+	for path, item := range d.Paths {
+		for method, op := range item {
+			ops = append(ops, &PathOperation{Path: path, Method: method, Operation: op})
+		}
+	}
+	slices.SortFunc(ops, func(a, b *PathOperation) int {
+		return strings.Compare(a.OperationId, b.OperationId)
+	})
+	return ops
+}
+
+type PathOperation struct {
+	Path   string
+	Method string
+	*Operation
+}
@@ -0,0 +1,69 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// AssetLoadError is a single asset file or index entry which failed to load
+// and was skipped.
+type AssetLoadError struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+func (e *AssetLoadError) Error() string {
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// AssetLoadReport collects the errors from loading an asset map so that one
+// bad file doesn't prevent the rest of the assets from loading.
+type AssetLoadReport struct {
+	Source     string            `json:"source"`
+	NumEntries int               `json:"num_entries"`
+	Errors     []*AssetLoadError `json:"errors"`
+}
+
+func NewAssetLoadReport(source string) *AssetLoadReport {
+	return &AssetLoadReport{
+		Source: source,
+		Errors: make([]*AssetLoadError, 0),
+	}
+}
+
+func (r *AssetLoadReport) AddError(file string, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, &AssetLoadError{
+		File:    file,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *AssetLoadReport) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// Merge appends the errors of other into this report
+func (r *AssetLoadReport) Merge(other *AssetLoadReport) {
+	r.NumEntries += other.NumEntries
+	r.Errors = append(r.Errors, other.Errors...)
+}
+
+func (r *AssetLoadReport) Print() {
+	log.Printf("Loaded %d entries from %s with %d errors\n", r.NumEntries, r.Source, len(r.Errors))
+	for _, loadErr := range r.Errors {
+		log.Printf("  %s\n", loadErr.Error())
+	}
+}
+
+// Err returns an error summarizing the report if there were any errors
+func (r *AssetLoadReport) Err() error {
+	if !r.HasErrors() {
+		return nil
+	}
+	messages := make([]string, 0, len(r.Errors))
+	for _, loadErr := range r.Errors {
+		messages = append(messages, loadErr.Error())
+	}
+	return fmt.Errorf("%d errors loading assets from %s: %s", len(r.Errors), r.Source, strings.Join(messages, "; "))
+}
@@ -0,0 +1,355 @@
+package operator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type AssetIssueSeverity string
+
+const (
+	ASSET_ISSUE_SEVERITY_ERROR   AssetIssueSeverity = "error"
+	ASSET_ISSUE_SEVERITY_WARNING AssetIssueSeverity = "warning"
+)
+
+type AssetIssueCode string
+
+const (
+	ASSET_ISSUE_LOAD_ERROR             AssetIssueCode = "load_error"
+	ASSET_ISSUE_MISSING_FILE           AssetIssueCode = "missing_file"
+	ASSET_ISSUE_MISSING_ATLAS          AssetIssueCode = "missing_atlas"
+	ASSET_ISSUE_MISSING_TEXTURE        AssetIssueCode = "missing_texture"
+	ASSET_ISSUE_MISSING_SKELETON       AssetIssueCode = "missing_skeleton"
+	ASSET_ISSUE_ATLAS_PAGE_MISMATCH    AssetIssueCode = "atlas_page_mismatch"
+	ASSET_ISSUE_ANIMATIONS_FILE        AssetIssueCode = "animations_file"
+	ASSET_ISSUE_NO_ANIMATIONS          AssetIssueCode = "no_animations"
+	ASSET_ISSUE_UNKNOWN_ANIMATION      AssetIssueCode = "unknown_animation"
+	ASSET_ISSUE_STALE_INDEX            AssetIssueCode = "stale_index"
+	ASSET_ISSUE_MISSING_MOVE_ANIMATION AssetIssueCode = "missing_move_animation"
+	ASSET_ISSUE_MISSING_IDLE_ANIMATION AssetIssueCode = "missing_idle_animation"
+)
+
+type AssetValidationIssue struct {
+	Severity   AssetIssueSeverity `json:"severity"`
+	Code       AssetIssueCode     `json:"code"`
+	Faction    FactionEnum        `json:"faction"`
+	OperatorId string             `json:"operator_id"`
+	Skin       string             `json:"skin"`
+	Stance     ChibiStanceEnum    `json:"stance"`
+	Facing     ChibiFacingEnum    `json:"facing"`
+	File       string             `json:"file,omitempty"`
+	Message    string             `json:"message"`
+}
+
+type AssetValidationReport struct {
+	NumChecked  int                     `json:"num_checked"`
+	NumErrors   int                     `json:"num_errors"`
+	NumWarnings int                     `json:"num_warnings"`
+	Issues      []*AssetValidationIssue `json:"issues"`
+}
+
+func NewAssetValidationReport() *AssetValidationReport {
+	return &AssetValidationReport{
+		Issues: make([]*AssetValidationIssue, 0),
+	}
+}
+
+func (r *AssetValidationReport) add(issue *AssetValidationIssue) {
+	if issue.Severity == ASSET_ISSUE_SEVERITY_ERROR {
+		r.NumErrors += 1
+	} else {
+		r.NumWarnings += 1
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// AddLoadReport records the entries which were skipped when loading the
+// asset map as errors.
+func (r *AssetValidationReport) AddLoadReport(faction FactionEnum, loadReport *AssetLoadReport) {
+	for _, loadErr := range loadReport.Errors {
+		r.add(&AssetValidationIssue{
+			Severity: ASSET_ISSUE_SEVERITY_ERROR,
+			Code:     ASSET_ISSUE_LOAD_ERROR,
+			Faction:  faction,
+			File:     loadErr.File,
+			Message:  loadErr.Message,
+		})
+	}
+}
+
+// Sort orders the issues so that reports can be diffed between runs
+func (r *AssetValidationReport) Sort() {
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		a, b := r.Issues[i], r.Issues[j]
+		keyA := []string{string(a.Faction), a.OperatorId, a.Skin, string(a.Stance), string(a.Facing), string(a.Code), a.Message}
+		keyB := []string{string(b.Faction), b.OperatorId, b.Skin, string(b.Stance), string(b.Facing), string(b.Code), b.Message}
+		return slices.Compare(keyA, keyB) < 0
+	})
+}
+
+// HasFailures reports whether the report should fail a CI run. Warnings only
+// count when strict is set.
+func (r *AssetValidationReport) HasFailures(strict bool) bool {
+	return r.NumErrors > 0 || (strict && r.NumWarnings > 0)
+}
+
+// ValidateSpineAssetMap checks every skin/stance/facing in the asset map
+// against the files in assetDir. It checks that:
+//   - the atlas, texture and skeleton files exist
+//   - the atlas page names match the png files next to it
+//   - the animations listed in the index and the .animations.json exist in
+//     the skeleton
+//   - there is a Move and an idle animation for the chibi to use
+func ValidateSpineAssetMap(
+	assetDir string,
+	faction FactionEnum,
+	assetMap *SpineAssetMap,
+	report *AssetValidationReport,
+) {
+	assetMap.Iterate(func(opId string, skin string, stance ChibiStanceEnum, facing ChibiFacingEnum, spineData *SpineData) {
+		v := &spineDataValidator{
+			assetDir:  assetDir,
+			report:    report,
+			spineData: spineData,
+			issue: AssetValidationIssue{
+				Faction:    faction,
+				OperatorId: opId,
+				Skin:       skin,
+				Stance:     stance,
+				Facing:     facing,
+			},
+		}
+		v.validate()
+		report.NumChecked += 1
+	})
+}
+
+type spineDataValidator struct {
+	assetDir  string
+	report    *AssetValidationReport
+	spineData *SpineData
+	issue     AssetValidationIssue
+}
+
+func (v *spineDataValidator) addIssue(severity AssetIssueSeverity, code AssetIssueCode, file string, format string, args ...interface{}) {
+	issue := v.issue
+	issue.Severity = severity
+	issue.Code = code
+	issue.File = file
+	issue.Message = fmt.Sprintf(format, args...)
+	v.report.add(&issue)
+}
+
+// checkFile returns the full path to the file if it exists
+func (v *spineDataValidator) checkFile(indiePath string) (string, bool) {
+	fullPath := filepath.Join(v.assetDir, filepath.FromSlash(indiePath))
+	if _, err := os.Stat(fullPath); err != nil {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_MISSING_FILE, indiePath, "file does not exist: %v", err)
+		return "", false
+	}
+	return fullPath, true
+}
+
+func (v *spineDataValidator) validate() {
+	spineData := v.spineData
+
+	var atlasPath string
+	if len(spineData.PlaformIndieAtlasFilepath) == 0 {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_MISSING_ATLAS, "", "no atlas file")
+	} else {
+		atlasPath, _ = v.checkFile(spineData.PlaformIndieAtlasFilepath)
+	}
+
+	// Spritesheet chibis are rendered without a skeleton
+	if len(spineData.PlatformIndieSpritesheetDataFilepath) > 0 {
+		v.checkFile(spineData.PlatformIndieSpritesheetDataFilepath)
+		v.validateAnimations(nil)
+		return
+	}
+
+	if len(spineData.PlaformIndiePngFilepath) == 0 {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_MISSING_TEXTURE, "", "no png file")
+	} else {
+		v.checkFile(spineData.PlaformIndiePngFilepath)
+	}
+	if len(atlasPath) > 0 {
+		v.validateAtlasPages(atlasPath)
+	}
+
+	var skeletonAnimations func(string) bool
+	switch {
+	case len(spineData.PlaformIndieSkelFilepath) > 0:
+		if skelPath, ok := v.checkFile(spineData.PlaformIndieSkelFilepath); ok {
+			skeletonAnimations = v.readBinarySkeleton(skelPath)
+		}
+	case len(spineData.PlaformIndieSkelJsonFilepath) > 0:
+		if skelPath, ok := v.checkFile(spineData.PlaformIndieSkelJsonFilepath); ok {
+			skeletonAnimations = v.readJsonSkeleton(skelPath)
+		}
+	default:
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_MISSING_SKELETON, "", "no skel or skel json file")
+	}
+	v.validateAnimations(skeletonAnimations)
+}
+
+// readAtlasPages returns the page (texture) names of a spine atlas file.
+// Pages are the lines following a blank line (or the start of the file).
+func readAtlasPages(atlasPath string) ([]string, error) {
+	file, err := os.Open(atlasPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pages := make([]string, 0)
+	expectPage := true
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			expectPage = true
+			continue
+		}
+		if expectPage {
+			pages = append(pages, line)
+			expectPage = false
+		}
+	}
+	return pages, scanner.Err()
+}
+
+func (v *spineDataValidator) validateAtlasPages(atlasPath string) {
+	atlasFile := v.spineData.PlaformIndieAtlasFilepath
+	pages, err := readAtlasPages(atlasPath)
+	if err != nil {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_ATLAS_PAGE_MISMATCH, atlasFile, "failed to read atlas: %v", err)
+		return
+	}
+	if len(pages) == 0 {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_ATLAS_PAGE_MISMATCH, atlasFile, "atlas has no pages")
+		return
+	}
+
+	atlasDir := filepath.Dir(atlasPath)
+	for _, page := range pages {
+		if _, err := os.Stat(filepath.Join(atlasDir, page)); err != nil {
+			v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_ATLAS_PAGE_MISMATCH, atlasFile, "atlas page %s does not exist", page)
+		}
+	}
+	pngName := filepath.Base(filepath.FromSlash(v.spineData.PlaformIndiePngFilepath))
+	if len(v.spineData.PlaformIndiePngFilepath) > 0 && !slices.Contains(pages, pngName) {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_ATLAS_PAGE_MISMATCH, atlasFile, "png %s is not a page of the atlas %v", pngName, pages)
+	}
+}
+
+// readBinarySkeleton returns a lookup for the animation names in a binary
+// .skel file. Names are stored as length prefixed strings in the binary format
+// so looking for the encoded name is good enough to detect missing animations
+// without parsing the whole skeleton.
+func (v *spineDataValidator) readBinarySkeleton(skelPath string) func(string) bool {
+	data, err := os.ReadFile(skelPath)
+	if err != nil {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_MISSING_SKELETON, v.spineData.PlaformIndieSkelFilepath, "failed to read skel: %v", err)
+		return nil
+	}
+	return func(animation string) bool {
+		return bytes.Contains(data, encodeSpineBinaryString(animation))
+	}
+}
+
+// encodeSpineBinaryString encodes s the way the spine binary format stores
+// strings: the byte length + 1 as a varint followed by the UTF-8 bytes.
+func encodeSpineBinaryString(s string) []byte {
+	encoded := binary.AppendUvarint(nil, uint64(len(s)+1))
+	return append(encoded, s...)
+}
+
+func (v *spineDataValidator) readJsonSkeleton(skelPath string) func(string) bool {
+	data, err := os.ReadFile(skelPath)
+	if err != nil {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_MISSING_SKELETON, v.spineData.PlaformIndieSkelJsonFilepath, "failed to read skel json: %v", err)
+		return nil
+	}
+	skeleton := struct {
+		Animations map[string]json.RawMessage `json:"animations"`
+	}{}
+	if err := json.Unmarshal(data, &skeleton); err != nil {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_MISSING_SKELETON, v.spineData.PlaformIndieSkelJsonFilepath, "failed to parse skel json: %v", err)
+		return nil
+	}
+	return func(animation string) bool {
+		_, ok := skeleton.Animations[animation]
+		return ok
+	}
+}
+
+// findAnimationsFile returns the .animations.json next to the atlas
+func (v *spineDataValidator) findAnimationsFile() string {
+	atlasFile := v.spineData.PlaformIndieAtlasFilepath
+	if len(atlasFile) == 0 {
+		return ""
+	}
+	dir := filepath.Join(v.assetDir, filepath.Dir(filepath.FromSlash(atlasFile)))
+	matches, err := filepath.Glob(filepath.Join(dir, "*.animations.json"))
+	if err != nil || len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[0]
+}
+
+func (v *spineDataValidator) validateAnimations(skeletonHasAnimation func(string) bool) {
+	animations := v.spineData.Animations
+	if len(animations) == 0 {
+		v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_NO_ANIMATIONS, "", "no animations")
+		return
+	}
+
+	if animationsFile := v.findAnimationsFile(); len(animationsFile) > 0 {
+		relPath, _ := filepath.Rel(v.assetDir, animationsFile)
+		relPath = filepath.ToSlash(relPath)
+		skelData, err := readJsonSkelAnimations(animationsFile)
+		if err != nil {
+			v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_ANIMATIONS_FILE, relPath, "failed to parse: %v", err)
+		} else if !slices.Equal(sortedCopy(skelData.Animations), sortedCopy(animations)) {
+			v.addIssue(ASSET_ISSUE_SEVERITY_WARNING, ASSET_ISSUE_STALE_INDEX, relPath,
+				"index animations %v do not match %v", animations, skelData.Animations)
+		}
+	}
+
+	if skeletonHasAnimation != nil {
+		for _, animation := range animations {
+			if !skeletonHasAnimation(animation) {
+				v.addIssue(ASSET_ISSUE_SEVERITY_ERROR, ASSET_ISSUE_UNKNOWN_ANIMATION, "", "animation %s is not in the skeleton", animation)
+			}
+		}
+	}
+
+	hasMove := slices.ContainsFunc(animations, func(animation string) bool {
+		return strings.Contains(animation, DEFAULT_MOVE_ANIM_NAME)
+	})
+	if !hasMove {
+		v.addIssue(ASSET_ISSUE_SEVERITY_WARNING, ASSET_ISSUE_MISSING_MOVE_ANIMATION, "", "no %s animation", DEFAULT_MOVE_ANIM_NAME)
+	}
+	idleAnimation := DEFAULT_ANIM_BATTLE
+	if v.issue.Stance == CHIBI_STANCE_ENUM_BASE {
+		idleAnimation = DEFAULT_ANIM_BASE_RELAX
+	}
+	if !slices.Contains(animations, idleAnimation) {
+		v.addIssue(ASSET_ISSUE_SEVERITY_WARNING, ASSET_ISSUE_MISSING_IDLE_ANIMATION, "", "no %s animation", idleAnimation)
+	}
+}
+
+func sortedCopy(values []string) []string {
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,119 @@
+package operator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestAssetFile(t *testing.T, assetDir string, indiePath string, content string) {
+	fullPath := filepath.Join(assetDir, filepath.FromSlash(indiePath))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestValidatorAssetMap(spineData *SpineData) *SpineAssetMap {
+	assetMap := NewSpineAssetMap()
+	assetMap.Data["char_001"] = &ChibiAssetPathEntry{
+		Skins: map[string]*SpineSkinData{
+			DEFAULT_SKIN_NAME: {
+				Base:   map[ChibiFacingEnum]*SpineData{CHIBI_FACING_ENUM_FRONT: spineData},
+				Battle: map[ChibiFacingEnum]*SpineData{},
+			},
+		},
+	}
+	return assetMap
+}
+
+func issueCodes(report *AssetValidationReport) []AssetIssueCode {
+	codes := make([]AssetIssueCode, 0)
+	for _, issue := range report.Issues {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestValidateSpineAssetMap_Valid(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.atlas", "\nbase.png\nsize: 10,10\nregion\n  bounds: 0,0,1,1\n")
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.png", "png")
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.skel", "\x00\x05Move\x06Relax\x00")
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.animations.json", `{"animations": ["Relax", "Move"]}`)
+
+	report := NewAssetValidationReport()
+	ValidateSpineAssetMap(assetDir, FACTION_ENUM_OPERATOR, newTestValidatorAssetMap(&SpineData{
+		PlaformIndieAtlasFilepath: "characters/char_001/base.atlas",
+		PlaformIndiePngFilepath:   "characters/char_001/base.png",
+		PlaformIndieSkelFilepath:  "characters/char_001/base.skel",
+		Animations:                []string{"Move", "Relax"},
+	}), report)
+
+	assert.Equal(1, report.NumChecked)
+	assert.Empty(report.Issues)
+	assert.False(report.HasFailures(true))
+}
+
+func TestEncodeSpineBinaryString(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]byte("\x01"), encodeSpineBinaryString(""))
+	assert.Equal([]byte("\x05Move"), encodeSpineBinaryString("Move"))
+	long := strings.Repeat("a", 200)
+	assert.Equal(append([]byte{0xc9, 0x01}, long...), encodeSpineBinaryString(long))
+}
+
+func TestValidateSpineAssetMap_Invalid(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.atlas", "other.png\nsize: 10,10\n\nmissing.png\nsize: 10,10\n")
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.png", "png")
+	writeTestAssetFile(t, assetDir, "characters/char_001/other.png", "png")
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.skel.json", `{"animations": {"Attack": {}}}`)
+	writeTestAssetFile(t, assetDir, "characters/char_001/base.animations.json", `{"animations": ["Attack", "Die"]}`)
+
+	report := NewAssetValidationReport()
+	ValidateSpineAssetMap(assetDir, FACTION_ENUM_OPERATOR, newTestValidatorAssetMap(&SpineData{
+		PlaformIndieAtlasFilepath:    "characters/char_001/base.atlas",
+		PlaformIndiePngFilepath:      "characters/char_001/base.png",
+		PlaformIndieSkelJsonFilepath: "characters/char_001/base.skel.json",
+		Animations:                   []string{"Attack", "Sit"},
+	}), report)
+	report.Sort()
+
+	assert.ElementsMatch([]AssetIssueCode{
+		ASSET_ISSUE_ATLAS_PAGE_MISMATCH, // missing.png does not exist
+		ASSET_ISSUE_ATLAS_PAGE_MISMATCH, // base.png is not a page
+		ASSET_ISSUE_STALE_INDEX,
+		ASSET_ISSUE_UNKNOWN_ANIMATION, // Sit
+		ASSET_ISSUE_MISSING_MOVE_ANIMATION,
+		ASSET_ISSUE_MISSING_IDLE_ANIMATION,
+	}, issueCodes(report))
+	assert.Equal(3, report.NumErrors)
+	assert.Equal(3, report.NumWarnings)
+	assert.True(report.HasFailures(false))
+}
+
+func TestValidateSpineAssetMap_MissingFiles(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+
+	report := NewAssetValidationReport()
+	ValidateSpineAssetMap(assetDir, FACTION_ENUM_ENEMY, newTestValidatorAssetMap(&SpineData{
+		PlaformIndieAtlasFilepath: "enemies/char_001/base.atlas",
+		Animations:                []string{"Move", "Relax"},
+	}), report)
+
+	assert.ElementsMatch([]AssetIssueCode{
+		ASSET_ISSUE_MISSING_FILE,
+		ASSET_ISSUE_MISSING_TEXTURE,
+		ASSET_ISSUE_MISSING_SKELETON,
+	}, issueCodes(report))
+	assert.Equal(FACTION_ENUM_ENEMY, report.Issues[0].Faction)
+}
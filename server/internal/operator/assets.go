@@ -10,6 +10,8 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/lithammer/fuzzysearch/fuzzy"
@@ -49,6 +51,26 @@ type SpineData struct {
 	PlatformIndieSpritesheetDataFilepath string `json:"spritesheet_data_filepath"`
 }
 
+// ClientFilepaths returns the forward-slash asset paths which a client will
+// request to render this chibi. Mirrors the files sent by the SpineBridge.
+func (s *SpineData) ClientFilepaths() []string {
+	toSlash := func(path string) string {
+		return strings.ReplaceAll(path, string(os.PathSeparator), "/")
+	}
+	paths := []string{toSlash(s.AtlasFilepath)}
+	if len(s.SpritesheetDataFilepath) > 0 {
+		return append(paths, toSlash(s.SpritesheetDataFilepath))
+	}
+	paths = append(paths, toSlash(s.PngFilepath))
+	if len(s.SkelFilepath) > 0 {
+		paths = append(paths, toSlash(s.SkelFilepath))
+	}
+	if len(s.SkelJsonFilepath) > 0 {
+		paths = append(paths, toSlash(s.SkelJsonFilepath))
+	}
+	return paths
+}
+
 func NewSpineAssetMap() *SpineAssetMap {
 	return &SpineAssetMap{
 		Data: make(map[string]*ChibiAssetPathEntry),
@@ -77,110 +99,102 @@ func readJsonSkelAnimations(path string) (*JsonSkelData, error) {
 	return data, nil
 }
 
-func (s *SpineAssetMap) MergeFromIndex(indexFile string) (err error) {
+// localizeFilepaths fills in the OS specific paths from the platform
+// independent paths stored in the index.
+func (s *SpineData) localizeFilepaths() (err error) {
+	localize := func(name string, indiePath string, dest *string) {
+		if err != nil || len(indiePath) == 0 {
+			return
+		}
+		*dest, err = filepath.Localize(indiePath)
+		if err != nil {
+			err = fmt.Errorf("invalid %s path %s: %w", name, indiePath, err)
+		}
+	}
+	localize("atlas", s.PlaformIndieAtlasFilepath, &s.AtlasFilepath)
+	localize("skel", s.PlaformIndieSkelFilepath, &s.SkelFilepath)
+	localize("skel json", s.PlaformIndieSkelJsonFilepath, &s.SkelJsonFilepath)
+	localize("png", s.PlaformIndiePngFilepath, &s.PngFilepath)
+	localize("spritesheet-json", s.PlatformIndieSpritesheetDataFilepath, &s.SpritesheetDataFilepath)
+	return err
+}
+
+// LocalizeFilepaths fills in the OS specific paths of every SpineData after
+// the entry has been decoded from JSON.
+func (e *ChibiAssetPathEntry) LocalizeFilepaths() error {
+	for _, skin := range e.Skins {
+		for _, facings := range []map[ChibiFacingEnum]*SpineData{skin.Base, skin.Battle} {
+			for _, spineData := range facings {
+				if err := spineData.localizeFilepaths(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MergeFromIndex adds the entries of the index file into this map. Entries
+// with invalid paths are skipped and recorded in the returned report. An error
+// is only returned if the index itself can't be read.
+func (s *SpineAssetMap) MergeFromIndex(indexFile string) (*AssetLoadReport, error) {
 	log.Println("Loading Asset maps from index", indexFile)
+	report := NewAssetLoadReport(indexFile)
 	file, err := os.Open(indexFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
 	output := NewSpineAssetMap()
 	err = json.NewDecoder(file).Decode(output)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for opId, opEntry := range output.Data {
 		for skin, skinEntry := range opEntry.Skins {
-			for facing, spineData := range skinEntry.Base {
-				if len(spineData.PlaformIndieAtlasFilepath) > 0 {
-					spineData.AtlasFilepath, err = filepath.Localize(spineData.PlaformIndieAtlasFilepath)
-					if err != nil {
-						log.Println(opId, skin, "base", facing, "atlas", spineData.PlaformIndieAtlasFilepath)
-						return err
-					}
-				}
-
-				if len(spineData.PlaformIndieSkelFilepath) > 0 {
-					spineData.SkelFilepath, err = filepath.Localize(spineData.PlaformIndieSkelFilepath)
-					if err != nil {
-						log.Println(opId, skin, "base", facing, "skel", spineData.PlaformIndieSkelFilepath)
-						return err
-					}
-				}
-				if len(spineData.PlaformIndieSkelJsonFilepath) > 0 {
-					spineData.SkelJsonFilepath, err = filepath.Localize(spineData.PlaformIndieSkelJsonFilepath)
-					if err != nil {
-						log.Println(opId, skin, "base", facing, "skel", spineData.PlaformIndieSkelJsonFilepath)
-						return err
-					}
-				}
-				if len(spineData.PlaformIndiePngFilepath) > 0 {
-					spineData.PngFilepath, err = filepath.Localize(spineData.PlaformIndiePngFilepath)
-					if err != nil {
-						log.Println(opId, skin, "base", facing, "png", spineData.PlaformIndiePngFilepath)
-						return err
-					}
-				}
-				if len(spineData.PlatformIndieSpritesheetDataFilepath) > 0 {
-					spineData.SpritesheetDataFilepath, err = filepath.Localize(spineData.PlatformIndieSpritesheetDataFilepath)
-					if err != nil {
-						log.Println(opId, skin, "base", facing, "spritesheet-json", spineData.PlatformIndieSpritesheetDataFilepath)
-						return err
-					}
-				}
+			stances := map[ChibiStanceEnum]map[ChibiFacingEnum]*SpineData{
+				CHIBI_STANCE_ENUM_BASE:   skinEntry.Base,
+				CHIBI_STANCE_ENUM_BATTLE: skinEntry.Battle,
 			}
-			for facing, spineData := range skinEntry.Battle {
-				if len(spineData.PlaformIndieAtlasFilepath) > 0 {
-					spineData.AtlasFilepath, err = filepath.Localize(spineData.PlaformIndieAtlasFilepath)
-					if err != nil {
-						log.Println(opId, skin, "battle", facing, "spine", spineData.PlaformIndieAtlasFilepath)
-						return err
-					}
-				}
-
-				if len(spineData.PlaformIndieSkelFilepath) > 0 {
-					spineData.SkelFilepath, err = filepath.Localize(spineData.PlaformIndieSkelFilepath)
-					if err != nil {
-						log.Println(opId, skin, "battle", facing, "skel", spineData.PlaformIndieSkelFilepath)
-						return err
-					}
-				}
-				if len(spineData.PlaformIndieSkelJsonFilepath) > 0 {
-					spineData.SkelJsonFilepath, err = filepath.Localize(spineData.PlaformIndieSkelJsonFilepath)
-					if err != nil {
-						log.Println(opId, skin, "battle", facing, "skel", spineData.PlaformIndieSkelJsonFilepath)
-						return err
+			for stance, facings := range stances {
+				for facing, spineData := range facings {
+					if err := spineData.localizeFilepaths(); err != nil {
+						report.AddError(
+							fmt.Sprintf("%s/%s/%s/%s", opId, skin, stance, facing),
+							"%v", err,
+						)
+						delete(facings, facing)
 					}
 				}
+			}
 
-				if len(spineData.PlaformIndiePngFilepath) > 0 {
-					spineData.PngFilepath, err = filepath.Localize(spineData.PlaformIndiePngFilepath)
-					if err != nil {
-						log.Println(opId, skin, "battle", facing, "png", spineData.PlaformIndiePngFilepath)
-						return err
-					}
-				}
-				if len(spineData.PlatformIndieSpritesheetDataFilepath) > 0 {
-					spineData.SpritesheetDataFilepath, err = filepath.Localize(spineData.PlatformIndieSpritesheetDataFilepath)
-					if err != nil {
-						log.Println(opId, skin, "battle", facing, "spritesheet-json", spineData.PlatformIndieSpritesheetDataFilepath)
-						return
-					}
-				}
+			// Prune whatever was left empty, the same as Load
+			if len(skinEntry.Base) == 0 {
+				skinEntry.Base = nil
+			}
+			if len(skinEntry.Battle) == 0 {
+				skinEntry.Battle = nil
+			}
+			if skinEntry.Base == nil && skinEntry.Battle == nil {
+				delete(opEntry.Skins, skin)
 			}
 		}
+		if len(opEntry.Skins) == 0 {
+			continue
+		}
 
 		// Add to current spineAssetMap
 		s.Data[opId] = opEntry
+		report.NumEntries += 1
 	}
 
 	log.Printf("Loaded %d assets from index %s", len(s.Data), indexFile)
-	return nil
+	return report, nil
 }
 
-func (s *SpineAssetMap) LoadFromIndex(indexFile string) (err error) {
+func (s *SpineAssetMap) LoadFromIndex(indexFile string) (*AssetLoadReport, error) {
 	return s.MergeFromIndex(indexFile)
 }
 
@@ -214,13 +228,38 @@ func (s *SpineAssetMap) Iterate(callback SpineAssetMapCallback) {
 	}
 }
 
-func (s *SpineAssetMap) Load(assetDir string, assetSubdir string) (err error) {
+func isKnownAssetFile(filePath string) bool {
+	switch filepath.Ext(filePath) {
+	case ".atlas", ".png", ".skel", ".jskel", ".dds":
+		return true
+	case ".json":
+		return strings.HasSuffix(filePath, ".animations.json") ||
+			strings.HasSuffix(filePath, ".spritesheet.json") ||
+			strings.HasSuffix(filePath, ".skel.json")
+	default:
+		return false
+	}
+}
+
+// Load walks the asset directory to build the asset map. Files which can't
+// be understood are recorded in the returned report and skipped. Chibis whose
+// animations can't be read are skipped entirely. An error is only returned if the directory can't be walked.
+func (s *SpineAssetMap) Load(assetDir string, assetSubdir string) (*AssetLoadReport, error) {
 	log.Println("Loading Asset maps")
 	assetDirAbs, err := filepath.Abs(assetDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	charsDir := filepath.Join(assetDirAbs, assetSubdir)
+	report := NewAssetLoadReport(charsDir)
+
+	type spineDataKey struct {
+		operatorName string
+		skinName     string
+		isBase       bool
+		isFront      bool
+	}
+	badEntries := make(map[spineDataKey]bool)
 
 	err = filepath.Walk(charsDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -230,14 +269,25 @@ func (s *SpineAssetMap) Load(assetDir string, assetSubdir string) (err error) {
 			return nil
 		}
 		newPath := strings.TrimPrefix(path, assetDirAbs+string(os.PathSeparator))
+		newPathIndie := filepath.ToSlash(newPath)
 		pathList := strings.Split(newPath, string(os.PathSeparator))
+		if len(pathList) < 6 {
+			report.AddError(newPathIndie, "expected a <operator>/<skin>/<stance>/<facing>/<file> path")
+			return nil
+		}
 
-		operatorName := pathList[1] // char_002_amiya
-		skinName := pathList[2]     // epoque#4,default
-		battleOrBase := pathList[3] // battle or base
-		frontOrBack := pathList[4]  // Front or Back
-		spineData := s.Get(operatorName, skinName, battleOrBase == "base", frontOrBack == "Front")
-		newPathIndie := filepath.ToSlash(newPath)
+		if !isKnownAssetFile(newPathIndie) {
+			report.AddError(newPathIndie, "unknown extension")
+			return nil
+		}
+
+		key := spineDataKey{
+			operatorName: pathList[1],            // char_002_amiya
+			skinName:     pathList[2],            // epoque#4,default
+			isBase:       pathList[3] == "base",  // battle or base
+			isFront:      pathList[4] == "Front", // Front or Back
+		}
+		spineData := s.Get(key.operatorName, key.skinName, key.isBase, key.isFront)
 
 		switch filepath.Ext(info.Name()) {
 		case ".atlas":
@@ -260,7 +310,10 @@ func (s *SpineAssetMap) Load(assetDir string, assetSubdir string) (err error) {
 			if strings.HasSuffix(newPathIndie, ".animations.json") {
 				skelData, err := readJsonSkelAnimations(path)
 				if err != nil {
-					log.Fatal("Failed to extract animations from skeleton json: ", err)
+					// Can't pick animations for this chibi so skip it entirely
+					report.AddError(newPathIndie, "failed to extract animations from skeleton json: %v", err)
+					badEntries[key] = true
+					return nil
 				}
 				spineData.Animations = skelData.Animations
 				spineData.UseStraightAlpha = skelData.UseStraightAlpha
@@ -271,19 +324,35 @@ func (s *SpineAssetMap) Load(assetDir string, assetSubdir string) (err error) {
 				spineData.SkelJsonFilepath = newPath
 				spineData.PlaformIndieSkelJsonFilepath = newPathIndie
 				// spineData.SkelFullJsonFilepath = path
-			} else {
-				log.Fatal("Unknown secondary extension: ", info.Name())
 			}
 		case ".dds":
 			// Ignore dds files
-		default:
-			log.Fatal("Unknown extension: ", info.Name())
 		}
 
 		return nil
 	})
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	// Skip the chibis which had a bad file
+	for key := range badEntries {
+		skinEntry := s.Data[key.operatorName].Skins[key.skinName]
+		facing := CHIBI_FACING_ENUM_BACK
+		if key.isFront {
+			facing = CHIBI_FACING_ENUM_FRONT
+		}
+		if key.isBase {
+			delete(skinEntry.Base, facing)
+		} else {
+			delete(skinEntry.Battle, facing)
+		}
+		if len(skinEntry.Base) == 0 && len(skinEntry.Battle) == 0 {
+			delete(s.Data[key.operatorName].Skins, key.skinName)
+		}
+		if len(s.Data[key.operatorName].Skins) == 0 {
+			delete(s.Data, key.operatorName)
+		}
 	}
 
 	// Fix up the skin names to make them easier to work with
@@ -325,8 +394,9 @@ func (s *SpineAssetMap) Load(assetDir string, assetSubdir string) (err error) {
 		}
 	}
 
+	report.NumEntries = len(s.Data)
 	fmt.Printf("Loaded %d assets from %s\n", len(s.Data), assetDir)
-	return nil
+	return report, nil
 
 	// for opName, entry := range assetMap.data {
 	// 	log.Println(opName)
@@ -443,15 +513,23 @@ func (c *CommonNames) GetOperatorIdToName(operatorId string) []string {
 	return c.operatorIdToNames[operatorId]
 }
 
+func (c *CommonNames) add(operatorId string, names []string) {
+	c.operatorIdToNames[operatorId] = names
+	for _, name := range names {
+		c.namesToOperatorId[name] = append(c.namesToOperatorId[name], operatorId)
+		c.allNames = append(c.allNames, name)
+	}
+}
+
 func (s *CommonNames) MergeLoad(assetFilePath string) error {
 	savedNames := make(map[string]([]string))
 	data, err := os.ReadFile(assetFilePath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	err = json.Unmarshal(data, &savedNames)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to parse %s: %w", assetFilePath, err)
 	}
 
 	nameToOperatorId := make(map[string][]string)
@@ -550,87 +628,184 @@ func (s *CommonNames) FindMatchs(userInput string, numSuggestions int) (output [
 	return
 }
 
+// factionAssets are the loaded assets of a single faction
+type factionAssets struct {
+	faction     *Faction
+	assetMap    *SpineAssetMap
+	commonNames *CommonNames
+	metadata    *OperatorMetadataMap
+}
+
 type AssetService struct {
-	AssetMap         *SpineAssetMap
-	CommonNames      *CommonNames
-	EnemyAssetMap    *SpineAssetMap
-	EnemyCommonNames *CommonNames
+	// Keyed by faction name, factionOrder keeps the order of the factions file
+	factions     map[FactionEnum]*factionAssets
+	factionOrder []FactionEnum
+
+	// Guards swapping the maps during a Reload
+	mutex          sync.RWMutex
+	assetDir       string
+	strict         bool
+	indexModTimes  map[string]time.Time
+	reloadingMutex sync.Mutex
 }
 
-func NewAssetService(assetDirArg misc.ImageAssetDirString) (*AssetService, error) {
-	log.Println("NewAssetService created")
-	assetDir := string(assetDirArg)
+func newAssetService(factions []*Faction) *AssetService {
 	s := &AssetService{
-		AssetMap:         NewSpineAssetMap(),
-		CommonNames:      NewCommonNames(),
-		EnemyAssetMap:    NewSpineAssetMap(),
-		EnemyCommonNames: NewCommonNames(),
+		factions:     make(map[FactionEnum]*factionAssets),
+		factionOrder: make([]FactionEnum, 0, len(factions)),
+	}
+	for _, faction := range factions {
+		s.factions[faction.Name] = &factionAssets{
+			faction:     faction,
+			assetMap:    NewSpineAssetMap(),
+			commonNames: NewCommonNames(),
+			metadata:    NewOperatorMetadataMap(),
+		}
+		s.factionOrder = append(s.factionOrder, faction.Name)
 	}
+	return s
+}
 
-	// if err := s.AssetMap.Load(assetDir, "characters"); err != nil {
-	// 	return nil, err
-	// }
-	if err := s.AssetMap.LoadFromIndex(filepath.Join(assetDir, "characters_index.json")); err != nil {
+func NewAssetService(assetDirArg misc.ImageAssetDirString, botConfig *misc.BotConfig) (*AssetService, error) {
+	log.Println("NewAssetService created")
+	assetDir := string(assetDirArg)
+	strict := botConfig.StrictAssetLoading
+	s, _, err := loadAssetService(assetDir, strict)
+	if err != nil {
 		return nil, err
 	}
-	if err := s.AssetMap.MergeFromIndex(filepath.Join(assetDir, "custom_index.json")); err != nil {
-		return nil, err
+	s.assetDir = assetDir
+	s.strict = strict
+	s.indexModTimes = readIndexModTimes(assetDir, s.indexFiles())
+	return s, nil
+}
+
+// loadAssetService loads the factions and then their asset maps from the
+// index files. Bad entries are skipped and printed, unless strict is set in
+// which case they fail the load.
+func loadAssetService(assetDir string, strict bool) (*AssetService, *AssetLoadReport, error) {
+	factions, err := LoadFactions(assetDir)
+	if err != nil {
+		return nil, nil, err
 	}
-	if err := s.CommonNames.Load(filepath.Join(assetDir, "saved_names.json")); err != nil {
-		return nil, err
+	s := newAssetService(factions)
+	report := NewAssetLoadReport(assetDir)
+
+	for _, name := range s.factionOrder {
+		loaded := s.factions[name]
+		for _, index := range loaded.faction.Indexes {
+			indexReport, err := loaded.assetMap.MergeFromIndex(filepath.Join(assetDir, index.IndexFile))
+			if err != nil {
+				return nil, nil, err
+			}
+			indexReport.Print()
+			report.Merge(indexReport)
+		}
+		for i, namesFile := range loaded.faction.NamesFiles {
+			load := loaded.commonNames.MergeLoad
+			if i == 0 {
+				load = loaded.commonNames.Load
+			}
+			if err := load(filepath.Join(assetDir, namesFile)); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if len(loaded.faction.MetadataFile) > 0 {
+			if err := loaded.metadata.Load(filepath.Join(assetDir, loaded.faction.MetadataFile)); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Check for missing assets
+		for operatorId, opNames := range loaded.commonNames.operatorIdToNames {
+			if _, ok := loaded.assetMap.Data[operatorId]; !ok {
+				if len(opNames) > 0 {
+					log.Println("Missing", name, operatorId)
+				}
+			}
+		}
 	}
-	if err := s.CommonNames.MergeLoad(filepath.Join(assetDir, "saved_custom_names.json")); err != nil {
-		return nil, err
+
+	if strict && report.HasErrors() {
+		return nil, nil, report.Err()
 	}
-	// if err := s.EnemyAssetMap.Load(assetDir, "enemies"); err != nil {
-	// 	return nil, err
-	// }
-	if err := s.EnemyAssetMap.LoadFromIndex(filepath.Join(assetDir, "enemy_index.json")); err != nil {
-		return nil, err
+	return s, report, nil
+}
+
+// GetFactions returns the loaded factions in the order they were listed
+func (s *AssetService) GetFactions() []*Faction {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	factions := make([]*Faction, 0, len(s.factionOrder))
+	for _, name := range s.factionOrder {
+		factions = append(factions, s.factions[name].faction)
 	}
-	if err := s.EnemyCommonNames.Load(filepath.Join(assetDir, "saved_enemy_names.json")); err != nil {
-		return nil, err
+	return factions
+}
+
+func (s *AssetService) GetFaction(faction FactionEnum) (*Faction, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	loaded, ok := s.factions[faction]
+	if !ok {
+		return nil, false
 	}
+	return loaded.faction, true
+}
 
-	// Check for missing assets
-	for enemyId, enemyNames := range s.EnemyCommonNames.operatorIdToNames {
-		if _, ok := s.EnemyAssetMap.Data[enemyId]; !ok {
-			if len(enemyNames) > 0 {
-				log.Println("Missing enemy", enemyId)
-			}
-		}
+// GetFactionByCommand returns the faction chosen by the chat command keyword
+func (s *AssetService) GetFactionByCommand(command string) (*Faction, bool) {
+	command = strings.ToLower(command)
+	if len(command) == 0 {
+		return nil, false
 	}
-	for operatorId, opNames := range s.CommonNames.operatorIdToNames {
-		if _, ok := s.AssetMap.Data[operatorId]; !ok {
-			if len(opNames) > 0 {
-				log.Println("Missing operator", operatorId)
-			}
+	for _, faction := range s.GetFactions() {
+		if strings.ToLower(faction.Command) == command {
+			return faction, true
 		}
 	}
+	return nil, false
+}
 
-	return s, nil
+func (s *AssetService) ParseFaction(str string) (FactionEnum, error) {
+	faction, ok := s.GetFaction(FactionEnum(strings.ToLower(str)))
+	if !ok {
+		return "", fmt.Errorf("invalid faction type (%s)", str)
+	}
+	return faction.Name, nil
 }
 
 func (s *AssetService) GetAssetMapFromFaction(faction FactionEnum) *SpineAssetMap {
-	switch faction {
-	case FACTION_ENUM_OPERATOR:
-		return s.AssetMap
-	case FACTION_ENUM_ENEMY:
-		return s.EnemyAssetMap
-	default:
-		log.Fatalf("Unknown faction when fetching assetmap: %v", faction)
-		return nil
-	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	loaded, ok := s.factions[faction]
+	if !ok {
+		log.Printf("Unknown faction when fetching assetmap: %v\n", faction)
+		return NewSpineAssetMap()
+	}
+	return loaded.assetMap
 }
 
 func (s *AssetService) GetCommonNamesFromFaction(faction FactionEnum) *CommonNames {
-	switch faction {
-	case FACTION_ENUM_OPERATOR:
-		return s.CommonNames
-	case FACTION_ENUM_ENEMY:
-		return s.EnemyCommonNames
-	default:
-		log.Fatalf("Unknown faction when fetching common names: %v", faction)
-		return nil
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	loaded, ok := s.factions[faction]
+	if !ok {
+		log.Printf("Unknown faction when fetching common names: %v\n", faction)
+		return NewCommonNames()
+	}
+	return loaded.commonNames
+}
+
+// GetMetadataFromFaction returns the rarity/class/nation of the faction's
+// chibis. Empty if the faction has no metadata file.
+func (s *AssetService) GetMetadataFromFaction(faction FactionEnum) *OperatorMetadataMap {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	loaded, ok := s.factions[faction]
+	if !ok {
+		return NewOperatorMetadataMap()
 	}
+	return loaded.metadata
 }
@@ -3,13 +3,10 @@ package operator
 // import "github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 
 func NewTestAssetService() *AssetService {
-	a := &AssetService{
-		AssetMap:         NewSpineAssetMap(),
-		CommonNames:      NewCommonNames(),
-		EnemyAssetMap:    NewSpineAssetMap(),
-		EnemyCommonNames: NewCommonNames(),
-	}
-	a.AssetMap.Data["char_002_amiya"] = &ChibiAssetPathEntry{
+	a := newAssetService(DefaultFactions())
+	operators := a.factions[FACTION_ENUM_OPERATOR]
+	enemies := a.factions[FACTION_ENUM_ENEMY]
+	operators.assetMap.Data["char_002_amiya"] = &ChibiAssetPathEntry{
 		Skins: map[string]*SpineSkinData{
 			DEFAULT_SKIN_NAME: {
 				Base: map[ChibiFacingEnum]*SpineData{
@@ -37,11 +34,17 @@ func NewTestAssetService() *AssetService {
 			},
 		},
 	}
-	a.CommonNames.allNames = []string{"Amiya"}
-	a.CommonNames.operatorIdToNames = map[string][]string{"char_002_amiya": {"Amiya"}}
-	a.CommonNames.namesToOperatorId = map[string][]string{"Amiya": {"char_002_amiya"}}
+	operators.commonNames.allNames = []string{"Amiya"}
+	operators.commonNames.operatorIdToNames = map[string][]string{"char_002_amiya": {"Amiya"}}
+	operators.commonNames.namesToOperatorId = map[string][]string{"Amiya": {"char_002_amiya"}}
+	operators.metadata.Data["char_002_amiya"] = &OperatorMetadata{
+		Rarity:   5,
+		Class:    "caster",
+		SubClass: "corecaster",
+		Nation:   "rhodes",
+	}
 
-	a.EnemyAssetMap.Data["enemy_1007_slime_2"] = &ChibiAssetPathEntry{
+	enemies.assetMap.Data["enemy_1007_slime_2"] = &ChibiAssetPathEntry{
 		Skins: map[string]*SpineSkinData{
 			DEFAULT_SKIN_NAME: {
 				Battle: map[ChibiFacingEnum]*SpineData{
@@ -55,8 +58,8 @@ func NewTestAssetService() *AssetService {
 			},
 		},
 	}
-	a.EnemyCommonNames.allNames = []string{"Slug"}
-	a.EnemyCommonNames.operatorIdToNames = map[string][]string{"enemy_1007_slime_2": {"Slug"}}
-	a.EnemyCommonNames.namesToOperatorId = map[string][]string{"Slug": {"enemy_1007_slime_2"}}
+	enemies.commonNames.allNames = []string{"Slug"}
+	enemies.commonNames.operatorIdToNames = map[string][]string{"enemy_1007_slime_2": {"Slug"}}
+	enemies.commonNames.namesToOperatorId = map[string][]string{"Slug": {"enemy_1007_slime_2"}}
 	return a
 }
@@ -0,0 +1,142 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type AssetReloadReport struct {
+	NumOperators int `json:"num_operators"`
+	NumEnemies   int `json:"num_enemies"`
+	// Number of chibis loaded in each faction
+	NumChibis         map[FactionEnum]int `json:"num_chibis"`
+	AddedIds          []string            `json:"added_ids"`
+	RemovedIds        []string            `json:"removed_ids"`
+	NumChibisMigrated int                 `json:"num_chibis_migrated"`
+	LoadErrors        []*AssetLoadError   `json:"load_errors"`
+	Duration          time.Duration       `json:"duration"`
+}
+
+// indexFiles returns the factions file along with the index and names files
+// of every loaded faction.
+func (s *AssetService) indexFiles() []string {
+	files := []string{FACTIONS_INDEX_FILE}
+	for _, faction := range s.GetFactions() {
+		files = append(files, faction.Files()...)
+	}
+	return files
+}
+
+func readIndexModTimes(assetDir string, indexFiles []string) map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, indexFile := range indexFiles {
+		info, err := os.Stat(filepath.Join(assetDir, indexFile))
+		if err != nil {
+			continue
+		}
+		modTimes[indexFile] = info.ModTime()
+	}
+	return modTimes
+}
+
+// IndexFilesChanged reports whether the factions file or any of the index or
+// names files have been modified since they were last loaded.
+func (s *AssetService) IndexFilesChanged() bool {
+	if len(s.assetDir) == 0 {
+		return false
+	}
+	current := readIndexModTimes(s.assetDir, s.indexFiles())
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if len(current) != len(s.indexModTimes) {
+		return true
+	}
+	for indexFile, modTime := range current {
+		if !s.indexModTimes[indexFile].Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload rebuilds the asset maps and common names from the asset directory
+// and atomically swaps them in. If loading fails the current assets are kept.
+func (s *AssetService) Reload() (*AssetReloadReport, error) {
+	if len(s.assetDir) == 0 {
+		return nil, fmt.Errorf("asset service was not loaded from a directory")
+	}
+	// Only one reload at a time, readers are only blocked during the swap
+	s.reloadingMutex.Lock()
+	defer s.reloadingMutex.Unlock()
+
+	start := time.Now()
+	loaded, loadReport, err := loadAssetService(s.assetDir, s.strict)
+	if err != nil {
+		return nil, err
+	}
+	modTimes := readIndexModTimes(s.assetDir, loaded.indexFiles())
+
+	report := &AssetReloadReport{
+		NumChibis:  make(map[FactionEnum]int),
+		AddedIds:   make([]string, 0),
+		RemovedIds: make([]string, 0),
+		LoadErrors: loadReport.Errors,
+	}
+	// Diff every faction which was or is now loaded
+	factions := loaded.factionOrder
+	for _, faction := range s.GetFactions() {
+		if _, ok := loaded.factions[faction.Name]; !ok {
+			factions = append(factions, faction.Name)
+		}
+	}
+	for _, faction := range factions {
+		newAssetMap := NewSpineAssetMap()
+		if loadedFaction, ok := loaded.factions[faction]; ok {
+			newAssetMap = loadedFaction.assetMap
+			report.NumChibis[faction] = len(newAssetMap.Data)
+		}
+		oldAssetMap := NewSpineAssetMap()
+		if _, ok := s.GetFaction(faction); ok {
+			oldAssetMap = s.GetAssetMapFromFaction(faction)
+		}
+		diffAssetMapIds(oldAssetMap, newAssetMap, report)
+	}
+	report.NumOperators = report.NumChibis[FACTION_ENUM_OPERATOR]
+	report.NumEnemies = report.NumChibis[FACTION_ENUM_ENEMY]
+
+	s.mutex.Lock()
+	s.factions = loaded.factions
+	s.factionOrder = loaded.factionOrder
+	s.indexModTimes = modTimes
+	s.mutex.Unlock()
+
+	report.Duration = time.Since(start)
+	log.Printf(
+		"Reloaded assets: %d factions, %d operators, %d enemies, %d added, %d removed\n",
+		len(report.NumChibis), report.NumOperators, report.NumEnemies, len(report.AddedIds), len(report.RemovedIds),
+	)
+	return report, nil
+}
+
+func diffAssetMapIds(oldMap *SpineAssetMap, newMap *SpineAssetMap, report *AssetReloadReport) {
+	added := make([]string, 0)
+	removed := make([]string, 0)
+	for id := range newMap.Data {
+		if _, ok := oldMap.Data[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	for id := range oldMap.Data {
+		if _, ok := newMap.Data[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	report.AddedIds = append(report.AddedIds, added...)
+	report.RemovedIds = append(report.RemovedIds, removed...)
+}
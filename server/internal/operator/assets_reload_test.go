@@ -0,0 +1,86 @@
+package operator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestIndexFiles(t *testing.T, assetDir string, operatorIds []string) {
+	assetMap := NewSpineAssetMap()
+	names := make(map[string][]string)
+	for _, operatorId := range operatorIds {
+		assetMap.Data[operatorId] = &ChibiAssetPathEntry{
+			Skins: map[string]*SpineSkinData{
+				DEFAULT_SKIN_NAME: {
+					Base: map[ChibiFacingEnum]*SpineData{
+						CHIBI_FACING_ENUM_FRONT: {
+							PlaformIndieAtlasFilepath: "characters/" + operatorId + "/base.atlas",
+							PlaformIndiePngFilepath:   "characters/" + operatorId + "/base.png",
+							Animations:                []string{DEFAULT_ANIM_BASE},
+						},
+					},
+				},
+			},
+		}
+		names[operatorId] = []string{"name_" + operatorId}
+	}
+	write := func(name string, v interface{}) {
+		data, _ := json.Marshal(v)
+		if err := os.WriteFile(filepath.Join(assetDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("characters_index.json", assetMap)
+	write("custom_index.json", NewSpineAssetMap())
+	write("enemy_index.json", NewSpineAssetMap())
+	write("saved_names.json", names)
+	write("saved_custom_names.json", map[string][]string{})
+	write("saved_enemy_names.json", map[string][]string{})
+}
+
+func TestAssetService_Reload(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestIndexFiles(t, assetDir, []string{"char_001", "char_002"})
+
+	sut, err := NewAssetService(misc.ImageAssetDirString(assetDir), &misc.BotConfig{})
+	assert.Nil(err)
+	assert.Equal(2, len(sut.GetAssetMapFromFaction(FACTION_ENUM_OPERATOR).Data))
+	assert.False(sut.IndexFilesChanged())
+
+	writeTestIndexFiles(t, assetDir, []string{"char_002", "char_003"})
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(assetDir, "characters_index.json"), future, future)
+	assert.True(sut.IndexFilesChanged())
+
+	report, err := sut.Reload()
+	assert.Nil(err)
+	assert.Equal(2, report.NumOperators)
+	assert.Equal([]string{"char_003"}, report.AddedIds)
+	assert.Equal([]string{"char_001"}, report.RemovedIds)
+	assert.False(sut.IndexFilesChanged())
+
+	assetMap := sut.GetAssetMapFromFaction(FACTION_ENUM_OPERATOR)
+	assert.Contains(assetMap.Data, "char_003")
+	assert.NotContains(assetMap.Data, "char_001")
+	assert.Equal("name_char_003", sut.GetCommonNamesFromFaction(FACTION_ENUM_OPERATOR).GetCanonicalName("char_003"))
+}
+
+func TestAssetService_ReloadKeepsAssetsOnError(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestIndexFiles(t, assetDir, []string{"char_001"})
+	sut, err := NewAssetService(misc.ImageAssetDirString(assetDir), &misc.BotConfig{})
+	assert.Nil(err)
+
+	os.WriteFile(filepath.Join(assetDir, "saved_names.json"), []byte("{not json"), 0644)
+	_, err = sut.Reload()
+	assert.NotNil(err)
+	assert.Contains(sut.GetAssetMapFromFaction(FACTION_ENUM_OPERATOR).Data, "char_001")
+}
@@ -0,0 +1,115 @@
+package operator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpineAssetMap_LoadSkipsBadFiles(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	good := "characters/char_001/default/base/Front/"
+	writeTestAssetFile(t, assetDir, good+"char_001.atlas", "char_001.png")
+	writeTestAssetFile(t, assetDir, good+"char_001.png", "png")
+	writeTestAssetFile(t, assetDir, good+"char_001.skel", "skel")
+	writeTestAssetFile(t, assetDir, good+"char_001.animations.json", `{"animations": ["Move"]}`)
+	writeTestAssetFile(t, assetDir, good+"notes.txt", "stray file")
+	bad := "characters/char_002/default/battle/Front/"
+	writeTestAssetFile(t, assetDir, bad+"char_002.atlas", "char_002.png")
+	writeTestAssetFile(t, assetDir, bad+"char_002.animations.json", `{not json`)
+	writeTestAssetFile(t, assetDir, "characters/stray.json", "{}")
+
+	sut := NewSpineAssetMap()
+	report, err := sut.Load(assetDir, "characters")
+	assert.Nil(err)
+	assert.Equal(3, len(report.Errors))
+	assert.True(report.HasErrors())
+	assert.NotNil(report.Err())
+
+	assert.Contains(sut.Data, "char_001")
+	assert.NotContains(sut.Data, "char_002")
+	spineData := sut.Data["char_001"].Skins["default"].Base[CHIBI_FACING_ENUM_FRONT]
+	assert.Equal([]string{"Move"}, spineData.Animations)
+	assert.Equal(good+"char_001.atlas", spineData.PlaformIndieAtlasFilepath)
+}
+
+func TestSpineAssetMap_MergeFromIndexSkipsBadPaths(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestAssetFile(t, assetDir, "index.json", `{"data": {
+		"char_001": {"skins": {"default": {
+			"base": {"Front": {"atlas_filepath": "characters/char_001/base.atlas"}},
+			"battle": {"Front": {"atlas_filepath": "../outside/char_001.atlas"}}
+		}}}
+	}}`)
+
+	sut := NewSpineAssetMap()
+	report, err := sut.MergeFromIndex(filepath.Join(assetDir, "index.json"))
+	assert.Nil(err)
+	assert.Equal(1, report.NumEntries)
+	assert.Equal(1, len(report.Errors))
+	assert.Equal("char_001/default/battle/Front", report.Errors[0].File)
+
+	skin := sut.Data["char_001"].Skins["default"]
+	assert.Contains(skin.Base, CHIBI_FACING_ENUM_FRONT)
+	assert.Empty(skin.Battle)
+
+	_, err = sut.MergeFromIndex(filepath.Join(assetDir, "missing.json"))
+	assert.NotNil(err)
+}
+
+func TestSpineAssetMap_MergeFromIndexPrunesEmptyEntries(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestAssetFile(t, assetDir, "index.json", `{"data": {
+		"char_001": {"skins": {
+			"default": {"base": {"Front": {"atlas_filepath": "characters/char_001/base.atlas"}}},
+			"epoque": {"base": {"Front": {"atlas_filepath": "../outside/char_001.atlas"}}}
+		}},
+		"char_002": {"skins": {"default": {
+			"battle": {"Back": {"atlas_filepath": "/abs/char_002.atlas"}}
+		}}}
+	}}`)
+
+	sut := NewSpineAssetMap()
+	report, err := sut.MergeFromIndex(filepath.Join(assetDir, "index.json"))
+	assert.Nil(err)
+	assert.Equal(1, report.NumEntries)
+	assert.Equal(2, len(report.Errors))
+
+	assert.Contains(sut.Data["char_001"].Skins, "default")
+	assert.NotContains(sut.Data["char_001"].Skins, "epoque")
+	assert.NotContains(sut.Data, "char_002")
+}
+
+func TestAssetService_StrictAssetLoading(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestIndexFiles(t, assetDir, []string{"char_001"})
+	writeTestAssetFile(t, assetDir, "custom_index.json", `{"data": {
+		"char_002": {"skins": {"default": {
+			"base": {"Front": {"atlas_filepath": "../char_002.atlas"}}
+		}}}
+	}}`)
+
+	sut, err := NewAssetService(misc.ImageAssetDirString(assetDir), &misc.BotConfig{})
+	assert.Nil(err)
+	assert.Contains(sut.GetAssetMapFromFaction(FACTION_ENUM_OPERATOR).Data, "char_001")
+
+	_, err = NewAssetService(misc.ImageAssetDirString(assetDir), &misc.BotConfig{StrictAssetLoading: true})
+	assert.NotNil(err)
+}
+
+func TestAssetService_UnknownFactionIsEmpty(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestIndexFiles(t, assetDir, []string{"char_001"})
+	sut, err := NewAssetService(misc.ImageAssetDirString(assetDir), &misc.BotConfig{})
+	assert.Nil(err)
+
+	assert.Empty(sut.GetAssetMapFromFaction("unknown").Data)
+	assert.NotNil(sut.GetCommonNamesFromFaction("unknown"))
+}
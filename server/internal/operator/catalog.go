@@ -0,0 +1,132 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	CATALOG_DEFAULT_LIMIT = 50
+	CATALOG_MAX_LIMIT     = 200
+)
+
+type CatalogEntry struct {
+	Id    string   `json:"id"`
+	Name  string   `json:"name"`
+	Names []string `json:"names"`
+	Skins []string `json:"skins"`
+}
+
+type CatalogPage struct {
+	Entries []*CatalogEntry `json:"entries"`
+	Total   int             `json:"total"`
+	Offset  int             `json:"offset"`
+	Limit   int             `json:"limit"`
+}
+
+type CatalogDetail struct {
+	*GetOperatorResponse
+	Faction FactionEnum `json:"faction"`
+	Names   []string    `json:"names"`
+	// Not set if there is no metadata for the chibi
+	Metadata *OperatorMetadata `json:"metadata,omitempty"`
+}
+
+func catalogEntryMatches(entry *CatalogEntry, query string) bool {
+	if len(query) == 0 {
+		return true
+	}
+	if strings.Contains(strings.ToLower(entry.Id), query) {
+		return true
+	}
+	for _, name := range entry.Names {
+		if strings.Contains(strings.ToLower(name), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListCatalog returns a page of the loaded operators/enemies of the faction,
+// sorted by display name. query is matched case-insensitively against the
+// id and all of the common names.
+func (s *OperatorService) ListCatalog(
+	faction FactionEnum,
+	query string,
+	offset int,
+	limit int,
+) (*CatalogPage, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must be non-negative")
+	}
+	if limit <= 0 {
+		limit = CATALOG_DEFAULT_LIMIT
+	}
+	limit = min(limit, CATALOG_MAX_LIMIT)
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	assetMap := s.Assets.GetAssetMapFromFaction(faction)
+	commonNames := s.Assets.GetCommonNamesFromFaction(faction)
+
+	matches := make([]*CatalogEntry, 0)
+	for operatorId, operatorData := range assetMap.Data {
+		entry := &CatalogEntry{
+			Id:    operatorId,
+			Name:  operatorId,
+			Names: commonNames.GetOperatorIdToName(operatorId),
+			Skins: make([]string, 0, len(operatorData.Skins)),
+		}
+		if len(entry.Names) > 0 {
+			entry.Name = entry.Names[0]
+		} else {
+			entry.Names = []string{}
+		}
+		if !catalogEntryMatches(entry, query) {
+			continue
+		}
+		for skinName := range operatorData.Skins {
+			entry.Skins = append(entry.Skins, skinName)
+		}
+		sort.Strings(entry.Skins)
+		matches = append(matches, entry)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Name != matches[j].Name {
+			return matches[i].Name < matches[j].Name
+		}
+		return matches[i].Id < matches[j].Id
+	})
+
+	start := min(offset, len(matches))
+	end := start + min(limit, len(matches)-start)
+	return &CatalogPage{
+		Entries: matches[start:end],
+		Total:   len(matches),
+		Offset:  offset,
+		Limit:   limit,
+	}, nil
+}
+
+// GetCatalogDetail returns the skins, stances, facings and animations of a
+// single operator/enemy along with all of its common names.
+func (s *OperatorService) GetCatalogDetail(operatorId string, faction FactionEnum) (*CatalogDetail, error) {
+	if _, ok := s.Assets.GetAssetMapFromFaction(faction).Data[operatorId]; !ok {
+		return nil, fmt.Errorf("no %s with id %s is loaded", faction, operatorId)
+	}
+	resp, err := s.GetOperator(operatorId, faction)
+	if err != nil {
+		return nil, err
+	}
+	names := s.Assets.GetCommonNamesFromFaction(faction).GetOperatorIdToName(operatorId)
+	if names == nil {
+		names = []string{}
+	}
+	metadata, _ := s.Assets.GetMetadataFromFaction(faction).Get(operatorId)
+	return &CatalogDetail{
+		GetOperatorResponse: resp,
+		Faction:             faction,
+		Names:               names,
+		Metadata:            metadata,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package operator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCatalog(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewOperatorService(NewTestAssetService(), misc.DefaultSpineRuntimeConfig())
+
+	page, err := sut.ListCatalog(FACTION_ENUM_OPERATOR, "", 0, 0)
+	assert.Nil(err)
+	assert.Equal(1, page.Total)
+	assert.Equal(CATALOG_DEFAULT_LIMIT, page.Limit)
+	assert.Equal("char_002_amiya", page.Entries[0].Id)
+	assert.Equal("Amiya", page.Entries[0].Name)
+	assert.Equal([]string{DEFAULT_SKIN_NAME}, page.Entries[0].Skins)
+
+	page, err = sut.ListCatalog(FACTION_ENUM_OPERATOR, "AMI", 0, 10)
+	assert.Nil(err)
+	assert.Equal(1, page.Total)
+
+	page, err = sut.ListCatalog(FACTION_ENUM_OPERATOR, "slug", 0, 10)
+	assert.Nil(err)
+	assert.Equal(0, page.Total)
+	assert.Empty(page.Entries)
+
+	page, err = sut.ListCatalog(FACTION_ENUM_ENEMY, "slug", 5, 10)
+	assert.Nil(err)
+	assert.Equal(1, page.Total)
+	assert.Empty(page.Entries)
+
+	page, err = sut.ListCatalog(FACTION_ENUM_OPERATOR, "", math.MaxInt, 10)
+	assert.Nil(err)
+	assert.Equal(1, page.Total)
+	assert.Empty(page.Entries)
+
+	_, err = sut.ListCatalog(FACTION_ENUM_ENEMY, "", -1, 10)
+	assert.NotNil(err)
+}
+
+func TestGetCatalogDetail(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewOperatorService(NewTestAssetService(), misc.DefaultSpineRuntimeConfig())
+
+	detail, err := sut.GetCatalogDetail("enemy_1007_slime_2", FACTION_ENUM_ENEMY)
+	assert.Nil(err)
+	assert.Equal("Slug", detail.OperatorName)
+	assert.Equal([]string{"Slug"}, detail.Names)
+	assert.Contains(detail.Skins, DEFAULT_SKIN_NAME)
+
+	_, err = sut.GetCatalogDetail("enemy_1007_slime_2", FACTION_ENUM_OPERATOR)
+	assert.NotNil(err)
+}
@@ -0,0 +1,266 @@
+package operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Uploaded chibis are stored under this asset subdirectory
+	CUSTOM_CHIBI_ASSET_SUBDIR = "uploads"
+	CUSTOM_CHIBI_ID_PREFIX    = "custom_"
+	CUSTOM_CHIBI_MAX_NAME_LEN = 32
+	// Largest width or height of an uploaded texture page
+	CUSTOM_CHIBI_MAX_TEXTURE_SIZE = 4096
+)
+
+var customChibiNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// CustomChibiOperatorId returns the namespaced operator id of a room's
+// uploaded chibi. Including the room id keeps the chibi out of other rooms.
+func CustomChibiOperatorId(roomId uint, name string) (string, error) {
+	slug := strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if len(slug) == 0 || len(slug) > CUSTOM_CHIBI_MAX_NAME_LEN {
+		return "", fmt.Errorf("name must be between 1 and %d characters", CUSTOM_CHIBI_MAX_NAME_LEN)
+	}
+	if !customChibiNameRegex.MatchString(slug) {
+		return "", errors.New("name can only contain letters, numbers, spaces and underscores")
+	}
+	return fmt.Sprintf("%s%d_%s", CUSTOM_CHIBI_ID_PREFIX, roomId, slug), nil
+}
+
+// CustomChibiAssetDir is the asset directory of one stance/facing of an
+// uploaded chibi.
+func CustomChibiAssetDir(operatorId string, stance ChibiStanceEnum, facing ChibiFacingEnum) string {
+	return path.Join(
+		CUSTOM_CHIBI_ASSET_SUBDIR,
+		operatorId,
+		DEFAULT_SKIN_NAME,
+		string(stance),
+		string(facing),
+	)
+}
+
+// CustomAssets holds the chibis uploaded for a single room. They are looked
+// up before the shared catalog by that room's OperatorService. The maps are
+// rebuilt on every change so readers can use a snapshot without locking.
+type CustomAssets struct {
+	mutex       sync.RWMutex
+	names       map[string]string
+	entries     map[string]*ChibiAssetPathEntry
+	assetMap    *SpineAssetMap
+	commonNames *CommonNames
+}
+
+func NewCustomAssets() *CustomAssets {
+	return &CustomAssets{
+		names:       make(map[string]string),
+		entries:     make(map[string]*ChibiAssetPathEntry),
+		assetMap:    NewSpineAssetMap(),
+		commonNames: NewCommonNames(),
+	}
+}
+
+func (c *CustomAssets) Set(operatorId string, name string, entry *ChibiAssetPathEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.names[operatorId] = name
+	c.entries[operatorId] = entry
+	c.rebuild()
+}
+
+func (c *CustomAssets) Remove(operatorId string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.names, operatorId)
+	delete(c.entries, operatorId)
+	c.rebuild()
+}
+
+// Reset replaces all of the chibis. names and entries are keyed by operator id
+func (c *CustomAssets) Reset(names map[string]string, entries map[string]*ChibiAssetPathEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.names = names
+	c.entries = entries
+	c.rebuild()
+}
+
+func (c *CustomAssets) rebuild() {
+	assetMap := NewSpineAssetMap()
+	commonNames := NewCommonNames()
+	for operatorId, entry := range c.entries {
+		assetMap.Data[operatorId] = entry
+		commonNames.add(operatorId, []string{c.names[operatorId]})
+	}
+	c.assetMap = assetMap
+	c.commonNames = commonNames
+}
+
+func (c *CustomAssets) snapshot() (*SpineAssetMap, *CommonNames) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.assetMap, c.commonNames
+}
+
+// CustomChibiUpload is a single stance/facing of a Spine export
+type CustomChibiUpload struct {
+	OperatorId string
+	Stance     ChibiStanceEnum
+	Facing     ChibiFacingEnum
+	// map[filename]contents. Must include an .atlas, its .png pages and
+	// either a binary .skel or a .skel.json
+	Files map[string][]byte
+	// Required for binary skeletons. Read from the skeleton for .skel.json
+	Animations []string
+}
+
+// ValidateCustomChibiUpload lays the upload out like the asset directory and
+// runs it through SpineAssetMap.Load and ValidateSpineAssetMap, the same
+// checks as the shared catalog. On success it returns the SpineData and the
+// files to store keyed by their asset path. Validation warnings are returned
+// in the report, any error in the report fails the upload.
+func ValidateCustomChibiUpload(upload *CustomChibiUpload) (
+	*SpineData,
+	map[string][]byte,
+	*AssetValidationReport,
+	error,
+) {
+	files, err := customChibiFiles(upload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "custom_chibi")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	for assetPath, data := range files {
+		fullPath := filepath.Join(tmpDir, filepath.FromSlash(assetPath))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	assetMap := NewSpineAssetMap()
+	loadReport, err := assetMap.Load(tmpDir, CUSTOM_CHIBI_ASSET_SUBDIR)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	report := NewAssetValidationReport()
+	report.AddLoadReport(FACTION_ENUM_OPERATOR, loadReport)
+	ValidateSpineAssetMap(tmpDir, FACTION_ENUM_OPERATOR, assetMap, report)
+	report.Sort()
+	if report.HasFailures(false) {
+		return nil, nil, report, errors.New("uploaded chibi failed validation")
+	}
+
+	err = assetMap.Contains(upload.OperatorId, DEFAULT_SKIN_NAME, upload.Stance, upload.Facing, nil)
+	if err != nil {
+		return nil, nil, report, err
+	}
+	spineData := assetMap.Get(
+		upload.OperatorId,
+		DEFAULT_SKIN_NAME,
+		upload.Stance == CHIBI_STANCE_ENUM_BASE,
+		upload.Facing == CHIBI_FACING_ENUM_FRONT,
+	)
+	return spineData, files, report, nil
+}
+
+// validateCustomChibiPng only reads the image header. The pages are served
+// to browsers as-is so anything which isn't a reasonably sized PNG is
+// rejected.
+func validateCustomChibiPng(name string, data []byte) error {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != "png" {
+		return fmt.Errorf("%s is not a valid png", name)
+	}
+	if config.Width > CUSTOM_CHIBI_MAX_TEXTURE_SIZE || config.Height > CUSTOM_CHIBI_MAX_TEXTURE_SIZE {
+		return fmt.Errorf(
+			"%s is %dx%d, textures can be at most %dx%d",
+			name, config.Width, config.Height,
+			CUSTOM_CHIBI_MAX_TEXTURE_SIZE, CUSTOM_CHIBI_MAX_TEXTURE_SIZE,
+		)
+	}
+	return nil
+}
+
+// customChibiFiles returns the upload files keyed by their asset path. An
+// .animations.json is generated for the skeleton if one was not uploaded.
+func customChibiFiles(upload *CustomChibiUpload) (map[string][]byte, error) {
+	dir := CustomChibiAssetDir(upload.OperatorId, upload.Stance, upload.Facing)
+	files := make(map[string][]byte)
+	var atlasName string
+	var skelJson []byte
+	hasAnimationsFile := false
+	for name, data := range upload.Files {
+		if name != path.Base(name) || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+			return nil, fmt.Errorf("invalid file name %s", name)
+		}
+		if !isKnownAssetFile(name) {
+			return nil, fmt.Errorf("unknown file type %s", name)
+		}
+		switch {
+		case strings.HasSuffix(name, ".png"):
+			if err := validateCustomChibiPng(name, data); err != nil {
+				return nil, err
+			}
+		case strings.HasSuffix(name, ".atlas"):
+			if len(atlasName) > 0 {
+				return nil, errors.New("only one .atlas file can be uploaded")
+			}
+			atlasName = name
+		case strings.HasSuffix(name, ".skel.json"):
+			skelJson = data
+		case strings.HasSuffix(name, ".animations.json"):
+			hasAnimationsFile = true
+		}
+		files[path.Join(dir, name)] = data
+	}
+	if len(atlasName) == 0 {
+		return nil, errors.New("missing .atlas file")
+	}
+	if hasAnimationsFile {
+		return files, nil
+	}
+
+	animations := upload.Animations
+	if len(animations) == 0 && skelJson != nil {
+		skeleton := struct {
+			Animations map[string]json.RawMessage `json:"animations"`
+		}{}
+		if err := json.Unmarshal(skelJson, &skeleton); err != nil {
+			return nil, fmt.Errorf("failed to parse skel json: %w", err)
+		}
+		for animation := range skeleton.Animations {
+			animations = append(animations, animation)
+		}
+		sort.Strings(animations)
+	}
+	if len(animations) == 0 {
+		return nil, errors.New("animations must be listed for binary .skel files")
+	}
+	animationsData, err := json.Marshal(&JsonSkelData{Animations: animations})
+	if err != nil {
+		return nil, err
+	}
+	animationsName := strings.TrimSuffix(atlasName, ".atlas") + ".animations.json"
+	files[path.Join(dir, animationsName)] = animationsData
+	return files, nil
+}
@@ -0,0 +1,150 @@
+package operator
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPng(width int, height int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	return buf.Bytes()
+}
+
+func newTestCustomChibiUpload() *CustomChibiUpload {
+	return &CustomChibiUpload{
+		OperatorId: "custom_1_kitty",
+		Stance:     CHIBI_STANCE_ENUM_BASE,
+		Facing:     CHIBI_FACING_ENUM_FRONT,
+		Files: map[string][]byte{
+			"kitty.atlas":     []byte("\nkitty.png\nsize: 10,10\nregion\n  bounds: 0,0,1,1\n"),
+			"kitty.png":       newTestPng(10, 10),
+			"kitty.skel.json": []byte(`{"animations": {"Move": {}, "Relax": {}}}`),
+		},
+	}
+}
+
+func TestCustomChibiOperatorId(t *testing.T) {
+	assert := assert.New(t)
+	operatorId, err := CustomChibiOperatorId(12, "Hello  Kitty")
+	assert.Nil(err)
+	assert.Equal("custom_12_hello_kitty", operatorId)
+
+	_, err = CustomChibiOperatorId(12, "")
+	assert.NotNil(err)
+	_, err = CustomChibiOperatorId(12, "../kitty")
+	assert.NotNil(err)
+}
+
+func TestValidateCustomChibiUpload_SkelJson(t *testing.T) {
+	assert := assert.New(t)
+	spineData, files, report, err := ValidateCustomChibiUpload(newTestCustomChibiUpload())
+	assert.Nil(err)
+	assert.Empty(report.Issues)
+	assert.Equal([]string{"Move", "Relax"}, spineData.Animations)
+	assert.Equal("uploads/custom_1_kitty/default/base/Front/kitty.atlas", spineData.PlaformIndieAtlasFilepath)
+	assert.Equal("uploads/custom_1_kitty/default/base/Front/kitty.skel.json", spineData.PlaformIndieSkelJsonFilepath)
+	assert.Contains(files, "uploads/custom_1_kitty/default/base/Front/kitty.png")
+	assert.Contains(files, "uploads/custom_1_kitty/default/base/Front/kitty.animations.json")
+}
+
+func TestValidateCustomChibiUpload_BinarySkel(t *testing.T) {
+	assert := assert.New(t)
+	upload := newTestCustomChibiUpload()
+	delete(upload.Files, "kitty.skel.json")
+	upload.Files["kitty.skel"] = []byte("\x00\x05Move\x06Relax\x00")
+
+	_, _, _, err := ValidateCustomChibiUpload(upload)
+	assert.ErrorContains(err, "animations must be listed")
+
+	upload.Animations = []string{"Move", "Relax"}
+	spineData, _, _, err := ValidateCustomChibiUpload(upload)
+	assert.Nil(err)
+	assert.Equal("uploads/custom_1_kitty/default/base/Front/kitty.skel", spineData.PlaformIndieSkelFilepath)
+
+	// Animations which aren't in the skeleton fail validation
+	upload.Animations = []string{"Move", "Relax", "Dance"}
+	_, _, report, err := ValidateCustomChibiUpload(upload)
+	assert.NotNil(err)
+	assert.Contains(issueCodes(report), ASSET_ISSUE_UNKNOWN_ANIMATION)
+
+	// Even if they're a part of another name
+	upload.Animations = []string{"Move", "Relax", "Rel"}
+	_, _, report, err = ValidateCustomChibiUpload(upload)
+	assert.NotNil(err)
+	assert.Contains(issueCodes(report), ASSET_ISSUE_UNKNOWN_ANIMATION)
+}
+
+func TestValidateCustomChibiUpload_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	upload := newTestCustomChibiUpload()
+	upload.Files["other.png"] = upload.Files["kitty.png"]
+	delete(upload.Files, "kitty.png")
+	_, _, report, err := ValidateCustomChibiUpload(upload)
+	assert.NotNil(err)
+	assert.Contains(issueCodes(report), ASSET_ISSUE_ATLAS_PAGE_MISMATCH)
+
+	upload = newTestCustomChibiUpload()
+	upload.Files["../kitty.png"] = []byte("png")
+	_, _, _, err = ValidateCustomChibiUpload(upload)
+	assert.ErrorContains(err, "invalid file name")
+
+	upload = newTestCustomChibiUpload()
+	upload.Files["kitty.exe"] = []byte("exe")
+	_, _, _, err = ValidateCustomChibiUpload(upload)
+	assert.ErrorContains(err, "unknown file type")
+
+	upload = newTestCustomChibiUpload()
+	delete(upload.Files, "kitty.atlas")
+	_, _, _, err = ValidateCustomChibiUpload(upload)
+	assert.ErrorContains(err, "missing .atlas")
+
+	upload = newTestCustomChibiUpload()
+	upload.Files["kitty.png"] = []byte("<svg></svg>")
+	_, _, _, err = ValidateCustomChibiUpload(upload)
+	assert.ErrorContains(err, "kitty.png is not a valid png")
+
+	upload = newTestCustomChibiUpload()
+	upload.Files["kitty.png"] = newTestPng(CUSTOM_CHIBI_MAX_TEXTURE_SIZE+1, 1)
+	_, _, _, err = ValidateCustomChibiUpload(upload)
+	assert.ErrorContains(err, "textures can be at most")
+}
+
+func TestOperatorService_CustomAssets(t *testing.T) {
+	assert := assert.New(t)
+	spineData, _, _, err := ValidateCustomChibiUpload(newTestCustomChibiUpload())
+	assert.Nil(err)
+
+	shared := NewDefaultOperatorService(NewTestAssetService())
+	sut := shared.WithConfig(shared.getConfig())
+	custom := NewCustomAssets()
+	sut.SetCustomAssets(custom)
+	custom.Set("custom_1_kitty", "Kitty", &ChibiAssetPathEntry{
+		Skins: map[string]*SpineSkinData{
+			DEFAULT_SKIN_NAME: {
+				Base:   map[ChibiFacingEnum]*SpineData{CHIBI_FACING_ENUM_FRONT: spineData},
+				Battle: map[ChibiFacingEnum]*SpineData{},
+			},
+		},
+	})
+
+	operatorId, matches := sut.GetOperatorIdFromName("kitty", FACTION_ENUM_OPERATOR)
+	assert.Nil(matches)
+	assert.Equal("custom_1_kitty", operatorId)
+	resp, err := sut.GetOperator(operatorId, FACTION_ENUM_OPERATOR)
+	assert.Nil(err)
+	assert.Equal("Kitty", resp.OperatorName)
+
+	// Only visible to the room which owns it
+	_, err = shared.GetOperator(operatorId, FACTION_ENUM_OPERATOR)
+	assert.NotNil(err)
+
+	custom.Remove(operatorId)
+	_, err = sut.GetOperator(operatorId, FACTION_ENUM_OPERATOR)
+	assert.NotNil(err)
+}
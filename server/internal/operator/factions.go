@@ -0,0 +1,143 @@
+package operator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Lists the factions to load. Lives at the top of the asset directory next
+// to the index files. If it is missing DefaultFactions() is used.
+const FACTIONS_INDEX_FILE = "factions_index.json"
+
+// FactionIndex is an index file of a faction along with the asset
+// subdirectory that the index tool walks to generate it.
+type FactionIndex struct {
+	AssetSubdir string `json:"asset_subdir"`
+	IndexFile   string `json:"index_file"`
+}
+
+// Faction is a category of chibis (ie. operators, enemies, npcs) which has
+// its own asset map and common names.
+type Faction struct {
+	Name    FactionEnum    `json:"name"`
+	Indexes []FactionIndex `json:"indexes"`
+	// The first file is the base names, the others are merged over it
+	NamesFiles []string `json:"names_files"`
+	// Optional. Rarity/class/nation of the chibis, see OperatorMetadata
+	MetadataFile  string          `json:"metadata_file,omitempty"`
+	DefaultStance ChibiStanceEnum `json:"default_stance"`
+	// Keyword for choosing a chibi of this faction (ie. !chibi enemy <name>).
+	// Empty for the default faction which is chosen with !chibi <name>.
+	// The built-in chat commands take precedence over the keyword.
+	Command string `json:"command"`
+}
+
+type FactionsIndex struct {
+	Factions []*Faction `json:"factions"`
+}
+
+// DefaultFactions returns the operator and enemy factions which were loaded
+// before the factions were data-driven.
+func DefaultFactions() []*Faction {
+	return []*Faction{
+		{
+			Name: FACTION_ENUM_OPERATOR,
+			Indexes: []FactionIndex{
+				{AssetSubdir: "characters", IndexFile: "characters_index.json"},
+				{AssetSubdir: "custom", IndexFile: "custom_index.json"},
+			},
+			NamesFiles:    []string{"saved_names.json", "saved_custom_names.json"},
+			MetadataFile:  "operator_metadata_index.json",
+			DefaultStance: CHIBI_STANCE_ENUM_BASE,
+			Command:       "",
+		},
+		{
+			Name: FACTION_ENUM_ENEMY,
+			Indexes: []FactionIndex{
+				{AssetSubdir: "enemies", IndexFile: "enemy_index.json"},
+			},
+			NamesFiles:    []string{"saved_enemy_names.json"},
+			DefaultStance: CHIBI_STANCE_ENUM_BATTLE,
+			Command:       "enemy",
+		},
+	}
+}
+
+// LoadFactions reads the factions from the FACTIONS_INDEX_FILE in the asset
+// directory, falling back to DefaultFactions() if the file does not exist.
+func LoadFactions(assetDir string) ([]*Faction, error) {
+	data, err := os.ReadFile(filepath.Join(assetDir, FACTIONS_INDEX_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultFactions(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := FactionsIndex{}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FACTIONS_INDEX_FILE, err)
+	}
+	if err := ValidateFactions(index.Factions); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FACTIONS_INDEX_FILE, err)
+	}
+	return index.Factions, nil
+}
+
+// ValidateFactions checks that the names and command keywords are unique and
+// that the operator faction, which is used as the default, is present.
+func ValidateFactions(factions []*Faction) error {
+	names := make(map[FactionEnum]bool)
+	commands := make(map[string]bool)
+	for _, faction := range factions {
+		if len(faction.Name) == 0 || string(faction.Name) != strings.ToLower(string(faction.Name)) {
+			return fmt.Errorf("faction name (%s) must be non-empty and lowercase", faction.Name)
+		}
+		if names[faction.Name] {
+			return fmt.Errorf("duplicate faction %s", faction.Name)
+		}
+		names[faction.Name] = true
+
+		if len(faction.Indexes) == 0 {
+			return fmt.Errorf("faction %s has no index files", faction.Name)
+		}
+		if _, err := ChibiStanceEnum_Parse(string(faction.DefaultStance)); err != nil {
+			return fmt.Errorf("faction %s: %w", faction.Name, err)
+		}
+
+		command := strings.ToLower(faction.Command)
+		if faction.Name == FACTION_ENUM_OPERATOR {
+			if len(command) > 0 {
+				return fmt.Errorf("the %s faction cannot have a command", faction.Name)
+			}
+			continue
+		}
+		if len(command) == 0 || strings.ContainsAny(command, " \t") {
+			return fmt.Errorf("faction %s must have a single word command", faction.Name)
+		}
+		if commands[command] {
+			return fmt.Errorf("duplicate faction command %s", command)
+		}
+		commands[command] = true
+	}
+	if !names[FACTION_ENUM_OPERATOR] {
+		return fmt.Errorf("missing the %s faction", FACTION_ENUM_OPERATOR)
+	}
+	return nil
+}
+
+// Files returns the index, names and metadata files of the faction
+func (f *Faction) Files() []string {
+	files := make([]string, 0, len(f.Indexes)+len(f.NamesFiles)+1)
+	for _, index := range f.Indexes {
+		files = append(files, index.IndexFile)
+	}
+	files = append(files, f.NamesFiles...)
+	if len(f.MetadataFile) > 0 {
+		files = append(files, f.MetadataFile)
+	}
+	return files
+}
@@ -0,0 +1,114 @@
+package operator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+const testFactionsIndex = `{"factions": [
+	{
+		"name": "operator",
+		"indexes": [{"asset_subdir": "characters", "index_file": "characters_index.json"}],
+		"names_files": ["saved_names.json"],
+		"default_stance": "base"
+	},
+	{
+		"name": "npc",
+		"indexes": [{"asset_subdir": "npcs", "index_file": "npc_index.json"}],
+		"names_files": ["saved_npc_names.json"],
+		"default_stance": "battle",
+		"command": "NPC"
+	}
+]}`
+
+func TestLoadFactions_DefaultsWhenMissing(t *testing.T) {
+	assert := assert.New(t)
+	factions, err := LoadFactions(t.TempDir())
+	assert.Nil(err)
+	assert.Equal(DefaultFactions(), factions)
+}
+
+func TestValidateFactions(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(ValidateFactions(DefaultFactions()))
+
+	factions := DefaultFactions()
+	factions[1].Command = ""
+	assert.ErrorContains(ValidateFactions(factions), "must have a single word command")
+
+	factions = DefaultFactions()
+	factions[1].Name = FACTION_ENUM_OPERATOR
+	assert.ErrorContains(ValidateFactions(factions), "duplicate faction")
+
+	factions = DefaultFactions()
+	factions[1].DefaultStance = "sitting"
+	assert.ErrorContains(ValidateFactions(factions), "invalid chibi type")
+
+	assert.ErrorContains(ValidateFactions(DefaultFactions()[1:]), "missing the operator faction")
+}
+
+func TestAssetService_LoadsFactionsIndex(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestIndexFiles(t, assetDir, []string{"char_001"})
+	writeTestAssetFile(t, assetDir, FACTIONS_INDEX_FILE, testFactionsIndex)
+	writeTestAssetFile(t, assetDir, "npc_index.json", `{"data": {
+		"npc_001": {"skins": {"default": {
+			"battle": {"Front": {"atlas_filepath": "npcs/npc_001/battle.atlas", "animations": ["Idle"]}}
+		}}}
+	}}`)
+	writeTestAssetFile(t, assetDir, "saved_npc_names.json", `{"npc_001": ["Shopkeeper"]}`)
+
+	sut, err := NewAssetService(misc.ImageAssetDirString(assetDir), &misc.BotConfig{})
+	assert.Nil(err)
+	assert.Equal(2, len(sut.GetFactions()))
+	assert.Contains(sut.GetAssetMapFromFaction("npc").Data, "npc_001")
+	assert.Equal("Shopkeeper", sut.GetCommonNamesFromFaction("npc").GetCanonicalName("npc_001"))
+
+	faction, ok := sut.GetFactionByCommand("npc")
+	assert.True(ok)
+	assert.Equal(FactionEnum("npc"), faction.Name)
+	_, ok = sut.GetFactionByCommand("")
+	assert.False(ok)
+
+	parsed, err := sut.ParseFaction("NPC")
+	assert.Nil(err)
+	assert.Equal(FactionEnum("npc"), parsed)
+	// The enemy faction is not listed in the factions file
+	_, err = sut.ParseFaction("enemy")
+	assert.NotNil(err)
+
+	// Changing the factions file is picked up by a reload
+	assert.False(sut.IndexFilesChanged())
+	writeTestAssetFile(t, assetDir, FACTIONS_INDEX_FILE, `{"factions": [{
+		"name": "operator",
+		"indexes": [{"index_file": "characters_index.json"}],
+		"names_files": ["saved_names.json"],
+		"default_stance": "base"
+	}]}`)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(assetDir, FACTIONS_INDEX_FILE), future, future)
+	assert.True(sut.IndexFilesChanged())
+
+	report, err := sut.Reload()
+	assert.Nil(err)
+	assert.Equal(map[FactionEnum]int{FACTION_ENUM_OPERATOR: 1}, report.NumChibis)
+	assert.Equal([]string{"npc_001"}, report.RemovedIds)
+	_, err = sut.ParseFaction("npc")
+	assert.NotNil(err)
+}
+
+func TestAssetService_InvalidFactionsIndex(t *testing.T) {
+	assert := assert.New(t)
+	assetDir := t.TempDir()
+	writeTestIndexFiles(t, assetDir, []string{"char_001"})
+	writeTestAssetFile(t, assetDir, FACTIONS_INDEX_FILE, `{"factions": []}`)
+
+	_, err := NewAssetService(misc.ImageAssetDirString(assetDir), &misc.BotConfig{})
+	assert.ErrorContains(err, "missing the operator faction")
+}
@@ -0,0 +1,177 @@
+package operator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	OPERATOR_MIN_RARITY = 1
+	OPERATOR_MAX_RARITY = 6
+	// Limits the number of terms in a room's random pool filter
+	OPERATOR_FILTER_MAX_TERMS = 10
+)
+
+var OPERATOR_CLASSES = []string{
+	"vanguard",
+	"guard",
+	"defender",
+	"sniper",
+	"caster",
+	"medic",
+	"supporter",
+	"specialist",
+}
+
+// ie. 6star, 6stars, 6*, 6-star
+var operatorRarityRegex = regexp.MustCompile(`^([1-9])[\s-]?(stars?|\*)$`)
+
+// OperatorMetadata is the game's information about an operator. Generated
+// from the game's character table by tools/collect_operator_metadata.py
+type OperatorMetadata struct {
+	// 1-6 stars
+	Rarity   int    `json:"rarity"`
+	Class    string `json:"class"`
+	SubClass string `json:"sub_class"`
+	Nation   string `json:"nation"`
+	// YYYY-MM-DD. Optional
+	ReleaseDate string `json:"release_date,omitempty"`
+}
+
+type OperatorMetadataMap struct {
+	Data map[string]*OperatorMetadata `json:"data"`
+}
+
+func NewOperatorMetadataMap() *OperatorMetadataMap {
+	return &OperatorMetadataMap{
+		Data: make(map[string]*OperatorMetadata),
+	}
+}
+
+// Load reads the metadata file. A missing file is not an error since the
+// metadata is optional, the map is left empty instead.
+func (m *OperatorMetadataMap) Load(assetFilePath string) error {
+	data, err := os.ReadFile(assetFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	loaded := NewOperatorMetadataMap()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", assetFilePath, err)
+	}
+	for operatorId, metadata := range loaded.Data {
+		metadata.Class = strings.ToLower(metadata.Class)
+		metadata.SubClass = strings.ToLower(metadata.SubClass)
+		metadata.Nation = strings.ToLower(metadata.Nation)
+		m.Data[operatorId] = metadata
+	}
+	return nil
+}
+
+func (m *OperatorMetadataMap) Get(operatorId string) (*OperatorMetadata, bool) {
+	metadata, ok := m.Data[operatorId]
+	return metadata, ok
+}
+
+// OperatorFilter selects operators by their metadata. A term of each non-empty
+// field must match (ie. 5star or 6star, and caster).
+type OperatorFilter struct {
+	Rarities   []int    `json:"rarities,omitempty"`
+	Classes    []string `json:"classes,omitempty"`
+	SubClasses []string `json:"sub_classes,omitempty"`
+	Nations    []string `json:"nations,omitempty"`
+}
+
+func (f *OperatorFilter) IsEmpty() bool {
+	return len(f.Rarities) == 0 &&
+		len(f.Classes) == 0 &&
+		len(f.SubClasses) == 0 &&
+		len(f.Nations) == 0
+}
+
+// Matches reports whether the operator's metadata passes the filter. An empty
+// filter matches everything, even operators without any metadata.
+func (f *OperatorFilter) Matches(metadata *OperatorMetadata) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	if metadata == nil {
+		return false
+	}
+	if len(f.Rarities) > 0 && !slices.Contains(f.Rarities, metadata.Rarity) {
+		return false
+	}
+	if len(f.Classes) > 0 && !slices.Contains(f.Classes, metadata.Class) {
+		return false
+	}
+	if len(f.SubClasses) > 0 && !slices.Contains(f.SubClasses, metadata.SubClass) {
+		return false
+	}
+	if len(f.Nations) > 0 && !slices.Contains(f.Nations, metadata.Nation) {
+		return false
+	}
+	return true
+}
+
+// ParseOperatorFilter builds a filter from terms like "6star", "caster" or
+// "rhodes". Sub-classes and nations are checked against the loaded metadata
+// so that typos are reported instead of silently matching nothing.
+func ParseOperatorFilter(terms []string, metadata *OperatorMetadataMap) (*OperatorFilter, error) {
+	if len(terms) > OPERATOR_FILTER_MAX_TERMS {
+		return nil, fmt.Errorf("at most %d filter terms can be used", OPERATOR_FILTER_MAX_TERMS)
+	}
+	subClasses := make(map[string]bool)
+	nations := make(map[string]bool)
+	for _, operatorMetadata := range metadata.Data {
+		subClasses[operatorMetadata.SubClass] = true
+		nations[operatorMetadata.Nation] = true
+	}
+
+	filter := &OperatorFilter{}
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if matches := operatorRarityRegex.FindStringSubmatch(term); matches != nil {
+			rarity, _ := strconv.Atoi(matches[1])
+			if rarity < OPERATOR_MIN_RARITY || rarity > OPERATOR_MAX_RARITY {
+				return nil, fmt.Errorf("rarity must be between %d and %d stars", OPERATOR_MIN_RARITY, OPERATOR_MAX_RARITY)
+			}
+			if !slices.Contains(filter.Rarities, rarity) {
+				filter.Rarities = append(filter.Rarities, rarity)
+			}
+			continue
+		}
+		// Allow the plural (ie. casters)
+		class := strings.TrimSuffix(term, "s")
+		switch {
+		case len(term) == 0:
+			return nil, errors.New("filter terms cannot be empty")
+		case slices.Contains(OPERATOR_CLASSES, term):
+			filter.Classes = appendUnique(filter.Classes, term)
+		case slices.Contains(OPERATOR_CLASSES, class):
+			filter.Classes = appendUnique(filter.Classes, class)
+		case subClasses[term]:
+			filter.SubClasses = appendUnique(filter.SubClasses, term)
+		case nations[term]:
+			filter.Nations = appendUnique(filter.Nations, term)
+		default:
+			return nil, fmt.Errorf("unknown filter (%s)", term)
+		}
+	}
+	return filter, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
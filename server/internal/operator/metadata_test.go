@@ -0,0 +1,57 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOperatorFilter(t *testing.T) {
+	assert := assert.New(t)
+	metadata := NewTestAssetService().GetMetadataFromFaction(FACTION_ENUM_OPERATOR)
+
+	filter, err := ParseOperatorFilter([]string{"6star", "5*", "Casters", "corecaster", "rhodes", "6-star"}, metadata)
+	assert.Nil(err)
+	assert.Equal(&OperatorFilter{
+		Rarities:   []int{6, 5},
+		Classes:    []string{"caster"},
+		SubClasses: []string{"corecaster"},
+		Nations:    []string{"rhodes"},
+	}, filter)
+
+	_, err = ParseOperatorFilter([]string{"7star"}, metadata)
+	assert.ErrorContains(err, "rarity must be between")
+	_, err = ParseOperatorFilter([]string{"laterano"}, metadata)
+	assert.ErrorContains(err, "unknown filter")
+}
+
+func TestOperatorFilter_Matches(t *testing.T) {
+	assert := assert.New(t)
+	amiya := &OperatorMetadata{Rarity: 5, Class: "caster", SubClass: "corecaster", Nation: "rhodes"}
+
+	assert.True((&OperatorFilter{}).Matches(nil))
+	assert.True((&OperatorFilter{Rarities: []int{5, 6}, Classes: []string{"caster"}}).Matches(amiya))
+	assert.False((&OperatorFilter{Rarities: []int{6}, Classes: []string{"caster"}}).Matches(amiya))
+	assert.False((&OperatorFilter{Classes: []string{"caster"}}).Matches(nil))
+}
+
+func TestOperatorService_GetRandomOperatorPool(t *testing.T) {
+	assert := assert.New(t)
+	config := misc.DefaultSpineRuntimeConfig()
+	config.RandomPoolFilter = []string{"caster"}
+	sut := NewOperatorService(NewTestAssetService(), config)
+
+	opInfo, err := sut.GetRandomOperator()
+	assert.Nil(err)
+	assert.Equal("char_002_amiya", opInfo.OperatorId)
+
+	_, err = sut.GetRandomOperatorWithFilter(&OperatorFilter{Rarities: []int{6}})
+	assert.ErrorIs(err, ErrNoMatchingOperators)
+
+	// Falls back to all operators when the pool is empty
+	config.RandomPoolFilter = []string{"6star"}
+	opInfo, err = sut.GetRandomOperator()
+	assert.Nil(err)
+	assert.Equal("char_002_amiya", opInfo.OperatorId)
+}
@@ -137,17 +137,6 @@ const (
 	FACTION_ENUM_ENEMY    FactionEnum = "enemy"
 )
 
-func FactionEnum_Parse(str string) (FactionEnum, error) {
-	switch strings.ToLower(str) {
-	case "operator":
-		return FACTION_ENUM_OPERATOR, nil
-	case "enemy":
-		return FACTION_ENUM_ENEMY, nil
-	default:
-		return "", fmt.Errorf("invalid faction type (%s)", str)
-	}
-}
-
 func GetDefaultAnimForChibiStance(chibiStance ChibiStanceEnum) string {
 	if chibiStance == CHIBI_STANCE_ENUM_BASE {
 		return DEFAULT_ANIM_BASE
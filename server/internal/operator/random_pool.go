@@ -0,0 +1,297 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+)
+
+var ErrNoMatchingOperators = errors.New("no operators match the filter")
+
+// randomPoolEntry is a chibi which can be randomly given to a chatter
+type randomPoolEntry struct {
+	operatorId string
+	faction    FactionEnum
+	weight     float64
+}
+
+// GetRandomOperator picks from the room's random pool (see
+// SpineRuntimeConfig.RandomPoolFilter and RandomPool). Any operator can be
+// picked if the filter is invalid or the pool no longer has any chibis.
+func (s *OperatorService) GetRandomOperator() (*OperatorInfo, error) {
+	config := s.getConfig()
+	filter, err := s.ParseOperatorFilter(config.RandomPoolFilter)
+	if err != nil {
+		log.Println("Ignoring invalid random pool filter", err)
+		filter = &OperatorFilter{}
+	}
+	pool := &config.RandomPool
+	entries := s.buildRandomPool(filter, pool)
+	if len(entries) == 0 && (!filter.IsEmpty() || !pool.IsEmpty()) {
+		log.Println("No chibis in the random pool, picking from all operators")
+		pool = misc.DefaultRandomPool()
+		entries = s.buildRandomPool(&OperatorFilter{}, pool)
+	}
+	entry, ok := pickRandomPoolEntry(rand.Float64, entries)
+	if !ok {
+		return nil, ErrNoMatchingOperators
+	}
+	return s.newRandomOperatorInfo(rand.Float64, entry.operatorId, entry.faction, pool.SkinWeights)
+}
+
+// GetRandomOperatorForUser returns the chibi given to a chatter without saved
+// preferences. If the room has SeededChibis enabled the chibi is picked
+// deterministically from the room's pool using the twitch user id.
+func (s *OperatorService) GetRandomOperatorForUser(twitchUserId string) (*OperatorInfo, error) {
+	config := s.getConfig()
+	if !config.SeededChibis || len(twitchUserId) == 0 {
+		return s.GetRandomOperator()
+	}
+	return s.GetSeededOperator(config.ChibiSeed, twitchUserId)
+}
+
+// GetSeededOperator picks the operator, skin and starting action from the
+// room's random pool using a hash of the seed and twitch user id. The same
+// user gets the same chibi as long as the pool and the assets don't change.
+func (s *OperatorService) GetSeededOperator(seed int64, twitchUserId string) (*OperatorInfo, error) {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d:%s", seed, twitchUserId)
+	randFloat := rand.New(rand.NewSource(int64(hash.Sum64()))).Float64
+
+	config := s.getConfig()
+	filter, err := s.ParseOperatorFilter(config.RandomPoolFilter)
+	if err != nil {
+		filter = &OperatorFilter{}
+	}
+	pool := &config.RandomPool
+	entries := s.buildRandomPool(filter, pool)
+	if len(entries) == 0 {
+		pool = misc.DefaultRandomPool()
+		entries = s.buildRandomPool(&OperatorFilter{}, pool)
+	}
+	entry, ok := pickRandomPoolEntry(randFloat, entries)
+	if !ok {
+		return nil, ErrNoMatchingOperators
+	}
+	opInfo, err := s.newRandomOperatorInfo(randFloat, entry.operatorId, entry.faction, pool.SkinWeights)
+	if err != nil {
+		return nil, err
+	}
+	s.setRandomStartingAction(randFloat, opInfo)
+	return opInfo, nil
+}
+
+// GetRandomOperatorWithFilter picks uniformly from the operators matching the
+// filter, ignoring the room's random pool.
+func (s *OperatorService) GetRandomOperatorWithFilter(filter *OperatorFilter) (*OperatorInfo, error) {
+	entry, ok := pickRandomPoolEntry(rand.Float64, s.buildRandomPool(filter, misc.DefaultRandomPool()))
+	if !ok {
+		return nil, ErrNoMatchingOperators
+	}
+	return s.newRandomOperatorInfo(rand.Float64, entry.operatorId, entry.faction, nil)
+}
+
+// ValidateRandomPool checks that the ids of the pool are loaded. The room's
+// custom chibis are only checked when they are picked.
+func (s *OperatorService) ValidateRandomPool(pool *misc.RandomPool) error {
+	for _, operatorId := range slices.Concat(pool.IncludeOperators, pool.ExcludeOperators) {
+		if strings.HasPrefix(operatorId, CUSTOM_CHIBI_ID_PREFIX) {
+			continue
+		}
+		if _, ok := s.getAssetMap(operatorId, FACTION_ENUM_OPERATOR).Data[operatorId]; !ok {
+			return errors.New("unknown operator id " + operatorId)
+		}
+	}
+	for _, enemyId := range pool.IncludeEnemies {
+		if _, ok := s.Assets.GetAssetMapFromFaction(FACTION_ENUM_ENEMY).Data[enemyId]; !ok {
+			return errors.New("unknown enemy id " + enemyId)
+		}
+	}
+	return nil
+}
+
+// buildRandomPool returns the weighted chibis of the pool sorted by id
+func (s *OperatorService) buildRandomPool(filter *OperatorFilter, pool *misc.RandomPool) []randomPoolEntry {
+	operatorIds := pool.IncludeOperators
+	if len(operatorIds) == 0 {
+		operatorIds, _ = s.GetOperatorIds(FACTION_ENUM_OPERATOR)
+	}
+	metadata := s.Assets.GetMetadataFromFaction(FACTION_ENUM_OPERATOR)
+	entries := make([]randomPoolEntry, 0, len(operatorIds)+len(pool.IncludeEnemies))
+	for _, operatorId := range operatorIds {
+		if _, ok := s.getAssetMap(operatorId, FACTION_ENUM_OPERATOR).Data[operatorId]; !ok {
+			continue
+		}
+		if slices.Contains(pool.ExcludeOperators, operatorId) {
+			continue
+		}
+		operatorMetadata, _ := metadata.Get(operatorId)
+		if !filter.Matches(operatorMetadata) {
+			continue
+		}
+		entries = append(entries, randomPoolEntry{operatorId, FACTION_ENUM_OPERATOR, 1})
+	}
+	enemyAssetMap := s.Assets.GetAssetMapFromFaction(FACTION_ENUM_ENEMY)
+	for _, enemyId := range pool.IncludeEnemies {
+		if _, ok := enemyAssetMap.Data[enemyId]; ok {
+			entries = append(entries, randomPoolEntry{enemyId, FACTION_ENUM_ENEMY, 1})
+		}
+	}
+
+	weighted := make([]randomPoolEntry, 0, len(entries))
+	for _, entry := range entries {
+		if weight, ok := pool.Weights[entry.operatorId]; ok {
+			entry.weight = weight
+		}
+		if entry.weight > 0 {
+			weighted = append(weighted, entry)
+		}
+	}
+	sort.Slice(weighted, func(i, j int) bool {
+		return weighted[i].operatorId < weighted[j].operatorId
+	})
+	return weighted
+}
+
+// pickRandomPoolEntry picks an entry with a probability proportional to its
+// weight. randFloat returns a number in [0.0, 1.0)
+func pickRandomPoolEntry(randFloat func() float64, entries []randomPoolEntry) (randomPoolEntry, bool) {
+	total := 0.0
+	for _, entry := range entries {
+		total += entry.weight
+	}
+	if len(entries) == 0 || total <= 0 {
+		return randomPoolEntry{}, false
+	}
+	target := randFloat() * total
+	for _, entry := range entries {
+		target -= entry.weight
+		if target < 0 {
+			return entry, true
+		}
+	}
+	return entries[len(entries)-1], true
+}
+
+// pickRandomSkin picks a skin using the weights of the skins whose name
+// contains the key. The default skin is used if there are no weights.
+func pickRandomSkin(randFloat func() float64, skinNames []string, skinWeights map[string]float64) string {
+	if len(skinWeights) == 0 {
+		return DEFAULT_SKIN_NAME
+	}
+	skinNames = slices.Sorted(slices.Values(skinNames))
+	entries := make([]randomPoolEntry, 0, len(skinNames))
+	for _, skinName := range skinNames {
+		weight := 0.0
+		matched := false
+		for key, keyWeight := range skinWeights {
+			if strings.Contains(strings.ToLower(skinName), key) {
+				weight = max(weight, keyWeight)
+				matched = true
+			}
+		}
+		if !matched && skinName == DEFAULT_SKIN_NAME {
+			weight = 1
+		}
+		entries = append(entries, randomPoolEntry{operatorId: skinName, weight: weight})
+	}
+	entry, ok := pickRandomPoolEntry(randFloat, entries)
+	if !ok {
+		return DEFAULT_SKIN_NAME
+	}
+	return entry.operatorId
+}
+
+// newRandomOperatorInfo returns the chibi wandering around in the faction's
+// default stance
+func (s *OperatorService) newRandomOperatorInfo(
+	randFloat func() float64,
+	operatorId string,
+	faction FactionEnum,
+	skinWeights map[string]float64,
+) (*OperatorInfo, error) {
+	operatorData, err := s.GetOperator(operatorId, faction)
+	if err != nil {
+		return nil, err
+	}
+	skinName := pickRandomSkin(randFloat, operatorData.GetSkinNames(), skinWeights)
+	if _, ok := operatorData.Skins[skinName]; !ok {
+		skinName = DEFAULT_SKIN_NAME
+	}
+
+	chibiStance := CHIBI_STANCE_ENUM_BASE
+	if factionData, ok := s.Assets.GetFaction(faction); ok {
+		chibiStance = factionData.DefaultStance
+	}
+	stanceMap, ok := operatorData.Skins[skinName].Stances[chibiStance]
+	if !ok || len(stanceMap.Facings) == 0 {
+		if chibiStance == CHIBI_STANCE_ENUM_BASE {
+			chibiStance = CHIBI_STANCE_ENUM_BATTLE
+		} else {
+			chibiStance = CHIBI_STANCE_ENUM_BASE
+		}
+	}
+	facing := CHIBI_FACING_ENUM_FRONT
+	availableAnimations := operatorData.Skins[skinName].Stances[chibiStance].Facings[facing]
+	availableAnimations = misc.FilterAnimations(availableAnimations)
+	availableSkins := operatorData.GetSkinNames()
+
+	opInfo := NewOperatorInfo(
+		operatorData.OperatorName,
+		faction,
+		operatorId,
+		skinName,
+		chibiStance,
+		facing,
+		availableSkins,
+		availableAnimations,
+		1.0,
+		misc.EmptyOption[misc.Vector2](),
+		ACTION_WANDER,
+		NewActionWander(
+			s.getDefaultMoveAnims(availableAnimations),
+			s.getDefaultIdleAnim(chibiStance),
+		),
+	)
+	return &opInfo, nil
+}
+
+// setRandomStartingAction picks between wandering, walking and playing one of
+// the chibi's animations. Chibis without a move animation always play one.
+func (s *OperatorService) setRandomStartingAction(randFloat func() float64, opInfo *OperatorInfo) {
+	animations := make([]string, 0, len(opInfo.AvailableAnimations))
+	for _, animation := range opInfo.AvailableAnimations {
+		if !strings.Contains(animation, "Move") {
+			animations = append(animations, animation)
+		}
+	}
+	sort.Strings(animations)
+	canWalk := len(GetAvailableMoveAnimations(opInfo.AvailableAnimations)) > 0
+
+	choice := int(randFloat() * 3)
+	if !canWalk {
+		choice = 2
+	}
+	switch {
+	case choice == 0:
+		opInfo.CurrentAction = ACTION_WANDER
+		opInfo.Action = NewActionWander(
+			s.getDefaultMoveAnims(opInfo.AvailableAnimations),
+			s.getDefaultIdleAnim(opInfo.ChibiStance),
+		)
+	case choice == 1:
+		opInfo.CurrentAction = ACTION_WALK
+		opInfo.Action = NewActionWalk(s.getDefaultMoveAnims(opInfo.AvailableAnimations))
+	case len(animations) > 0:
+		animation := animations[int(randFloat()*float64(len(animations)))]
+		opInfo.CurrentAction = ACTION_PLAY_ANIMATION
+		opInfo.Action = NewActionPlayAnimation([]string{animation})
+	}
+}
@@ -0,0 +1,101 @@
+package operator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperatorService_GetRandomOperatorCuratedPool(t *testing.T) {
+	assert := assert.New(t)
+	config := misc.DefaultSpineRuntimeConfig()
+	config.RandomPool.ExcludeOperators = []string{"char_002_amiya"}
+	config.RandomPool.IncludeEnemies = []string{"enemy_1007_slime_2"}
+	sut := NewOperatorService(NewTestAssetService(), config)
+
+	opInfo, err := sut.GetRandomOperator()
+	assert.Nil(err)
+	assert.Equal("enemy_1007_slime_2", opInfo.OperatorId)
+	assert.Equal(FACTION_ENUM_ENEMY, opInfo.Faction)
+	assert.Equal(CHIBI_STANCE_ENUM_BATTLE, opInfo.ChibiStance)
+	assert.Equal("Slug", opInfo.OperatorDisplayName)
+
+	// A weight of 0 removes the chibi from the pool
+	config.RandomPool.ExcludeOperators = []string{}
+	config.RandomPool.Weights = map[string]float64{"enemy_1007_slime_2": 0}
+	for range 10 {
+		opInfo, err = sut.GetRandomOperator()
+		assert.Nil(err)
+		assert.Equal("char_002_amiya", opInfo.OperatorId)
+		assert.Equal(CHIBI_STANCE_ENUM_BASE, opInfo.ChibiStance)
+	}
+}
+
+func TestOperatorService_GetRandomOperatorEmptyPoolFallback(t *testing.T) {
+	assert := assert.New(t)
+	config := misc.DefaultSpineRuntimeConfig()
+	config.RandomPool.Weights = map[string]float64{"char_002_amiya": 0}
+	sut := NewOperatorService(NewTestAssetService(), config)
+
+	opInfo, err := sut.GetRandomOperator()
+	assert.Nil(err)
+	assert.Equal("char_002_amiya", opInfo.OperatorId)
+}
+
+func TestOperatorService_ValidateRandomPool(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewDefaultOperatorService(NewTestAssetService())
+
+	pool := misc.DefaultRandomPool()
+	pool.IncludeOperators = []string{"char_002_amiya"}
+	pool.IncludeEnemies = []string{"enemy_1007_slime_2"}
+	assert.Nil(sut.ValidateRandomPool(pool))
+
+	pool.IncludeEnemies = []string{"char_002_amiya"}
+	assert.ErrorContains(sut.ValidateRandomPool(pool), "unknown enemy id")
+	pool.IncludeEnemies = []string{}
+	pool.ExcludeOperators = []string{"char_999_missing"}
+	assert.ErrorContains(sut.ValidateRandomPool(pool), "unknown operator id")
+}
+
+func TestPickRandomSkin(t *testing.T) {
+	assert := assert.New(t)
+	skins := []string{DEFAULT_SKIN_NAME, "summer#1", "epoque#4"}
+
+	assert.Equal(DEFAULT_SKIN_NAME, pickRandomSkin(rand.Float64, skins, nil))
+	assert.Equal("summer#1", pickRandomSkin(rand.Float64, skins, map[string]float64{"summer": 5, DEFAULT_SKIN_NAME: 0}))
+	// Unlisted skins are never picked
+	for range 10 {
+		assert.NotEqual("epoque#4", pickRandomSkin(rand.Float64, skins, map[string]float64{"summer": 1}))
+	}
+	// Falls back to the default skin if every weight is 0
+	assert.Equal(DEFAULT_SKIN_NAME, pickRandomSkin(rand.Float64, skins, map[string]float64{DEFAULT_SKIN_NAME: 0}))
+}
+
+func TestOperatorService_GetRandomOperatorForUserSeeded(t *testing.T) {
+	assert := assert.New(t)
+	config := misc.DefaultSpineRuntimeConfig()
+	config.RandomPool.IncludeEnemies = []string{"enemy_1007_slime_2"}
+	config.SeededChibis = true
+	config.ChibiSeed = 1234
+	sut := NewOperatorService(NewTestAssetService(), config)
+
+	expected, err := sut.GetRandomOperatorForUser("100")
+	assert.Nil(err)
+	for range 10 {
+		opInfo, err := sut.GetRandomOperatorForUser("100")
+		assert.Nil(err)
+		assert.Equal(expected, opInfo)
+	}
+
+	// Different seeds shuffle the chibis between the pool entries
+	operatorIds := make(map[string]bool)
+	for seed := range int64(20) {
+		opInfo, err := sut.GetSeededOperator(seed, "100")
+		assert.Nil(err)
+		operatorIds[opInfo.OperatorId] = true
+	}
+	assert.Len(operatorIds, 2)
+}
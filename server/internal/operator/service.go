@@ -2,8 +2,8 @@ package operator
 
 import (
 	"errors"
+	"fmt"
 	"log"
-	"math/rand"
 	"slices"
 	"strings"
 
@@ -13,6 +13,8 @@ import (
 type OperatorService struct {
 	Assets *AssetService
 	config *misc.SpineRuntimeConfig
+	// The room's uploaded chibis. nil for the shared service
+	custom *CustomAssets
 }
 
 func NewDefaultOperatorService(assets *AssetService) *OperatorService {
@@ -36,9 +38,40 @@ func (s *OperatorService) WithConfig(newConfig *misc.SpineRuntimeConfig) *Operat
 	return &OperatorService{
 		Assets: s.Assets,
 		config: newConfig,
+		custom: s.custom,
 	}
 }
 
+func (s *OperatorService) SetCustomAssets(custom *CustomAssets) {
+	s.custom = custom
+}
+
+func (s *OperatorService) GetCustomAssets() *CustomAssets {
+	return s.custom
+}
+
+// getAssetMap returns the asset map which operatorId is loaded in. A room's
+// custom chibis are checked before the shared catalog.
+func (s *OperatorService) getAssetMap(operatorId string, faction FactionEnum) *SpineAssetMap {
+	if faction == FACTION_ENUM_OPERATOR && s.custom != nil {
+		assetMap, _ := s.custom.snapshot()
+		if _, ok := assetMap.Data[operatorId]; ok {
+			return assetMap
+		}
+	}
+	return s.Assets.GetAssetMapFromFaction(faction)
+}
+
+func (s *OperatorService) getCommonNames(operatorId string, faction FactionEnum) *CommonNames {
+	if faction == FACTION_ENUM_OPERATOR && s.custom != nil {
+		_, commonNames := s.custom.snapshot()
+		if len(commonNames.GetOperatorIdToName(operatorId)) > 0 {
+			return commonNames
+		}
+	}
+	return s.Assets.GetCommonNamesFromFaction(faction)
+}
+
 func (s *OperatorService) SetConfig(newConfig *misc.SpineRuntimeConfig) {
 	s.config = newConfig
 }
@@ -82,7 +115,7 @@ func (s *OperatorService) GetMaxSpritePixelSize() int {
 }
 
 func (s *OperatorService) ValidateOperatorRequest(info *OperatorInfo) error {
-	assetMap := s.Assets.GetAssetMapFromFaction(info.Faction)
+	assetMap := s.getAssetMap(info.OperatorId, info.Faction)
 
 	log.Println("Request setOperator", info.OperatorId, info.Faction,
 		info.Skin, info.ChibiStance, info.Facing, info.CurrentAction)
@@ -208,11 +241,11 @@ func (c *OperatorService) ValidateUpdateSetDefaultOtherwise(update *OperatorInfo
 				)
 			}
 		}
-	} else if update.Faction == FACTION_ENUM_ENEMY {
-		// When switching to an enemy and the action is a walking action we
-		// need to check to see if their is "move" animation that can be used
-		// to render the walking action. If there is not, then we need to
-		// default back to a play animation.
+	} else {
+		// When switching to an enemy (or any other faction) and the action
+		// is a walking action we need to check to see if their is "move"
+		// animation that can be used to render the walking action. If there
+		// is not, then we need to default back to a play animation.
 		if IsWalkingAction(update.CurrentAction) {
 			hasMoveAnim := len(GetAvailableMoveAnimations(update.AvailableAnimations)) > 0
 			if !hasMoveAnim {
@@ -355,10 +388,29 @@ func (c *OperatorService) ValidateUpdateSetDefaultOtherwise(update *OperatorInfo
 }
 
 func (s *OperatorService) GetSpineData(opeatorId string, faction FactionEnum, skin string, isBase bool, isFront bool) *SpineData {
-	assetMap := s.Assets.GetAssetMapFromFaction(faction)
+	assetMap := s.getAssetMap(opeatorId, faction)
 	return assetMap.Get(opeatorId, skin, isBase, isFront)
 }
 
+// GetAssetFilepaths returns the asset paths needed to render the chibi, or
+// nil if the operator/skin/stance/facing is not loaded.
+func (s *OperatorService) GetAssetFilepaths(info *OperatorInfo) []string {
+	if _, err := s.Assets.ParseFaction(string(info.Faction)); err != nil {
+		return nil
+	}
+	assetMap := s.getAssetMap(info.OperatorId, info.Faction)
+	if err := assetMap.Contains(info.OperatorId, info.Skin, info.ChibiStance, info.Facing, nil); err != nil {
+		return nil
+	}
+	spineData := assetMap.Get(
+		info.OperatorId,
+		info.Skin,
+		info.ChibiStance == CHIBI_STANCE_ENUM_BASE,
+		info.Facing == CHIBI_FACING_ENUM_FRONT,
+	)
+	return spineData.ClientFilepaths()
+}
+
 func (s *OperatorService) GetOperatorIds(faction FactionEnum) ([]string, error) {
 	assetMap := s.Assets.GetAssetMapFromFaction(faction)
 	operatorIds := make([]string, 0)
@@ -372,7 +424,7 @@ func (s *OperatorService) GetOperator(
 	OperatorId string,
 	Faction FactionEnum,
 ) (*GetOperatorResponse, error) {
-	assetMap := s.Assets.GetAssetMapFromFaction(Faction)
+	assetMap := s.getAssetMap(OperatorId, Faction)
 
 	operatorData, ok := assetMap.Data[OperatorId]
 	if !ok {
@@ -403,7 +455,7 @@ func (s *OperatorService) GetOperator(
 		}
 	}
 
-	canonicalName := s.Assets.GetCommonNamesFromFaction(Faction).GetCanonicalName(OperatorId)
+	canonicalName := s.getCommonNames(OperatorId, Faction).GetCanonicalName(OperatorId)
 
 	return &GetOperatorResponse{
 		OperatorId:   OperatorId,
@@ -413,6 +465,12 @@ func (s *OperatorService) GetOperator(
 }
 
 func (s *OperatorService) GetOperatorIdFromName(name string, faction FactionEnum) (string, []string) {
+	if faction == FACTION_ENUM_OPERATOR && s.custom != nil {
+		_, customNames := s.custom.snapshot()
+		if operatorId, ok := customNames.IsMatch(name); ok {
+			return operatorId, nil
+		}
+	}
 	commonNames := s.Assets.GetCommonNamesFromFaction(faction)
 
 	if operatorId, ok := commonNames.IsMatch(name); ok {
@@ -451,102 +509,82 @@ func (c *OperatorService) getDefaultIdleAnim(stance ChibiStanceEnum) string {
 	return defaultIdleAnimation
 }
 
-func (s *OperatorService) GetRandomOperator() (*OperatorInfo, error) {
-	operatorIds, err := s.GetOperatorIds(FACTION_ENUM_OPERATOR)
-	if err != nil {
-		return nil, err
-	}
-
-	index := rand.Intn(len(operatorIds))
-	operatorId := operatorIds[index]
+// SetDefaultWanderAction resets the chibi's action back to wandering using
+// the animations available for its current skin/stance/facing.
+func (s *OperatorService) SetDefaultWanderAction(info *OperatorInfo) {
+	info.CurrentAction = ACTION_WANDER
+	info.Action = NewActionWander(
+		s.getDefaultMoveAnims(info.AvailableAnimations),
+		s.getDefaultIdleAnim(info.ChibiStance),
+	)
+}
 
-	faction := FACTION_ENUM_OPERATOR
-	operatorData, err := s.GetOperator(operatorId, faction)
-	if err != nil {
-		return nil, err
-	}
-	chibiStance := CHIBI_STANCE_ENUM_BASE
-	skinName := DEFAULT_SKIN_NAME
-	stanceMap, ok := operatorData.Skins[skinName].Stances[chibiStance]
-	if !ok {
-		chibiStance = CHIBI_STANCE_ENUM_BATTLE
-	}
-	if len(stanceMap.Facings) == 0 {
-		chibiStance = CHIBI_STANCE_ENUM_BATTLE
-	}
-	facing := CHIBI_FACING_ENUM_FRONT
-	availableAnimations := operatorData.Skins[skinName].Stances[chibiStance].Facings[facing]
-	availableAnimations = misc.FilterAnimations(availableAnimations)
-	availableSkins := operatorData.GetSkinNames()
+// SetIdleAction stops the chibi in place playing its stance's idle animation
+func (s *OperatorService) SetIdleAction(info *OperatorInfo) {
+	info.CurrentAction = ACTION_PLAY_ANIMATION
+	info.Action = NewActionPlayAnimation([]string{s.getDefaultIdleAnim(info.ChibiStance)})
+}
 
-	commonNames := s.Assets.GetCommonNamesFromFaction(faction)
-	operatorDisplayName := commonNames.GetCanonicalName(operatorId)
+// GetOperatorMetadata returns the rarity/class/nation of the operator. Not
+// available for custom chibis.
+func (s *OperatorService) GetOperatorMetadata(operatorId string) (*OperatorMetadata, bool) {
+	return s.Assets.GetMetadataFromFaction(FACTION_ENUM_OPERATOR).Get(operatorId)
+}
 
-	opInfo := NewOperatorInfo(
-		operatorDisplayName,
-		faction,
-		operatorId,
-		skinName,
-		chibiStance,
-		facing,
-		availableSkins,
-		availableAnimations,
-		1.0,
-		misc.EmptyOption[misc.Vector2](),
-		ACTION_WANDER,
-		NewActionWander(
-			s.getDefaultMoveAnims(availableAnimations),
-			s.getDefaultIdleAnim(chibiStance),
-		),
-	)
-	return &opInfo, nil
+// ParseOperatorFilter parses filter terms (ie. 6star, caster) against the
+// loaded operator metadata.
+func (s *OperatorService) ParseOperatorFilter(terms []string) (*OperatorFilter, error) {
+	return ParseOperatorFilter(terms, s.Assets.GetMetadataFromFaction(FACTION_ENUM_OPERATOR))
 }
 
+// OperatorFromDefault builds the broadcaster's default chibi. An unknown
+// operator name or stance is an error, an invalid skin or animation falls
+// back to the defaults through ValidateUpdateSetDefaultOtherwise.
 func (s *OperatorService) OperatorFromDefault(
 	opName string,
 	details misc.InitialOperatorDetails,
-) *OperatorInfo {
+) (*OperatorInfo, error) {
 	if len(opName) == 0 {
 		opName = "Amiya"
 	}
 
-	faction := FACTION_ENUM_OPERATOR
-	opId, matches := s.GetOperatorIdFromName(opName, FACTION_ENUM_OPERATOR)
-	if matches != nil {
-		faction = FACTION_ENUM_ENEMY
-		opId, matches = s.GetOperatorIdFromName(opName, FACTION_ENUM_ENEMY)
+	// Search the factions in the order they are listed
+	var faction FactionEnum
+	var opId string
+	var matches []string
+	for _, f := range s.Assets.GetFactions() {
+		faction = f.Name
+		opId, matches = s.GetOperatorIdFromName(opName, faction)
+		if matches == nil {
+			break
+		}
 	}
 	if matches != nil {
-		log.Panic("Failed to get operator id", matches)
+		return nil, fmt.Errorf("unknown operator (%s)", opName)
 	}
-	stance, err2 := ChibiStanceEnum_Parse(details.Stance)
-	if err2 != nil {
-		log.Panic("Failed to parse stance", err2)
-	}
-
-	opResp, err := s.GetOperator(opId, faction)
+	stance, err := ChibiStanceEnum_Parse(details.Stance)
 	if err != nil {
-		log.Panic("Failed to fetch operator info")
+		return nil, err
 	}
-	availableAnims := opResp.Skins[details.Skin].Stances[stance].Facings[CHIBI_FACING_ENUM_FRONT]
-	availableAnims = misc.FilterAnimations(availableAnims)
-	availableSkins := opResp.GetSkinNames()
 
 	opInfo := NewOperatorInfo(
-		opResp.OperatorName,
+		"",
 		faction,
 		opId,
 		details.Skin,
 		stance,
 		CHIBI_FACING_ENUM_FRONT,
-		availableSkins,
-		availableAnims,
+		[]string{},
+		[]string{},
 		1.0,
 		misc.NewOption(misc.Vector2{X: details.PositionX, Y: 0.0}),
 		ACTION_PLAY_ANIMATION,
 		NewActionPlayAnimation(details.Animations),
 	)
-	return &opInfo
+	if err := s.ValidateUpdateSetDefaultOtherwise(&opInfo); err != nil {
+		return nil, err
+	}
+	return &opInfo, nil
 }
 
 func getValidAnimations(availableAnimations []string, actionAnimations []string, defaultAnim string) []string {
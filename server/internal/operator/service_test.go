@@ -1,6 +1,11 @@
 package operator
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/stretchr/testify/assert"
+)
 
 func TestValidateUpdateSetDefaultOtherwise(t *testing.T) {
 	// Default to operator if faction not provided
@@ -19,3 +24,52 @@ func TestValidateUpdateSetDefaultOtherwise(t *testing.T) {
 	// test unassigned currentAction/action
 	// regression test for enemy->walk->operator->battle causes the chibi to slide across screen
 }
+
+func TestGetAssetFilepaths(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewOperatorService(NewTestAssetService(), misc.DefaultSpineRuntimeConfig())
+
+	info := OperatorInfo{
+		Faction:     FACTION_ENUM_OPERATOR,
+		OperatorId:  "char_002_amiya",
+		Skin:        DEFAULT_SKIN_NAME,
+		ChibiStance: CHIBI_STANCE_ENUM_BATTLE,
+		Facing:      CHIBI_FACING_ENUM_BACK,
+	}
+	assert.Equal(
+		[]string{"battle_back_atlas_filepath", "battle_back_png_filepath", "battle_back_skel_filepath"},
+		sut.GetAssetFilepaths(&info),
+	)
+
+	// Missing facing
+	info.ChibiStance = CHIBI_STANCE_ENUM_BASE
+	assert.Nil(sut.GetAssetFilepaths(&info))
+	// Unknown faction
+	info.Faction = "unknown"
+	assert.Nil(sut.GetAssetFilepaths(&info))
+}
+
+func TestOperatorFromDefault(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewDefaultOperatorService(NewTestAssetService())
+
+	opInfo, err := sut.OperatorFromDefault("Amiya", misc.InitialOperatorDetails{
+		Skin:       "missing_skin",
+		Stance:     string(CHIBI_STANCE_ENUM_BASE),
+		Animations: []string{"missing_animation"},
+		PositionX:  0.5,
+	})
+	assert.Nil(err)
+	assert.Equal("char_002_amiya", opInfo.OperatorId)
+	assert.Equal(DEFAULT_SKIN_NAME, opInfo.Skin)
+	assert.Equal([]string{DEFAULT_ANIM_BASE}, opInfo.Action.Animations)
+
+	_, err = sut.OperatorFromDefault("NotAnOperator", misc.InitialOperatorDetails{
+		Stance: string(CHIBI_STANCE_ENUM_BASE),
+	})
+	assert.ErrorContains(err, "unknown operator")
+	_, err = sut.OperatorFromDefault("Amiya", misc.InitialOperatorDetails{
+		Stance: "sitting",
+	})
+	assert.NotNil(err)
+}
@@ -0,0 +1,170 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+)
+
+const (
+	BULK_MAX_CONCURRENCY    = 8
+	ANNOUNCEMENT_MAX_LENGTH = 500
+)
+
+var ErrEmptyRoomFilter = errors.New("room filter must match on something")
+
+type BulkRoomResult struct {
+	ChannelName string `json:"channel_name"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+}
+
+type BulkRoomsReport struct {
+	Results      []*BulkRoomResult `json:"results"`
+	NumSucceeded int               `json:"num_succeeded"`
+	NumFailed    int               `json:"num_failed"`
+}
+
+// RoomFilter selects active rooms. A room must match all of the set fields.
+type RoomFilter struct {
+	ChannelNames []string `json:"channel_names,omitempty"`
+	// Rooms which haven't had a chat message in at least this many minutes
+	IdleMins int `json:"idle_mins,omitempty"`
+	// Rooms without any overlays connected
+	NoConnections bool `json:"no_connections,omitempty"`
+}
+
+func (f *RoomFilter) IsEmpty() bool {
+	return len(f.ChannelNames) == 0 && f.IdleMins <= 0 && !f.NoConnections
+}
+
+func (f *RoomFilter) Matches(roomObj *Room, now time.Time) bool {
+	if len(f.ChannelNames) > 0 && !slices.Contains(f.ChannelNames, roomObj.GetChannelName()) {
+		return false
+	}
+	if f.IdleMins > 0 && now.Sub(roomObj.GetLastChatterTime()) < time.Duration(f.IdleMins)*time.Minute {
+		return false
+	}
+	if f.NoConnections && roomObj.NumConnectedClients() > 0 {
+		return false
+	}
+	return true
+}
+
+// runBulk calls fn for each of the rooms, running at most
+// BULK_MAX_CONCURRENCY at a time. Rooms which haven't started by the time the
+// context is done are reported as failed.
+func runBulk(ctx context.Context, rooms []*Room, fn func(context.Context, *Room) error) *BulkRoomsReport {
+	slices.SortFunc(rooms, func(a, b *Room) int {
+		return strings.Compare(a.GetChannelName(), b.GetChannelName())
+	})
+
+	results := make([]*BulkRoomResult, len(rooms))
+	sem := make(chan struct{}, BULK_MAX_CONCURRENCY)
+	var wg sync.WaitGroup
+	for i, roomObj := range rooms {
+		results[i] = &BulkRoomResult{ChannelName: roomObj.GetChannelName()}
+		if err := ctx.Err(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Error = ctx.Err().Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, roomObj); err != nil {
+				log.Printf("Bulk operation failed for %s: %s\n", roomObj.GetChannelName(), err)
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Success = true
+		}()
+	}
+	wg.Wait()
+
+	report := &BulkRoomsReport{Results: results}
+	for _, result := range results {
+		if result.Success {
+			report.NumSucceeded += 1
+		} else {
+			report.NumFailed += 1
+		}
+	}
+	return report
+}
+
+// RefreshAllRooms reloads the spine runtime config of every active room
+func (r *RoomsManager) RefreshAllRooms(ctx context.Context) *BulkRoomsReport {
+	return runBulk(ctx, r.GetRooms(), func(ctx context.Context, roomObj *Room) error {
+		return roomObj.RefreshConfigs(ctx, r.botConfig)
+	})
+}
+
+// EvictRooms closes all the active rooms matching the filter and marks them
+// as inactive.
+func (r *RoomsManager) EvictRooms(ctx context.Context, filter RoomFilter) (*BulkRoomsReport, error) {
+	if filter.IsEmpty() {
+		return nil, ErrEmptyRoomFilter
+	}
+
+	now := misc.Clock.Now()
+	r.rooms_mutex.Lock()
+	evicted := make([]*Room, 0)
+	for channel, roomObj := range r.Rooms {
+		if filter.Matches(roomObj, now) {
+			evicted = append(evicted, roomObj)
+			delete(r.Rooms, channel)
+		}
+	}
+	r.rooms_mutex.Unlock()
+
+	// The rooms are already removed, so always close them even if the
+	// request goes away.
+	return runBulk(context.WithoutCancel(ctx), evicted, func(ctx context.Context, roomObj *Room) error {
+		roomObj.SetActive(false)
+		return roomObj.Close()
+	}), nil
+}
+
+// AnnounceToRooms shows the message in every active room over the chibi of
+// the bot's account. Overlays only show it if they have chat messages turned
+// on.
+func (r *RoomsManager) AnnounceToRooms(ctx context.Context, message string) (*BulkRoomsReport, error) {
+	message = strings.TrimSpace(message)
+	if len(message) == 0 {
+		return nil, fmt.Errorf("announcement message must be provided")
+	}
+	if len(message) > ANNOUNCEMENT_MAX_LENGTH {
+		return nil, fmt.Errorf("announcement message must be at most %d characters", ANNOUNCEMENT_MAX_LENGTH)
+	}
+
+	announcer := misc.UserInfo{
+		Username:        strings.ToLower(r.botConfig.TwitchBot),
+		UsernameDisplay: r.botConfig.TwitchBot,
+	}
+	return runBulk(ctx, r.GetRooms(), func(ctx context.Context, roomObj *Room) error {
+		return roomObj.chibiActor.WithLock(func() error {
+			return roomObj.chibiActor.Announce(
+				ctx,
+				announcer,
+				r.botConfig.InitialOperator,
+				r.botConfig.OperatorDetails,
+				message,
+			)
+		})
+	}), nil
+}
@@ -0,0 +1,106 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBulk_ReportsPerRoomInChannelOrder(t *testing.T) {
+	assert := assert.New(t)
+	rooms := []*Room{
+		{channelName: "charlie"},
+		{channelName: "alpha"},
+		{channelName: "bravo"},
+	}
+
+	report := runBulk(context.Background(), rooms, func(ctx context.Context, roomObj *Room) error {
+		if roomObj.GetChannelName() == "bravo" {
+			return errors.New("refresh failed")
+		}
+		return nil
+	})
+
+	assert.Equal(2, report.NumSucceeded)
+	assert.Equal(1, report.NumFailed)
+	assert.Equal([]*BulkRoomResult{
+		{ChannelName: "alpha", Success: true},
+		{ChannelName: "bravo", Success: false, Error: "refresh failed"},
+		{ChannelName: "charlie", Success: true},
+	}, report.Results)
+}
+
+func TestRunBulk_BoundsConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	rooms := make([]*Room, 0)
+	for range BULK_MAX_CONCURRENCY * 3 {
+		rooms = append(rooms, &Room{channelName: "room"})
+	}
+
+	var running, maxRunning atomic.Int32
+	report := runBulk(context.Background(), rooms, func(ctx context.Context, roomObj *Room) error {
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+
+	assert.Equal(len(rooms), report.NumSucceeded)
+	assert.LessOrEqual(maxRunning.Load(), int32(BULK_MAX_CONCURRENCY))
+}
+
+func TestRunBulk_ReportsSlowRoomsAfterDeadline(t *testing.T) {
+	assert := assert.New(t)
+	rooms := make([]*Room, 0)
+	for i := range BULK_MAX_CONCURRENCY + 1 {
+		rooms = append(rooms, &Room{channelName: string(rune('a' + i))})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// The started rooms outlive the deadline but still finish and are
+	// reported, the last room never gets a slot
+	report := runBulk(ctx, rooms, func(ctx context.Context, roomObj *Room) error {
+		<-ctx.Done()
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	assert.Equal(BULK_MAX_CONCURRENCY, report.NumSucceeded)
+	assert.Equal(1, report.NumFailed)
+	last := report.Results[len(report.Results)-1]
+	assert.Equal(rooms[len(rooms)-1].GetChannelName(), last.ChannelName)
+	assert.Equal(context.DeadlineExceeded.Error(), last.Error)
+}
+
+func TestRunBulk_CancelledContextSkipsRooms(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := runBulk(ctx, []*Room{{channelName: "alpha"}}, func(ctx context.Context, roomObj *Room) error {
+		return nil
+	})
+	assert.Equal(1, report.NumFailed)
+	assert.Equal(context.Canceled.Error(), report.Results[0].Error)
+}
+
+func TestRoomFilter_ChannelNames(t *testing.T) {
+	assert := assert.New(t)
+	filter := RoomFilter{}
+	assert.True(filter.IsEmpty())
+
+	filter.ChannelNames = []string{"alpha"}
+	assert.False(filter.IsEmpty())
+	assert.True(filter.Matches(&Room{channelName: "alpha"}, time.Now()))
+	assert.False(filter.Matches(&Room{channelName: "bravo"}, time.Now()))
+}
@@ -9,11 +9,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/chatbot"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/chibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/customchibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 
 	spine "github.com/Stymphalian/ak_chibi_bot/server/internal/spine_runtime"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
@@ -33,6 +37,10 @@ type RoomsManager struct {
 
 	botConfig      *misc.BotConfig
 	twitchClient   twitch_api.TwitchApiClientInterface
+	authService    auth.AuthServiceInterface
+	notifier       webhook.Notifier
+	publisher      events.Publisher
+	customChibis   *customchibi.CustomChibiService
 	shutdownDoneCh chan struct{}
 	removeRoomCh   chan string
 }
@@ -44,6 +52,10 @@ func NewRoomsManager(
 	userPrefsRepo users.UserPreferencesRepository,
 	chattersRepo users.ChatterRepository,
 	twitchClient twitch_api.TwitchApiClientInterface,
+	authService auth.AuthServiceInterface,
+	notifier webhook.Notifier,
+	publisher events.Publisher,
+	customChibis *customchibi.CustomChibiService,
 	botConfig *misc.BotConfig,
 ) *RoomsManager {
 	spineService := operator.NewOperatorService(assets, botConfig.SpineRuntimeConfig)
@@ -59,6 +71,10 @@ func NewRoomsManager(
 
 		botConfig:      botConfig,
 		twitchClient:   twitchClient,
+		authService:    authService,
+		notifier:       notifier,
+		publisher:      publisher,
+		customChibis:   customChibis,
 		shutdownDoneCh: make(chan struct{}),
 		removeRoomCh:   make(chan string, 10),
 	}
@@ -80,6 +96,88 @@ func (r *RoomsManager) LoadExistingRooms(ctx context.Context) error {
 	return nil
 }
 
+// GetActiveAssetPaths returns the asset paths of every chibi in the active
+// rooms. Used to warm up the asset cache.
+func (r *RoomsManager) GetActiveAssetPaths(ctx context.Context) ([]string, error) {
+	roomDbs, err := r.roomRepo.GetActiveRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	paths := make([]string, 0)
+	for _, roomDb := range roomDbs {
+		chatters, err := r.chattersRepo.GetActiveChatters(ctx, roomDb.RoomId)
+		if err != nil {
+			return nil, err
+		}
+		for _, chatter := range chatters {
+			for _, path := range r.spineService.GetAssetFilepaths(&chatter.OperatorInfo) {
+				if !seen[path] {
+					seen[path] = true
+					paths = append(paths, path)
+				}
+			}
+		}
+	}
+	return paths, nil
+}
+
+// ReloadAssets reloads the asset catalog from disk and then migrates any
+// chibis in the active rooms which reference assets that were removed.
+func (r *RoomsManager) ReloadAssets(ctx context.Context) (*operator.AssetReloadReport, error) {
+	report, err := r.assetService.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	r.rooms_mutex.Lock()
+	for _, room := range r.Rooms {
+		report.NumChibisMigrated += room.MigrateInvalidChibis(ctx)
+	}
+	r.rooms_mutex.Unlock()
+
+	log.Printf(
+		"Reloaded assets: %d operators, %d enemies, %d added, %d removed, %d chibis migrated\n",
+		report.NumOperators,
+		report.NumEnemies,
+		len(report.AddedIds),
+		len(report.RemovedIds),
+		report.NumChibisMigrated,
+	)
+	return report, nil
+}
+
+// ReloadCustomChibis refreshes the uploaded chibis of an active room and
+// migrates any chatters using a chibi which was removed. Does nothing if the
+// room is not active.
+func (r *RoomsManager) ReloadCustomChibis(ctx context.Context, channelName string) error {
+	r.rooms_mutex.Lock()
+	room, ok := r.Rooms[channelName]
+	r.rooms_mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	customAssets := room.operatorService.GetCustomAssets()
+	if customAssets == nil {
+		return nil
+	}
+	if err := r.customChibis.LoadCustomAssets(ctx, room.GetRoomId(), customAssets); err != nil {
+		return err
+	}
+	room.MigrateInvalidChibis(ctx)
+	return nil
+}
+
+func (r *RoomsManager) reloadAssetsIfChanged() {
+	if !r.assetService.IndexFilesChanged() {
+		return
+	}
+	if _, err := r.ReloadAssets(context.Background()); err != nil {
+		log.Println("Failed to reload assets", err)
+	}
+}
+
 func (r *RoomsManager) garbageCollectRooms() {
 	log.Println("Garbage collecting unused chat rooms")
 	period := time.Duration(r.botConfig.RemoveUnusedRoomsAfterMinutes) * time.Minute
@@ -102,6 +200,29 @@ func (r *RoomsManager) garbageCollectRooms() {
 	log.Printf("Finished garbage collecting unused chat rooms: %d removed\n", roomsRemoved)
 }
 
+// refreshRoomBotTokens pushes the latest custom bot tokens to the live rooms.
+// The auth service refreshes the stored tokens but the rooms' bots only read
+// their token once when they are created.
+func (r *RoomsManager) refreshRoomBotTokens() {
+	r.rooms_mutex.Lock()
+	rooms := make([]*Room, 0, len(r.Rooms))
+	for _, room := range r.Rooms {
+		rooms = append(rooms, room)
+	}
+	r.rooms_mutex.Unlock()
+
+	for _, room := range rooms {
+		botCreds, err := r.authService.GetRoomBotCredentials(context.Background(), room.roomId)
+		if err != nil {
+			log.Println("Failed to get bot credentials for", room.GetChannelName(), err)
+			continue
+		}
+		if botCreds != nil {
+			room.SetBotAccessToken(botCreds.BotUsername, botCreds.AccessToken)
+		}
+	}
+}
+
 func (r *RoomsManager) GetNextGarbageCollectionTime() time.Time {
 	return r.nextGarbageCollectionTime
 }
@@ -121,6 +242,22 @@ func (r *RoomsManager) RunLoop() {
 		)
 		defer stopTimer()
 	}
+	if r.botConfig.WatchAssetIndexSeconds > 0 {
+		stopTimer := misc.StartTimer(
+			"reloadAssetsIfChanged",
+			time.Duration(r.botConfig.WatchAssetIndexSeconds)*time.Second,
+			r.reloadAssetsIfChanged,
+		)
+		defer stopTimer()
+	}
+	// Run twice per auth refresh period so a live bot always picks up a
+	// refreshed token before the old one expires.
+	stopBotTokensTimer := misc.StartTimer(
+		"refreshRoomBotTokens",
+		auth.VALIDATE_OAUTH_TOKENS_PERIOD/2,
+		r.refreshRoomBotTokens,
+	)
+	defer stopBotTokensTimer()
 	go func() {
 		for channel := range r.removeRoomCh {
 			log.Println("Removing room", channel, "from manager")
@@ -159,8 +296,14 @@ func (r *RoomsManager) getRoomServices(roomDb *RoomDb) (
 		return nil, nil, nil, nil, err
 	}
 	newSpineService := r.spineService.WithConfig(spineRuntimeConfig)
+	customAssets := operator.NewCustomAssets()
+	err = r.customChibis.LoadCustomAssets(context.Background(), roomDb.RoomId, customAssets)
+	if err != nil {
+		log.Println("Failed to load custom chibis for", channelName, err)
+	}
+	newSpineService.SetCustomAssets(customAssets)
 
-	spineBridge, err := spine.NewSpineBridge(newSpineService)
+	spineBridge, err := spine.NewSpineBridge(roomDb.RoomId, newSpineService, r.publisher)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -171,15 +314,28 @@ func (r *RoomsManager) getRoomServices(roomDb *RoomDb) (
 		r.userPrefsRepo,
 		r.chattersRepo,
 		spineBridge,
+		r.notifier,
+		r.publisher,
 		append(r.botConfig.ExcludeNames, spineRuntimeConfig.UsernamesBlacklist...),
 	)
 
+	// Use the channel's own bot account if the owner authorized one
+	botName := r.botConfig.TwitchBot
+	botAccessToken := r.botConfig.TwitchAccessToken
+	botCreds, err := r.authService.GetRoomBotCredentials(context.Background(), roomDb.RoomId)
+	if err != nil {
+		log.Println("Failed to get bot credentials for", channelName, "using default bot:", err)
+	} else if botCreds != nil {
+		botName = botCreds.BotUsername
+		botAccessToken = botCreds.AccessToken
+	}
+
 	chatBotters := make([]chatbot.ChatBotter, 0)
 	twitchBot, err := chatbot.NewTwitchBot(
 		chibiActor,
 		channelName,
-		r.botConfig.TwitchBot,
-		r.botConfig.TwitchAccessToken,
+		botName,
+		botAccessToken,
 	)
 	if err != nil {
 		return nil, nil, nil, nil, err
@@ -216,6 +372,8 @@ func (r *RoomsManager) InsertRoom(roomDb *RoomDb) error {
 		spineBridge,
 		chibiActor,
 		chatBots,
+		r.notifier,
+		r.publisher,
 		r.removeRoomCh,
 	)
 	if err != nil {
@@ -289,6 +447,8 @@ func (r *RoomsManager) CreateRoomOrNoOp(ctx context.Context, channel string) err
 		spineBridge,
 		chibiActor,
 		chatBots,
+		r.notifier,
+		r.publisher,
 		r.removeRoomCh,
 	)
 	if err != nil {
@@ -302,22 +462,36 @@ func (r *RoomsManager) CreateRoomOrNoOp(ctx context.Context, channel string) err
 			defaultOperatorConfig = roomDb.DefaultOperatorConfig
 		}
 
-		pref, _ := r.userPrefsRepo.GetByTwitchIdOrNil(ctx, userinfo.TwitchUserId)
-		if pref == nil {
+		if !roomObj.chibiActor.HasSavedChibi(ctx, userinfo.TwitchUserId) {
 			log.Println("Adding default chibi for ", roomDb.ChannelName)
-			roomObj.chibiActor.SetToDefault(
-				ctx,
-				*userinfo,
-				defaultOperatorName,
-				defaultOperatorConfig,
-			)
+			err = roomObj.chibiActor.WithLock(func() error {
+				return roomObj.chibiActor.SetToDefault(
+					ctx,
+					*userinfo,
+					defaultOperatorName,
+					defaultOperatorConfig,
+				)
+			})
+			if err != nil {
+				log.Printf("Invalid default chibi for %s, giving a random chibi: %s\n", roomDb.ChannelName, err)
+				err = roomObj.GiveChibiToUser(ctx, *userinfo)
+				if err != nil {
+					return err
+				}
+			}
 		} else {
 			log.Println("Adding user preference default chibi for ", roomDb.ChannelName)
-			err = roomObj.chibiActor.GiveChibiToUser(ctx, *userinfo)
+			err = roomObj.GiveChibiToUser(ctx, *userinfo)
 			if err != nil {
 				return err
 			}
 		}
+		r.notifier.Notify(roomDb.RoomId, webhook.EVENT_ROOM_CREATED, webhook.RoomEventData{
+			ChannelName: roomDb.ChannelName,
+		})
+		r.publisher.Publish(roomDb.RoomId, events.EVENT_ROOM_CREATED, webhook.RoomEventData{
+			ChannelName: roomDb.ChannelName,
+		})
 	}
 
 	r.rooms_mutex.Lock()
@@ -412,6 +586,10 @@ func (r *RoomsManager) UpdateSpineRuntimeConfig(ctx context.Context, roomId uint
 		runtimeConfig.DefaultMovementSpeed = newConfig.DefaultMovementSpeed
 	}
 	runtimeConfig.UsernamesBlacklist = newConfig.UsernamesBlacklist
+	runtimeConfig.RandomPoolFilter = newConfig.RandomPoolFilter
+	runtimeConfig.RandomPool = newConfig.RandomPool
+	runtimeConfig.SeededChibis = newConfig.SeededChibis
+	runtimeConfig.ChibiSeed = newConfig.ChibiSeed
 
 	r.roomRepo.UpdateSpineRuntimeConfigForId(ctx, roomId, runtimeConfig)
 	return nil
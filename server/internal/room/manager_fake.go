@@ -2,10 +2,14 @@ package room
 
 import (
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/customchibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 )
 
 func NewFakeRoomsManager() *RoomsManager {
@@ -35,6 +39,13 @@ func NewFakeRoomsManager() *RoomsManager {
 		userPrefsRepo,
 		chattersRepo,
 		twitch_api.NewFakeTwitchApiClient(),
+		auth.NewFakeAuthService(),
+		webhook.NewFakeNotifier(),
+		events.NewHub(),
+		customchibi.NewCustomChibiService(
+			customchibi.NewFakeCustomChibiRepository(),
+			akdb.NewFakeAssetStore(),
+		),
 		botConfig,
 	)
 }
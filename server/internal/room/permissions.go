@@ -0,0 +1,50 @@
+package room
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoomRole is the level of access a user has to a room's settings.
+// The owner of a room is always the user whose username matches the channel
+// name and is never stored in the DB.
+type RoomRole string
+
+const (
+	ROOM_ROLE_NONE   = RoomRole("")
+	ROOM_ROLE_VIEWER = RoomRole("viewer")
+	ROOM_ROLE_EDITOR = RoomRole("editor")
+	ROOM_ROLE_OWNER  = RoomRole("owner")
+
+	ROOM_PERMISSION_SOURCE_MANUAL           = "manual"
+	ROOM_PERMISSION_SOURCE_TWITCH_MODERATOR = "twitch_moderator"
+)
+
+func RoomRole_Parse(str string) (RoomRole, error) {
+	switch strings.ToLower(str) {
+	case "viewer":
+		return ROOM_ROLE_VIEWER, nil
+	case "editor":
+		return ROOM_ROLE_EDITOR, nil
+	default:
+		return ROOM_ROLE_NONE, fmt.Errorf("invalid room role (%s)", str)
+	}
+}
+
+func (r RoomRole) rank() int {
+	switch r {
+	case ROOM_ROLE_VIEWER:
+		return 1
+	case ROOM_ROLE_EDITOR:
+		return 2
+	case ROOM_ROLE_OWNER:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// AtLeast returns true if the role grants at least the access of other.
+func (r RoomRole) AtLeast(other RoomRole) bool {
+	return r.rank() >= other.rank()
+}
@@ -0,0 +1,101 @@
+package room
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoomPermissionRepositoryPsql struct {
+	*akdb.DatbaseConn
+}
+
+func NewRoomPermissionRepositoryPsql(akDb *akdb.DatbaseConn) *RoomPermissionRepositoryPsql {
+	return &RoomPermissionRepositoryPsql{
+		DatbaseConn: akDb,
+	}
+}
+
+func (r *RoomPermissionRepositoryPsql) GetByRoomId(ctx context.Context, roomId uint) ([]*RoomPermissionUserDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	permissions := make([]*RoomPermissionUserDb, 0)
+	result := db.
+		Table("room_permissions").
+		Select("room_permissions.*, users.username, users.user_display_name").
+		Joins("JOIN users ON users.user_id = room_permissions.user_id").
+		Where("room_permissions.room_id = ?", roomId).
+		Order("users.username ASC").
+		Scan(&permissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return permissions, nil
+}
+
+func (r *RoomPermissionRepositoryPsql) GetRoleOrNone(ctx context.Context, roomId uint, userId uint) (RoomRole, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var permission RoomPermissionDb
+	result := db.Where("room_id = ? AND user_id = ?", roomId, userId).First(&permission)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return ROOM_ROLE_NONE, nil
+		}
+		return ROOM_ROLE_NONE, result.Error
+	}
+	return permission.Role, nil
+}
+
+func (r *RoomPermissionRepositoryPsql) SetRole(
+	ctx context.Context,
+	roomId uint,
+	userId uint,
+	role RoomRole,
+	source string,
+) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "room_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "source"}),
+	}).Create(&RoomPermissionDb{
+		RoomId: roomId,
+		UserId: userId,
+		Role:   role,
+		Source: source,
+	})
+	return result.Error
+}
+
+func (r *RoomPermissionRepositoryPsql) DeleteByUserId(ctx context.Context, roomId uint, userId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.Where("room_id = ? AND user_id = ?", roomId, userId).Delete(&RoomPermissionDb{})
+	return result.Error
+}
+
+func (r *RoomPermissionRepositoryPsql) SyncModerators(ctx context.Context, roomId uint, userIds []uint) error {
+	return r.DefaultDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deleteTx := tx.Where("room_id = ? AND source = ?", roomId, ROOM_PERMISSION_SOURCE_TWITCH_MODERATOR)
+		if len(userIds) > 0 {
+			deleteTx = deleteTx.Where("user_id NOT IN ?", userIds)
+		}
+		if err := deleteTx.Delete(&RoomPermissionDb{}).Error; err != nil {
+			return err
+		}
+
+		for _, userId := range userIds {
+			// Don't clobber permissions which were granted manually by the owner
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&RoomPermissionDb{
+				RoomId: roomId,
+				UserId: userId,
+				Role:   ROOM_ROLE_EDITOR,
+				Source: ROOM_PERMISSION_SOURCE_TWITCH_MODERATOR,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,29 @@
+package room
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoomRole_Parse(t *testing.T) {
+	assert := assert.New(t)
+
+	role, err := RoomRole_Parse("Editor")
+	assert.Nil(err)
+	assert.Equal(ROOM_ROLE_EDITOR, role)
+
+	// Owners can not be granted, they are implied by the channel name
+	_, err = RoomRole_Parse("owner")
+	assert.NotNil(err)
+}
+
+func TestRoomRole_AtLeast(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(ROOM_ROLE_OWNER.AtLeast(ROOM_ROLE_EDITOR))
+	assert.True(ROOM_ROLE_EDITOR.AtLeast(ROOM_ROLE_EDITOR))
+	assert.True(ROOM_ROLE_EDITOR.AtLeast(ROOM_ROLE_VIEWER))
+	assert.False(ROOM_ROLE_VIEWER.AtLeast(ROOM_ROLE_EDITOR))
+	assert.False(ROOM_ROLE_NONE.AtLeast(ROOM_ROLE_VIEWER))
+}
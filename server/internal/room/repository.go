@@ -13,6 +13,9 @@ type RoomRepository interface {
 	GetOrInsertRoom(ctx context.Context, roomConfig *RoomConfig) (
 		roomDb *RoomDb, isNew bool, err error)
 	GetRoomByChannelName(ctx context.Context, channelName string) (*RoomDb, error)
+	// Returns a page of the rooms along with the total number of rooms
+	GetRoomsPage(ctx context.Context, isActive bool, orderBy string, offset int, limit int) (
+		roomDbs []*RoomDb, total int64, err error)
 
 	GetRoomGarbageCollectionPeriodMins(ctx context.Context, roomId uint) int
 
@@ -23,11 +26,29 @@ type RoomRepository interface {
 		roomId uint,
 		config *misc.SpineRuntimeConfig,
 	) error
+	// An empty operatorName clears the room's default chibi so that the
+	// bot's InitialOperator is used instead.
+	UpdateDefaultOperatorForId(
+		ctx context.Context,
+		roomId uint,
+		operatorName string,
+		details *misc.InitialOperatorDetails,
+	) error
 
 	IsRoomActiveById(ctx context.Context, roomId uint) bool
 	SetRoomActiveById(ctx context.Context, roomId uint, isActive bool) error
 }
 
+type RoomPermissionRepository interface {
+	GetByRoomId(ctx context.Context, roomId uint) ([]*RoomPermissionUserDb, error)
+	GetRoleOrNone(ctx context.Context, roomId uint, userId uint) (RoomRole, error)
+	SetRole(ctx context.Context, roomId uint, userId uint, role RoomRole, source string) error
+	DeleteByUserId(ctx context.Context, roomId uint, userId uint) error
+	// Grant the editor role to all the given users and remove it from
+	// any previously synced moderators which are no longer in the list.
+	SyncModerators(ctx context.Context, roomId uint, userIds []uint) error
+}
+
 type RoomDb struct {
 	RoomId                      uint                        `gorm:"primarykey"`
 	ChannelName                 string                      `gorm:"column:channel_name"`
@@ -44,3 +65,23 @@ type RoomDb struct {
 func (RoomDb) TableName() string {
 	return "rooms"
 }
+
+type RoomPermissionDb struct {
+	RoomPermissionId uint      `gorm:"primarykey"`
+	RoomId           uint      `gorm:"column:room_id"`
+	UserId           uint      `gorm:"column:user_id"`
+	Role             RoomRole  `gorm:"column:role"`
+	Source           string    `gorm:"column:source"`
+	CreatedAt        time.Time `gorm:"column:created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at"`
+}
+
+func (RoomPermissionDb) TableName() string {
+	return "room_permissions"
+}
+
+type RoomPermissionUserDb struct {
+	RoomPermissionDb
+	Username        string `gorm:"column:username"`
+	UserDisplayName string `gorm:"column:user_display_name"`
+}
@@ -62,6 +62,34 @@ func (r *RoomRepositoryPsql) GetRoomByChannelName(ctx context.Context, channelNa
 	return &roomDb, nil
 }
 
+func (r *RoomRepositoryPsql) GetRoomsPage(
+	ctx context.Context,
+	isActive bool,
+	orderBy string,
+	offset int,
+	limit int,
+) ([]*RoomDb, int64, error) {
+	db := r.DefaultDB.WithContext(ctx)
+
+	var total int64
+	result := db.Model(&RoomDb{}).Where("is_active = ?", isActive).Count(&total)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	var roomDbs []*RoomDb
+	result = db.
+		Where("is_active = ?", isActive).
+		Order(orderBy).
+		Order("room_id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&roomDbs)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return roomDbs, total, nil
+}
+
 func (r *RoomRepositoryPsql) GetRoomGarbageCollectionPeriodMins(ctx context.Context, roomId uint) int {
 	db := r.DefaultDB.WithContext(ctx)
 	var roomDb RoomDb
@@ -99,6 +127,27 @@ func (r *RoomRepositoryPsql) UpdateSpineRuntimeConfigForId(
 	return result.Error
 }
 
+func (r *RoomRepositoryPsql) UpdateDefaultOperatorForId(
+	ctx context.Context,
+	roomId uint,
+	operatorName string,
+	details *misc.InitialOperatorDetails,
+) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.
+		Model(&RoomDb{}).
+		Where("room_id = ?", roomId).
+		Select("default_operator_name", "default_operator_config").
+		Updates(&RoomDb{
+			DefaultOperatorName:   operatorName,
+			DefaultOperatorConfig: *details,
+		})
+	if result.Error != nil {
+		log.Println("Error updating room ", roomId, result.Error)
+	}
+	return result.Error
+}
+
 func (r *RoomRepositoryPsql) IsRoomActiveById(ctx context.Context, roomId uint) bool {
 	db := r.DefaultDB.WithContext(ctx)
 	var roomDb RoomDb
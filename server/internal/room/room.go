@@ -6,14 +6,17 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/chatbot"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/chibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	spine "github.com/Stymphalian/ak_chibi_bot/server/internal/spine_runtime"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 )
 
 type RoomConfig struct {
@@ -29,20 +32,23 @@ type RoomConfig struct {
 // Model - chibiActor
 // View-Model/Controller - twitchChat
 type Room struct {
-	operatorService           *operator.OperatorService
-	roomId                    uint
-	channelName               string
-	roomRepo                  RoomRepository
-	usersRepo                 users.UserRepository
-	chatterRepo               users.ChatterRepository
-	spineRuntime              spine.SpineRuntime
-	chibiActor                *chibi.ChibiActor
-	chatBots                  []chatbot.ChatBotter
-	createdAt                 time.Time
-	nextGarbageCollectionTime time.Time
-	isClosed                  bool
-	removeRoomCh              chan string
-	removalFns                []func()
+	operatorService *operator.OperatorService
+	roomId          uint
+	channelName     string
+	roomRepo        RoomRepository
+	usersRepo       users.UserRepository
+	chatterRepo     users.ChatterRepository
+	spineRuntime    spine.SpineRuntime
+	chibiActor      *chibi.ChibiActor
+	chatBots        []chatbot.ChatBotter
+	notifier        webhook.Notifier
+	publisher       events.Publisher
+	createdAt       time.Time
+	isClosed        bool
+	removeRoomCh    chan string
+	removalFns      []func()
+	// Written by the garbage collection timer and read by the stats
+	nextGarbageCollectionTime atomic.Pointer[time.Time]
 }
 
 func NewRoom(
@@ -55,6 +61,8 @@ func NewRoom(
 	spineRuntime spine.SpineRuntime,
 	chibiActor *chibi.ChibiActor,
 	chatBots []chatbot.ChatBotter,
+	notifier webhook.Notifier,
+	publisher events.Publisher,
 	removeRoomCh chan string) (*Room, error) {
 	r := &Room{
 		roomId:          roomId,
@@ -66,6 +74,8 @@ func NewRoom(
 		spineRuntime:    spineRuntime,
 		chibiActor:      chibiActor,
 		chatBots:        chatBots,
+		notifier:        notifier,
+		publisher:       publisher,
 		createdAt:       misc.Clock.Now(),
 		isClosed:        false,
 		removeRoomCh:    removeRoomCh,
@@ -93,7 +103,25 @@ func (r *Room) CreatedAt() time.Time {
 }
 
 func (r *Room) GetNextGarbageCollectionTime() time.Time {
-	return r.nextGarbageCollectionTime
+	if nextTime := r.nextGarbageCollectionTime.Load(); nextTime != nil {
+		return *nextTime
+	}
+	return time.Time{}
+}
+
+func (r *Room) setNextGarbageCollectionTime(nextTime time.Time) {
+	r.nextGarbageCollectionTime.Store(&nextTime)
+}
+
+// SetBotAccessToken hands a refreshed token to the room's twitch bot so that
+// it is used when the bot reconnects. Ignored if the room is running under a
+// different bot account.
+func (r *Room) SetBotAccessToken(botName string, accessToken string) {
+	for _, chatBot := range r.chatBots {
+		if twitchBot, ok := chatBot.(*chatbot.TwitchBot); ok && twitchBot.BotName() == botName {
+			twitchBot.SetAccessToken(accessToken)
+		}
+	}
 }
 
 func (r *Room) SetActive(isActive bool) {
@@ -106,11 +134,17 @@ func (r *Room) Close() error {
 
 	if !r.roomRepo.IsRoomActiveById(context.Background(), r.roomId) {
 		// If the room is inactive, we can clear out all the chibis/chatters
-		err := r.chibiActor.Close()
+		err := r.chibiActor.WithLock(r.chibiActor.Close)
 		if err != nil {
 			log.Println("Failed to close ChibiActor", err)
 		}
+		r.notifier.Notify(r.roomId, webhook.EVENT_ROOM_CLOSED, webhook.RoomEventData{
+			ChannelName: r.channelName,
+		})
 	}
+	r.publisher.Publish(r.roomId, events.EVENT_ROOM_CLOSED, webhook.RoomEventData{
+		ChannelName: r.channelName,
+	})
 
 	// Disconnect the twitch chat and other chats bots
 	for _, chatBot := range r.chatBots {
@@ -139,23 +173,26 @@ func (r *Room) garbageCollectOldChibis(interval time.Duration) {
 	ctx := context.Background()
 	numRemoved := 0
 	numRemovedErr := 0
-	for username, chatUser := range r.chibiActor.ChatUsers {
-		if username == r.GetChannelName() {
-			// Skip removing the broadcaster's chibi
-			continue
-		}
-		if !chatUser.IsActiveChatter(interval) {
-			log.Println("Removing chibi for", username)
-			err := r.chibiActor.RemoveUserChibi(ctx, username)
-			if err != nil {
-				numRemovedErr += 1
-			} else {
-				numRemoved += 1
+	r.chibiActor.WithLock(func() error {
+		for username, chatUser := range r.chibiActor.ChatUsers {
+			if username == r.GetChannelName() {
+				// Skip removing the broadcaster's chibi
+				continue
+			}
+			if !chatUser.IsActiveChatter(interval) {
+				log.Println("Removing chibi for", username)
+				err := r.chibiActor.RemoveUserChibi(ctx, username)
+				if err != nil {
+					numRemovedErr += 1
+				} else {
+					numRemoved += 1
+				}
 			}
 		}
-	}
+		return nil
+	})
 
-	r.nextGarbageCollectionTime = time.Now().Add(interval)
+	r.setNextGarbageCollectionTime(time.Now().Add(interval))
 	log.Printf(
 		"Finished garbage collecting old chibis from room %s, %d removed, %d errors\n",
 		r.GetChannelName(), numRemoved, numRemovedErr,
@@ -170,7 +207,7 @@ func (r *Room) Run() {
 	GCPeriodMins := r.roomRepo.GetRoomGarbageCollectionPeriodMins(context.Background(), r.roomId)
 	if GCPeriodMins > 0 {
 		period := time.Duration(GCPeriodMins) * time.Minute
-		r.nextGarbageCollectionTime = time.Now().Add(period)
+		r.setNextGarbageCollectionTime(time.Now().Add(period))
 		stopTimer := misc.StartTimer(
 			fmt.Sprintf("GarbageCollectOldChibis %s", r.GetChannelName()),
 			period,
@@ -202,9 +239,12 @@ func (r *Room) Run() {
 
 func (r *Room) GetChatters() []users.ChatUser {
 	chatters := make([]users.ChatUser, 0)
-	for _, chatter := range r.chibiActor.ChatUsers {
-		chatters = append(chatters, *chatter)
-	}
+	r.chibiActor.WithLock(func() error {
+		for _, chatter := range r.chibiActor.ChatUsers {
+			chatters = append(chatters, *chatter)
+		}
+		return nil
+	})
 	return chatters
 }
 
@@ -230,27 +270,157 @@ func (r *Room) AddOperatorToRoom(
 	opInfo.StartPos = misc.NewOption(startPos)
 	opInfo.ChibiStance = stance
 
-	err = r.chibiActor.UpdateChibi(ctx, userinfo, opInfo)
-	if err != nil {
-		return err
+	return r.chibiActor.WithLock(func() error {
+		return r.chibiActor.UpdateChibi(ctx, userinfo, opInfo)
+	})
+}
+
+// UpdateChatterChibi replaces the chibi of a chatter already in the room
+func (r *Room) UpdateChatterChibi(ctx context.Context, username string, opInfo *operator.OperatorInfo) error {
+	return r.chibiActor.WithLock(func() error {
+		chatUser, ok := r.chibiActor.ChatUsers[username]
+		if !ok {
+			return spine.NewUserNotFound("User not found: " + username)
+		}
+		return r.chibiActor.UpdateChibi(
+			ctx,
+			misc.UserInfo{
+				Username:        chatUser.GetUsername(),
+				UsernameDisplay: chatUser.GetUsernameDisplay(),
+				TwitchUserId:    chatUser.GetTwitchUserId(),
+			},
+			opInfo,
+		)
+	})
+}
+
+// MigrateInvalidChibis fixes up the chibis whose assets no longer exist after
+// the assets were reloaded. Missing skins/stances/facings fallback to their
+// defaults, chatters whose operator was removed are given a random operator.
+// Returns the number of chibis that were changed.
+func (r *Room) MigrateInvalidChibis(ctx context.Context) int {
+	numMigrated := 0
+	for _, chatUser := range r.GetChatters() {
+		opInfo := *chatUser.GetOperatorInfo()
+		if r.operatorService.ValidateOperatorRequest(&opInfo) == nil {
+			continue
+		}
+
+		userInfo := misc.UserInfo{
+			Username:        chatUser.GetUsername(),
+			UsernameDisplay: chatUser.GetUsernameDisplay(),
+			TwitchUserId:    chatUser.GetTwitchUserId(),
+		}
+		if err := r.operatorService.ValidateUpdateSetDefaultOtherwise(&opInfo); err != nil {
+			log.Printf("Operator %s was removed, replacing chibi for %s\n", opInfo.OperatorId, userInfo.Username)
+			randomOpInfo, err := r.operatorService.GetRandomOperatorForUser(userInfo.TwitchUserId)
+			if err != nil {
+				log.Println("Failed to get random operator", err)
+				continue
+			}
+			randomOpInfo.StartPos = opInfo.StartPos
+			opInfo = *randomOpInfo
+		} else if r.operatorService.ValidateOperatorRequest(&opInfo) != nil {
+			// The action references animations which no longer exist
+			r.operatorService.SetDefaultWanderAction(&opInfo)
+		}
+		err := r.chibiActor.WithLock(func() error {
+			return r.chibiActor.UpdateChibi(ctx, userInfo, &opInfo)
+		})
+		if err != nil {
+			log.Println("Failed to migrate chibi for", userInfo.Username, err)
+			continue
+		}
+		numMigrated += 1
 	}
-	return nil
+	return numMigrated
+}
+
+// ResetChatterChibi gives the chatter back the chibi they would get when
+// joining the room, either their saved chibi or a random one.
+func (r *Room) ResetChatterChibi(ctx context.Context, username string) error {
+	return r.chibiActor.WithLock(func() error {
+		chatUser, ok := r.chibiActor.ChatUsers[username]
+		if !ok {
+			return spine.NewUserNotFound("User not found: " + username)
+		}
+		return r.chibiActor.GiveChibiToUser(ctx, misc.UserInfo{
+			Username:        chatUser.GetUsername(),
+			UsernameDisplay: chatUser.GetUsernameDisplay(),
+			TwitchUserId:    chatUser.GetTwitchUserId(),
+		})
+	})
+}
+
+// FindChatterChibi flashes the chatter's chibi on the overlays
+func (r *Room) FindChatterChibi(ctx context.Context, username string) error {
+	return r.chibiActor.WithLock(func() error {
+		chatUser, ok := r.chibiActor.ChatUsers[username]
+		if !ok {
+			return spine.NewUserNotFound("User not found: " + username)
+		}
+		return r.chibiActor.FindOperator(ctx, misc.UserInfo{
+			Username:        chatUser.GetUsername(),
+			UsernameDisplay: chatUser.GetUsernameDisplay(),
+			TwitchUserId:    chatUser.GetTwitchUserId(),
+		})
+	})
+}
+
+func (r *Room) HasChatter(username string) bool {
+	ok := false
+	r.chibiActor.WithLock(func() error {
+		_, ok = r.chibiActor.ChatUsers[username]
+		return nil
+	})
+	return ok
+}
+
+// ClearChibis removes every chibi from the room. Returns how many were removed.
+func (r *Room) ClearChibis(ctx context.Context) int {
+	numRemoved := 0
+	r.chibiActor.WithLock(func() error {
+		for username := range r.chibiActor.ChatUsers {
+			if err := r.chibiActor.RemoveUserChibi(ctx, username); err != nil {
+				log.Println("Failed to remove chibi for", username, err)
+				continue
+			}
+			numRemoved += 1
+		}
+		return nil
+	})
+	return numRemoved
+}
+
+func (r *Room) IsPaused() bool {
+	return r.chibiActor.IsPaused()
+}
+
+func (r *Room) SetPaused(ctx context.Context, paused bool) error {
+	return r.chibiActor.WithLock(func() error {
+		return r.chibiActor.SetPaused(ctx, paused)
+	})
 }
 
 func (r *Room) GiveChibiToUser(ctx context.Context, userInfo misc.UserInfo) error {
-	return r.chibiActor.GiveChibiToUser(ctx, userInfo)
+	return r.chibiActor.WithLock(func() error {
+		return r.chibiActor.GiveChibiToUser(ctx, userInfo)
+	})
 }
 
 func (s *Room) AddWebsocketConnection(w http.ResponseWriter, r *http.Request) error {
 	// TODO: Maybe just pass in the map directly
 	chatters := make([]*spine.ChatterInfo, 0)
-	for _, chatUser := range s.chibiActor.ChatUsers {
-		chatters = append(chatters, &spine.ChatterInfo{
-			Username:        chatUser.GetUsername(),
-			UsernameDisplay: chatUser.GetUsernameDisplay(),
-			OperatorInfo:    *chatUser.GetOperatorInfo(),
-		})
-	}
+	s.chibiActor.WithLock(func() error {
+		for _, chatUser := range s.chibiActor.ChatUsers {
+			chatters = append(chatters, &spine.ChatterInfo{
+				Username:        chatUser.GetUsername(),
+				UsernameDisplay: chatUser.GetUsernameDisplay(),
+				OperatorInfo:    *chatUser.GetOperatorInfo(),
+			})
+		}
+		return nil
+	})
 	return s.spineRuntime.AddConnection(w, r, chatters)
 }
 
@@ -262,10 +432,15 @@ func (r *Room) NumConnectedClients() int {
 	return r.spineRuntime.NumConnections()
 }
 
+// ForEachChatter runs the callback while holding the chibi actor's lock. The
+// callback must not call back into the room.
 func (r *Room) ForEachChatter(callback func(chatUser *users.ChatUser)) {
-	for _, chatUser := range r.chibiActor.ChatUsers {
-		callback(chatUser)
-	}
+	r.chibiActor.WithLock(func() error {
+		for _, chatUser := range r.chibiActor.ChatUsers {
+			callback(chatUser)
+		}
+		return nil
+	})
 }
 
 func (r *Room) LoadExistingChatters(ctx context.Context) error {
@@ -278,22 +453,24 @@ func (r *Room) LoadExistingChatters(ctx context.Context) error {
 		if err != nil {
 			continue
 		}
-		if r.chibiActor.ShouldExcludeUser(user.Username) {
-			log.Println("Excluding user ", user.Username)
-			r.chibiActor.RemoveUserChibi(ctx, user.Username)
-			continue
-		}
+		err = r.chibiActor.WithLock(func() error {
+			if r.chibiActor.ShouldExcludeUser(user.Username) {
+				log.Println("Excluding user ", user.Username)
+				r.chibiActor.RemoveUserChibi(ctx, user.Username)
+				return nil
+			}
 
-		log.Printf("Reloading chatter %s in room %s with operator %s", user.Username, r.GetChannelName(), chatter.OperatorInfo.OperatorDisplayName)
-		err = r.chibiActor.UpdateChibi(
-			ctx,
-			misc.UserInfo{
-				Username:        user.Username,
-				UsernameDisplay: user.UserDisplayName,
-				TwitchUserId:    user.TwitchUserId,
-			},
-			&chatter.OperatorInfo,
-		)
+			log.Printf("Reloading chatter %s in room %s with operator %s", user.Username, r.GetChannelName(), chatter.OperatorInfo.OperatorDisplayName)
+			return r.chibiActor.UpdateChibi(
+				ctx,
+				misc.UserInfo{
+					Username:        user.Username,
+					UsernameDisplay: user.UserDisplayName,
+					TwitchUserId:    user.TwitchUserId,
+				},
+				&chatter.OperatorInfo,
+			)
+		})
 		if err != nil {
 			log.Printf("Failed to reload chatter %s in room %s: %s\n", user.Username, r.GetChannelName(), err)
 		}
@@ -303,7 +480,9 @@ func (r *Room) LoadExistingChatters(ctx context.Context) error {
 
 // TODO: Leaky interface. Exposing all the ChibiActor methods through the Room
 func (r *Room) RemoveUserChibi(ctx context.Context, username string) error {
-	return r.chibiActor.RemoveUserChibi(ctx, username)
+	return r.chibiActor.WithLock(func() error {
+		return r.chibiActor.RemoveUserChibi(ctx, username)
+	})
 }
 
 func (r *Room) GetSpineRuntimeConfig(ctx context.Context) (*misc.SpineRuntimeConfig, error) {
@@ -320,10 +499,12 @@ func (r *Room) RefreshConfigs(ctx context.Context, botConfig *misc.BotConfig) er
 		return err
 	}
 	r.operatorService.SetConfig(newConfig)
-	r.chibiActor.UpdateExcludeNames(
-		append(botConfig.ExcludeNames, newConfig.UsernamesBlacklist...),
-	)
-	return nil
+	return r.chibiActor.WithLock(func() error {
+		r.chibiActor.UpdateExcludeNames(
+			append(botConfig.ExcludeNames, newConfig.UsernamesBlacklist...),
+		)
+		return nil
+	})
 }
 
 func (r *Room) Refresh(ctx context.Context, botConfig *misc.BotConfig) error {
@@ -0,0 +1,265 @@
+package room
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+)
+
+const (
+	STATS_DEFAULT_LIMIT = 50
+	STATS_MAX_LIMIT     = 200
+)
+
+// Sort orders of the rooms. Names/creation can be used for inactive rooms,
+// the others are only known for the active rooms.
+const (
+	ROOMS_SORT_CHANNEL     = "channel"
+	ROOMS_SORT_CREATED     = "created"
+	ROOMS_SORT_LAST_ACTIVE = "last_active"
+	ROOMS_SORT_CHATTERS    = "chatters"
+	ROOMS_SORT_CONNECTIONS = "connections"
+	ROOMS_SORT_COMMANDS    = "commands"
+)
+
+const (
+	CHATTERS_SORT_USERNAME  = "username"
+	CHATTERS_SORT_LAST_CHAT = "last_chat"
+)
+
+// The database columns to sort the inactive rooms by
+var inactiveRoomsOrderBy = map[string]string{
+	ROOMS_SORT_CHANNEL:     "channel_name ASC",
+	ROOMS_SORT_CREATED:     "created_at DESC",
+	ROOMS_SORT_LAST_ACTIVE: "updated_at DESC",
+}
+
+type RoomStats struct {
+	RoomId      uint   `json:"room_id"`
+	ChannelName string `json:"channel_name"`
+	IsActive    bool   `json:"is_active"`
+	// When the active room was started, for inactive rooms when the room was
+	// first created.
+	CreatedAt time.Time `json:"created_at"`
+	// The last chat for active rooms, otherwise when the room was closed
+	LastActiveAt time.Time `json:"last_active_at"`
+
+	// Only set for active rooms
+	NextGCTime              *time.Time         `json:"next_gc_time,omitempty"`
+	NumChatters             int                `json:"num_chatters"`
+	NumWebsocketConnections int                `json:"num_websocket_connections"`
+	ConnectionAverageFps    map[string]float64 `json:"connection_average_fps"`
+	CommandsPerMinute       float64            `json:"commands_per_minute"`
+	NumCommands             int                `json:"num_commands"`
+}
+
+type RoomStatsPage struct {
+	Rooms  []*RoomStats `json:"rooms"`
+	Total  int          `json:"total"`
+	Offset int          `json:"offset"`
+	Limit  int          `json:"limit"`
+}
+
+type ChatterStats struct {
+	Username        string    `json:"username"`
+	UsernameDisplay string    `json:"username_display"`
+	TwitchUserId    string    `json:"twitch_user_id"`
+	OperatorId      string    `json:"operator_id"`
+	OperatorName    string    `json:"operator_name"`
+	Faction         string    `json:"faction"`
+	LastChatTime    time.Time `json:"last_chat_time"`
+}
+
+type ChatterStatsPage struct {
+	ChannelName string          `json:"channel_name"`
+	Chatters    []*ChatterStats `json:"chatters"`
+	Total       int             `json:"total"`
+	Offset      int             `json:"offset"`
+	Limit       int             `json:"limit"`
+}
+
+func (r *Room) GetStats() *RoomStats {
+	now := misc.Clock.Now()
+	nextGCTime := r.GetNextGarbageCollectionTime()
+	commandRate := r.chibiActor.GetCommandRate()
+	return &RoomStats{
+		RoomId:                  r.roomId,
+		ChannelName:             r.channelName,
+		IsActive:                true,
+		CreatedAt:               r.createdAt,
+		LastActiveAt:            r.GetLastChatterTime(),
+		NextGCTime:              &nextGCTime,
+		NumChatters:             r.chibiActor.NumChatters(),
+		NumWebsocketConnections: r.NumConnectedClients(),
+		ConnectionAverageFps:    r.spineRuntime.ConnectionAverageFps(),
+		CommandsPerMinute:       commandRate.PerMinute(now),
+		NumCommands:             commandRate.Total(),
+	}
+}
+
+func newChatterStats(chatUser *users.ChatUser) *ChatterStats {
+	opInfo := chatUser.GetOperatorInfo()
+	return &ChatterStats{
+		Username:        chatUser.GetUsername(),
+		UsernameDisplay: chatUser.GetUsernameDisplay(),
+		TwitchUserId:    chatUser.GetTwitchUserId(),
+		OperatorId:      opInfo.OperatorId,
+		OperatorName:    opInfo.OperatorDisplayName,
+		Faction:         string(opInfo.Faction),
+		LastChatTime:    chatUser.GetLastChatTime(),
+	}
+}
+
+// GetRooms returns a snapshot of the active rooms
+func (r *RoomsManager) GetRooms() []*Room {
+	r.rooms_mutex.Lock()
+	defer r.rooms_mutex.Unlock()
+	rooms := make([]*Room, 0, len(r.Rooms))
+	for _, roomObj := range r.Rooms {
+		rooms = append(rooms, roomObj)
+	}
+	return rooms
+}
+
+func (r *RoomsManager) GetRoom(channelName string) (*Room, bool) {
+	r.rooms_mutex.Lock()
+	defer r.rooms_mutex.Unlock()
+	roomObj, ok := r.Rooms[channelName]
+	return roomObj, ok
+}
+
+func clampStatsPagination(offset int, limit int) (int, int, error) {
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("offset must be non-negative")
+	}
+	if limit <= 0 {
+		limit = STATS_DEFAULT_LIMIT
+	}
+	return offset, min(limit, STATS_MAX_LIMIT), nil
+}
+
+// ListRoomStats returns a page of the active rooms, or of the inactive rooms
+// from the database.
+func (r *RoomsManager) ListRoomStats(
+	ctx context.Context,
+	active bool,
+	sortBy string,
+	offset int,
+	limit int,
+) (*RoomStatsPage, error) {
+	offset, limit, err := clampStatsPagination(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(sortBy) == 0 {
+		sortBy = ROOMS_SORT_CHANNEL
+	}
+
+	if !active {
+		orderBy, ok := inactiveRoomsOrderBy[sortBy]
+		if !ok {
+			return nil, fmt.Errorf("inactive rooms cannot be sorted by %s", sortBy)
+		}
+		roomDbs, total, err := r.roomRepo.GetRoomsPage(ctx, false, orderBy, offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		stats := make([]*RoomStats, 0, len(roomDbs))
+		for _, roomDb := range roomDbs {
+			stats = append(stats, &RoomStats{
+				RoomId:               roomDb.RoomId,
+				ChannelName:          roomDb.ChannelName,
+				IsActive:             false,
+				CreatedAt:            roomDb.CreatedAt,
+				LastActiveAt:         roomDb.UpdatedAt,
+				ConnectionAverageFps: map[string]float64{},
+			})
+		}
+		return &RoomStatsPage{Rooms: stats, Total: int(total), Offset: offset, Limit: limit}, nil
+	}
+
+	var compare func(a, b *RoomStats) int
+	switch sortBy {
+	case ROOMS_SORT_CHANNEL:
+		compare = func(a, b *RoomStats) int { return strings.Compare(a.ChannelName, b.ChannelName) }
+	case ROOMS_SORT_CREATED:
+		compare = func(a, b *RoomStats) int { return b.CreatedAt.Compare(a.CreatedAt) }
+	case ROOMS_SORT_LAST_ACTIVE:
+		compare = func(a, b *RoomStats) int { return b.LastActiveAt.Compare(a.LastActiveAt) }
+	case ROOMS_SORT_CHATTERS:
+		compare = func(a, b *RoomStats) int { return cmp.Compare(b.NumChatters, a.NumChatters) }
+	case ROOMS_SORT_CONNECTIONS:
+		compare = func(a, b *RoomStats) int {
+			return cmp.Compare(b.NumWebsocketConnections, a.NumWebsocketConnections)
+		}
+	case ROOMS_SORT_COMMANDS:
+		compare = func(a, b *RoomStats) int { return cmp.Compare(b.CommandsPerMinute, a.CommandsPerMinute) }
+	default:
+		return nil, fmt.Errorf("unknown sort %s", sortBy)
+	}
+
+	rooms := r.GetRooms()
+	stats := make([]*RoomStats, 0, len(rooms))
+	for _, roomObj := range rooms {
+		stats = append(stats, roomObj.GetStats())
+	}
+	// Ties are broken by the channel name so the pages are stable
+	slices.SortFunc(stats, func(a, b *RoomStats) int {
+		return cmp.Or(compare(a, b), strings.Compare(a.ChannelName, b.ChannelName))
+	})
+
+	start := min(offset, len(stats))
+	end := start + min(limit, len(stats)-start)
+	return &RoomStatsPage{Rooms: stats[start:end], Total: len(stats), Offset: offset, Limit: limit}, nil
+}
+
+// ListChatterStats returns a page of the chatters in the active room
+func (r *RoomsManager) ListChatterStats(
+	channelName string,
+	sortBy string,
+	offset int,
+	limit int,
+) (*ChatterStatsPage, error) {
+	offset, limit, err := clampStatsPagination(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	roomObj, ok := r.GetRoom(channelName)
+	if !ok {
+		return nil, fmt.Errorf("room %s does not exist", channelName)
+	}
+
+	var compare func(a, b *ChatterStats) int
+	switch sortBy {
+	case "", CHATTERS_SORT_USERNAME:
+		compare = func(a, b *ChatterStats) int { return strings.Compare(a.Username, b.Username) }
+	case CHATTERS_SORT_LAST_CHAT:
+		compare = func(a, b *ChatterStats) int {
+			return cmp.Or(b.LastChatTime.Compare(a.LastChatTime), strings.Compare(a.Username, b.Username))
+		}
+	default:
+		return nil, fmt.Errorf("unknown sort %s", sortBy)
+	}
+
+	chatters := make([]*ChatterStats, 0)
+	roomObj.ForEachChatter(func(chatUser *users.ChatUser) {
+		chatters = append(chatters, newChatterStats(chatUser))
+	})
+	slices.SortFunc(chatters, compare)
+
+	start := min(offset, len(chatters))
+	end := start + min(limit, len(chatters)-start)
+	return &ChatterStatsPage{
+		ChannelName: channelName,
+		Chatters:    chatters[start:end],
+		Total:       len(chatters),
+		Offset:      offset,
+		Limit:       limit,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package room
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/chibi"
+	spine "github.com/Stymphalian/ak_chibi_bot/server/internal/spine_runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListStats_HugeOffsetIsAnEmptyPage(t *testing.T) {
+	assert := assert.New(t)
+	actor := chibi.NewChibiActor(1, nil, nil, nil, nil, spine.NewFakeSpineClient(), nil, nil, nil)
+	sut := &RoomsManager{
+		Rooms: map[string]*Room{"alpha": {channelName: "alpha", chibiActor: actor}},
+	}
+
+	chatters, err := sut.ListChatterStats("alpha", "", math.MaxInt, 10)
+	assert.Nil(err)
+	assert.Equal(0, chatters.Total)
+	assert.Empty(chatters.Chatters)
+
+	_, err = sut.ListChatterStats("alpha", "", -1, 10)
+	assert.NotNil(err)
+
+	sut = &RoomsManager{Rooms: map[string]*Room{}}
+	rooms, err := sut.ListRoomStats(context.Background(), true, "", math.MaxInt, 10)
+	assert.Nil(err)
+	assert.Equal(0, rooms.Total)
+	assert.Empty(rooms.Rooms)
+}
@@ -6,13 +6,11 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -24,12 +22,14 @@ import (
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/login"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 )
 
 const (
@@ -42,14 +42,16 @@ type MainServer struct {
 	botConfig *misc.BotConfig
 
 	akDb         *akdb.DatbaseConn
-	assetStore   *akdb.AssetStore
+	assetStore   akdb.AssetStore
 	roomsRepo    room.RoomRepository
 	usersRepo    users.UserRepository
 	chattersRepo users.ChatterRepository
 	authRepo     auth.AuthRepository
 
-	assetService *operator.AssetService
-	authService  *auth.AuthService
+	assetService   *operator.AssetService
+	authService    *auth.AuthService
+	webhookService *webhook.WebhookService
+	eventsHub      *events.Hub
 
 	roomManager *room.RoomsManager
 	apiServer   *api.ApiServer
@@ -66,11 +68,13 @@ func NewMainServer(
 	authRepo auth.AuthRepository,
 	twitchApiClient twitch_api.TwitchApiClientInterface,
 	authService *auth.AuthService,
+	webhookService *webhook.WebhookService,
+	eventsHub *events.Hub,
 	loginServer *login.LoginServer,
 	roomsManager *room.RoomsManager,
 	apiServer *api.ApiServer,
 	akDb *akdb.DatbaseConn,
-	assetStore *akdb.AssetStore,
+	assetStore akdb.AssetStore,
 ) *MainServer {
 	return &MainServer{
 		args:      *args,
@@ -83,11 +87,13 @@ func NewMainServer(
 		chattersRepo: chattersRepo,
 		authRepo:     authRepo,
 
-		assetService: assetService,
-		authService:  authService,
-		roomManager:  roomsManager,
-		apiServer:    apiServer,
-		loginServer:  loginServer,
+		assetService:   assetService,
+		authService:    authService,
+		webhookService: webhookService,
+		eventsHub:      eventsHub,
+		roomManager:    roomsManager,
+		apiServer:      apiServer,
+		loginServer:    loginServer,
 	}
 }
 
@@ -95,6 +101,9 @@ func (s *MainServer) Run() {
 	go s.roomManager.RunLoop()
 	go s.authService.RunLoop()
 	s.roomManager.LoadExistingRooms(context.Background())
+	if s.botConfig.AssetStore.PreloadActiveAssets {
+		go s.preloadActiveAssets()
+	}
 
 	log.Println("Starting server")
 
@@ -110,6 +119,9 @@ func (s *MainServer) Run() {
 	}
 	server.RegisterOnShutdown(s.roomManager.Shutdown)
 	server.RegisterOnShutdown(s.authService.Shutdown)
+	server.RegisterOnShutdown(s.webhookService.Shutdown)
+	// Ends any open event streams, otherwise Shutdown would wait on them forever
+	server.RegisterOnShutdown(s.eventsHub.Shutdown)
 
 	log.Printf("Images Assets = %s\n", s.args.ImageAssetDir)
 	log.Printf("Static Assets = %s\n", s.args.StaticAssetDir)
@@ -138,25 +150,10 @@ func (s *MainServer) Run() {
 		),
 	)
 
-	// Image Asset Handler (served from DB)
+	// Image Asset Handler (served from the configured asset store)
 	mux.Handle("/image/assets/",
 		http.TimeoutHandler(
-			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				filePath := strings.TrimPrefix(r.URL.Path, "/image/assets/")
-				data, err := s.assetStore.GetAsset(filePath)
-				if err != nil || len(data) == 0 {
-					http.NotFound(w, r)
-					return
-				}
-				ct := mime.TypeByExtension(filepath.Ext(filePath))
-				if ct == "" {
-					ct = "application/octet-stream"
-				}
-				w.Header().Set("Content-Type", ct)
-				w.Header().Set("Content-Encoding", "gzip")
-				w.WriteHeader(http.StatusOK)
-				w.Write(data)
-			}),
+			akdb.NewAssetHandler(s.assetStore, "/image/assets/"),
 			DEFAULT_TIMEOUT,
 			"",
 		),
@@ -214,9 +211,25 @@ func (s *MainServer) Run() {
 	s.WaitForShutdownsWithTimeout(
 		s.roomManager.GetShutdownChan(),
 		s.authService.GetShutdownChan(),
+		s.webhookService.GetShutdownChan(),
 	)
 }
 
+func (s *MainServer) preloadActiveAssets() {
+	cache, ok := s.assetStore.(*akdb.AssetCache)
+	if !ok {
+		log.Println("Asset cache is disabled, skipping preload")
+		return
+	}
+	paths, err := s.roomManager.GetActiveAssetPaths(context.Background())
+	if err != nil {
+		log.Println("Failed to get active asset paths", err)
+		return
+	}
+	numLoaded := cache.Preload(context.Background(), paths)
+	log.Printf("Preloaded %d/%d assets\n", numLoaded, len(paths))
+}
+
 func (s *MainServer) WaitForShutdownsWithTimeout(shutdownChans ...chan struct{}) {
 	log.Println("Waiting for shutdown")
 	allChanDones := make(chan struct{})
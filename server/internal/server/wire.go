@@ -7,12 +7,15 @@ import (
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/customchibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/login"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 	"github.com/google/wire"
 )
 
@@ -29,8 +32,12 @@ func InitializeMainServer() (*MainServer, error) {
 		// Repositories
 		akdb.ProvideDatabaseConn,
 		akdb.ProvideAssetStore,
+		akdb.NewAssetManifestRepositoryPsql,
+		wire.Bind(new(akdb.AssetManifestRepository), new(*akdb.AssetManifestRepositoryPsql)),
 		room.NewRoomRepositoryPsql,
 		wire.Bind(new(room.RoomRepository), new(*room.RoomRepositoryPsql)),
+		room.NewRoomPermissionRepositoryPsql,
+		wire.Bind(new(room.RoomPermissionRepository), new(*room.RoomPermissionRepositoryPsql)),
 		users.NewUserRepositoryPsql,
 		wire.Bind(new(users.UserRepository), new(*users.UserRepositoryPsql)),
 		users.NewChatterRepositoryPsql,
@@ -39,6 +46,10 @@ func InitializeMainServer() (*MainServer, error) {
 		wire.Bind(new(users.UserPreferencesRepository), new(*users.UserPreferencesRepositoryPsql)),
 		auth.NewAuthRepositoryPsql,
 		wire.Bind(new(auth.AuthRepository), new(*auth.AuthRepositoryPsql)),
+		webhook.NewWebhookRepositoryPsql,
+		wire.Bind(new(webhook.WebhookRepository), new(*webhook.WebhookRepositoryPsql)),
+		customchibi.NewCustomChibiRepositoryPsql,
+		wire.Bind(new(customchibi.CustomChibiRepository), new(*customchibi.CustomChibiRepositoryPsql)),
 
 		// Services
 		operator.NewAssetService,
@@ -47,6 +58,12 @@ func InitializeMainServer() (*MainServer, error) {
 		wire.Bind(new(twitch_api.TwitchApiClientInterface), new(*twitch_api.TwitchApiClient)),
 		auth.ProvideAuthService,
 		wire.Bind(new(auth.AuthServiceInterface), new(*auth.AuthService)),
+		webhook.NewWebhookService,
+		wire.Bind(new(webhook.Notifier), new(*webhook.WebhookService)),
+		events.NewHub,
+		wire.Bind(new(events.Publisher), new(*events.Hub)),
+		akdb.NewAssetVersionService,
+		customchibi.NewCustomChibiService,
 		room.NewRoomsManager,
 
 		// API Controllers and Servers
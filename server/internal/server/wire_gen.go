@@ -10,12 +10,15 @@ import (
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/auth"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/customchibi"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/login"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/room"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/twitch_api"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/users"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/webhook"
 )
 
 import (
@@ -31,7 +34,7 @@ func InitializeMainServer() (*MainServer, error) {
 	}
 	botConfig := misc.ProvideBotConfig(commandLineArgs)
 	imageAssetDirString := misc.ProvideImageAssetDirString(commandLineArgs)
-	assetService, err := operator.NewAssetService(imageAssetDirString)
+	assetService, err := operator.NewAssetService(imageAssetDirString, botConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -44,20 +47,31 @@ func InitializeMainServer() (*MainServer, error) {
 	chatterRepositoryPsql := users.NewChatterRepositoryPsql(datbaseConn)
 	authRepositoryPsql := auth.NewAuthRepositoryPsql(datbaseConn)
 	twitchApiClient := twitch_api.ProvideTwitchApiClient(botConfig)
-	authService, err := auth.ProvideAuthService(botConfig, twitchApiClient, userRepositoryPsql, datbaseConn)
+	authService, err := auth.ProvideAuthService(botConfig, twitchApiClient, userRepositoryPsql, authRepositoryPsql, datbaseConn)
 	if err != nil {
 		return nil, err
 	}
+	webhookRepositoryPsql := webhook.NewWebhookRepositoryPsql(datbaseConn)
+	webhookService := webhook.NewWebhookService(webhookRepositoryPsql)
+	hub := events.NewHub()
 	staticAssetDirString := misc.ProvideStaticAssetDirString(commandLineArgs)
-	loginServer, err := login.NewLoginServer(staticAssetDirString, authService, userRepositoryPsql)
+	roomPermissionRepositoryPsql := room.NewRoomPermissionRepositoryPsql(datbaseConn)
+	loginServer, err := login.NewLoginServer(staticAssetDirString, authService, userRepositoryPsql, roomRepositoryPsql, roomPermissionRepositoryPsql, twitchApiClient)
 	if err != nil {
 		return nil, err
 	}
 	userPreferencesRepositoryPsql := users.NewUserPreferencesRepositoryPsql(datbaseConn)
-	roomsManager := room.NewRoomsManager(assetService, roomRepositoryPsql, userRepositoryPsql, userPreferencesRepositoryPsql, chatterRepositoryPsql, twitchApiClient, botConfig)
+	customChibiRepositoryPsql := customchibi.NewCustomChibiRepositoryPsql(datbaseConn)
+	assetStore, err := akdb.ProvideAssetStore(datbaseConn, botConfig, imageAssetDirString)
+	if err != nil {
+		return nil, err
+	}
+	customChibiService := customchibi.NewCustomChibiService(customChibiRepositoryPsql, assetStore)
+	roomsManager := room.NewRoomsManager(assetService, roomRepositoryPsql, userRepositoryPsql, userPreferencesRepositoryPsql, chatterRepositoryPsql, twitchApiClient, authService, webhookService, hub, customChibiService, botConfig)
 	operatorService := operator.NewDefaultOperatorService(assetService)
-	apiServer := api.NewApiServer(roomsManager, authService, roomRepositoryPsql, userRepositoryPsql, userPreferencesRepositoryPsql, operatorService, botConfig)
-	assetStore := akdb.ProvideAssetStore(datbaseConn)
-	mainServer := NewMainServer(commandLineArgs, botConfig, assetService, roomRepositoryPsql, userRepositoryPsql, chatterRepositoryPsql, authRepositoryPsql, twitchApiClient, authService, loginServer, roomsManager, apiServer, datbaseConn, assetStore)
+	assetManifestRepositoryPsql := akdb.NewAssetManifestRepositoryPsql(datbaseConn)
+	assetVersionService := akdb.NewAssetVersionService(assetManifestRepositoryPsql, assetStore, botConfig, imageAssetDirString)
+	apiServer := api.NewApiServer(roomsManager, authService, roomRepositoryPsql, roomPermissionRepositoryPsql, userRepositoryPsql, userPreferencesRepositoryPsql, operatorService, twitchApiClient, webhookService, hub, customChibiService, assetVersionService, botConfig)
+	mainServer := NewMainServer(commandLineArgs, botConfig, assetService, roomRepositoryPsql, userRepositoryPsql, chatterRepositoryPsql, authRepositoryPsql, twitchApiClient, authService, webhookService, hub, loginServer, roomsManager, apiServer, datbaseConn, assetStore)
 	return mainServer, nil
 }
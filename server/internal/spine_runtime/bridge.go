@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/events"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 	"github.com/google/uuid"
@@ -29,19 +30,26 @@ type WebSocketConn struct {
 }
 
 type SpineBridge struct {
+	roomId                uint
 	spineService          *operator.OperatorService
+	publisher             events.Publisher
 	WebSocketConnections  map[string]*WebSocketConn
 	websocketPingerTicker *time.Ticker
 	websocketPingerDone   chan bool
 
 	clientResponseCallbackListenersId int
 	clientResponseCallbackListeners   map[int]ClientRequestCallback
-	// TODO: Might want to add mutex locking for updating websocket connections
+
+	// Guards WebSocketConnections and the connections' state. Also serializes
+	// the writes to each connection.
+	mutex sync.Mutex
 }
 
-func NewSpineBridge(spineService *operator.OperatorService) (*SpineBridge, error) {
+func NewSpineBridge(roomId uint, spineService *operator.OperatorService, publisher events.Publisher) (*SpineBridge, error) {
 	s := &SpineBridge{
+		roomId:               roomId,
 		spineService:         spineService,
+		publisher:            publisher,
 		WebSocketConnections: make(map[string]*WebSocketConn, 0),
 
 		clientResponseCallbackListenersId: 0,
@@ -65,6 +73,7 @@ func (s *SpineBridge) pingWebSockets() {
 			s.websocketPingerDone = nil
 			return
 		case <-s.websocketPingerTicker.C:
+			s.mutex.Lock()
 			for _, websocketConn := range s.WebSocketConnections {
 				if websocketConn.conn == nil {
 					continue
@@ -75,6 +84,7 @@ func (s *SpineBridge) pingWebSockets() {
 					misc.Clock.Now().Add(time.Duration(1)*time.Second),
 				)
 			}
+			s.mutex.Unlock()
 		}
 	}
 }
@@ -86,7 +96,10 @@ func (s *SpineBridge) Close() error {
 	}
 	// Close
 
+	// The connections remove themselves once closed so don't hold the lock
+	// while waiting for them
 	var wg sync.WaitGroup
+	s.mutex.Lock()
 	for roomName, websocketConn := range s.WebSocketConnections {
 		if websocketConn.conn == nil {
 			continue
@@ -111,6 +124,7 @@ func (s *SpineBridge) Close() error {
 			wg.Done()
 		}()
 	}
+	s.mutex.Unlock()
 
 	// Wait for the clients to reply
 	wg.Wait()
@@ -119,10 +133,6 @@ func (s *SpineBridge) Close() error {
 	return nil
 }
 
-func (s *SpineBridge) clientConnected() bool {
-	return len(s.WebSocketConnections) > 0
-}
-
 func (s *SpineBridge) handleResponseMessages(connectionId string, message []byte) {
 	var data map[string]interface{}
 	// log.Println("Received message", string(message))
@@ -149,9 +159,11 @@ func (s *SpineBridge) handleResponseMessages(connectionId string, message []byte
 			log.Println(err)
 			return
 		}
+		s.mutex.Lock()
 		if _, ok := s.WebSocketConnections[connectionId]; ok {
 			s.WebSocketConnections[connectionId].DebugInfo.AverageFps.Add(debugUpdateReq.AverageFps)
 		}
+		s.mutex.Unlock()
 	case RUNTIME_ROOM_SETTINGS:
 		var req RuntimeRoomSettingsRequest
 		err := json.Unmarshal(message, &req)
@@ -159,9 +171,11 @@ func (s *SpineBridge) handleResponseMessages(connectionId string, message []byte
 			log.Println(err)
 			return
 		}
+		s.mutex.Lock()
 		if _, ok := s.WebSocketConnections[connectionId]; ok {
 			s.WebSocketConnections[connectionId].SendChatMsgsFlag = req.ShowChatMessages
 		}
+		s.mutex.Unlock()
 	default:
 		log.Printf("Unhandled message type: %s", typeName)
 	}
@@ -172,9 +186,29 @@ func (s *SpineBridge) handleResponseMessages(connectionId string, message []byte
 }
 
 func (s *SpineBridge) NumConnections() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	return len(s.WebSocketConnections)
 }
 
+func (s *SpineBridge) ConnectionAverageFps() map[string]float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	averageFps := make(map[string]float64, len(s.WebSocketConnections))
+	for connectionId, conn := range s.WebSocketConnections {
+		fps := conn.DebugInfo.AverageFps
+		if fps.GetLength() == 0 {
+			continue
+		}
+		total := 0.0
+		for i := range fps.GetLength() {
+			total += fps.Get(i)
+		}
+		averageFps[connectionId] = total / float64(fps.GetLength())
+	}
+	return averageFps
+}
+
 func (s *SpineBridge) AddConnection(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -203,16 +237,30 @@ func (s *SpineBridge) AddConnection(
 		},
 		SendChatMsgsFlag: false,
 	}
+	s.mutex.Lock()
 	s.WebSocketConnections[connectionName] = websocketConn
+	numConnections := len(s.WebSocketConnections)
+	s.mutex.Unlock()
+	s.publisher.Publish(s.roomId, events.EVENT_WEBSOCKET_CONNECTED, events.ConnectionEventData{
+		ConnectionId:   connectionName,
+		NumConnections: numConnections,
+	})
 
 	// Track that something has connected to the client
 	log.Print("Client connected.")
 	defer func() {
 		log.Println("Closing connection and done channel.")
+		s.mutex.Lock()
 		close(websocketConn.done)
 		websocketConn.conn.Close()
 		websocketConn.conn = nil
 		delete(s.WebSocketConnections, connectionName)
+		numConnections := len(s.WebSocketConnections)
+		s.mutex.Unlock()
+		s.publisher.Publish(s.roomId, events.EVENT_WEBSOCKET_DISCONNECTED, events.ConnectionEventData{
+			ConnectionId:   connectionName,
+			NumConnections: numConnections,
+		})
 	}()
 
 	for _, chatterInfo := range chatters {
@@ -327,6 +375,8 @@ func (s *SpineBridge) setInternalSpineOperator(
 	data_json, _ := json.Marshal(data)
 	log.Println("setInternalSpineOperator sending: ", string(data_json))
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	for _, websocketConn := range s.WebSocketConnections {
 		if websocketConn.conn == nil {
 			continue
@@ -376,7 +426,9 @@ func (s *SpineBridge) RemoveOperator(r *RemoveOperatorRequest) (*RemoveOperatorR
 		"user_name": r.UserName,
 	}
 
-	if s.clientConnected() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.WebSocketConnections) > 0 {
 		data_json, _ := json.Marshal(data)
 		log.Println("RemoveOperator() sending: ", string(data_json))
 		for _, websocketConn := range s.WebSocketConnections {
@@ -390,7 +442,9 @@ func (s *SpineBridge) RemoveOperator(r *RemoveOperatorRequest) (*RemoveOperatorR
 }
 
 func (s *SpineBridge) ShowChatMessage(r *ShowChatMessageRequest) (*ShowChatMessageResponse, error) {
-	if s.clientConnected() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.WebSocketConnections) > 0 {
 		data := ShowChatMessageInternalRequest{
 			BridgeRequest: BridgeRequest{
 				TypeName: SHOW_CHAT_MESSAGE,
@@ -421,7 +475,9 @@ func (s *SpineBridge) ShowChatMessage(r *ShowChatMessageRequest) (*ShowChatMessa
 }
 
 func (s *SpineBridge) FindOperator(r *FindOperatorRequest) (*FindOperatorResponse, error) {
-	if s.clientConnected() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.WebSocketConnections) > 0 {
 		data := FindOperatorInternalRequest{
 			BridgeRequest: BridgeRequest{
 				TypeName: FIND_OPERATOR,
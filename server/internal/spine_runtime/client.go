@@ -25,7 +25,7 @@ type BridgeRequest struct {
 type BridgeResponse struct {
 	TypeName   string `json:"type_name"`
 	ErrorMsg   string `json:"error_msg"`
-	StatusCode int    `json:"staus_code"`
+	StatusCode int    `json:"status_code"`
 }
 
 // SetOperator
@@ -116,6 +116,8 @@ type SpineRuntime interface {
 	Close() error
 	AddConnection(w http.ResponseWriter, r *http.Request, chatters []*ChatterInfo) error
 	NumConnections() int
+	// The average FPS reported by each connected client, by connection id
+	ConnectionAverageFps() map[string]float64
 
 	// Add listeners for any incoming requests from the connected clients
 	// Returns a function which can be used to remove the listener
@@ -20,6 +20,7 @@ type TwitchApiClientInterface interface {
 	RevokeToken(accessToken string) error
 	ValidateToken(token string) (*ValidateTokenResponse, error)
 	RefreshToken(clientSecret string, refreshToken string) (*RefreshTokenResponse, error)
+	GetModerators(userAccessToken string, broadcasterId string) ([]GetModeratorsResponseData, error)
 }
 
 type TwitchApiClient struct {
@@ -226,3 +227,51 @@ func (c *TwitchApiClient) RefreshToken(clientSecret string, refreshToken string)
 	}
 	return &respBody, nil
 }
+
+// GetModerators returns all the moderators of the broadcaster's channel.
+// Requires a user access token of the broadcaster with the moderation:read scope
+func (c *TwitchApiClient) GetModerators(userAccessToken string, broadcasterId string) ([]GetModeratorsResponseData, error) {
+	moderators := make([]GetModeratorsResponseData, 0)
+	cursor := ""
+	for {
+		req, err := http.NewRequest(
+			http.MethodGet,
+			"https://api.twitch.tv/helix/moderation/moderators",
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", userAccessToken))
+		req.Header.Add("Client-Id", c.ClientId)
+		q := req.URL.Query()
+		q.Add("broadcaster_id", broadcasterId)
+		q.Add("first", "100")
+		if cursor != "" {
+			q.Add("after", cursor)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("request failed with status %v", resp.StatusCode)
+		}
+
+		var respBody GetModeratorsResponse
+		err = json.NewDecoder(resp.Body).Decode(&respBody)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		moderators = append(moderators, respBody.Data...)
+		if respBody.Pagination.Cursor == "" {
+			break
+		}
+		cursor = respBody.Pagination.Cursor
+	}
+	return moderators, nil
+}
@@ -60,6 +60,10 @@ func (f *FakeTwitchApiClient) GetOpenIdConfiguration() (*GetOpenIdConfigurationR
 	return nil, nil
 }
 
+func (f *FakeTwitchApiClient) GetModerators(userAccessToken string, broadcasterId string) ([]GetModeratorsResponseData, error) {
+	return []GetModeratorsResponseData{}, nil
+}
+
 func NewFakeTwitchApiClient() TwitchApiClientInterface {
 	return &FakeTwitchApiClient{}
 }
@@ -54,3 +54,18 @@ type GetOpenIdConfigurationResponse struct {
 	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
 	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
 }
+
+type PaginationResponse struct {
+	Cursor string `json:"cursor"`
+}
+
+type GetModeratorsResponseData struct {
+	UserId    string `json:"user_id"`
+	UserLogin string `json:"user_login"`
+	UserName  string `json:"user_name"`
+}
+
+type GetModeratorsResponse struct {
+	Data       []GetModeratorsResponseData `json:"data"`
+	Pagination PaginationResponse          `json:"pagination"`
+}
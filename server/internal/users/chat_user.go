@@ -56,6 +56,10 @@ func (c *ChatUser) GetUsername() string {
 	return c.user.Username
 }
 
+func (c *ChatUser) GetTwitchUserId() string {
+	return c.user.TwitchUserId
+}
+
 func (c *ChatUser) GetUsernameDisplay() string {
 	return c.user.UserDisplayName
 }
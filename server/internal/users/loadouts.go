@@ -0,0 +1,32 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits the number of named loadouts saved per user
+const USER_LOADOUTS_MAX = 10
+
+// Reserved for the room's preferences (ie. !chibi save here)
+const LOADOUT_NAME_HERE = "here"
+
+var ErrTooManyLoadouts = fmt.Errorf("at most %d loadouts can be saved", USER_LOADOUTS_MAX)
+var ErrLoadoutNotFound = errors.New("loadout not found")
+
+var loadoutNameRegex = regexp.MustCompile(`^[a-z0-9_-]{1,20}$`)
+
+// NormalizeLoadoutName lowercases the name and checks that it is a single
+// word so that it can be used in chat (ie. !chibi load casual).
+func NormalizeLoadoutName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !loadoutNameRegex.MatchString(name) {
+		return "", fmt.Errorf("loadout names must be 1-20 letters, numbers, - or _ (%s)", name)
+	}
+	if name == LOADOUT_NAME_HERE {
+		return "", fmt.Errorf("%s cannot be used as a loadout name", name)
+	}
+	return name, nil
+}
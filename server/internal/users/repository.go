@@ -17,6 +17,7 @@ const USER_ROLE_USER = "user"
 type UserRepository interface {
 	GetById(ctx context.Context, userId uint) (*UserDb, error)
 	GetByTwitchId(ctx context.Context, twitchUserId string) (*UserDb, error)
+	GetByUsername(ctx context.Context, username string) (*UserDb, error)
 	GetOrInsertUser(ctx context.Context, info misc.UserInfo) (*UserDb, error)
 }
 
@@ -42,6 +43,26 @@ type UserPreferencesRepository interface {
 	GetByTwitchIdOrNil(ctx context.Context, twitchUserId string) (*UserPreferencesDb, error)
 	SetByUserId(ctx context.Context, userId uint, opInfo *operator.OperatorInfo) error
 	DeleteByUserId(ctx context.Context, userId uint) error
+
+	// Preferences for a single room which override the global preferences
+	GetRoomPrefsByUserIdOrNil(ctx context.Context, userId uint, roomId uint) (*UserRoomPreferencesDb, error)
+	SetRoomPrefsByUserId(ctx context.Context, userId uint, roomId uint, opInfo *operator.OperatorInfo) error
+	DeleteRoomPrefsByUserId(ctx context.Context, userId uint, roomId uint) error
+
+	// Named loadouts. Saving an existing name overwrites it, otherwise
+	// ErrTooManyLoadouts is returned once the user has USER_LOADOUTS_MAX.
+	GetLoadoutsByUserId(ctx context.Context, userId uint) ([]*UserLoadoutDb, error)
+	GetLoadoutByNameOrNil(ctx context.Context, userId uint, name string) (*UserLoadoutDb, error)
+	SaveLoadout(ctx context.Context, userId uint, name string, opInfo *operator.OperatorInfo) (*UserLoadoutDb, error)
+	RenameLoadout(ctx context.Context, userId uint, loadoutId uint, name string) error
+	DeleteLoadout(ctx context.Context, userId uint, loadoutId uint) error
+	// The loadout given to the user when they first chat in the room
+	SetRoomLoadout(ctx context.Context, userId uint, roomId uint, loadoutId uint) error
+	ClearRoomLoadout(ctx context.Context, userId uint, roomId uint) error
+
+	// The chibi saved for the room, then the room loadout, then the global
+	// preferences. nil if none are saved.
+	GetSavedChibiByTwitchIdOrNil(ctx context.Context, twitchUserId string, roomId uint) (*operator.OperatorInfo, error)
 }
 
 type UserDb struct {
@@ -104,3 +125,41 @@ type UserPreferencesDb struct {
 func (UserPreferencesDb) TableName() string {
 	return "user_preferences"
 }
+
+type UserRoomPreferencesDb struct {
+	UserRoomPreferenceId uint                  `gorm:"primarykey"`
+	UserId               uint                  `gorm:"column:user_id"`
+	RoomId               uint                  `gorm:"column:room_id"`
+	OperatorInfo         operator.OperatorInfo `gorm:"operator_info;type:json"`
+	CreatedAt            time.Time             `gorm:"column:created_at"`
+	UpdatedAt            time.Time             `gorm:"column:updated_at"`
+}
+
+func (UserRoomPreferencesDb) TableName() string {
+	return "user_room_preferences"
+}
+
+type UserLoadoutDb struct {
+	UserLoadoutId uint                  `gorm:"primarykey"`
+	UserId        uint                  `gorm:"column:user_id"`
+	Name          string                `gorm:"column:name"`
+	OperatorInfo  operator.OperatorInfo `gorm:"operator_info;type:json"`
+	CreatedAt     time.Time             `gorm:"column:created_at"`
+	UpdatedAt     time.Time             `gorm:"column:updated_at"`
+}
+
+func (UserLoadoutDb) TableName() string {
+	return "user_loadouts"
+}
+
+type UserRoomLoadoutDb struct {
+	UserId        uint      `gorm:"primaryKey;column:user_id"`
+	RoomId        uint      `gorm:"primaryKey;column:room_id"`
+	UserLoadoutId uint      `gorm:"column:user_loadout_id"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at"`
+}
+
+func (UserRoomLoadoutDb) TableName() string {
+	return "user_room_loadouts"
+}
@@ -43,6 +43,17 @@ func (r *UserRepositoryPsql) GetByTwitchId(ctx context.Context, twitchUserId str
 	return &userDb, nil
 }
 
+func (r *UserRepositoryPsql) GetByUsername(ctx context.Context, username string) (*UserDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+
+	var userDb UserDb
+	result := db.First(&userDb, "username = ?", username)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &userDb, nil
+}
+
 func (r *UserRepositoryPsql) GetOrInsertUser(ctx context.Context, userinfo misc.UserInfo) (*UserDb, error) {
 	db := r.DefaultDB.WithContext(ctx)
 
@@ -272,3 +283,228 @@ func (r *UserPreferencesRepositoryPsql) DeleteByUserId(ctx context.Context, user
 		Delete(&UserPreferencesDb{})
 	return result.Error
 }
+
+func (r *UserPreferencesRepositoryPsql) GetRoomPrefsByUserIdOrNil(
+	ctx context.Context,
+	userId uint,
+	roomId uint,
+) (*UserRoomPreferencesDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+
+	var prefDb UserRoomPreferencesDb
+	result := db.
+		Where("user_id = ? AND room_id = ?", userId, roomId).
+		First(&prefDb)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &prefDb, nil
+}
+
+func (r *UserPreferencesRepositoryPsql) SetRoomPrefsByUserId(
+	ctx context.Context,
+	userId uint,
+	roomId uint,
+	opInfo *operator.OperatorInfo,
+) error {
+	db := r.DefaultDB.WithContext(ctx)
+
+	var prefDb UserRoomPreferencesDb
+	result := db.
+		Where("user_id = ? AND room_id = ?", userId, roomId).
+		Assign(
+			UserRoomPreferencesDb{
+				UserId:       userId,
+				RoomId:       roomId,
+				OperatorInfo: *opInfo,
+			},
+		).
+		FirstOrCreate(&prefDb)
+	return result.Error
+}
+
+func (r *UserPreferencesRepositoryPsql) DeleteRoomPrefsByUserId(ctx context.Context, userId uint, roomId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+
+	result := db.
+		Where("user_id = ? AND room_id = ?", userId, roomId).
+		Delete(&UserRoomPreferencesDb{})
+	return result.Error
+}
+
+func (r *UserPreferencesRepositoryPsql) GetLoadoutsByUserId(ctx context.Context, userId uint) ([]*UserLoadoutDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+
+	var loadouts []*UserLoadoutDb
+	result := db.
+		Where("user_id = ?", userId).
+		Order("name ASC").
+		Find(&loadouts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return loadouts, nil
+}
+
+func (r *UserPreferencesRepositoryPsql) GetLoadoutByNameOrNil(ctx context.Context, userId uint, name string) (*UserLoadoutDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+
+	var loadout UserLoadoutDb
+	result := db.
+		Where("user_id = ? AND name = ?", userId, name).
+		First(&loadout)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &loadout, nil
+}
+
+func (r *UserPreferencesRepositoryPsql) SaveLoadout(
+	ctx context.Context,
+	userId uint,
+	name string,
+	opInfo *operator.OperatorInfo,
+) (*UserLoadoutDb, error) {
+	var loadout UserLoadoutDb
+	err := r.DefaultDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the user so concurrent saves can't both pass the count below
+		if err := tx.Exec("SELECT 1 FROM users WHERE user_id = ? FOR UPDATE", userId).Error; err != nil {
+			return err
+		}
+		result := tx.
+			Where("user_id = ? AND name = ?", userId, name).
+			First(&loadout)
+		if result.Error == nil {
+			loadout.OperatorInfo = *opInfo
+			return tx.Save(&loadout).Error
+		}
+		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return result.Error
+		}
+
+		var count int64
+		if err := tx.Model(&UserLoadoutDb{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= USER_LOADOUTS_MAX {
+			return ErrTooManyLoadouts
+		}
+		loadout = UserLoadoutDb{
+			UserId:       userId,
+			Name:         name,
+			OperatorInfo: *opInfo,
+		}
+		return tx.Create(&loadout).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &loadout, nil
+}
+
+func (r *UserPreferencesRepositoryPsql) RenameLoadout(ctx context.Context, userId uint, loadoutId uint, name string) error {
+	db := r.DefaultDB.WithContext(ctx)
+
+	result := db.
+		Model(&UserLoadoutDb{}).
+		Where("user_loadout_id = ? AND user_id = ?", loadoutId, userId).
+		Update("name", name)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLoadoutNotFound
+	}
+	return nil
+}
+
+func (r *UserPreferencesRepositoryPsql) DeleteLoadout(ctx context.Context, userId uint, loadoutId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+
+	result := db.
+		Where("user_loadout_id = ? AND user_id = ?", loadoutId, userId).
+		Delete(&UserLoadoutDb{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLoadoutNotFound
+	}
+	return nil
+}
+
+func (r *UserPreferencesRepositoryPsql) SetRoomLoadout(ctx context.Context, userId uint, roomId uint, loadoutId uint) error {
+	return r.DefaultDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&UserLoadoutDb{}).
+			Where("user_loadout_id = ? AND user_id = ?", loadoutId, userId).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrLoadoutNotFound
+		}
+
+		var roomLoadout UserRoomLoadoutDb
+		return tx.
+			Where("user_id = ? AND room_id = ?", userId, roomId).
+			Assign(UserRoomLoadoutDb{
+				UserId:        userId,
+				RoomId:        roomId,
+				UserLoadoutId: loadoutId,
+			}).
+			FirstOrCreate(&roomLoadout).Error
+	})
+}
+
+func (r *UserPreferencesRepositoryPsql) ClearRoomLoadout(ctx context.Context, userId uint, roomId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+
+	result := db.
+		Where("user_id = ? AND room_id = ?", userId, roomId).
+		Delete(&UserRoomLoadoutDb{})
+	return result.Error
+}
+
+func (r *UserPreferencesRepositoryPsql) GetSavedChibiByTwitchIdOrNil(
+	ctx context.Context,
+	twitchUserId string,
+	roomId uint,
+) (*operator.OperatorInfo, error) {
+	db := r.DefaultDB.WithContext(ctx)
+
+	var saved struct {
+		OperatorInfo operator.OperatorInfo
+	}
+	result := db.Raw(
+		`SELECT operator_info FROM (
+			SELECT p.operator_info, 0 AS priority FROM user_room_preferences p
+			JOIN users u ON u.user_id = p.user_id
+			WHERE u.twitch_user_id = ? AND p.room_id = ?
+			UNION ALL
+			SELECT l.operator_info, 1 AS priority FROM user_room_loadouts rl
+			JOIN user_loadouts l ON l.user_loadout_id = rl.user_loadout_id
+			JOIN users u ON u.user_id = rl.user_id
+			WHERE u.twitch_user_id = ? AND rl.room_id = ?
+			UNION ALL
+			SELECT p.operator_info, 2 AS priority FROM user_preferences p
+			JOIN users u ON u.user_id = p.user_id
+			WHERE u.twitch_user_id = ?
+		) saved ORDER BY priority LIMIT 1`,
+		twitchUserId, roomId, twitchUserId, roomId, twitchUserId,
+	).Scan(&saved)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &saved.OperatorInfo, nil
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+)
+
+type EventType string
+
+const (
+	EVENT_ROOM_CREATED      = EventType("room_created")
+	EVENT_ROOM_CLOSED       = EventType("room_closed")
+	EVENT_CHIBI_SET         = EventType("chibi_set")
+	EVENT_CHIBI_REMOVED     = EventType("chibi_removed")
+	EVENT_PREFERENCES_SAVED = EventType("preferences_saved")
+	// Only sent through the test endpoint
+	EVENT_TEST = EventType("test")
+)
+
+var AllEventTypes = []EventType{
+	EVENT_ROOM_CREATED,
+	EVENT_ROOM_CLOSED,
+	EVENT_CHIBI_SET,
+	EVENT_CHIBI_REMOVED,
+	EVENT_PREFERENCES_SAVED,
+}
+
+func EventType_Parse(str string) (EventType, error) {
+	for _, event := range AllEventTypes {
+		if string(event) == str {
+			return event, nil
+		}
+	}
+	return "", fmt.Errorf("invalid webhook event (%s)", str)
+}
+
+// Payload is the JSON body POSTed to the webhook URL
+type Payload struct {
+	DeliveryId  string      `json:"delivery_id"`
+	Event       EventType   `json:"event"`
+	RoomId      uint        `json:"room_id"`
+	ChannelName string      `json:"channel_name"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Data        interface{} `json:"data"`
+}
+
+type RoomEventData struct {
+	ChannelName string `json:"channel_name"`
+}
+
+type ChibiEventData struct {
+	Username     string `json:"username"`
+	OperatorId   string `json:"operator_id,omitempty"`
+	OperatorName string `json:"operator_name,omitempty"`
+	Faction      string `json:"faction,omitempty"`
+	Skin         string `json:"skin,omitempty"`
+}
@@ -0,0 +1,30 @@
+package webhook
+
+import "sync"
+
+type FakeNotification struct {
+	RoomId uint
+	Event  EventType
+	Data   interface{}
+}
+
+type FakeNotifier struct {
+	mutex         sync.Mutex
+	Notifications []FakeNotification
+}
+
+func NewFakeNotifier() *FakeNotifier {
+	return &FakeNotifier{
+		Notifications: make([]FakeNotification, 0),
+	}
+}
+
+func (f *FakeNotifier) Notify(roomId uint, event EventType, data interface{}) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Notifications = append(f.Notifications, FakeNotification{
+		RoomId: roomId,
+		Event:  event,
+		Data:   data,
+	})
+}
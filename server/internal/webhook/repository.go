@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	GetById(ctx context.Context, webhookId uint) (*WebhookDb, error)
+	GetByRoomId(ctx context.Context, roomId uint) ([]*WebhookDb, error)
+	Create(ctx context.Context, webhook *WebhookDb) error
+	DeleteById(ctx context.Context, roomId uint, webhookId uint) error
+
+	// CreateDelivery saves the delivery and removes the webhook's older
+	// deliveries beyond the newest keep
+	CreateDelivery(ctx context.Context, delivery *WebhookDeliveryDb, keep int) error
+	GetDeliveriesByWebhookId(ctx context.Context, webhookId uint, limit int) ([]*WebhookDeliveryDb, error)
+}
+
+type WebhookDb struct {
+	WebhookId   uint           `gorm:"primarykey"`
+	RoomId      uint           `gorm:"column:room_id"`
+	ChannelName string         `gorm:"column:channel_name"`
+	Url         string         `gorm:"column:url"`
+	Secret      string         `gorm:"column:secret"`
+	Events      pq.StringArray `gorm:"column:events;type:text[]"`
+	CreatedAt   time.Time      `gorm:"column:created_at"`
+	UpdatedAt   time.Time      `gorm:"column:updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (WebhookDb) TableName() string {
+	return "webhooks"
+}
+
+func (w *WebhookDb) IsSubscribed(event EventType) bool {
+	if event == EVENT_TEST {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == string(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryDb is a single delivery attempt of an event to a webhook
+type WebhookDeliveryDb struct {
+	WebhookDeliveryId uint      `gorm:"primarykey"`
+	WebhookId         uint      `gorm:"column:webhook_id"`
+	DeliveryUuid      string    `gorm:"column:delivery_uuid"`
+	Event             EventType `gorm:"column:event"`
+	Payload           []byte    `gorm:"column:payload;type:jsonb"`
+	Attempt           int       `gorm:"column:attempt"`
+	StatusCode        int       `gorm:"column:status_code"`
+	ErrorMsg          string    `gorm:"column:error_msg"`
+	Success           bool      `gorm:"column:success"`
+	DurationMs        int64     `gorm:"column:duration_ms"`
+	CreatedAt         time.Time `gorm:"column:created_at"`
+}
+
+func (WebhookDeliveryDb) TableName() string {
+	return "webhook_deliveries"
+}
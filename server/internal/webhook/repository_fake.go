@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type FakeWebhookRepository struct {
+	mutex          sync.Mutex
+	Webhooks       map[uint]*WebhookDb
+	Deliveries     []*WebhookDeliveryDb
+	nextDeliveryId uint
+}
+
+func NewFakeWebhookRepository() *FakeWebhookRepository {
+	return &FakeWebhookRepository{
+		Webhooks:   make(map[uint]*WebhookDb),
+		Deliveries: make([]*WebhookDeliveryDb, 0),
+	}
+}
+
+func (r *FakeWebhookRepository) GetById(ctx context.Context, webhookId uint) (*WebhookDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	webhook, ok := r.Webhooks[webhookId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return webhook, nil
+}
+
+func (r *FakeWebhookRepository) GetByRoomId(ctx context.Context, roomId uint) ([]*WebhookDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	webhooks := make([]*WebhookDb, 0)
+	for _, webhook := range r.Webhooks {
+		if webhook.RoomId == roomId {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *FakeWebhookRepository) Create(ctx context.Context, webhook *WebhookDb) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	webhook.WebhookId = uint(len(r.Webhooks) + 1)
+	r.Webhooks[webhook.WebhookId] = webhook
+	return nil
+}
+
+func (r *FakeWebhookRepository) DeleteById(ctx context.Context, roomId uint, webhookId uint) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	webhook, ok := r.Webhooks[webhookId]
+	if !ok || webhook.RoomId != roomId {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.Webhooks, webhookId)
+	return nil
+}
+
+func (r *FakeWebhookRepository) CreateDelivery(ctx context.Context, delivery *WebhookDeliveryDb, keep int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.nextDeliveryId++
+	delivery.WebhookDeliveryId = r.nextDeliveryId
+	r.Deliveries = append(r.Deliveries, delivery)
+
+	kept := make([]*WebhookDeliveryDb, 0, len(r.Deliveries))
+	count := 0
+	for i := len(r.Deliveries) - 1; i >= 0; i-- {
+		if r.Deliveries[i].WebhookId == delivery.WebhookId {
+			count++
+			if count > keep {
+				continue
+			}
+		}
+		kept = append(kept, r.Deliveries[i])
+	}
+	slices.Reverse(kept)
+	r.Deliveries = kept
+	return nil
+}
+
+func (r *FakeWebhookRepository) GetDeliveriesByWebhookId(ctx context.Context, webhookId uint, limit int) ([]*WebhookDeliveryDb, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	deliveries := make([]*WebhookDeliveryDb, 0)
+	for i := len(r.Deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if r.Deliveries[i].WebhookId == webhookId {
+			deliveries = append(deliveries, r.Deliveries[i])
+		}
+	}
+	return deliveries, nil
+}
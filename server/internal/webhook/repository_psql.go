@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"gorm.io/gorm"
+)
+
+type WebhookRepositoryPsql struct {
+	*akdb.DatbaseConn
+}
+
+func NewWebhookRepositoryPsql(akDb *akdb.DatbaseConn) *WebhookRepositoryPsql {
+	return &WebhookRepositoryPsql{
+		DatbaseConn: akDb,
+	}
+}
+
+func (r *WebhookRepositoryPsql) GetById(ctx context.Context, webhookId uint) (*WebhookDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	var webhookDb WebhookDb
+	result := db.First(&webhookDb, webhookId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &webhookDb, nil
+}
+
+func (r *WebhookRepositoryPsql) GetByRoomId(ctx context.Context, roomId uint) ([]*WebhookDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	webhooks := make([]*WebhookDb, 0)
+	result := db.Where("room_id = ?", roomId).Order("webhook_id ASC").Find(&webhooks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepositoryPsql) Create(ctx context.Context, webhook *WebhookDb) error {
+	db := r.DefaultDB.WithContext(ctx)
+	return db.Create(webhook).Error
+}
+
+func (r *WebhookRepositoryPsql) DeleteById(ctx context.Context, roomId uint, webhookId uint) error {
+	db := r.DefaultDB.WithContext(ctx)
+	result := db.Where("room_id = ? AND webhook_id = ?", roomId, webhookId).Delete(&WebhookDb{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryPsql) CreateDelivery(ctx context.Context, delivery *WebhookDeliveryDb, keep int) error {
+	db := r.DefaultDB.WithContext(ctx)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+		return tx.Exec(
+			`DELETE FROM webhook_deliveries
+			WHERE webhook_id = ?
+			AND webhook_delivery_id NOT IN (
+				SELECT webhook_delivery_id FROM webhook_deliveries
+				WHERE webhook_id = ?
+				ORDER BY webhook_delivery_id DESC
+				LIMIT ?
+			)`,
+			delivery.WebhookId, delivery.WebhookId, keep,
+		).Error
+	})
+}
+
+func (r *WebhookRepositoryPsql) GetDeliveriesByWebhookId(ctx context.Context, webhookId uint, limit int) ([]*WebhookDeliveryDb, error) {
+	db := r.DefaultDB.WithContext(ctx)
+	deliveries := make([]*WebhookDeliveryDb, 0)
+	result := db.
+		Where("webhook_id = ?", webhookId).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,379 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
+	"github.com/google/uuid"
+)
+
+const (
+	WEBHOOK_MAX_PER_ROOM              = 5
+	WEBHOOK_MAX_ATTEMPTS              = 4
+	WEBHOOK_INITIAL_BACKOFF           = 2 * time.Second
+	WEBHOOK_REQUEST_TIMEOUT           = 5 * time.Second
+	WEBHOOK_MAX_CONCURRENT_DELIVERIES = 16
+	WEBHOOK_DELIVERY_LOG_LIMIT        = 50
+	WEBHOOK_MAX_REDIRECTS             = 3
+
+	HEADER_SIGNATURE = "X-Ak-Chibi-Bot-Signature"
+	HEADER_EVENT     = "X-Ak-Chibi-Bot-Event"
+	HEADER_DELIVERY  = "X-Ak-Chibi-Bot-Delivery"
+)
+
+// Notifier is implemented by anything which wants to hear about room and
+// chibi events. Notify must never block the caller.
+type Notifier interface {
+	Notify(roomId uint, event EventType, data interface{})
+}
+
+type WebhookService struct {
+	repo       WebhookRepository
+	httpClient *http.Client
+
+	MaxAttempts    int
+	InitialBackoff time.Duration
+
+	deliverySem    chan struct{}
+	wg             sync.WaitGroup
+	mutex          sync.Mutex
+	isShutdown     bool
+	shutdownChan   chan struct{}
+	shutdownDoneCh chan struct{}
+}
+
+func NewWebhookService(repo WebhookRepository) *WebhookService {
+	return &WebhookService{
+		repo:           repo,
+		httpClient:     newWebhookHttpClient(),
+		MaxAttempts:    WEBHOOK_MAX_ATTEMPTS,
+		InitialBackoff: WEBHOOK_INITIAL_BACKOFF,
+		deliverySem:    make(chan struct{}, WEBHOOK_MAX_CONCURRENT_DELIVERIES),
+		shutdownChan:   make(chan struct{}),
+		shutdownDoneCh: make(chan struct{}),
+	}
+}
+
+func (s *WebhookService) Shutdown() {
+	log.Println("WebhookService calling Shutdown")
+	s.mutex.Lock()
+	s.isShutdown = true
+	close(s.shutdownChan)
+	s.mutex.Unlock()
+
+	go func() {
+		// Pending retries are abandoned, in-flight requests are allowed to finish
+		s.wg.Wait()
+		close(s.shutdownDoneCh)
+	}()
+}
+
+func (s *WebhookService) GetShutdownChan() chan struct{} {
+	return s.shutdownDoneCh
+}
+
+// Sign returns the signature sent in the HEADER_SIGNATURE header. Receivers
+// should compute the same HMAC over the raw request body with their secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateSecret() (string, error) {
+	var secretBytes [32]byte
+	if _, err := rand.Read(secretBytes[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secretBytes[:]), nil
+}
+
+var ErrBlockedAddress = errors.New("webhook address is not allowed")
+
+// isPublicIP reports whether a webhook is allowed to connect to the ip.
+// Webhook urls are user provided so we must never reach the bot's own
+// network through them.
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() ||
+		ip.IsMulticast())
+}
+
+// checkDialAddress runs after DNS resolution so it also catches hostnames
+// which resolve to internal addresses.
+func checkDialAddress(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= WEBHOOK_MAX_REDIRECTS {
+		return fmt.Errorf("stopped after %d redirects", WEBHOOK_MAX_REDIRECTS)
+	}
+	if err := ValidateWebhookUrl(req.URL.String()); err != nil {
+		return err
+	}
+	ips, err := net.DefaultResolver.LookupIP(req.Context(), "ip", req.URL.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+func newWebhookHttpClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: WEBHOOK_REQUEST_TIMEOUT,
+		Control: checkDialAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the dial check see the proxy's address instead
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:       WEBHOOK_REQUEST_TIMEOUT,
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+func ValidateWebhookUrl(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("webhook url must be http or https")
+	}
+	if len(u.Host) == 0 {
+		return fmt.Errorf("webhook url must have a host")
+	}
+	return nil
+}
+
+func (s *WebhookService) CreateWebhook(
+	ctx context.Context,
+	roomId uint,
+	channelName string,
+	webhookUrl string,
+	events []EventType,
+) (*WebhookDb, error) {
+	if err := ValidateWebhookUrl(webhookUrl); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("at least one event must be provided")
+	}
+	existing, err := s.repo.GetByRoomId(ctx, roomId)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= WEBHOOK_MAX_PER_ROOM {
+		return nil, fmt.Errorf("too many webhooks, maximum is %d", WEBHOOK_MAX_PER_ROOM)
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &WebhookDb{
+		RoomId:      roomId,
+		ChannelName: channelName,
+		Url:         webhookUrl,
+		Secret:      secret,
+	}
+	for _, event := range events {
+		webhook.Events = append(webhook.Events, string(event))
+	}
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, webhookId uint) (*WebhookDb, error) {
+	return s.repo.GetById(ctx, webhookId)
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, roomId uint) ([]*WebhookDb, error) {
+	return s.repo.GetByRoomId(ctx, roomId)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, roomId uint, webhookId uint) error {
+	return s.repo.DeleteById(ctx, roomId, webhookId)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhook *WebhookDb, limit int) ([]*WebhookDeliveryDb, error) {
+	return s.repo.GetDeliveriesByWebhookId(ctx, webhook.WebhookId, limit)
+}
+
+// Notify delivers the event to all of the room's webhooks subscribed to it.
+// Delivery happens in the background with retries.
+func (s *WebhookService) Notify(roomId uint, event EventType, data interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.isShutdown {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		webhooks, err := s.repo.GetByRoomId(context.Background(), roomId)
+		if err != nil {
+			log.Println("Failed to get webhooks for room", roomId, err)
+			return
+		}
+		for _, webhook := range webhooks {
+			if !webhook.IsSubscribed(event) {
+				continue
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.deliverWithRetries(webhook, event, data)
+			}()
+		}
+	}()
+}
+
+// SendTestEvent makes a single synchronous delivery attempt of a test event.
+func (s *WebhookService) SendTestEvent(ctx context.Context, webhook *WebhookDb) (*WebhookDeliveryDb, error) {
+	body, err := s.newPayload(webhook, EVENT_TEST, RoomEventData{ChannelName: webhook.ChannelName})
+	if err != nil {
+		return nil, err
+	}
+	return s.attemptDelivery(ctx, webhook, EVENT_TEST, body, 1), nil
+}
+
+func (s *WebhookService) newPayload(webhook *WebhookDb, event EventType, data interface{}) ([]byte, error) {
+	return json.Marshal(&Payload{
+		DeliveryId:  uuid.New().String(),
+		Event:       event,
+		RoomId:      webhook.RoomId,
+		ChannelName: webhook.ChannelName,
+		Timestamp:   misc.Clock.Now().UTC(),
+		Data:        data,
+	})
+}
+
+func (s *WebhookService) deliverWithRetries(webhook *WebhookDb, event EventType, data interface{}) {
+	body, err := s.newPayload(webhook, event, data)
+	if err != nil {
+		log.Println("Failed to create webhook payload", webhook.WebhookId, err)
+		return
+	}
+
+	backoff := s.InitialBackoff
+	for attempt := 1; attempt <= s.MaxAttempts; attempt++ {
+		delivery := s.attemptDelivery(context.Background(), webhook, event, body, attempt)
+		if delivery.Success {
+			return
+		}
+		if attempt == s.MaxAttempts {
+			break
+		}
+		select {
+		case <-s.shutdownChan:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	log.Printf("Webhook %d gave up delivering %s after %d attempts\n", webhook.WebhookId, event, s.MaxAttempts)
+}
+
+func (s *WebhookService) attemptDelivery(
+	ctx context.Context,
+	webhook *WebhookDb,
+	event EventType,
+	body []byte,
+	attempt int,
+) *WebhookDeliveryDb {
+	s.deliverySem <- struct{}{}
+	defer func() { <-s.deliverySem }()
+
+	var payload Payload
+	json.Unmarshal(body, &payload)
+	delivery := &WebhookDeliveryDb{
+		WebhookId:    webhook.WebhookId,
+		DeliveryUuid: payload.DeliveryId,
+		Event:        event,
+		Payload:      body,
+		Attempt:      attempt,
+	}
+
+	start := time.Now()
+	statusCode, err := s.post(ctx, webhook, event, payload.DeliveryId, body)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.StatusCode = statusCode
+	if err != nil {
+		delivery.ErrorMsg = err.Error()
+	} else {
+		delivery.Success = true
+	}
+
+	if err := s.repo.CreateDelivery(context.Background(), delivery, WEBHOOK_DELIVERY_LOG_LIMIT); err != nil {
+		log.Println("Failed to save webhook delivery", webhook.WebhookId, err)
+	}
+	return delivery
+}
+
+func (s *WebhookService) post(
+	ctx context.Context,
+	webhook *WebhookDb,
+	event EventType,
+	deliveryId string,
+	body []byte,
+) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ak-chibi-bot-webhook")
+	req.Header.Set(HEADER_EVENT, string(event))
+	req.Header.Set(HEADER_DELIVERY, deliveryId)
+	req.Header.Set(HEADER_SIGNATURE, Sign(webhook.Secret, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("request failed with status %v", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testReceiver struct {
+	mutex     sync.Mutex
+	secret    string
+	failFirst int
+	requests  []*Payload
+	badSigs   int
+	received  chan struct{}
+}
+
+func (t *testReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get(HEADER_SIGNATURE) != Sign(t.secret, body) {
+		t.badSigs += 1
+	}
+	var payload Payload
+	json.Unmarshal(body, &payload)
+	t.requests = append(t.requests, &payload)
+	if len(t.requests) <= t.failFirst {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	t.received <- struct{}{}
+}
+
+func setupWebhook(t *testing.T, receiver *testReceiver) (*WebhookService, *FakeWebhookRepository, *WebhookDb) {
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	repo := NewFakeWebhookRepository()
+	sut := NewWebhookService(repo)
+	sut.InitialBackoff = time.Millisecond
+	// The default client refuses to connect to the loopback test server
+	sut.httpClient = server.Client()
+	webhook, err := sut.CreateWebhook(
+		context.Background(), 1, "test", server.URL,
+		[]EventType{EVENT_CHIBI_SET})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver.secret = webhook.Secret
+	return sut, repo, webhook
+}
+
+func TestWebhookService_NotifyRetriesUntilSuccess(t *testing.T) {
+	assert := assert.New(t)
+	receiver := &testReceiver{failFirst: 2, received: make(chan struct{}, 1)}
+	sut, repo, _ := setupWebhook(t, receiver)
+
+	sut.Notify(1, EVENT_CHIBI_SET, ChibiEventData{Username: "user1", OperatorId: "char_002_amiya"})
+	select {
+	case <-receiver.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was never delivered")
+	}
+	sut.Shutdown()
+	<-sut.GetShutdownChan()
+
+	assert.Equal(3, len(receiver.requests))
+	assert.Equal(0, receiver.badSigs)
+	assert.Equal(EVENT_CHIBI_SET, receiver.requests[2].Event)
+	// Retries reuse the same delivery id
+	assert.Equal(receiver.requests[0].DeliveryId, receiver.requests[2].DeliveryId)
+
+	assert.Equal(3, len(repo.Deliveries))
+	assert.False(repo.Deliveries[0].Success)
+	assert.Equal(500, repo.Deliveries[0].StatusCode)
+	assert.True(repo.Deliveries[2].Success)
+	assert.Equal(3, repo.Deliveries[2].Attempt)
+}
+
+func TestWebhookService_NotifySkipsUnsubscribedEvents(t *testing.T) {
+	assert := assert.New(t)
+	receiver := &testReceiver{received: make(chan struct{}, 1)}
+	sut, repo, _ := setupWebhook(t, receiver)
+
+	sut.Notify(1, EVENT_ROOM_CLOSED, RoomEventData{ChannelName: "test"})
+	sut.Notify(2, EVENT_CHIBI_SET, ChibiEventData{Username: "user1"})
+	sut.Shutdown()
+	<-sut.GetShutdownChan()
+
+	assert.Equal(0, len(receiver.requests))
+	assert.Equal(0, len(repo.Deliveries))
+}
+
+func TestWebhookService_SendTestEvent(t *testing.T) {
+	assert := assert.New(t)
+	receiver := &testReceiver{received: make(chan struct{}, 1)}
+	sut, repo, webhook := setupWebhook(t, receiver)
+
+	delivery, err := sut.SendTestEvent(context.Background(), webhook)
+	assert.Nil(err)
+	assert.True(delivery.Success)
+	assert.Equal(http.StatusOK, delivery.StatusCode)
+	assert.Equal(EVENT_TEST, receiver.requests[0].Event)
+	assert.Equal(0, receiver.badSigs)
+	assert.Equal(1, len(repo.Deliveries))
+}
+
+func TestWebhookService_TrimsDeliveryLog(t *testing.T) {
+	assert := assert.New(t)
+	receiver := &testReceiver{received: make(chan struct{}, WEBHOOK_DELIVERY_LOG_LIMIT+5)}
+	sut, repo, webhook := setupWebhook(t, receiver)
+	other := &WebhookDeliveryDb{WebhookId: webhook.WebhookId + 1}
+	repo.CreateDelivery(context.Background(), other, WEBHOOK_DELIVERY_LOG_LIMIT)
+
+	for i := 0; i < WEBHOOK_DELIVERY_LOG_LIMIT+5; i++ {
+		_, err := sut.SendTestEvent(context.Background(), webhook)
+		assert.Nil(err)
+	}
+	deliveries, err := sut.GetDeliveries(context.Background(), webhook, WEBHOOK_DELIVERY_LOG_LIMIT+5)
+	assert.Nil(err)
+	assert.Equal(WEBHOOK_DELIVERY_LOG_LIMIT, len(deliveries))
+	assert.Equal(receiver.requests[len(receiver.requests)-1].DeliveryId, deliveries[0].DeliveryUuid)
+	assert.Equal(receiver.requests[5].DeliveryId, deliveries[len(deliveries)-1].DeliveryUuid)
+	assert.Equal(WEBHOOK_DELIVERY_LOG_LIMIT+1, len(repo.Deliveries))
+	assert.Same(other, repo.Deliveries[0])
+}
+
+func TestWebhookService_CreateWebhookValidation(t *testing.T) {
+	assert := assert.New(t)
+	sut := NewWebhookService(NewFakeWebhookRepository())
+	ctx := context.Background()
+
+	_, err := sut.CreateWebhook(ctx, 1, "test", "ftp://example.com", []EventType{EVENT_CHIBI_SET})
+	assert.NotNil(err)
+	_, err = sut.CreateWebhook(ctx, 1, "test", "https://example.com", []EventType{})
+	assert.NotNil(err)
+	for i := 0; i < WEBHOOK_MAX_PER_ROOM; i++ {
+		_, err = sut.CreateWebhook(ctx, 1, "test", "https://example.com", []EventType{EVENT_CHIBI_SET})
+		assert.Nil(err)
+	}
+	_, err = sut.CreateWebhook(ctx, 1, "test", "https://example.com", []EventType{EVENT_CHIBI_SET})
+	assert.NotNil(err)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isPublicIP(net.ParseIP("8.8.8.8")))
+	assert.True(isPublicIP(net.ParseIP("2001:4860:4860::8888")))
+	assert.False(isPublicIP(net.ParseIP("127.0.0.1")))
+	assert.False(isPublicIP(net.ParseIP("::1")))
+	assert.False(isPublicIP(net.ParseIP("10.1.2.3")))
+	assert.False(isPublicIP(net.ParseIP("192.168.0.10")))
+	assert.False(isPublicIP(net.ParseIP("169.254.169.254")))
+	assert.False(isPublicIP(net.ParseIP("0.0.0.0")))
+	assert.False(isPublicIP(net.ParseIP("224.0.0.1")))
+	assert.False(isPublicIP(net.ParseIP("::ffff:127.0.0.1")))
+	assert.False(isPublicIP(net.ParseIP("fd00::1")))
+}
+
+func TestWebhookService_RefusesInternalAddresses(t *testing.T) {
+	assert := assert.New(t)
+	receiver := &testReceiver{received: make(chan struct{}, 1)}
+	sut, repo, webhook := setupWebhook(t, receiver)
+	sut.httpClient = newWebhookHttpClient()
+
+	delivery, err := sut.SendTestEvent(context.Background(), webhook)
+	assert.Nil(err)
+	assert.False(delivery.Success)
+	assert.Contains(delivery.ErrorMsg, ErrBlockedAddress.Error())
+	assert.Equal(0, len(receiver.requests))
+	assert.Equal(1, len(repo.Deliveries))
+}
+
+func TestWebhookService_RefusesRedirectsToInternalAddresses(t *testing.T) {
+	assert := assert.New(t)
+	req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:8080/hook", nil)
+	via := []*http.Request{httptest.NewRequest(http.MethodPost, "https://example.com/hook", nil)}
+	assert.ErrorIs(checkRedirect(req, via), ErrBlockedAddress)
+
+	req = httptest.NewRequest(http.MethodPost, "file:///etc/passwd", nil)
+	assert.NotNil(checkRedirect(req, via))
+}
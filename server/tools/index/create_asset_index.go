@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"log"
 	"os"
@@ -10,53 +11,61 @@ import (
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 )
 
-func run(assetDir string, outputDir string) error {
-	assetMap := operator.NewSpineAssetMap()
-	customMap := operator.NewSpineAssetMap()
-	enemyAssetMap := operator.NewSpineAssetMap()
-
-	if err := assetMap.Load(assetDir, "characters"); err != nil {
-		return err
-	}
-	if err := customMap.Load(assetDir, "custom"); err != nil {
-		return err
-	}
-	if err := enemyAssetMap.Load(assetDir, "enemies"); err != nil {
+func run(assetDir string, outputDir string, strict bool) error {
+	factions, err := operator.LoadFactions(assetDir)
+	if err != nil {
 		return err
 	}
 
-	assetMapJsonBytes, err := json.MarshalIndent(assetMap, "", "  ")
-	if err != nil {
-		return err
+	type load struct {
+		assetMap *operator.SpineAssetMap
+		index    operator.FactionIndex
 	}
-	if err := os.WriteFile(filepath.Join(outputDir, "characters_index.json"), assetMapJsonBytes, 0666); err != nil {
-		return err
+	loads := make([]load, 0)
+	for _, faction := range factions {
+		for _, index := range faction.Indexes {
+			if len(index.AssetSubdir) == 0 {
+				continue
+			}
+			loads = append(loads, load{operator.NewSpineAssetMap(), index})
+		}
 	}
-
-	customMapJsonBytes, err := json.MarshalIndent(customMap, "", "  ")
-	if err != nil {
-		return err
+	hasErrors := false
+	for _, load := range loads {
+		report, err := load.assetMap.Load(assetDir, load.index.AssetSubdir)
+		if err != nil {
+			return err
+		}
+		report.Print()
+		hasErrors = hasErrors || report.HasErrors()
 	}
-	if err := os.WriteFile(filepath.Join(outputDir, "custom_index.json"), customMapJsonBytes, 0666); err != nil {
-		return err
+	if strict && hasErrors {
+		return errors.New("refusing to write the index files because of load errors (-strict)")
 	}
 
-	enemyMapJsonBytes, err := json.MarshalIndent(enemyAssetMap, "", "  ")
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(filepath.Join(outputDir, "enemy_index.json"), enemyMapJsonBytes, 0666); err != nil {
-		return err
+	for _, load := range loads {
+		assetMapJsonBytes, err := json.MarshalIndent(load.assetMap, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, load.index.IndexFile), assetMapJsonBytes, 0666); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// Writes an index file for every asset subdirectory listed in the
+// factions_index.json of the asset directory (or the default operator and
+// enemy factions if there isn't one).
+//
 // cd server/tools/index
 // go run create_asset_index.go -assetDir ../../../static/assets -outputDir .
 // go run create_asset_index.go -assetDir C:/dev/lab/ak_etl/output/organized -outputDir C:/dev/lab/ak_etl/output/organized
 func main() {
 	assetDirPtr := flag.String("assetDir", "", "path to the assets")
 	outputDirPtr := flag.String("outputDir", "", "path to the output directory")
+	strictPtr := flag.Bool("strict", false, "do not write the index files if any asset fails to load")
 	flag.Parse()
 	if *assetDirPtr == "" {
 		log.Fatal("must specify -assetDir")
@@ -67,5 +76,8 @@ func main() {
 
 	log.Println("-assetDir: ", *assetDirPtr)
 	log.Println("-outputDir: ", *outputDirPtr)
-	log.Println(run(*assetDirPtr, *outputDirPtr))
+	log.Println("-strict: ", *strictPtr)
+	if err := run(*assetDirPtr, *outputDirPtr, *strictPtr); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -3,13 +3,15 @@ package main
 // go run server/tools/ingest_assets/main.go -assetDir static/assets -dry-run
 // go run server/tools/ingest_assets/main.go -assetDir static/assets -diff -password-file secrets/postgres-password.txt
 // go run server/tools/ingest_assets/main.go -assetDir static/assets -diff -host <prod-host> -user <prod-user> -dbname <prod-db> -password-file secrets/prod-postgress-password.txt
+// go run server/tools/ingest_assets/main.go -list -password-file secrets/postgres-password.txt
+// go run server/tools/ingest_assets/main.go -assetDir static/assets -rollback 12 -password-file secrets/postgres-password.txt
+// go run server/tools/ingest_assets/main.go -gc -dry-run -password-file secrets/postgres-password.txt
 // If -password-file is not set, DB connection falls back to environment variables (see .envrc)
 
 import (
 	"bytes"
 	"compress/gzip"
-	"crypto/sha256"
-	"encoding/binary"
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -19,13 +21,9 @@ import (
 	"time"
 
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/akdb"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/misc"
 )
 
-func hashFilePath(filePath string) int64 {
-	sum := sha256.Sum256([]byte(filePath))
-	return int64(binary.BigEndian.Uint64(sum[:8]))
-}
-
 func gzipCompress(data []byte) ([]byte, error) {
 	var buf bytes.Buffer
 	w := gzip.NewWriter(&buf)
@@ -52,31 +50,174 @@ func collectFilePaths(assetDir string) ([]string, error) {
 		if err != nil {
 			return err
 		}
-		// Skip the _index.json and _names.json files
+		relPath = strings.ReplaceAll(relPath, string(filepath.Separator), "/")
+		// Skip the _index.json and _names.json files, other than the top
+		// level ones which are versioned along with the assets.
 		if strings.HasSuffix(relPath, "_index.json") || strings.HasSuffix(relPath, "_names.json") {
-			return nil
+			if !akdb.IsAssetIndexFile(relPath) {
+				return nil
+			}
 		}
 
-		paths = append(paths, strings.ReplaceAll(relPath, string(filepath.Separator), "/"))
+		paths = append(paths, relPath)
 		return nil
 	})
 	return paths, err
 }
 
-func fetchExistingHashes(dbConn *akdb.DatbaseConn) (map[int64]bool, error) {
-	var hashes []int64
-	result := dbConn.DefaultDB.Raw("SELECT file_path_hash FROM asset_files").Scan(&hashes)
+// Held by ingests and by gc. Blobs are inserted before the manifest which
+// references them is created, so gc must not run while an ingest is in progress.
+const ASSET_BLOBS_LOCK_ID = 20261018
+
+// Blobs newer than this are never collected, which covers uploads written
+// while gc is running. Uploads don't take the advisory lock, instead they
+// bump the created_at of the blob they reference, even an existing one.
+const GC_MIN_BLOB_AGE = time.Hour
+
+// lockAssetBlobs takes the session advisory lock on its own connection. The
+// lock is released by the returned func, or by postgres if the tool dies.
+func lockAssetBlobs(dbConn *akdb.DatbaseConn) (func(), error) {
+	sqlDb, err := dbConn.DefaultDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Waiting for the asset blobs lock...")
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", ASSET_BLOBS_LOCK_ID); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", ASSET_BLOBS_LOCK_ID); err != nil {
+			log.Printf("Failed to release the asset blobs lock: %v", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+func fetchExistingBlobs(dbConn *akdb.DatbaseConn) (map[string]bool, error) {
+	var contentHashes []string
+	result := dbConn.DefaultDB.Raw("SELECT content_hash FROM asset_blobs").Scan(&contentHashes)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	existing := make(map[int64]bool, len(hashes))
-	for _, h := range hashes {
+	existing := make(map[string]bool, len(contentHashes))
+	for _, h := range contentHashes {
 		existing[h] = true
 	}
 	return existing, nil
 }
 
-func run(assetDir string, dryRun bool, diffOnly bool, getConn func() (*akdb.DatbaseConn, error)) error {
+// gcBlobs deletes the blobs which are not referenced by any manifest or
+// uploaded asset, and are older than GC_MIN_BLOB_AGE
+func gcBlobs(dryRun bool, getConn func() (*akdb.DatbaseConn, error)) error {
+	start := time.Now()
+	dbConn, err := getConn()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockAssetBlobs(dbConn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	minAgeSecs := GC_MIN_BLOB_AGE.Seconds()
+
+	var unreferenced []struct {
+		ContentHash string
+		Size        int64
+	}
+	result := dbConn.DefaultDB.Raw(
+		`SELECT b.content_hash, octet_length(b.data) AS size FROM asset_blobs b
+		WHERE b.created_at < CURRENT_TIMESTAMP - make_interval(secs => ?)
+		AND NOT EXISTS (
+			SELECT 1 FROM asset_manifest_files f WHERE f.content_hash = b.content_hash
+		) AND NOT EXISTS (
+			SELECT 1 FROM asset_uploads u WHERE u.content_hash = b.content_hash
+		)`,
+		minAgeSecs,
+	).Scan(&unreferenced)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var totalBytes int64
+	contentHashes := make([]string, 0, len(unreferenced))
+	for _, blob := range unreferenced {
+		log.Printf("Unreferenced blob %s with size %d bytes compressed", blob.ContentHash, blob.Size)
+		contentHashes = append(contentHashes, blob.ContentHash)
+		totalBytes += blob.Size
+	}
+	if !dryRun && len(contentHashes) > 0 {
+		// Re-check the references in case an asset was uploaded in between
+		result = dbConn.DefaultDB.Exec(
+			`DELETE FROM asset_blobs b
+			WHERE b.content_hash IN ?
+			AND b.created_at < CURRENT_TIMESTAMP - make_interval(secs => ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM asset_manifest_files f WHERE f.content_hash = b.content_hash
+			) AND NOT EXISTS (
+				SELECT 1 FROM asset_uploads u WHERE u.content_hash = b.content_hash
+			)`,
+			contentHashes, minAgeSecs,
+		)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+	log.Printf("Done — unreferenced blobs: %d, freed: %.1f MB, dry-run: %v, elapsed: %s",
+		len(contentHashes), float64(totalBytes)/1e6, dryRun,
+		time.Since(start).Round(time.Millisecond),
+	)
+	return nil
+}
+
+func listManifests(getConn func() (*akdb.DatbaseConn, error)) error {
+	dbConn, err := getConn()
+	if err != nil {
+		return err
+	}
+	repo := akdb.NewAssetManifestRepositoryPsql(dbConn)
+	manifests, err := repo.GetManifests(context.Background())
+	if err != nil {
+		return err
+	}
+	pinnedId, err := repo.GetPinnedManifestId(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, manifest := range manifests {
+		pinned := ""
+		if manifest.ManifestId == pinnedId {
+			pinned = " (pinned)"
+		}
+		log.Printf("manifest %d%s: %d files, created %s, %q",
+			manifest.ManifestId, pinned, manifest.NumFiles,
+			manifest.CreatedAt.Format(time.DateTime), manifest.Description,
+		)
+	}
+	return nil
+}
+
+func rollback(assetDir string, manifestId uint, getConn func() (*akdb.DatbaseConn, error)) error {
+	dbConn, err := getConn()
+	if err != nil {
+		return err
+	}
+	repo := akdb.NewAssetManifestRepositoryPsql(dbConn)
+	versions := akdb.NewAssetVersionService(repo, nil, &misc.BotConfig{}, misc.ImageAssetDirString(assetDir))
+	if err := versions.Rollback(context.Background(), manifestId); err != nil {
+		return err
+	}
+	log.Printf("Rolled back to manifest %d. Running servers pick up the change after POST /api/admin/assets/reload/", manifestId)
+	return nil
+}
+
+func run(assetDir string, dryRun bool, diffOnly bool, pin bool, description string, getConn func() (*akdb.DatbaseConn, error)) error {
 	start := time.Now()
 	var dbConn *akdb.DatbaseConn
 	var err error
@@ -85,19 +226,25 @@ func run(assetDir string, dryRun bool, diffOnly bool, getConn func() (*akdb.Datb
 		if err != nil {
 			return err
 		}
+		unlock, err := lockAssetBlobs(dbConn)
+		if err != nil {
+			return err
+		}
+		defer unlock()
 	}
 
-	var existingHashes map[int64]bool
+	// content hash -> already stored. Identical files are only stored once.
+	storedBlobs := make(map[string]bool)
 	if diffOnly {
 		if dryRun {
 			return fmt.Errorf("-diff and -dry-run cannot be used together")
 		}
-		log.Printf("Fetching existing hashes from DB...")
-		existingHashes, err = fetchExistingHashes(dbConn)
+		log.Printf("Fetching existing blobs from DB...")
+		storedBlobs, err = fetchExistingBlobs(dbConn)
 		if err != nil {
 			return err
 		}
-		log.Printf("Found %d existing entries in DB", len(existingHashes))
+		log.Printf("Found %d existing blobs in DB", len(storedBlobs))
 	}
 
 	paths, err := collectFilePaths(assetDir)
@@ -110,8 +257,11 @@ func run(assetDir string, dryRun bool, diffOnly bool, getConn func() (*akdb.Datb
 
 	ingested := 0
 	skipped := 0
-	var totalRaw, totalCompressed int64
+	deduped := 0
+	var totalRaw, totalCompressed, uniqueCompressed int64
+	uniqueBlobs := make(map[string]bool)
 	seenHashes := make(map[int64][]string) // hash -> all paths that produced it
+	manifestFiles := make([]*akdb.AssetManifestFileDb, 0, total)
 	for i, relPath := range paths {
 		fullPath := filepath.Join(assetDir, filepath.FromSlash(relPath))
 		_, err := os.Stat(fullPath)
@@ -133,7 +283,13 @@ func run(assetDir string, dryRun bool, diffOnly bool, getConn func() (*akdb.Datb
 			skipped++
 			continue
 		}
-		hash := hashFilePath(relPath)
+		hash := akdb.HashFilePath(relPath)
+		contentHash := akdb.HashContent(raw)
+		manifestFiles = append(manifestFiles, &akdb.AssetManifestFileDb{
+			FilePathHash: hash,
+			FilePath:     relPath,
+			ContentHash:  contentHash,
+		})
 
 		seenHashes[hash] = append(seenHashes[hash], relPath)
 		if len(seenHashes[hash]) == 2 {
@@ -144,18 +300,25 @@ func run(assetDir string, dryRun bool, diffOnly bool, getConn func() (*akdb.Datb
 
 		totalRaw += int64(len(raw))
 		totalCompressed += int64(len(data))
+		if uniqueBlobs[contentHash] {
+			deduped++
+		} else {
+			uniqueBlobs[contentHash] = true
+			uniqueCompressed += int64(len(data))
+		}
 
-		if diffOnly && existingHashes[hash] {
+		if storedBlobs[contentHash] {
 			continue
 		}
+		storedBlobs[contentHash] = true
 
-		log.Printf("Processing file [%d]%s with size %d bytes compressed", hash, relPath, len(data))
+		log.Printf("Processing file [%s]%s with size %d bytes compressed", contentHash[:16], relPath, len(data))
 		if !dryRun {
 			result := dbConn.DefaultDB.Exec(
-				`INSERT INTO asset_files (file_path_hash, file_path, data)
-				VALUES (?, ?, ?)
-				ON CONFLICT (file_path_hash) DO UPDATE SET data = EXCLUDED.data`,
-				hash, relPath, data,
+				`INSERT INTO asset_blobs (content_hash, data)
+				VALUES (?, ?)
+				ON CONFLICT (content_hash) DO NOTHING`,
+				contentHash, data,
 			)
 			if result.Error != nil {
 				return result.Error
@@ -181,6 +344,21 @@ func run(assetDir string, dryRun bool, diffOnly bool, getConn func() (*akdb.Datb
 		}
 	}
 
+	if !dryRun {
+		repo := akdb.NewAssetManifestRepositoryPsql(dbConn)
+		manifest := &akdb.AssetManifestDb{Description: description}
+		if err := repo.CreateManifest(context.Background(), manifest, manifestFiles); err != nil {
+			return err
+		}
+		log.Printf("Created manifest %d with %d files", manifest.ManifestId, manifest.NumFiles)
+		if pin {
+			if err := repo.PinManifest(context.Background(), manifest.ManifestId); err != nil {
+				return err
+			}
+			log.Printf("Pinned manifest %d", manifest.ManifestId)
+		}
+	}
+
 	var savingsPct float64
 	if totalRaw > 0 {
 		savingsPct = float64(totalRaw-totalCompressed) / float64(totalRaw) * 100
@@ -191,13 +369,22 @@ func run(assetDir string, dryRun bool, diffOnly bool, getConn func() (*akdb.Datb
 		savingsPct,
 		time.Since(start).Round(time.Millisecond),
 	)
+	log.Printf("Dedup — files: %d, unique blobs: %d, duplicate files: %d, stored: %.1f MB, saved: %.1f MB",
+		len(manifestFiles), len(uniqueBlobs), deduped,
+		float64(uniqueCompressed)/1e6, float64(totalCompressed-uniqueCompressed)/1e6,
+	)
 	return nil
 }
 
 func main() {
 	assetDirPtr := flag.String("assetDir", "static/assets", "path to the assets root directory")
 	dryRunPtr := flag.Bool("dry-run", false, "log what would be ingested without writing to the DB")
-	diffOnlyPtr := flag.Bool("diff", false, "only insert files not already present in the DB")
+	diffOnlyPtr := flag.Bool("diff", false, "only insert blobs not already present in the DB")
+	noPinPtr := flag.Bool("no-pin", false, "create the manifest without making it the version served")
+	descriptionPtr := flag.String("description", "", "description stored with the created manifest")
+	listPtr := flag.Bool("list", false, "list the manifest versions and exit")
+	gcPtr := flag.Bool("gc", false, "delete blobs not referenced by any manifest or upload and exit. Combine with -dry-run to only report")
+	rollbackPtr := flag.Uint("rollback", 0, "pin the given manifest id, restore its index files into -assetDir and exit")
 	hostPtr := flag.String("host", "localhost", "database host")
 	portPtr := flag.String("port", "55443", "database port")
 	userPtr := flag.String("user", "postgres", "database user")
@@ -208,6 +395,9 @@ func main() {
 	log.Printf("-assetDir: %s", *assetDirPtr)
 	log.Printf("-dry-run: %v", *dryRunPtr)
 	log.Printf("-diff: %v", *diffOnlyPtr)
+	log.Printf("-no-pin: %v", *noPinPtr)
+	log.Printf("-rollback: %d", *rollbackPtr)
+	log.Printf("-gc: %v", *gcPtr)
 	log.Printf("-host: %s", *hostPtr)
 	log.Printf("-port: %s", *portPtr)
 	log.Printf("-user: %s", *userPtr)
@@ -225,7 +415,18 @@ func main() {
 		return akdb.ProvideDatabaseConn()
 	}
 
-	if err := run(*assetDirPtr, *dryRunPtr, *diffOnlyPtr, getConn); err != nil {
+	var err error
+	switch {
+	case *listPtr:
+		err = listManifests(getConn)
+	case *gcPtr:
+		err = gcBlobs(*dryRunPtr, getConn)
+	case *rollbackPtr > 0:
+		err = rollback(*assetDirPtr, *rollbackPtr, getConn)
+	default:
+		err = run(*assetDirPtr, *dryRunPtr, *diffOnlyPtr, !*noPinPtr, *descriptionPtr, getConn)
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 }
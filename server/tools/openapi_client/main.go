@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/api"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/openapi"
+)
+
+func run(outputFile string, specFile string) error {
+	doc := api.NewOpenApiDocument()
+	src, err := openapi.GenerateClient(doc, "apiclient")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, src, 0666); err != nil {
+		return err
+	}
+	log.Printf("Wrote the client for %d operations to %s", len(doc.Operations()), outputFile)
+
+	if specFile == "" {
+		return nil
+	}
+	specBytes, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(specFile, specBytes, 0666)
+}
+
+// Generates the typed Go client in internal/apiclient from the same OpenAPI
+// document that the server serves at /api/openapi.json. Rerun it whenever a
+// route or one of the request/response models changes.
+//
+// go generate ./server/internal/apiclient
+// go run server/tools/openapi_client/main.go -output server/internal/apiclient/client_gen.go -spec openapi.json
+func main() {
+	outputPtr := flag.String("output", "client_gen.go", "path to write the generated client to")
+	specPtr := flag.String("spec", "", "optional path to also write the OpenAPI document to")
+	flag.Parse()
+
+	log.Println("-output: ", *outputPtr)
+	log.Println("-spec: ", *specPtr)
+
+	if err := run(*outputPtr, *specPtr); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,15 +1,13 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"path/filepath"
 
-	"github.com/Stymphalian/ak_chibi_bot/server/internal/api"
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/apiclient"
 	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
 )
 
@@ -20,12 +18,12 @@ type Context struct {
 	positionY        float32
 	count            int
 	faction          operator.FactionEnum
-	authToken        string
+	client           *apiclient.Client
 	weirdOperatorIds map[string]bool
 	weirdEnemyIds    map[string]bool
 }
 
-func NewContext(authToken string) *Context {
+func NewContext(serverUrl string, authToken string) *Context {
 	weirdOperatorIds := map[string]bool{
 		"char_261_sddrag":  true,
 		"char_260_durnar":  true,
@@ -118,7 +116,7 @@ func NewContext(authToken string) *Context {
 		positionX:        0.025,
 		positionY:        0.0,
 		count:            0,
-		authToken:        authToken,
+		client:           apiclient.NewClient(serverUrl, authToken, nil),
 		faction:          operator.FACTION_ENUM_OPERATOR,
 		weirdOperatorIds: weirdOperatorIds,
 		weirdEnemyIds:    weirdEnemyIds,
@@ -140,43 +138,21 @@ func (c *Context) Run(opId string, skin string, stance operator.ChibiStanceEnum,
 
 	log.Println(opId, skin, stance, facing, spineData.SkelFilepath)
 
-	jsonObj := api.RoomSetOperatorRequest{
+	err := c.client.SetOperator(context.Background(), &apiclient.RoomSetOperatorRequest{
 		ChannelName:     "stymphalian__",
 		Username:        fmt.Sprintf("%s-%s-%s-%s", opId, skin, stance, facing),
 		UserDisplayName: fmt.Sprintf("%s-%s-%s-%s", opId, skin, stance, facing),
-		Faction:         c.faction,
+		Faction:         string(c.faction),
 		OperatorId:      opId,
 		Skin:            skin,
-		Stance:          stance,
+		Stance:          string(stance),
 		PositionX:       float64(c.positionX),
 		PositionY:       float64(c.positionY),
-	}
-	jsonBytes, err := json.Marshal(jsonObj)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	req, err := http.NewRequest(
-		"POST",
-		"http://localhost:8080/api/rooms/users/set/",
-		bytes.NewReader(jsonBytes),
-	)
-	req.Header.Set("Authorization", "Bearer "+c.authToken)
-	req.Header.Set("Content-Type", "application/json")
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		log.Fatal(resp.StatusCode)
-	}
-
 	c.count += 1
 	c.positionX += c.nextX
 	if c.count%(int(1/c.nextX)) == 0 {
@@ -190,21 +166,22 @@ func main() {
 	var err error
 	assetDir := flag.String("assetDir", "", "path to the assets")
 	authToken := flag.String("authToken", "", "auth token for the bot")
+	serverUrl := flag.String("serverUrl", "http://localhost:8080", "url of the running server")
 	flag.Parse()
 	log.Println("-assetDir: ", *assetDir)
 
 	assetMap := operator.NewSpineAssetMap()
 	enemyAssetMap := operator.NewSpineAssetMap()
-	err = assetMap.LoadFromIndex(filepath.Join(*assetDir, "characters_index.json"))
+	_, err = assetMap.LoadFromIndex(filepath.Join(*assetDir, "characters_index.json"))
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = enemyAssetMap.LoadFromIndex(filepath.Join(*assetDir, "enemy_index.json"))
+	_, err = enemyAssetMap.LoadFromIndex(filepath.Join(*assetDir, "enemy_index.json"))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	context := NewContext(*authToken)
+	context := NewContext(*serverUrl, *authToken)
 	context.faction = operator.FACTION_ENUM_OPERATOR
 	assetMap.Iterate(context.Run)
 	// context.faction = operator.FACTION_ENUM_ENEMY
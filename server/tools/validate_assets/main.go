@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Stymphalian/ak_chibi_bot/server/internal/operator"
+)
+
+func run(assetDir string, outputFile string, strict bool) (bool, error) {
+	factions, err := operator.LoadFactions(assetDir)
+	if err != nil {
+		return false, err
+	}
+
+	report := operator.NewAssetValidationReport()
+	for _, faction := range factions {
+		for _, index := range faction.Indexes {
+			assetMap := operator.NewSpineAssetMap()
+			loadReport, err := assetMap.LoadFromIndex(filepath.Join(assetDir, index.IndexFile))
+			if err != nil {
+				return false, err
+			}
+			report.AddLoadReport(faction.Name, loadReport)
+			operator.ValidateSpineAssetMap(assetDir, faction.Name, assetMap, report)
+		}
+	}
+	report.Sort()
+
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	if outputFile == "" {
+		fmt.Println(string(reportBytes))
+	} else if err := os.WriteFile(outputFile, reportBytes, 0666); err != nil {
+		return false, err
+	}
+
+	log.Printf("Checked %d chibis: %d errors, %d warnings", report.NumChecked, report.NumErrors, report.NumWarnings)
+	return !report.HasFailures(strict), nil
+}
+
+// Validates every skin/stance/facing listed in the asset index files without
+// needing a running server. Exits with a non-zero status if there are errors
+// (or warnings when -strict is set) so that it can be used in CI.
+//
+// go run server/tools/validate_assets/main.go -assetDir static/assets
+// go run server/tools/validate_assets/main.go -assetDir static/assets -output report.json -strict
+func main() {
+	assetDirPtr := flag.String("assetDir", "static/assets", "path to the assets root directory")
+	outputPtr := flag.String("output", "", "path to write the JSON report to. Defaults to stdout")
+	strictPtr := flag.Bool("strict", false, "treat warnings (ie. missing Move/Idle animations) as failures")
+	flag.Parse()
+
+	log.Println("-assetDir: ", *assetDirPtr)
+	log.Println("-output: ", *outputPtr)
+	log.Println("-strict: ", *strictPtr)
+
+	ok, err := run(*assetDirPtr, *outputPtr, *strictPtr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
@@ -102,6 +102,19 @@ export function DocsPage() {
                             Use <Code>!chibi unsave</Code> to clear out your preferences.
                         </td>
                     </tr>
+                    <tr>
+                        <td>!chibi [save, unsave] here</td>
+                        <td>Save the current chibi for only this channel.<br />
+                            It is used instead of your saved chibi when you chat in this channel.
+                        </td>
+                    </tr>
+                    <tr>
+                        <td>!chibi [save, load] [name]</td>
+                        <td>Save the current chibi as a named loadout (up to 10).<br />
+                            <Code>!chibi save casual</Code>, <Code>!chibi load casual</Code>.
+                            Use <Code>!chibi loadouts</Code> to list your loadouts.
+                        </td>
+                    </tr>
                     <tr>
                         <td>!chibi follow [username]</td>
                         <td>Make your chibi follow another Viewer's chibi around.<br />
@@ -112,6 +125,12 @@ export function DocsPage() {
                         <td>!chibi findme</td>
                         <td>Highlight your chibi on screen to make it easier to find.</td>
                     </tr>
+                    <tr>
+                        <td>!chibi random [filters]</td>
+                        <td>Change into a random operator. Filter by rarity, class, sub-class or nation.<br />
+                        <Code>!chibi random 6star caster</Code>
+                        </td>
+                    </tr>
                 </tbody>
             </table>
 
@@ -0,0 +1,105 @@
+import argparse
+import os
+import json
+from pathlib import Path
+
+FROM_INTERNAL_CONFIG = True
+CHARACTER_KEY = "Characters"
+RARITY_KEY = "Rarity" if FROM_INTERNAL_CONFIG else "rarity"
+PROFESSION_KEY = "Profession" if FROM_INTERNAL_CONFIG else "profession"
+SUB_PROFESSION_KEY = "SubProfessionId" if FROM_INTERNAL_CONFIG else "subProfessionId"
+NATION_KEY = "NationId" if FROM_INTERNAL_CONFIG else "nationId"
+
+# The game's profession ids to the class names shown in game
+PROFESSIONS = {
+    "PIONEER": "vanguard",
+    "WARRIOR": "guard",
+    "TANK": "defender",
+    "SNIPER": "sniper",
+    "CASTER": "caster",
+    "MEDIC": "medic",
+    "SUPPORT": "supporter",
+    "SPECIAL": "specialist",
+}
+
+
+def parse_rarity(rarity):
+    # Older tables use a 0-indexed int, newer ones use "TIER_6"
+    if isinstance(rarity, int):
+        return rarity + 1
+    return int(str(rarity).replace("TIER_", ""))
+
+
+def process_character_table(character_table_path: Path, existing_metadata):
+    output_dict = {}
+    with character_table_path.open("r", encoding="utf-8") as f:
+        character_json = json.load(f)
+        character_json = (
+            character_json[CHARACTER_KEY] if FROM_INTERNAL_CONFIG else character_json
+        )
+
+        for key, operator in character_json.items():
+            if not key.startswith("char_"):
+                continue
+            profession = operator[PROFESSION_KEY]
+            if profession not in PROFESSIONS:
+                # Tokens, traps and other summons
+                continue
+
+            metadata = {
+                "rarity": parse_rarity(operator[RARITY_KEY]),
+                "class": PROFESSIONS[profession],
+                "sub_class": (operator[SUB_PROFESSION_KEY] or "").lower(),
+                "nation": (operator[NATION_KEY] or "").lower(),
+            }
+            # The release dates aren't in the character table so they are
+            # kept from the existing file
+            release_date = existing_metadata.get(key, {}).get("release_date")
+            if release_date:
+                metadata["release_date"] = release_date
+            output_dict[key] = metadata
+
+    for key in existing_metadata:
+        if key not in output_dict:
+            print("Keeping operator missing from the character table: " + key)
+            output_dict[key] = existing_metadata[key]
+    return output_dict
+
+
+def main():
+    parser = argparse.ArgumentParser(
+        description="Collect operator rarity/class/nation metadata from character table"
+    )
+    parser.add_argument(
+        "--output",
+        type=Path,
+        default=None,
+        help="Output filepath (default: ./operator_metadata_index.json)",
+    )
+    args = parser.parse_args()
+
+    currentdir = Path(os.getcwd())
+    character_table_path = currentdir / Path("character_table.json")
+    output_path = (
+        args.output if args.output else currentdir / Path("operator_metadata_index.json")
+    )
+
+    existing_metadata = {}
+    if output_path.exists():
+        with output_path.open(encoding="utf-8") as f:
+            existing_metadata = json.load(f).get("data", {})
+
+    output_dict = process_character_table(character_table_path, existing_metadata)
+
+    sorted_keys = sorted(output_dict.keys())
+    with output_path.open("w", encoding="utf-8") as f:
+        json.dump(
+            {"data": {k: output_dict[k] for k in sorted_keys}},
+            f,
+            indent=4,
+            ensure_ascii=False,
+        )
+
+
+if __name__ == "__main__":
+    main()